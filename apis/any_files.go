@@ -8,16 +8,17 @@ import (
 	"net/url"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/webitel/storage/model"
 	"github.com/webitel/storage/utils"
 )
 
 func (api *API) InitAnyFile() {
-	api.PublicRoutes.AnyFiles.Handle("/{id}/stream", api.ApiHandler(streamAnyFile)).Methods("GET")
-	api.PublicRoutes.AnyFiles.Handle("/{id}/download", api.ApiHandler(downloadAnyFile)).Methods("GET")
-	api.PublicRoutes.AnyFiles.Handle("/stream", api.ApiHandler(streamAnyFileByQuery)).Methods("GET")
-	api.PublicRoutes.AnyFiles.Handle("/download", api.ApiHandler(downloadAnyFileByQuery)).Methods("GET")
+	api.PublicRoutes.AnyFiles.Handle("/{id}/stream", api.ApiHandler(streamAnyFile)).Methods("GET", "HEAD")
+	api.PublicRoutes.AnyFiles.Handle("/{id}/download", api.ApiHandler(downloadAnyFile)).Methods("GET", "HEAD")
+	api.PublicRoutes.AnyFiles.Handle("/stream", api.ApiHandler(streamAnyFileByQuery)).Methods("GET", "HEAD")
+	api.PublicRoutes.AnyFiles.Handle("/download", api.ApiHandler(downloadAnyFileByQuery)).Methods("GET", "HEAD")
 }
 
 func streamAnyFile(c *Context, w http.ResponseWriter, r *http.Request) {
@@ -48,9 +49,6 @@ func streamAnyFile(c *Context, w http.ResponseWriter, r *http.Request) {
 	var backend utils.FileBackend
 	var id, domainId int
 	var err error
-	var ranges []HttpRange
-	var offset int64 = 0
-	var reader io.ReadCloser
 
 	if id, err = strconv.Atoi(c.Params.Id); err != nil {
 		c.SetInvalidUrlParam("id")
@@ -63,38 +61,15 @@ func streamAnyFile(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if ranges, c.Err = parseRange(r.Header.Get("Range"), file.Size); c.Err != nil {
-		return
-	}
-
-	sendSize := file.Size
-	code := http.StatusOK
-
-	switch {
-	case len(ranges) == 1:
-		code = http.StatusPartialContent
-		offset = ranges[0].Start
-		sendSize = ranges[0].Length
-		w.Header().Set("Content-Range", ranges[0].ContentRange(file.Size))
-	default:
-
-	}
-
-	if reader, c.Err = backend.Reader(file, offset); c.Err != nil {
-		return
-	}
-
-	defer reader.Close()
-
-	if w.Header().Get("Content-Encoding") == "" {
-		w.Header().Set("Content-Length", strconv.FormatInt(sendSize, 10))
-	}
-
-	w.Header().Set("Accept-Ranges", "bytes")
-	w.Header().Set("Content-Type", file.MimeType)
-
-	w.WriteHeader(code)
-	io.CopyN(w, reader, sendSize)
+	streamFileContent(c, w, r, &fileContent{
+		etag:     fileETag(&file.BaseFile, fmt.Sprintf("%s-%d", file.Uuid, file.Id)),
+		modified: time.UnixMilli(file.CreatedAt),
+		size:     file.Size,
+		mimeType: file.MimeType,
+		open: func(offset int64) (io.ReadCloser, model.AppError) {
+			return backend.Reader(file, offset)
+		},
+	})
 }
 
 func downloadAnyFile(c *Context, w http.ResponseWriter, r *http.Request) {
@@ -124,7 +99,6 @@ func downloadAnyFile(c *Context, w http.ResponseWriter, r *http.Request) {
 	var backend utils.FileBackend
 	var id, domainId int
 	var err error
-	var reader io.ReadCloser
 
 	if id, err = strconv.Atoi(c.Params.Id); err != nil {
 		c.SetInvalidUrlParam("id")
@@ -137,21 +111,21 @@ func downloadAnyFile(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	sendSize := file.Size
-	code := http.StatusOK
-
-	if reader, c.Err = backend.Reader(file, 0); c.Err != nil {
+	etag := fileETag(&file.BaseFile, fmt.Sprintf("%s-%d", file.Uuid, file.Id))
+	if r.URL.Query().Get("format") != "" {
+		downloadConvertedFile(c, w, r, file, backend, etag, file.GetViewName())
 		return
 	}
 
-	defer reader.Close()
-
-	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment;  filename=\"%s\"", model.EncodeURIComponent(file.GetViewName())))
-	w.Header().Set("Content-Type", file.MimeType)
-	w.Header().Set("Content-Length", strconv.FormatInt(sendSize, 10))
-
-	w.WriteHeader(code)
-	io.Copy(w, reader)
+	downloadFileContent(c, w, r, &fileContent{
+		etag:     etag,
+		modified: time.UnixMilli(file.CreatedAt),
+		size:     file.Size,
+		mimeType: file.MimeType,
+		open: func(offset int64) (io.ReadCloser, model.AppError) {
+			return backend.Reader(file, offset)
+		},
+	}, file.GetViewName())
 }
 
 func streamAnyFileByQuery(c *Context, w http.ResponseWriter, r *http.Request) {
@@ -189,9 +163,6 @@ func streamAnyFileByQuery(c *Context, w http.ResponseWriter, r *http.Request) {
 	var file *model.File
 	var backend utils.FileBackend
 	var domainId int
-	var ranges []HttpRange
-	var offset int64 = 0
-	var reader io.ReadCloser
 
 	domainId, _ = strconv.Atoi(c.Params.Domain)
 
@@ -199,38 +170,15 @@ func streamAnyFileByQuery(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if ranges, c.Err = parseRange(r.Header.Get("Range"), file.Size); c.Err != nil {
-		return
-	}
-
-	sendSize := file.Size
-	code := http.StatusOK
-
-	switch {
-	case len(ranges) == 1:
-		code = http.StatusPartialContent
-		offset = ranges[0].Start
-		sendSize = ranges[0].Length
-		w.Header().Set("Content-Range", ranges[0].ContentRange(file.Size))
-	default:
-
-	}
-
-	if reader, c.Err = backend.Reader(file, offset); c.Err != nil {
-		return
-	}
-
-	defer reader.Close()
-
-	if w.Header().Get("Content-Encoding") == "" {
-		w.Header().Set("Content-Length", strconv.FormatInt(sendSize, 10))
-	}
-
-	w.Header().Set("Accept-Ranges", "bytes")
-	w.Header().Set("Content-Type", file.MimeType)
-
-	w.WriteHeader(code)
-	io.CopyN(w, reader, sendSize)
+	streamFileContent(c, w, r, &fileContent{
+		etag:     fileETag(&file.BaseFile, fmt.Sprintf("%s-%d", file.Uuid, file.Id)),
+		modified: time.UnixMilli(file.CreatedAt),
+		size:     file.Size,
+		mimeType: file.MimeType,
+		open: func(offset int64) (io.ReadCloser, model.AppError) {
+			return backend.Reader(file, offset)
+		},
+	})
 }
 
 func createValidationKey(key url.URL) string {
@@ -273,7 +221,8 @@ func downloadAnyFileByQuery(c *Context, w http.ResponseWriter, r *http.Request)
 	var file utils.File
 	var backend utils.FileBackend
 	var domainId int
-	var reader io.ReadCloser
+	var etag string
+	var modified time.Time
 
 	// region VALIDATION
 	validationString := createValidationKey(*r.URL)
@@ -293,14 +242,24 @@ func downloadAnyFileByQuery(c *Context, w http.ResponseWriter, r *http.Request)
 		}
 		backend = c.App.MediaFileStore
 		mediaId, _ := strconv.Atoi(uuid)
-		file, c.Err = c.App.GetMediaFile(int64(domainId), mediaId)
+		var media *model.MediaFile
+		if media, c.Err = c.App.GetMediaFile(int64(domainId), mediaId); c.Err == nil {
+			file = media
+			etag = fileETag(&media.BaseFile, fmt.Sprintf("media-%d-%d", media.Id, media.UpdatedAt))
+			modified = time.UnixMilli(media.UpdatedAt)
+		}
 	case "file":
 		if uuid == "" {
 			c.SetInvalidUrlParam("uuid")
 			return
 		}
 		fileId, _ := strconv.Atoi(uuid)
-		file, backend, c.Err = c.App.GetFileWithProfile(int64(domainId), int64(fileId))
+		var f *model.File
+		if f, backend, c.Err = c.App.GetFileWithProfile(int64(domainId), int64(fileId)); c.Err == nil {
+			file = f
+			etag = fileETag(&f.BaseFile, fmt.Sprintf("%s-%d", f.Uuid, f.Id))
+			modified = time.UnixMilli(f.CreatedAt)
+		}
 	case "tts":
 		tts(c, w, r, true)
 		return
@@ -347,7 +306,12 @@ func downloadAnyFileByQuery(c *Context, w http.ResponseWriter, r *http.Request)
 			c.SetInvalidUrlParam("uuid")
 			return
 		}
-		file, backend, c.Err = c.App.GetFileByUuidWithProfile(int64(domainId), uuid)
+		var f *model.File
+		if f, backend, c.Err = c.App.GetFileByUuidWithProfile(int64(domainId), uuid); c.Err == nil {
+			file = f
+			etag = fileETag(&f.BaseFile, fmt.Sprintf("%s-%d", f.Uuid, f.Id))
+			modified = time.UnixMilli(f.CreatedAt)
+		}
 	}
 
 	if c.Err != nil {
@@ -359,19 +323,13 @@ func downloadAnyFileByQuery(c *Context, w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	sendSize := file.GetSize()
-	code := http.StatusOK
-
-	if reader, c.Err = backend.Reader(file, 0); c.Err != nil {
-		return
-	}
-
-	defer reader.Close()
-
-	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment;  filename=\"%s\"", model.EncodeURIComponent(file.GetStoreName())))
-	w.Header().Set("Content-Type", file.GetMimeType())
-	w.Header().Set("Content-Length", strconv.FormatInt(sendSize, 10))
-
-	w.WriteHeader(code)
-	io.Copy(w, reader)
+	downloadFileContent(c, w, r, &fileContent{
+		etag:     etag,
+		modified: modified,
+		size:     file.GetSize(),
+		mimeType: file.GetMimeType(),
+		open: func(offset int64) (io.ReadCloser, model.AppError) {
+			return backend.Reader(file, offset)
+		},
+	}, file.GetStoreName())
 }
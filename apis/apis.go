@@ -20,6 +20,7 @@ type RoutesPublic struct {
 	Files               *mux.Router // for chat
 	Jobs                *mux.Router
 	Tts                 *mux.Router
+	Encryption          *mux.Router
 }
 
 type API struct {
@@ -42,6 +43,7 @@ func Init(a *app.App, root *mux.Router) *API {
 	api.PublicRoutes.Files = api.PublicRoutes.ApiRoot.PathPrefix("/file").Subrouter()
 	api.PublicRoutes.Jobs = api.PublicRoutes.ApiRoot.PathPrefix("/jobs").Subrouter()
 	api.PublicRoutes.Tts = api.PublicRoutes.ApiRoot.PathPrefix("/tts").Subrouter()
+	api.PublicRoutes.Encryption = api.PublicRoutes.ApiRoot.PathPrefix("/encryption").Subrouter()
 
 	api.PublicRoutes.AnyFiles = api.PublicRoutes.ApiRoot.PathPrefix(model.AnyFileRouteName).Subrouter()
 
@@ -49,8 +51,12 @@ func Init(a *app.App, root *mux.Router) *API {
 	api.InitCallRecordingsFiles()
 	api.InitAnyFile()
 	api.InitFile()
+	api.InitResumableUpload()
+	api.InitDirectUpload()
 	api.InitJobs()
 	api.InitTts()
+	api.InitEncryption()
+	api.InitFileArchive()
 
 	return api
 }
@@ -3,7 +3,6 @@ package apis
 import (
 	"context"
 	"fmt"
-	"io"
 	"net/http"
 	"strconv"
 	"time"
@@ -15,14 +14,14 @@ import (
 )
 
 const (
-	SysNamePeriodToPlaybackRecord = "period_to_playback_records"
+	SysNamePeriodToPlaybackRecord = model.SysNamePeriodToPlaybackRecord
 )
 
 var errNoPermissionRecordFile = model.NewForbiddenError("call.recordings.access.forbidden", "Not allow")
 
 func (api *API) InitCallRecordingsFiles() {
-	api.PublicRoutes.CallRecordingsFiles.Handle("/{id}/stream", api.ApiSessionRequired(streamRecordFile)).Methods("GET")
-	api.PublicRoutes.CallRecordingsFiles.Handle("/{id}/download", api.ApiSessionRequired(downloadRecordFile)).Methods("GET")
+	api.PublicRoutes.CallRecordingsFiles.Handle("/{id}/stream", api.ApiSessionRequired(streamRecordFile)).Methods("GET", "HEAD")
+	api.PublicRoutes.CallRecordingsFiles.Handle("/{id}/download", api.ApiSessionRequired(downloadRecordFile)).Methods("GET", "HEAD")
 }
 
 func streamRecordFile(c *Context, w http.ResponseWriter, r *http.Request) {
@@ -65,9 +64,6 @@ func streamFile(c *Context, w http.ResponseWriter, r *http.Request) {
 	var backend utils.FileBackend
 	var id, domainId int
 	var err error
-	var ranges []HttpRange
-	var offset int64 = 0
-	var reader io.ReadCloser
 
 	if id, err = strconv.Atoi(c.Params.Id); err != nil {
 		c.SetInvalidUrlParam("id")
@@ -81,6 +77,12 @@ func streamFile(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if r.Method != http.MethodHead {
+		defer func() {
+			auditFileAccess(c, r, file, int64(id), model.AuditActionStream)
+		}()
+	}
+
 	if file, backend, c.Err = c.Ctrl.GetFileWithProfile(&c.Session, int64(domainId), int64(id)); c.Err != nil {
 		return
 	}
@@ -93,47 +95,19 @@ func streamFile(c *Context, w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	key := fmt.Sprintf("%s-%d", file.Uuid, file.Id)
 	if file.Thumbnail != nil && query.Get("fetch_thumbnail") == "true" {
 		file.BaseFile = file.Thumbnail.BaseFile
+		key += "-thumbnail"
 	}
 
-	if ranges, c.Err = parseRange(r.Header.Get("Range"), file.Size); c.Err != nil {
-		return
-	}
-
-	sendSize := file.Size
-	code := http.StatusOK
-
-	switch {
-	case len(ranges) == 1:
-		code = http.StatusPartialContent
-		offset = ranges[0].Start
-		sendSize = ranges[0].Length
-		w.Header().Set("Content-Range", ranges[0].ContentRange(file.Size))
-	default:
-
-	}
-
-	if reader, c.Err = backend.Reader(file, offset); c.Err != nil {
-		return
-	}
-
-	defer reader.Close()
-
-	reader, c.Err = c.App.FilePolicyForDownload(file.DomainId, &file.BaseFile, reader)
-	if c.Err != nil {
-		return
-	}
-
-	if w.Header().Get("Content-Encoding") == "" {
-		w.Header().Set("Content-Length", strconv.FormatInt(sendSize, 10))
-	}
-
-	w.Header().Set("Accept-Ranges", "bytes")
-	w.Header().Set("Content-Type", file.MimeType)
-
-	w.WriteHeader(code)
-	io.CopyN(w, reader, sendSize)
+	streamFileContent(c, w, r, &fileContent{
+		etag:     fileETag(&file.BaseFile, key),
+		modified: time.UnixMilli(file.CreatedAt),
+		size:     file.Size,
+		mimeType: file.MimeType,
+		open:     fileContentWithPolicy(c, backend, file, &file.BaseFile),
+	})
 }
 
 func downloadFile(c *Context, w http.ResponseWriter, r *http.Request) {
@@ -148,7 +122,6 @@ func downloadFile(c *Context, w http.ResponseWriter, r *http.Request) {
 	var backend utils.FileBackend
 	var id, domainId int
 	var err error
-	var reader io.ReadCloser
 
 	if id, err = strconv.Atoi(c.Params.Id); err != nil {
 		c.SetInvalidUrlParam("id")
@@ -162,6 +135,12 @@ func downloadFile(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if r.Method != http.MethodHead {
+		defer func() {
+			auditFileAccess(c, r, file, int64(id), model.AuditActionDownload)
+		}()
+	}
+
 	if file, backend, c.Err = c.Ctrl.GetFileWithProfile(&c.Session, int64(domainId), int64(id)); c.Err != nil {
 		return
 	}
@@ -174,22 +153,10 @@ func downloadFile(c *Context, w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	key := fmt.Sprintf("%s-%d", file.Uuid, file.Id)
 	if file.Thumbnail != nil && query.Get("fetch_thumbnail") == "true" {
 		file.BaseFile = file.Thumbnail.BaseFile
-	}
-
-	sendSize := file.Size
-	code := http.StatusOK
-
-	if reader, c.Err = backend.Reader(file, 0); c.Err != nil {
-		return
-	}
-
-	defer reader.Close()
-
-	reader, c.Err = c.App.FilePolicyForDownload(file.DomainId, &file.BaseFile, reader)
-	if c.Err != nil {
-		return
+		key += "-thumbnail"
 	}
 
 	var name = file.GetViewName()
@@ -197,53 +164,47 @@ func downloadFile(c *Context, w http.ResponseWriter, r *http.Request) {
 		name = c.Params.Name
 	}
 
-	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment;  filename=\"%s\"", model.EncodeURIComponent(name)))
-	w.Header().Set("Content-Type", file.MimeType)
-	w.Header().Set("Content-Length", strconv.FormatInt(sendSize, 10))
+	if query.Get("format") != "" {
+		downloadConvertedFile(c, w, r, file, backend, fileETag(&file.BaseFile, key), name)
+		return
+	}
 
-	w.WriteHeader(code)
-	io.Copy(w, reader)
+	downloadFileContent(c, w, r, &fileContent{
+		etag:     fileETag(&file.BaseFile, key),
+		modified: time.UnixMilli(file.CreatedAt),
+		size:     file.Size,
+		mimeType: file.MimeType,
+		open:     fileContentWithPolicy(c, backend, file, &file.BaseFile),
+	}, name)
 }
 
-func allowTimeLimited(ctx context.Context, c *Context, createdAt int64) bool {
-	if c.Session.HasAction(auth_manager.PermissionRecordFile) {
-		return true
+// auditFileAccess records the access to the file by the result of the request
+func auditFileAccess(c *Context, r *http.Request, file *model.File, id int64, action string) {
+	domainId := c.Session.Domain(0)
+	if file != nil {
+		domainId = file.DomainId
 	}
 
-	if c.Session.HasAction(auth_manager.PermissionTimeLimitedRecordFile) {
-		if showFilePeriodDay, _ := c.App.GetCachedSystemSetting(ctx, c.Session.Domain(0), SysNamePeriodToPlaybackRecord); showFilePeriodDay.Int() != nil {
-			t := time.Now().Add(-(time.Hour * 24 * time.Duration(*showFilePeriodDay.Int())))
-			return time.Unix(0, createdAt*int64(time.Millisecond)).After(t)
-		}
-	}
+	c.App.AuditFiles(r.Context(), domainId, c.Session.UserId, c.IpAddress, action, []int64{id}, c.Err)
+}
 
-	return false
+func allowTimeLimited(ctx context.Context, c *Context, createdAt int64) bool {
+	return c.Ctrl.AllowTimeLimited(ctx, &c.Session, createdAt)
 }
 
 func checkCallRecordPermission(c *Context, r *http.Request) (bool, model.AppError) {
+	permission := c.Session.GetPermission(model.PERMISSION_SCOPE_RECORD_FILE)
 	if !c.Session.HasAction(auth_manager.PermissionRecordFile) &&
-		!c.Session.HasAction(auth_manager.PermissionTimeLimitedRecordFile) {
-		session := c.Session
-		permission := session.GetPermission(model.PERMISSION_SCOPE_RECORD_FILE)
-		if !permission.CanRead() {
-			return false, errNoPermissionRecordFile
-		}
-		if session.UseRBAC(auth_manager.PERMISSION_ACCESS_READ, permission) {
-			id, err := strconv.Atoi(c.Params.Id)
-			if err != nil {
-				return false, web.NewInvalidUrlParamError("id")
-			}
-			isAccessible, appErr := c.App.CheckCallRecordPermissions(r.Context(), id, session.UserId, session.DomainId, session.RoleIds)
-			if appErr != nil {
-				return false, appErr
-			}
-			return isAccessible, nil
-
-		}
+		!c.Session.HasAction(auth_manager.PermissionTimeLimitedRecordFile) && !permission.CanRead() {
+		return false, errNoPermissionRecordFile
 	}
 
-	return true, nil
+	id, err := strconv.ParseInt(c.Params.Id, 10, 64)
+	if err != nil {
+		return false, web.NewInvalidUrlParamError("id")
+	}
 
+	return c.Ctrl.CanReadRecordFile(r.Context(), &c.Session, id)
 }
 
 func sourceFromRequest(r *http.Request) string {
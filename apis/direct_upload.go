@@ -0,0 +1,70 @@
+package apis
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/webitel/storage/model"
+	"github.com/webitel/storage/utils"
+)
+
+// direct uploads: the client writes the file to the bucket with presigned urls and completes the upload
+func (api *API) InitDirectUpload() {
+	api.PublicRoutes.Files.Handle("/{id}/upload/direct", api.ApiSessionRequired(createDirectUpload)).Methods("POST")
+	api.PublicRoutes.Files.Handle("/{id}/upload/direct/{upload_id}/complete", api.ApiSessionRequired(completeDirectUpload)).Methods("POST")
+	api.PublicRoutes.Files.Handle("/{id}/upload/direct/{upload_id}", api.ApiSessionRequired(abortDirectUpload)).Methods("DELETE")
+}
+
+func createDirectUpload(c *Context, w http.ResponseWriter, r *http.Request) {
+	var upload *model.DirectUpload
+	c.RequireId()
+
+	if c.Err != nil {
+		return
+	}
+
+	req := model.DirectUploadRequestFromJson(r.Body)
+	if req == nil {
+		c.SetInvalidParam("body")
+		return
+	}
+
+	upload, c.Err = c.App.CreateDirectUpload(c.Session.DomainId, c.Params.Id, req, CustomPropertiesFromQuery(r.URL.Query()), &c.Session.UserId)
+	if c.Err != nil {
+		if c.Err.GetId() == utils.ErrMaxLimitId {
+			c.Err.SetDetailedError(utils.BytesSize(float64(c.App.MaxUploadFileSize())))
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	w.Write([]byte(upload.ToJson()))
+}
+
+func completeDirectUpload(c *Context, w http.ResponseWriter, r *http.Request) {
+	var file *model.File
+
+	complete := model.DirectUploadCompleteFromJson(r.Body)
+	if file, c.Err = c.App.CompleteDirectUpload(c.Session.DomainId, mux.Vars(r)["upload_id"], complete); c.Err != nil {
+		return
+	}
+
+	sig, _ := c.App.GeneratePreSignedResourceSignature(model.AnyFileRouteName, "download", file.Id, file.DomainId)
+	data, _ := json.Marshal(&fileResponse{
+		Id:        file.Id,
+		Name:      file.GetViewName(),
+		Size:      file.Size,
+		MimeType:  file.MimeType,
+		SharedUrl: sig,
+	})
+	w.Write(data)
+}
+
+func abortDirectUpload(c *Context, w http.ResponseWriter, r *http.Request) {
+	if c.Err = c.App.AbortDirectUpload(c.Session.DomainId, mux.Vars(r)["upload_id"]); c.Err != nil {
+		return
+	}
+
+	ReturnStatusOK(w)
+}
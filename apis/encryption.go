@@ -0,0 +1,58 @@
+package apis
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/webitel/storage/model"
+)
+
+// the data key of the domain for the envelope encryption (config crypto_kms)
+// and the progress of the files encryption of the policy
+func (api *API) InitEncryption() {
+	api.PublicRoutes.Encryption.Handle("/key", api.ApiSessionRequired(getDomainKey)).Methods("GET")
+	api.PublicRoutes.Encryption.Handle("/key", api.ApiSessionRequired(destroyDomainKey)).Methods("DELETE")
+	api.PublicRoutes.Encryption.Handle("/policy/{id}", api.ApiSessionRequired(filePolicyEncryption)).Methods("GET")
+}
+
+func getDomainKey(c *Context, w http.ResponseWriter, r *http.Request) {
+	var key *model.DomainKey
+	if key, c.Err = c.Ctrl.GetDomainKey(&c.Session); c.Err != nil {
+		return
+	}
+
+	w.Write([]byte(key.ToJson()))
+}
+
+func destroyDomainKey(c *Context, w http.ResponseWriter, r *http.Request) {
+	var key *model.DomainKey
+	if key, c.Err = c.Ctrl.DestroyDomainKey(&c.Session); c.Err != nil {
+		return
+	}
+
+	w.Write([]byte(key.ToJson()))
+}
+
+func filePolicyEncryption(c *Context, w http.ResponseWriter, r *http.Request) {
+	var progress *model.FilePolicyEncryption
+	c.RequireId()
+
+	if c.Err != nil {
+		return
+	}
+
+	id, err := strconv.Atoi(c.Params.Id)
+	if err != nil {
+		c.SetInvalidUrlParam("id")
+		return
+	}
+
+	applyToNullChannel := r.URL.Query().Get("apply_to_null_channel") == "true"
+	if progress, c.Err = c.Ctrl.FilePolicyEncryptionProgress(r.Context(), &c.Session, int32(id), applyToNullChannel); c.Err != nil {
+		return
+	}
+
+	data, _ := json.Marshal(progress)
+	w.Write(data)
+}
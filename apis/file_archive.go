@@ -0,0 +1,130 @@
+package apis
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/webitel/storage/model"
+)
+
+// fileArchiveRequest is the body of POST /file/archive, GET takes the same parameters from the query
+type fileArchiveRequest struct {
+	Ids            []int64  `json:"id"`
+	ReferenceIds   []string `json:"reference_id"`
+	CallId         string   `json:"call_id"`
+	UploadedBy     []int64  `json:"uploaded_by"`
+	Channels       []string `json:"channel"`
+	UploadedAtFrom int64    `json:"uploaded_at_from"`
+	UploadedAtTo   int64    `json:"uploaded_at_to"`
+	Name           string   `json:"name"`
+}
+
+func (api *API) InitFileArchive() {
+	api.PublicRoutes.Files.Handle("/archive", api.ApiSessionRequired(downloadFilesArchive)).Methods("GET", "POST")
+}
+
+// downloadFilesArchive streams the zip archive of the files, the archive isn't buffered
+func downloadFilesArchive(c *Context, w http.ResponseWriter, r *http.Request) {
+	var archive *model.FileArchive
+	var ids []int64
+
+	if archive, c.Err = fileArchiveFromRequest(r); c.Err != nil {
+		return
+	}
+
+	if ids, c.Err = c.Ctrl.FileArchiveIds(r.Context(), &c.Session, archive); c.Err != nil {
+		return
+	}
+
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment;  filename=\"%s\"", model.EncodeURIComponent(archive.FileName())))
+	w.Header().Set("Content-Type", model.FileArchiveMimeType)
+	w.WriteHeader(http.StatusOK)
+
+	archived, err := c.Ctrl.WriteFileArchive(r.Context(), &c.Session, ids, w)
+	if err != nil {
+		// the response is started, the client receives the incomplete archive
+		c.LogError(err)
+	}
+
+	c.App.AuditFiles(r.Context(), c.Session.Domain(0), c.Session.UserId, c.IpAddress, model.AuditActionDownload, archived, nil)
+}
+
+func fileArchiveFromRequest(r *http.Request) (*model.FileArchive, model.AppError) {
+	var req fileArchiveRequest
+
+	if r.Method == http.MethodPost {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			return nil, model.NewBadRequestError("api.file_archive.body", err.Error())
+		}
+	} else {
+		var err error
+		query := r.URL.Query()
+		if req.Ids, err = queryInt64List(query, "id"); err != nil {
+			return nil, model.NewBadRequestError("api.file_archive.id", err.Error())
+		}
+		if req.UploadedBy, err = queryInt64List(query, "uploaded_by"); err != nil {
+			return nil, model.NewBadRequestError("api.file_archive.uploaded_by", err.Error())
+		}
+		req.UploadedAtFrom, _ = strconv.ParseInt(query.Get("uploaded_at_from"), 10, 64)
+		req.UploadedAtTo, _ = strconv.ParseInt(query.Get("uploaded_at_to"), 10, 64)
+		req.ReferenceIds = queryList(query, "reference_id")
+		req.Channels = queryList(query, "channel")
+		req.CallId = query.Get("call_id")
+		req.Name = query.Get("name")
+	}
+
+	archive := &model.FileArchive{
+		Name: req.Name,
+		Search: model.SearchFile{
+			Ids:          req.Ids,
+			ReferenceIds: req.ReferenceIds,
+			UploadedBy:   req.UploadedBy,
+			Channels:     req.Channels,
+		},
+	}
+
+	if req.CallId != "" {
+		archive.Search.CallId = &req.CallId
+	}
+
+	if req.UploadedAtFrom > 0 || req.UploadedAtTo > 0 {
+		archive.Search.UploadedAt = &model.FilterBetween{
+			From: req.UploadedAtFrom,
+			To:   req.UploadedAtTo,
+		}
+	}
+
+	return archive, nil
+}
+
+// queryList returns the values of the parameter, the value may be the comma separated list
+func queryList(query url.Values, name string) []string {
+	var res []string
+	for _, v := range query[name] {
+		for _, s := range strings.Split(v, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				res = append(res, s)
+			}
+		}
+	}
+
+	return res
+}
+
+func queryInt64List(query url.Values, name string) ([]int64, error) {
+	list := queryList(query, name)
+	res := make([]int64, 0, len(list))
+	for _, v := range list {
+		i, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, i)
+	}
+
+	return res, nil
+}
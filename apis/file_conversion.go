@@ -0,0 +1,52 @@
+package apis
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/webitel/storage/model"
+	"github.com/webitel/storage/utils"
+)
+
+// downloadConvertedFile sends the file converted to the "format" of the query, the size of the file converted
+// on the fly is unknown, so the response is chunked and the ranges are not supported
+func downloadConvertedFile(c *Context, w http.ResponseWriter, r *http.Request, file *model.File, backend utils.FileBackend, etag string, name string) {
+	var format *model.AudioFormat
+	var reader io.ReadCloser
+	var size int64
+
+	if format, c.Err = model.ParseAudioFormat(r.URL.Query().Get("format")); c.Err != nil {
+		return
+	}
+
+	etag = strings.TrimSuffix(etag, `"`) + "-" + strings.ReplaceAll(format.String(), ":", "-") + `"`
+	if checkNotModified(w, r, etag, time.UnixMilli(file.CreatedAt)) {
+		return
+	}
+
+	if r.Method == http.MethodHead {
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment;  filename=\"%s\"", model.EncodeURIComponent(format.FileName(name))))
+		w.Header().Set("Content-Type", format.MimeType())
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if reader, size, c.Err = c.App.FileConversionReader(file, backend, format); c.Err != nil {
+		return
+	}
+
+	defer reader.Close()
+
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment;  filename=\"%s\"", model.EncodeURIComponent(format.FileName(name))))
+	w.Header().Set("Content-Type", format.MimeType())
+	if size >= 0 {
+		w.Header().Set("Content-Length", strconv.FormatInt(size, 10))
+	}
+
+	w.WriteHeader(http.StatusOK)
+	io.Copy(w, reader)
+}
@@ -4,10 +4,20 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/webitel/storage/model"
+	"github.com/webitel/storage/utils"
+	"github.com/webitel/wlog"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
 	"strconv"
 	"strings"
+	"time"
 )
 
+// the gap between the ranges that is read and discarded instead of reopening the file
+const multipartRangesMaxGap = utils.BlockSize
+
 type HttpRange struct {
 	Start, Length int64
 }
@@ -21,6 +31,13 @@ func (r HttpRange) ContentRange(size int64) string {
 	return fmt.Sprintf("bytes %d-%d/%d", r.Start, r.Start+r.Length-1, size)
 }
 
+func (r HttpRange) mimeHeader(contentType string, size int64) textproto.MIMEHeader {
+	return textproto.MIMEHeader{
+		"Content-Range": {r.ContentRange(size)},
+		"Content-Type":  {contentType},
+	}
+}
+
 var errFailedToOverlapRange = model.NewBadRequestError("api.helper.parse_range.failed_to_overlap.app_error", "")
 var errFailedRange = model.NewBadRequestError("api.helper.parse_range.failed_range.app_error", "")
 
@@ -93,6 +110,278 @@ func parseRange(s string, size int64) ([]HttpRange, model.AppError) {
 	return ranges, nil
 }
 
+// sumRangesSize returns the bytes of the ranges
+func sumRangesSize(ranges []HttpRange) (size int64) {
+	for _, ra := range ranges {
+		size += ra.Length
+	}
+	return
+}
+
+// rangesMIMESize returns the length of the multipart/byteranges body
+func rangesMIMESize(ranges []HttpRange, contentType string, size int64, boundary string) int64 {
+	var w countingWriter
+	mw := multipart.NewWriter(&w)
+	mw.SetBoundary(boundary)
+	for _, ra := range ranges {
+		mw.CreatePart(ra.mimeHeader(contentType, size))
+		w += countingWriter(ra.Length)
+	}
+	mw.Close()
+
+	return int64(w)
+}
+
+type countingWriter int64
+
+func (w *countingWriter) Write(p []byte) (n int, err error) {
+	*w += countingWriter(len(p))
+	return len(p), nil
+}
+
+// writeMultipartRanges sends the ranges of the file as multipart/byteranges. The file is opened from each range,
+// so the backend reads the encrypted file from the first block of the range; a short gap after the previous range
+// is read and discarded instead of reopening the file
+func writeMultipartRanges(c *Context, w http.ResponseWriter, r *http.Request, ranges []HttpRange, size int64, mimeType string,
+	open func(offset int64) (io.ReadCloser, model.AppError)) model.AppError {
+	var reader io.ReadCloser
+	var pos int64
+	var err model.AppError
+
+	if r.Method != http.MethodHead {
+		if reader, err = open(ranges[0].Start); err != nil {
+			return err
+		}
+		pos = ranges[0].Start
+		defer func() {
+			if reader != nil {
+				reader.Close()
+			}
+		}()
+	}
+
+	boundary := multipart.NewWriter(io.Discard).Boundary()
+	w.Header().Set("Content-Type", "multipart/byteranges; boundary="+boundary)
+	w.Header().Set("Content-Length", strconv.FormatInt(rangesMIMESize(ranges, mimeType, size, boundary), 10))
+	w.Header().Set("Accept-Ranges", "bytes")
+	w.WriteHeader(http.StatusPartialContent)
+
+	if r.Method == http.MethodHead {
+		return nil
+	}
+
+	mw := multipart.NewWriter(w)
+	mw.SetBoundary(boundary)
+	for _, ra := range ranges {
+		if ra.Start < pos || ra.Start-pos > multipartRangesMaxGap {
+			reader.Close()
+			if reader, err = open(ra.Start); err != nil {
+				// the status is sent, the response is broken
+				c.Log.Error(fmt.Sprintf("open range %d-%d", ra.Start, ra.Start+ra.Length-1), wlog.Err(err))
+				return nil
+			}
+			pos = ra.Start
+		} else if ra.Start > pos {
+			n, _ := io.CopyN(io.Discard, reader, ra.Start-pos)
+			pos += n
+		}
+
+		part, e := mw.CreatePart(ra.mimeHeader(mimeType, size))
+		if e != nil {
+			return nil
+		}
+		n, e := io.CopyN(part, reader, ra.Length)
+		pos += n
+		if e != nil {
+			c.Log.Error(fmt.Sprintf("read range %d-%d", ra.Start, ra.Start+ra.Length-1), wlog.Err(e))
+			return nil
+		}
+	}
+	mw.Close()
+
+	return nil
+}
+
+// fileContent is the file that is sent by the stream and download handlers, open returns the reader from the offset
+type fileContent struct {
+	etag     string
+	modified time.Time
+	size     int64
+	mimeType string
+	open     func(offset int64) (io.ReadCloser, model.AppError)
+}
+
+// fileContentWithPolicy opens the file of the backend with the download policy of the domain
+func fileContentWithPolicy(c *Context, backend utils.FileBackend, file utils.File, base *model.BaseFile) func(offset int64) (io.ReadCloser, model.AppError) {
+	return func(offset int64) (io.ReadCloser, model.AppError) {
+		reader, err := backend.Reader(file, offset)
+		if err != nil {
+			return nil, err
+		}
+		policyReader, err := c.App.FilePolicyForDownload(file.Domain(), base, reader)
+		if err != nil {
+			reader.Close()
+			return nil, err
+		}
+		return policyReader, nil
+	}
+}
+
+// streamFileContent sends the file with the validators and the ranges of the request, HEAD gets the headers only
+func streamFileContent(c *Context, w http.ResponseWriter, r *http.Request, content *fileContent) {
+	var ranges []HttpRange
+	var offset int64
+	var reader io.ReadCloser
+
+	if checkNotModified(w, r, content.etag, content.modified) {
+		return
+	}
+
+	if ranges, c.Err = parseRange(rangeHeader(r, content.etag, content.modified), content.size); c.Err != nil {
+		return
+	}
+
+	sendSize := content.size
+	code := http.StatusOK
+
+	switch {
+	case len(ranges) == 1:
+		code = http.StatusPartialContent
+		offset = ranges[0].Start
+		sendSize = ranges[0].Length
+		w.Header().Set("Content-Range", ranges[0].ContentRange(content.size))
+	case len(ranges) > 1 && sumRangesSize(ranges) <= content.size:
+		// the ranges larger than the file are ignored and the whole file is sent
+		c.Err = writeMultipartRanges(c, w, r, ranges, content.size, content.mimeType, content.open)
+		return
+	default:
+
+	}
+
+	if r.Method == http.MethodHead {
+		w.Header().Set("Content-Length", strconv.FormatInt(sendSize, 10))
+		w.Header().Set("Accept-Ranges", "bytes")
+		w.Header().Set("Content-Type", content.mimeType)
+		w.WriteHeader(code)
+		return
+	}
+
+	if reader, c.Err = content.open(offset); c.Err != nil {
+		return
+	}
+
+	defer reader.Close()
+
+	if w.Header().Get("Content-Encoding") == "" {
+		w.Header().Set("Content-Length", strconv.FormatInt(sendSize, 10))
+	}
+
+	w.Header().Set("Accept-Ranges", "bytes")
+	w.Header().Set("Content-Type", content.mimeType)
+
+	w.WriteHeader(code)
+	io.CopyN(w, reader, sendSize)
+}
+
+// downloadFileContent sends the file as the attachment with the validators, HEAD gets the headers only
+func downloadFileContent(c *Context, w http.ResponseWriter, r *http.Request, content *fileContent, name string) {
+	var reader io.ReadCloser
+
+	if checkNotModified(w, r, content.etag, content.modified) {
+		return
+	}
+
+	if r.Method != http.MethodHead {
+		if reader, c.Err = content.open(0); c.Err != nil {
+			return
+		}
+
+		defer reader.Close()
+	}
+
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment;  filename=\"%s\"", model.EncodeURIComponent(name)))
+	w.Header().Set("Content-Type", content.mimeType)
+	w.Header().Set("Content-Length", strconv.FormatInt(content.size, 10))
+
+	w.WriteHeader(http.StatusOK)
+	if reader != nil {
+		io.Copy(w, reader)
+	}
+}
+
+// fileETag is the strong validator of the file content: the checksum, or the key of the file when the checksum is not calculated
+func fileETag(file *model.BaseFile, key string) string {
+	if file.SHA256Sum != nil && *file.SHA256Sum != "" {
+		return `"` + *file.SHA256Sum + `"`
+	}
+
+	return `"` + key + `"`
+}
+
+// checkNotModified writes ETag and Last-Modified and checks If-None-Match and If-Modified-Since,
+// returns true when the client copy is not modified and the response is completed with 304
+func checkNotModified(w http.ResponseWriter, r *http.Request, etag string, modified time.Time) bool {
+	w.Header().Set("ETag", etag)
+	if !modified.IsZero() {
+		w.Header().Set("Last-Modified", modified.UTC().Format(http.TimeFormat))
+	}
+
+	notModified := false
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		// If-Modified-Since is ignored when If-None-Match is present
+		notModified = etagListMatch(inm, etag)
+	} else if ims := r.Header.Get("If-Modified-Since"); ims != "" && !modified.IsZero() {
+		if t, err := http.ParseTime(ims); err == nil {
+			notModified = !modified.Truncate(time.Second).After(t)
+		}
+	}
+
+	if !notModified {
+		return false
+	}
+
+	w.Header().Del("Content-Type")
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// rangeHeader returns the Range of the request, or empty when the If-Range validator doesn't match
+// and the full file must be sent
+func rangeHeader(r *http.Request, etag string, modified time.Time) string {
+	ra := r.Header.Get("Range")
+	ir := r.Header.Get("If-Range")
+	if ra == "" || ir == "" {
+		return ra
+	}
+
+	if strings.HasPrefix(ir, `"`) || strings.HasPrefix(ir, "W/") {
+		// If-Range requires the strong comparison
+		if ir == etag && !strings.HasPrefix(etag, "W/") {
+			return ra
+		}
+		return ""
+	}
+
+	if t, err := http.ParseTime(ir); err == nil && !modified.IsZero() && modified.Truncate(time.Second).Equal(t) {
+		return ra
+	}
+
+	return ""
+}
+
+// etagListMatch is the weak comparison of If-None-Match list with the etag
+func etagListMatch(list string, etag string) bool {
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, v := range strings.Split(list, ",") {
+		v = strings.TrimSpace(v)
+		if v == "*" || strings.TrimPrefix(v, "W/") == etag {
+			return true
+		}
+	}
+
+	return false
+}
+
 func (list *ListResponse) ToJson() string {
 	b, _ := json.Marshal(list)
 	return string(b)
@@ -8,14 +8,15 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/webitel/storage/model"
 )
 
 func (api *API) InitMediaFile() {
 	api.PublicRoutes.MediaFiles.Handle("", api.ApiSessionRequired(saveMediaFile)).Methods("POST")
-	api.PublicRoutes.MediaFiles.Handle("/{id}/stream", api.ApiSessionRequired(streamMediaFile)).Methods("GET")
-	api.PublicRoutes.MediaFiles.Handle("/{id}/download", api.ApiSessionRequired(downloadMediaFile)).Methods("GET")
+	api.PublicRoutes.MediaFiles.Handle("/{id}/stream", api.ApiSessionRequired(streamMediaFile)).Methods("GET", "HEAD")
+	api.PublicRoutes.MediaFiles.Handle("/{id}/download", api.ApiSessionRequired(downloadMediaFile)).Methods("GET", "HEAD")
 }
 
 func streamMediaFile(c *Context, w http.ResponseWriter, r *http.Request) {
@@ -28,9 +29,6 @@ func streamMediaFile(c *Context, w http.ResponseWriter, r *http.Request) {
 	var file *model.MediaFile
 	var id, domainId int
 	var err error
-	var ranges []HttpRange
-	var offset int64 = 0
-	var reader io.ReadCloser
 
 	if id, err = strconv.Atoi(c.Params.Id); err != nil {
 		c.SetInvalidUrlParam("id")
@@ -43,43 +41,13 @@ func streamMediaFile(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if ranges, c.Err = parseRange(r.Header.Get("Range"), file.Size); c.Err != nil {
-		return
-	}
-
-	sendSize := file.Size
-	code := http.StatusOK
-
-	switch {
-	case len(ranges) == 1:
-		code = http.StatusPartialContent
-		offset = ranges[0].Start
-		sendSize = ranges[0].Length
-		w.Header().Set("Content-Range", ranges[0].ContentRange(file.Size))
-	default:
-
-	}
-
-	if reader, c.Err = c.App.MediaFileStore.Reader(file, offset); c.Err != nil {
-		return
-	}
-
-	defer reader.Close()
-
-	reader, c.Err = c.App.FilePolicyForDownload(file.DomainId, &file.BaseFile, reader)
-	if c.Err != nil {
-		return
-	}
-
-	if w.Header().Get("Content-Encoding") == "" {
-		w.Header().Set("Content-Length", strconv.FormatInt(sendSize, 10))
-	}
-
-	w.Header().Set("Accept-Ranges", "bytes")
-	w.Header().Set("Content-Type", file.MimeType)
-
-	w.WriteHeader(code)
-	io.CopyN(w, reader, sendSize)
+	streamFileContent(c, w, r, &fileContent{
+		etag:     fileETag(&file.BaseFile, fmt.Sprintf("media-%d-%d", file.Id, file.UpdatedAt)),
+		modified: time.UnixMilli(file.UpdatedAt),
+		size:     file.Size,
+		mimeType: file.MimeType,
+		open:     fileContentWithPolicy(c, c.App.MediaFileStore, file, &file.BaseFile),
+	})
 }
 
 func downloadMediaFile(c *Context, w http.ResponseWriter, r *http.Request) {
@@ -92,7 +60,6 @@ func downloadMediaFile(c *Context, w http.ResponseWriter, r *http.Request) {
 	var file *model.MediaFile
 	var id, domainId int
 	var err error
-	var reader io.ReadCloser
 
 	if id, err = strconv.Atoi(c.Params.Id); err != nil {
 		c.SetInvalidUrlParam("id")
@@ -105,26 +72,13 @@ func downloadMediaFile(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	sendSize := file.Size
-	code := http.StatusOK
-
-	if reader, c.Err = c.App.MediaFileStore.Reader(file, 0); c.Err != nil {
-		return
-	}
-
-	defer reader.Close()
-
-	reader, c.Err = c.App.FilePolicyForDownload(file.DomainId, &file.BaseFile, reader)
-	if c.Err != nil {
-		return
-	}
-
-	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment;  filename=\"%s\"", model.EncodeURIComponent(file.Name)))
-	w.Header().Set("Content-Type", file.MimeType)
-	w.Header().Set("Content-Length", strconv.FormatInt(sendSize, 10))
-
-	w.WriteHeader(code)
-	io.Copy(w, reader)
+	downloadFileContent(c, w, r, &fileContent{
+		etag:     fileETag(&file.BaseFile, fmt.Sprintf("media-%d-%d", file.Id, file.UpdatedAt)),
+		modified: time.UnixMilli(file.UpdatedAt),
+		size:     file.Size,
+		mimeType: file.MimeType,
+		open:     fileContentWithPolicy(c, c.App.MediaFileStore, file, &file.BaseFile),
+	}, file.Name)
 }
 
 func saveMediaFile(c *Context, w http.ResponseWriter, r *http.Request) {
@@ -3,6 +3,8 @@ package private
 import (
 	"fmt"
 	. "github.com/webitel/storage/apis/helper"
+	"github.com/webitel/storage/app"
+	tts2 "github.com/webitel/storage/tts"
 	"github.com/webitel/storage/utils"
 	"github.com/webitel/wlog"
 	"io"
@@ -19,9 +21,7 @@ type ttsPerform struct {
 	requestId       string
 	callId          string
 	key             string
-	src             io.ReadCloser
-	size            *int
-	mime            *string
+	speech          *tts2.Speech
 	cancelSleepChan chan struct{}
 	mx              sync.RWMutex
 }
@@ -43,7 +43,7 @@ func (tts *ttsPerform) stopPerform() {
 func (tts *ttsPerform) timeout() {
 	tts.stopPerform()
 	wlog.Debug(fmt.Sprintf("[%s] timeout tts", tts))
-	tts.src.Close()
+	tts.speech.Body.Close()
 }
 
 func (tts *ttsPerform) store() {
@@ -72,14 +72,14 @@ func doTTSByProfile(c *Context, w http.ResponseWriter, r *http.Request) {
 			requestId: c.RequestId,
 			key:       r.RequestURI,
 		}
-		tts.src, tts.mime, tts.size, c.Err = c.App.TTS(c.Params.Id, params)
+		tts.speech, c.Err = c.App.TTS(c.Params.Id, params)
 		if c.Err != nil {
 			wlog.Debug(fmt.Sprintf("[%s] store tts error: %s, duration %v", tts, c.Err.Error(), time.Since(t)))
 			return
 		}
 
 		tts.store()
-		wlog.Debug(fmt.Sprintf("[%s] store tts, generate duration %v", tts, time.Since(t)))
+		wlog.Debug(fmt.Sprintf("[%s] store tts of %s, generate duration %v", tts, tts.speech.Provider, time.Since(t)))
 		w.WriteHeader(http.StatusOK)
 	} else {
 		u, ok := ttsPerformCache.Get(r.RequestURI)
@@ -88,19 +88,14 @@ func doTTSByProfile(c *Context, w http.ResponseWriter, r *http.Request) {
 			tts.stopPerform()
 			wlog.Debug(fmt.Sprintf("[%s] play tts", tts))
 
-			defer tts.src.Close()
-			if tts.mime != nil {
-				w.Header().Set("Content-Type", *tts.mime)
-			}
-			if tts.size != nil {
-				w.Header().Set("Content-Length", strconv.Itoa(*tts.size))
-			}
+			defer tts.speech.Body.Close()
+			setSpeechHeaders(w, tts.speech)
 
 			w.WriteHeader(http.StatusOK)
 			if params.Format == "mp3" {
-				ttsCopy(w, tts.src)
+				ttsCopy(w, tts.speech.Body)
 			} else {
-				io.Copy(w, tts.src)
+				io.Copy(w, tts.speech.Body)
 			}
 		} else {
 			ttsByProfile(c, w, r)
@@ -112,28 +107,32 @@ func doTTSByProfile(c *Context, w http.ResponseWriter, r *http.Request) {
 func ttsByProfile(c *Context, w http.ResponseWriter, r *http.Request) {
 	params := TtsParamsFromRequest(r)
 
-	out, t, size, err := c.App.TTS(c.Params.Id, params)
+	speech, err := c.App.TTS(c.Params.Id, params)
 	if err != nil {
 		c.Err = err
 		return
 	}
 
-	defer out.Close()
-
-	if t != nil {
-		w.Header().Set("Content-Type", *t)
-	}
-	if size != nil {
-		w.Header().Set("Content-Length", strconv.Itoa(*size))
-	}
+	defer speech.Body.Close()
+	setSpeechHeaders(w, speech)
 
-	wlog.Debug(fmt.Sprintf("[%s] play tts", c.RequestId))
+	wlog.Debug(fmt.Sprintf("[%s] play tts of %s", c.RequestId, speech.Provider))
 
 	if params.Format == "mp3" {
-		ttsCopy(w, out)
+		ttsCopy(w, speech.Body)
 	} else {
-		io.Copy(w, out)
+		io.Copy(w, speech.Body)
+	}
+}
+
+func setSpeechHeaders(w http.ResponseWriter, speech *tts2.Speech) {
+	if speech.MimeType != nil {
+		w.Header().Set("Content-Type", *speech.MimeType)
+	}
+	if speech.Size != nil {
+		w.Header().Set("Content-Length", strconv.Itoa(*speech.Size))
 	}
+	w.Header().Set(app.HeaderTtsProvider, speech.Provider)
 }
 
 func ttsCopy(dst io.Writer, src io.Reader) {
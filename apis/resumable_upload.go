@@ -0,0 +1,176 @@
+package apis
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/webitel/storage/model"
+	"github.com/webitel/storage/utils"
+)
+
+const resumableUploadContentType = "application/offset+octet-stream"
+
+// tus-style protocol: POST creates the upload, HEAD returns the offset, PATCH appends the bytes from the offset
+func (api *API) InitResumableUpload() {
+	api.PublicRoutes.Files.Handle("/{id}/upload/resumable", api.ApiSessionRequired(createResumableUpload)).Methods("POST")
+	api.PublicRoutes.Files.Handle("/{id}/upload/resumable/{upload_id}", api.ApiSessionRequired(headResumableUpload)).Methods("HEAD")
+	api.PublicRoutes.Files.Handle("/{id}/upload/resumable/{upload_id}", api.ApiSessionRequired(getResumableUpload)).Methods("GET")
+	api.PublicRoutes.Files.Handle("/{id}/upload/resumable/{upload_id}", api.ApiSessionRequired(patchResumableUpload)).Methods("PATCH")
+	api.PublicRoutes.Files.Handle("/{id}/upload/resumable/{upload_id}", api.ApiSessionRequired(deleteResumableUpload)).Methods("DELETE")
+}
+
+func createResumableUpload(c *Context, w http.ResponseWriter, r *http.Request) {
+	c.RequireId()
+
+	if c.Err != nil {
+		return
+	}
+
+	size, err := strconv.ParseInt(r.Header.Get("Upload-Length"), 10, 64)
+	if err != nil {
+		c.SetInvalidParam("Upload-Length")
+		return
+	}
+
+	q := r.URL.Query()
+	meta := parseUploadMetadata(r.Header.Get("Upload-Metadata"))
+
+	upload := &model.ResumableUpload{
+		DomainId:          c.Session.DomainId,
+		Uuid:              c.Params.Id,
+		ViewName:          firstNotEmpty(q.Get("name"), meta["filename"]),
+		MimeType:          firstNotEmpty(meta["filetype"], "application/octet-stream"),
+		Channel:           firstNotEmpty(q.Get("channel"), meta["channel"], model.UploadFileChannelUnknown),
+		Size:              size,
+		GenerateThumbnail: q.Get("thumbnail") == "true",
+		CustomProperties:  CustomPropertiesFromQuery(q),
+		UploadedBy:        &c.Session.UserId,
+	}
+
+	if upload, c.Err = c.App.CreateResumableUpload(upload); c.Err != nil {
+		if c.Err.GetId() == "app.resumable_upload.create.size" {
+			c.Err.SetDetailedError(utils.BytesSize(float64(c.App.MaxUploadFileSize())))
+		}
+		return
+	}
+
+	setResumableUploadHeaders(w, upload)
+	w.Header().Set("Location", strings.TrimSuffix(r.URL.Path, "/")+"/"+upload.Id)
+	w.WriteHeader(http.StatusCreated)
+}
+
+func headResumableUpload(c *Context, w http.ResponseWriter, r *http.Request) {
+	var upload *model.ResumableUpload
+
+	if upload, c.Err = c.App.GetResumableUpload(c.Session.DomainId, mux.Vars(r)["upload_id"]); c.Err != nil {
+		return
+	}
+
+	setResumableUploadHeaders(w, upload)
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+}
+
+func getResumableUpload(c *Context, w http.ResponseWriter, r *http.Request) {
+	var upload *model.ResumableUpload
+
+	if upload, c.Err = c.App.GetResumableUpload(c.Session.DomainId, mux.Vars(r)["upload_id"]); c.Err != nil {
+		return
+	}
+
+	setResumableUploadHeaders(w, upload)
+	w.Header().Set("Cache-Control", "no-store")
+	data, _ := json.Marshal(upload)
+	w.Write(data)
+}
+
+func patchResumableUpload(c *Context, w http.ResponseWriter, r *http.Request) {
+	var upload *model.ResumableUpload
+	defer r.Body.Close()
+
+	if r.Header.Get("Content-Type") != resumableUploadContentType {
+		c.Err = model.NewCustomCodeError("api.resumable_upload.content_type", "Content-Type must be "+resumableUploadContentType, http.StatusUnsupportedMediaType)
+		return
+	}
+
+	offset, err := strconv.ParseInt(r.Header.Get("Upload-Offset"), 10, 64)
+	if err != nil {
+		c.SetInvalidParam("Upload-Offset")
+		return
+	}
+
+	upload, c.Err = c.App.WriteResumableUpload(c.Session.DomainId, mux.Vars(r)["upload_id"], offset, r.Body)
+	if upload != nil {
+		setResumableUploadHeaders(w, upload)
+	}
+
+	if c.Err != nil {
+		return
+	}
+
+	if !upload.Completed() {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	sig, _ := c.App.GeneratePreSignedResourceSignature(model.AnyFileRouteName, "download", *upload.FileId, upload.DomainId)
+	data, _ := json.Marshal(&fileResponse{
+		Id:        *upload.FileId,
+		Name:      upload.ViewName,
+		Size:      upload.Size,
+		MimeType:  upload.MimeType,
+		SharedUrl: sig,
+	})
+	w.Write(data)
+}
+
+func deleteResumableUpload(c *Context, w http.ResponseWriter, r *http.Request) {
+	if c.Err = c.App.RemoveResumableUpload(c.Session.DomainId, mux.Vars(r)["upload_id"]); c.Err != nil {
+		return
+	}
+
+	w.Header().Set("Tus-Resumable", model.ResumableUploadProtocolVersion)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func setResumableUploadHeaders(w http.ResponseWriter, upload *model.ResumableUpload) {
+	w.Header().Set("Tus-Resumable", model.ResumableUploadProtocolVersion)
+	w.Header().Set("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+	w.Header().Set("Upload-Length", strconv.FormatInt(upload.Size, 10))
+	if upload.FileId != nil {
+		w.Header().Set("Upload-File-Id", strconv.FormatInt(*upload.FileId, 10))
+	}
+}
+
+// parseUploadMetadata parses "key base64value,key base64value"
+func parseUploadMetadata(s string) map[string]string {
+	meta := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		kv := strings.Fields(pair)
+		if len(kv) == 0 {
+			continue
+		}
+
+		var v []byte
+		if len(kv) > 1 {
+			v, _ = base64.StdEncoding.DecodeString(kv[1])
+		}
+		meta[kv[0]] = string(v)
+	}
+
+	return meta
+}
+
+func firstNotEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+
+	return ""
+}
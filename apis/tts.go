@@ -23,25 +23,26 @@ func tts(c *Context, w http.ResponseWriter, r *http.Request, download bool) {
 	if params.DomainId == 0 {
 		params.DomainId = int(c.Session.DomainId)
 	}
-	out, t, size, err := c.App.TTS(app.TtsProfile, params)
+	speech, err := c.App.TTS(app.TtsProfile, params)
 	if err != nil {
 		c.Err = err
 		return
 	}
 
-	defer out.Close()
+	defer speech.Body.Close()
 
-	if t != nil {
-		w.Header().Set("Content-Type", *t)
+	if speech.MimeType != nil {
+		w.Header().Set("Content-Type", *speech.MimeType)
 	}
 
-	if size != nil {
-		w.Header().Set("Content-Length", strconv.Itoa(*size))
+	if speech.Size != nil {
+		w.Header().Set("Content-Length", strconv.Itoa(*speech.Size))
 	}
+	w.Header().Set(app.HeaderTtsProvider, speech.Provider)
 
 	if download {
 		w.Header().Set("Content-Disposition", "attachment; filename=\"tts_output.wav\"")
 	}
 
-	io.Copy(w, out)
+	io.Copy(w, speech.Body)
 }
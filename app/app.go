@@ -52,6 +52,7 @@ type App struct {
 	MediaFileStore   utils.FileBackend
 	FileCache        utils.FileBackend
 	DefaultFileStore utils.FileBackend
+	TtsCacheStore    utils.FileBackend
 
 	fileBackendCache *utils.Cache
 	sttProfilesCache *utils.Cache
@@ -79,7 +80,8 @@ type App struct {
 	ctx              context.Context
 	otelShutdownFunc otelsdk.ShutdownFunc
 
-	fileChipher utils.Chipher
+	keyring    *utils.Keyring
+	domainKeys *domainKeys
 
 	//------ Watcher Manager -------
 	watcherManager watcherkit.Manager
@@ -93,7 +95,7 @@ type App struct {
 	// ---- Logger ------
 	wtelLogger      *wlogger.Logger
 	loggerPublisher rabbitmq.Publisher
-	clamd           *Clamav
+	scanner         MalwareScanner
 }
 
 func New(options ...string) (outApp *App, outErr error) {
@@ -180,11 +182,21 @@ func New(options ...string) (outApp *App, outErr error) {
 	if len(config.CryptoKey) != 0 {
 		cryptoFileKey = config.CryptoKey
 	}
-	app.fileChipher, outErr = utils.NewChipher(cryptoFileKey)
+	app.keyring, outErr = utils.LoadKeyring(cryptoFileKey, config.CryptoKeyring)
 	if outErr != nil {
 		return nil, outErr
 	}
 
+	if config.CryptoKms != "" {
+		kms, err := utils.NewKMS(config.CryptoKms, config.CryptoKmsToken, app.keyring)
+		if err != nil {
+			return nil, err
+		}
+		app.domainKeys = newDomainKeys(app, kms)
+		app.keyring.SetDomainKeys(app.domainKeys)
+		app.Log.Info(fmt.Sprintf("use domain keys, kms \"%s\"", kms.Name()))
+	}
+
 	if err := app.initLocalFileStores(); err != nil {
 		return nil, err
 	}
@@ -193,9 +205,10 @@ func New(options ...string) (outApp *App, outErr error) {
 
 	app.cluster = NewCluster(app)
 
-	if app.Config().Clamav.Address != "" {
-		app.clamd = NewClamav(app.Config().Clamav)
-		app.Log.Info("use clamd")
+	if app.scanner, outErr = NewMalwareScanner(app.Config()); outErr != nil {
+		return nil, outErr
+	} else if app.scanner != nil {
+		app.Log.Info(fmt.Sprintf("use malware scanner \"%s\"", app.scanner.Name()))
 	}
 
 	if app.newStore == nil {
@@ -441,7 +454,17 @@ func (app *App) initLocalFileStores() model.AppError {
 			Type:       model.StorageBackendTypeFromString(fileSettings.Type),
 			ExpireDay:  fileSettings.ExpireDay,
 			Properties: fileSettings.Props,
-		}, app.fileChipher); appErr != nil {
+		}, app.keyring); appErr != nil {
+			return appErr
+		}
+	}
+
+	if ttsSettings := app.Config().TtsCache; ttsSettings.Enabled() {
+		if app.TtsCacheStore, appErr = utils.NewBackendStore(&model.FileBackendProfile{
+			Name:       "TTS cache",
+			Type:       model.StorageBackendTypeFromString(ttsSettings.Type),
+			Properties: ttsSettings.Props,
+		}, app.keyring); appErr != nil {
 			return appErr
 		}
 	}
@@ -0,0 +1,70 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/rabbitmq/amqp091-go"
+	"github.com/webitel/storage/model"
+	"github.com/webitel/wlog"
+)
+
+// AuditFiles records the action of the user with the files and publishes the event to the message broker
+// when audit_publish is enabled. The error of the audit doesn't fail the action
+func (app *App) AuditFiles(ctx context.Context, domainId int64, userId int64, ip string, action string, fileIds []int64, actionErr error) {
+	if len(fileIds) == 0 {
+		return
+	}
+
+	event := &model.AuditEvent{
+		CreatedAt: model.GetMillis(),
+		DomainId:  domainId,
+		Ip:        ip,
+		Action:    action,
+	}
+	if userId != 0 {
+		event.UserId = &userId
+	}
+	event.SetResult(actionErr)
+
+	// the event is stored when the request is canceled by the client
+	ctx = context.WithoutCancel(ctx)
+
+	if err := app.Store.Audit().Create(ctx, event, fileIds); err != nil {
+		wlog.Error(fmt.Sprintf("audit %s of files %v, error: %s", action, fileIds, err.Error()))
+	}
+
+	if app.Config().AuditPublish {
+		app.publishAuditEvent(ctx, event, fileIds)
+	}
+}
+
+func (app *App) SearchAuditEvents(ctx context.Context, domainId int64, search *model.SearchAuditEvent) ([]*model.AuditEvent, bool, model.AppError) {
+	res, err := app.Store.Audit().GetAllPage(ctx, domainId, search)
+	if err != nil {
+		return nil, false, err
+	}
+	search.RemoveLastElemIfNeed(&res)
+	return res, search.EndOfList(), nil
+}
+
+func (app *App) publishAuditEvent(ctx context.Context, event *model.AuditEvent, fileIds []int64) {
+	if app.rabbitPublisher == nil {
+		return
+	}
+
+	data, err := json.Marshal(&model.AuditAMQPMessage{
+		Event:   event,
+		FileIds: fileIds,
+	})
+	if err != nil {
+		wlog.Error(fmt.Sprintf("audit %s, marshal error: %s", event.Action, err.Error()))
+		return
+	}
+
+	routingKey := fmt.Sprintf("storage.audit.%s.%d", event.Action, event.DomainId)
+	if err = app.rabbitPublisher.Publish(ctx, routingKey, data, amqp091.Table{}); err != nil {
+		wlog.Error(fmt.Sprintf("audit %s, publish error: %s", event.Action, err.Error()))
+	}
+}
@@ -19,6 +19,10 @@ func (app *App) FileBackendProfileCheckAccess(domainId, id int64, groups []int,
 }
 
 func (app *App) CreateFileBackendProfile(profile *model.FileBackendProfile) (*model.FileBackendProfile, model.AppError) {
+	if err := app.validLinkedProfiles(profile); err != nil {
+		return nil, err
+	}
+
 	return app.Store.FileBackendProfile().Create(profile)
 }
 
@@ -65,6 +69,10 @@ func (app *App) UpdateFileBackendProfile(profile *model.FileBackendProfile) (*mo
 	oldProfile.Properties = profile.Properties
 	oldProfile.Description = profile.Description
 
+	if err = app.validLinkedProfiles(oldProfile); err != nil {
+		return nil, err
+	}
+
 	return app.Store.FileBackendProfile().Update(oldProfile)
 
 }
@@ -81,6 +89,10 @@ func (app *App) PatchFileBackendProfile(domainId, id int64, patch *model.FileBac
 		return nil, err
 	}
 
+	if err = app.validLinkedProfiles(oldProfile); err != nil {
+		return nil, err
+	}
+
 	return app.Store.FileBackendProfile().Update(oldProfile)
 }
 
@@ -150,7 +162,7 @@ func (app *App) GetFileBackendStore(id *int, syncTime *int64) (store utils.FileB
 		if err != nil {
 			return nil, err
 		}
-		return utils.NewBackendStore(profile, app.fileChipher)
+		return utils.NewBackendStore(profile, app.keyring)
 	})
 
 	if err != nil {
@@ -172,10 +184,47 @@ func (app *App) GetFileBackendStore(id *int, syncTime *int64) (store utils.FileB
 	return store, nil
 }
 
+// validLinkedProfiles checks that replica and tiering profiles exist in the domain
+func (app *App) validLinkedProfiles(profile *model.FileBackendProfile) model.AppError {
+	for _, id := range profile.ReplicaIds() {
+		if _, err := app.GetFileBackendProfile(int64(id), profile.DomainId); err != nil {
+			return model.NewBadRequestError("app.backend_profile.replicas.valid", fmt.Sprintf("replica profile %d: %s", id, err.Error()))
+		}
+	}
+
+	rules, _ := profile.TieringRules()
+	for _, r := range rules {
+		if _, err := app.GetFileBackendProfile(int64(r.ProfileId), profile.DomainId); err != nil {
+			return model.NewBadRequestError("app.backend_profile.tiering.valid", fmt.Sprintf("tiering profile %d: %s", r.ProfileId, err.Error()))
+		}
+	}
+
+	return nil
+}
+
 func (app *App) SetRemoveFileJobs() model.AppError {
 	return app.Store.SyncFile().SetRemoveJobs(app.DefaultFileStore.ExpireDay())
 }
 
+func (app *App) SetMigrateFileJobs() model.AppError {
+	return app.Store.SyncFile().SetMigrateJobs()
+}
+
+// SetReEncryptFileJobs creates jobs for encrypted files whose key is older than the current key of the keyring,
+// the domain keys wrapped with the keyring are moved to the current key as well
+func (app *App) SetReEncryptFileJobs() model.AppError {
+	if err := app.RewrapDomainKeys(); err != nil {
+		wlog.Error(fmt.Sprintf("rewrap domain keys error: %s", err.Error()))
+	}
+
+	return app.Store.SyncFile().SetReEncryptJobs(app.keyring.Current())
+}
+
+// CurrentKeyVersion returns the version of the key the new files are encrypted with
+func (app *App) CurrentKeyVersion() int {
+	return app.keyring.Current()
+}
+
 func (app *App) FetchFileJobs(limit int) ([]*model.SyncJob, model.AppError) {
 	return app.Store.SyncFile().FetchJobs(limit)
 }
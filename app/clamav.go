@@ -2,6 +2,10 @@ package app
 
 import (
 	"fmt"
+	"io"
+	"strings"
+	"time"
+
 	"github.com/webitel/storage/app/clamd"
 	"github.com/webitel/storage/model"
 	"github.com/webitel/wlog"
@@ -38,3 +42,64 @@ func (c *Clamav) IsStoreFile(fv *model.MalwareScan) bool {
 
 	return true
 }
+
+func (c *Clamav) Name() string {
+	return "clamav"
+}
+
+// Scan returns the result of the antivirus scan of the content, the status is ERROR when clamd is not available
+func (c *Clamav) Scan(src io.Reader) *model.MalwareScan {
+	now := time.Now()
+	ms := &model.MalwareScan{
+		Status:   model.MalwareStatusError,
+		ScanDate: &now,
+	}
+
+	cancel := make(chan bool)
+	defer close(cancel)
+
+	ch, err := c.ScanStream(src, cancel)
+	if err != nil {
+		ms.Desc = model.NewString(err.Error())
+		return ms
+	}
+
+	res := <-ch
+	if res == nil {
+		ms.Desc = model.NewString("empty response")
+		return ms
+	}
+
+	switch res.Status {
+	case clamd.RES_OK:
+		ms.Status = model.MalwareStatusOk
+	case clamd.RES_FOUND:
+		ms.Status = model.MalwareStatusFound
+		ms.Found = true
+		ms.Desc = &res.Description
+	default:
+		ms.Desc = model.NewString(res.Description)
+	}
+
+	return ms
+}
+
+// Signatures returns the version of the signature database, clamd replies "ClamAV 1.0.0/27000/Mon Jan 1 00:00:00 2024"
+func (c *Clamav) Signatures() (string, error) {
+	ch, err := c.Version()
+	if err != nil {
+		return "", err
+	}
+
+	res := <-ch
+	if res == nil {
+		return "", fmt.Errorf("empty response")
+	}
+
+	parts := strings.Split(res.Raw, "/")
+	if len(parts) < 2 || parts[1] == "" {
+		return "", fmt.Errorf("bad version \"%s\"", res.Raw)
+	}
+
+	return parts[1], nil
+}
@@ -35,6 +35,17 @@ func (app *App) GetCognitiveProfile(id, domain int64) (*model.CognitiveProfile,
 	return app.Store.CognitiveProfile().Get(id, domain)
 }
 
+// SearchCognitiveProfileVoices returns the voices of the TTS engine of the profile that contain q
+func (app *App) SearchCognitiveProfileVoices(id, domain int64, q string) ([]*model.CognitiveProfileVoice, model.AppError) {
+	profile, err := app.GetCognitiveProfile(id, domain)
+	if err != nil {
+		return nil, err
+	}
+
+	// the voices of the Local provider (tts.LocalVoices) are listed when the provider is registered
+	return nil, model.NewBadRequestError("app.cognitive_profile.voices.not_supported", "Provider "+profile.Provider+" doesn't list the voices")
+}
+
 func (app *App) UpdateCognitiveProfile(profile *model.CognitiveProfile) (*model.CognitiveProfile, model.AppError) {
 	oldProfile, err := app.GetCognitiveProfile(profile.Id, profile.DomainId)
 	if err != nil {
@@ -40,9 +40,24 @@ func loadConfig(fileName string) (*model.Config, model.AppError) {
 		config.DefaultFileStore = nil
 	}
 
+	if config.TtsCache.Enabled() {
+		if config.TtsCache.PropsString != "" {
+			if err = json.Unmarshal([]byte(config.TtsCache.PropsString), &config.TtsCache.Props); err != nil {
+				panic(err)
+			}
+		}
+		if config.TtsCache.MaxSize, err = utils.FromHumanSize(config.TtsCache.MaxSizeString); err != nil {
+			panic(err.Error())
+		}
+		if config.TtsCache.MaxEntrySize, err = utils.FromHumanSize(config.TtsCache.MaxEntrySizeString); err != nil {
+			panic(err.Error())
+		}
+	}
+
 	if config.TtsEndpoint != "" {
 		tts.SetWbtTTSEndpoint(config.TtsEndpoint)
 	}
+	tts.SetLocalEngine(config.TtsLocal)
 
 	if !config.Log.Console && !config.Log.Otel && len(config.Log.File) == 0 {
 		config.Log.Console = true
@@ -0,0 +1,297 @@
+package app
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/webitel/storage/model"
+	"github.com/webitel/storage/utils"
+	"github.com/webitel/wlog"
+)
+
+func (app *App) directUploadBackend(store utils.FileBackend) (utils.DirectUploadBackend, model.AppError) {
+	backend, ok := store.(utils.DirectUploadBackend)
+	if !ok || store.Id() == nil {
+		return nil, model.NewBadRequestError("app.direct_upload.backend", fmt.Sprintf("store \"%s\" doesn't support direct uploads", store.Name()))
+	}
+
+	return backend, nil
+}
+
+// CreateDirectUpload issues presigned urls to upload the file to the domain backend bypassing the service
+func (app *App) CreateDirectUpload(domainId int64, uuid string, req *model.DirectUploadRequest, custom *model.CustomFileProperties, uploadedBy *int64) (*model.DirectUpload, model.AppError) {
+	if err := req.IsValid(); err != nil {
+		return nil, err
+	}
+
+	if max := app.MaxUploadFileSize(); max > 0 && req.Size > max {
+		return nil, model.NewCustomCodeError(utils.ErrMaxLimitId, fmt.Sprintf("size %d exceeds maximum %d", req.Size, max), http.StatusRequestEntityTooLarge)
+	}
+
+	store, err := app.domainStore(domainId)
+	if err != nil {
+		return nil, err
+	}
+
+	backend, err := app.directUploadBackend(store)
+	if err != nil {
+		return nil, err
+	}
+
+	channel := model.UploadFileChannelUnknown
+	if req.Channel != nil && *req.Channel != "" {
+		channel = *req.Channel
+	}
+
+	base := &model.BaseFile{
+		Name:     model.NewId() + "_" + req.Name,
+		MimeType: req.MimeType,
+		Size:     req.Size,
+		Channel:  &channel,
+	}
+
+	if app.useMalwareScan(domainId, base) {
+		return nil, model.NewBadRequestError("app.direct_upload.malware_scan", "files of the channel are scanned by antivirus, upload the file through the service")
+	}
+
+	maxSize, err := app.FilePolicyForDirectUpload(domainId, base)
+	if err != nil {
+		return nil, err
+	}
+	if maxSize > 0 && req.Size > maxSize {
+		return nil, model.PolicyErrorMaxLimit
+	}
+
+	expire := app.Config().DirectUploadExpire
+	upload := &model.DirectUpload{
+		DomainId:         domainId,
+		Uuid:             uuid,
+		Name:             base.Name,
+		ViewName:         req.Name,
+		MimeType:         req.MimeType,
+		Channel:          channel,
+		Size:             req.Size,
+		ProfileId:        *store.Id(),
+		Properties:       base.Properties,
+		RetentionUntil:   base.RetentionUntil,
+		CustomProperties: custom,
+		UploadedBy:       uploadedBy,
+		ExpiresAt:        model.GetMillis() + expire.Milliseconds(),
+	}
+
+	file := upload.File()
+	if req.Size <= model.DirectUploadPartSize {
+		upload.Url, upload.Headers, err = backend.PresignPut(file, req.Size, expire)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		var multipartId string
+		if multipartId, err = backend.CreateMultipartUpload(file); err != nil {
+			return nil, err
+		}
+		upload.MultipartId = &multipartId
+		upload.PartSize = model.DirectUploadPartSize
+
+		for offset, n := int64(0), 1; offset < req.Size; offset, n = offset+upload.PartSize, n+1 {
+			part := model.DirectUploadPart{
+				Number: n,
+				Size:   min(upload.PartSize, req.Size-offset),
+			}
+			if part.Url, err = backend.PresignPart(file, multipartId, part.Number, part.Size, expire); err != nil {
+				backend.AbortMultipartUpload(file, multipartId)
+				return nil, err
+			}
+			upload.Parts = append(upload.Parts, part)
+		}
+	}
+	upload.Method = http.MethodPut
+	upload.Properties = file.Properties
+
+	if _, err = app.Store.DirectUpload().Create(upload); err != nil {
+		if upload.IsMultipart() {
+			backend.AbortMultipartUpload(file, *upload.MultipartId)
+		}
+		return nil, err
+	}
+
+	wlog.Debug(fmt.Sprintf("created direct upload id=%s to \"%s\", name=%s, size=%d, parts=%d", upload.Id, store.Name(), upload.Name, upload.Size, len(upload.Parts)))
+
+	return upload, nil
+}
+
+// CompleteDirectUpload claims the upload, verifies the uploaded object by the policy and creates the file
+func (app *App) CompleteDirectUpload(domainId int64, id string, complete *model.DirectUploadComplete) (*model.File, model.AppError) {
+	var deleted bool
+	upload, err := app.Store.DirectUpload().Get(domainId, id)
+	if err != nil {
+		return nil, err
+	}
+
+	store, err := app.GetFileBackendStore(&upload.ProfileId, upload.ProfileUpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	backend, err := app.directUploadBackend(store)
+	if err != nil {
+		return nil, err
+	}
+
+	if upload.IsMultipart() && (complete == nil || len(complete.Parts) == 0) {
+		return nil, model.NewBadRequestError("app.direct_upload.complete.parts", "parts are required")
+	}
+
+	// the upload is claimed before the object is verified, so the concurrent request can't store the same object twice
+	if deleted, err = app.Store.DirectUpload().Delete(domainId, id); err != nil {
+		return nil, err
+	} else if !deleted {
+		return nil, model.NewNotFoundError("app.direct_upload.complete.not_found", "upload was completed or aborted")
+	}
+
+	file := upload.File()
+	file.Instance = app.GetInstanceId()
+
+	if upload.IsMultipart() {
+		if err = backend.CompleteMultipartUpload(file, *upload.MultipartId, complete.Parts); err != nil {
+			app.cleanDirectUpload(upload)
+			return nil, err
+		}
+	}
+
+	if err = app.verifyDirectUpload(backend, upload, file); err != nil {
+		wlog.Warn(fmt.Sprintf("direct upload id=%s, name=%s rejected: %s", upload.Id, upload.Name, err.Error()))
+		if e := backend.Remove(file); e != nil {
+			wlog.Error(fmt.Sprintf("direct upload id=%s, remove error: %s", upload.Id, e.Error()))
+		}
+		return nil, err
+	}
+
+	if _, err = app.storeFile(store, file, nil); err != nil {
+		if e := backend.Remove(file); e != nil {
+			wlog.Error(fmt.Sprintf("direct upload id=%s, remove error: %s", upload.Id, e.Error()))
+		}
+		return nil, err
+	}
+
+	return file, nil
+}
+
+// verifyDirectUpload checks the object by the policy, the whole object is read to compute the hash of the file
+func (app *App) verifyDirectUpload(backend utils.DirectUploadBackend, upload *model.DirectUpload, file *model.File) model.AppError {
+	size, mimeType, err := backend.Stat(file)
+	if err != nil {
+		return err
+	}
+
+	if size != upload.Size {
+		return model.NewBadRequestError("app.direct_upload.complete.size", fmt.Sprintf("uploaded %d bytes, expected %d", size, upload.Size))
+	}
+
+	if mimeType != upload.MimeType {
+		return model.NewBadRequestError("app.direct_upload.complete.mime_type", fmt.Sprintf("uploaded %s, expected %s", mimeType, upload.MimeType))
+	}
+
+	// the policy could be changed after the urls were issued
+	check := file.BaseFile
+	maxSize, err := app.FilePolicyForDirectUpload(upload.DomainId, &check)
+	if err != nil {
+		return err
+	}
+	if maxSize > 0 && size > maxSize {
+		return model.PolicyErrorMaxLimit
+	}
+
+	src, err := backend.Reader(file, 0)
+	if err != nil {
+		return err
+	}
+
+	reader, err := app.FilePolicyForUpload(upload.DomainId, &check, src)
+	if err != nil {
+		src.Close()
+		return err
+	}
+	defer reader.Close()
+
+	h := sha256.New()
+	n, e := io.Copy(h, reader)
+	if e != nil {
+		if appErr, ok := e.(model.AppError); ok {
+			return appErr
+		}
+		return model.NewInternalError("app.direct_upload.complete.read", e.Error())
+	}
+
+	if n != upload.Size {
+		return model.NewBadRequestError("app.direct_upload.complete.size", fmt.Sprintf("read %d bytes, expected %d", n, upload.Size))
+	}
+
+	file.SHA256Sum = model.NewString(hex.EncodeToString(h.Sum(nil)))
+
+	return nil
+}
+
+// AbortDirectUpload removes the upload and the uploaded parts
+func (app *App) AbortDirectUpload(domainId int64, id string) model.AppError {
+	upload, err := app.Store.DirectUpload().Get(domainId, id)
+	if err != nil {
+		return err
+	}
+
+	if deleted, err := app.Store.DirectUpload().Delete(domainId, id); err != nil || !deleted {
+		return err
+	}
+
+	app.cleanDirectUpload(upload)
+
+	return nil
+}
+
+// RemoveExpiredDirectUploads removes the uploads that were not completed in time
+func (app *App) RemoveExpiredDirectUploads() model.AppError {
+	uploads, err := app.Store.DirectUpload().RemoveExpired(100)
+	if err != nil {
+		return err
+	}
+
+	for _, upload := range uploads {
+		app.cleanDirectUpload(upload)
+	}
+
+	if len(uploads) != 0 {
+		wlog.Debug(fmt.Sprintf("removed %d expired direct uploads", len(uploads)))
+	}
+
+	return nil
+}
+
+func (app *App) cleanDirectUpload(upload *model.DirectUpload) {
+	store, err := app.GetFileBackendStore(&upload.ProfileId, upload.ProfileUpdatedAt)
+	if err != nil {
+		wlog.Error(fmt.Sprintf("direct upload id=%s, profile %d error: %s", upload.Id, upload.ProfileId, err.Error()))
+		return
+	}
+
+	backend, err := app.directUploadBackend(store)
+	if err != nil {
+		wlog.Error(fmt.Sprintf("direct upload id=%s, error: %s", upload.Id, err.Error()))
+		return
+	}
+
+	file := upload.File()
+	if upload.IsMultipart() {
+		err = backend.AbortMultipartUpload(file, *upload.MultipartId)
+	} else if _, _, err = backend.Stat(file); err == nil {
+		err = backend.Remove(file)
+	} else if err.GetStatusCode() == http.StatusNotFound {
+		err = nil
+	}
+
+	if err != nil {
+		wlog.Error(fmt.Sprintf("direct upload id=%s, clean \"%s\" error: %s", upload.Id, store.Name(), err.Error()))
+	}
+}
@@ -0,0 +1,237 @@
+package app
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/webitel/storage/model"
+	"github.com/webitel/storage/utils"
+	"github.com/webitel/wlog"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	// the destroyed key may be used by other instances while it's cached
+	domainKeyExpire = 60
+
+	domainKeyRewrapLimit = 100
+)
+
+// domainKeys keeps the unwrapped data keys of the domains for the envelope encryption
+type domainKeys struct {
+	app    *App
+	kms    utils.KMS
+	active utils.ObjectCache
+	keys   utils.ObjectCache
+	group  singleflight.Group
+}
+
+type domainChipher struct {
+	id      int64
+	chipher utils.Chipher
+}
+
+func newDomainKeys(app *App, kms utils.KMS) *domainKeys {
+	return &domainKeys{
+		app:    app,
+		kms:    kms,
+		active: utils.NewLruWithParams(500, "domain active keys", domainKeyExpire, ""),
+		keys:   utils.NewLruWithParams(1000, "domain keys", domainKeyExpire, ""),
+	}
+}
+
+// ActiveKey returns the key of the domain, the key is created by the first encrypted file of the domain
+func (d *domainKeys) ActiveKey(domainId int64) (int64, utils.Chipher, error) {
+	if c, ok := d.active.Get(domainId); ok {
+		return c.(*domainChipher).id, c.(*domainChipher).chipher, nil
+	}
+
+	v, err, _ := d.group.Do("active-"+strconv.FormatInt(domainId, 10), func() (interface{}, error) {
+		key, err := d.app.Store.DomainKey().GetActive(domainId)
+		if err != nil {
+			return nil, err
+		}
+
+		if key == nil {
+			if key, err = d.createKey(domainId); err != nil {
+				return nil, err
+			}
+		}
+
+		c, e := d.unwrap(key)
+		if e != nil {
+			return nil, e
+		}
+		d.active.AddWithDefaultExpires(domainId, c)
+
+		return c, nil
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return v.(*domainChipher).id, v.(*domainChipher).chipher, nil
+}
+
+func (d *domainKeys) Key(domainId int64, id int64) (utils.Chipher, error) {
+	if c, ok := d.keys.Get(id); ok {
+		return c.(*domainChipher).chipher, nil
+	}
+
+	v, err, _ := d.group.Do("key-"+strconv.FormatInt(id, 10), func() (interface{}, error) {
+		key, err := d.app.Store.DomainKey().Get(domainId, id)
+		if err != nil {
+			return nil, err
+		}
+
+		return d.unwrap(key)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return v.(*domainChipher).chipher, nil
+}
+
+func (d *domainKeys) createKey(domainId int64) (*model.DomainKey, model.AppError) {
+	dataKey, e := utils.NewDataKey()
+	if e != nil {
+		return nil, model.NewInternalError("app.domain_key.create.app_error", e.Error())
+	}
+
+	wrapped, e := d.kms.WrapKey(dataKey)
+	if e != nil {
+		return nil, model.NewInternalError("app.domain_key.wrap.app_error", e.Error())
+	}
+
+	key, err := d.app.Store.DomainKey().Create(&model.DomainKey{
+		DomainId:   domainId,
+		Kms:        d.kms.Name(),
+		WrappedKey: &wrapped,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	wlog.Debug(fmt.Sprintf("domain %d, created key %d with kms \"%s\"", domainId, key.Id, key.Kms))
+
+	return key, nil
+}
+
+func (d *domainKeys) unwrap(key *model.DomainKey) (*domainChipher, model.AppError) {
+	if key.IsDestroyed() {
+		return nil, model.NewCustomCodeError("app.domain_key.destroyed", fmt.Sprintf("domain key %d is destroyed", key.Id), http.StatusGone)
+	}
+
+	if key.Kms != d.kms.Name() {
+		return nil, model.NewInternalError("app.domain_key.kms", fmt.Sprintf("domain key %d is wrapped by kms \"%s\"", key.Id, key.Kms))
+	}
+
+	dataKey, e := d.kms.UnwrapKey(*key.WrappedKey)
+	if e != nil {
+		return nil, model.NewInternalError("app.domain_key.unwrap.app_error", e.Error())
+	}
+
+	c, e := utils.NewDataKeyChipher(dataKey)
+	if e != nil {
+		return nil, model.NewInternalError("app.domain_key.unwrap.app_error", e.Error())
+	}
+
+	dc := &domainChipher{
+		id:      key.Id,
+		chipher: c,
+	}
+	d.keys.AddWithDefaultExpires(key.Id, dc)
+
+	return dc, nil
+}
+
+func (app *App) domainKeysEnabled() model.AppError {
+	if app.domainKeys == nil {
+		return model.NewBadRequestError("app.domain_key.disabled", "domain keys are not configured, set crypto_kms")
+	}
+
+	return nil
+}
+
+func (app *App) GetDomainKey(domainId int64) (*model.DomainKey, model.AppError) {
+	if err := app.domainKeysEnabled(); err != nil {
+		return nil, err
+	}
+
+	key, err := app.Store.DomainKey().GetActive(domainId)
+	if err != nil {
+		return nil, err
+	}
+
+	if key == nil {
+		return nil, model.NewNotFoundError("app.domain_key.not_found", "domain has no key")
+	}
+
+	return key, nil
+}
+
+// DestroyDomainKey destroys the active key of the domain (crypto-shredding), the files encrypted with the key
+// can't be read anymore, the next encrypted file creates the new key
+func (app *App) DestroyDomainKey(domainId int64) (*model.DomainKey, model.AppError) {
+	if err := app.domainKeysEnabled(); err != nil {
+		return nil, err
+	}
+
+	key, err := app.Store.DomainKey().Destroy(domainId)
+	if err != nil {
+		return nil, err
+	}
+
+	app.domainKeys.active.Remove(domainId)
+	app.domainKeys.keys.Remove(key.Id)
+	wlog.Info(fmt.Sprintf("domain %d, destroyed key %d", domainId, key.Id))
+
+	return key, nil
+}
+
+// RewrapDomainKeys wraps the keys of the domains wrapped with the old key of the keyring with the current key,
+// the data keys are not changed, so the files are read with the cached keys during the rotation
+func (app *App) RewrapDomainKeys() model.AppError {
+	if app.domainKeys == nil {
+		return nil
+	}
+
+	kms, ok := app.domainKeys.kms.(*utils.LocalKMS)
+	if !ok {
+		return nil
+	}
+
+	prefix := kms.CurrentPrefix()
+	for {
+		keys, err := app.Store.DomainKey().GetNotWrappedWith(kms.Name(), prefix, domainKeyRewrapLimit)
+		if err != nil {
+			return err
+		}
+
+		for _, key := range keys {
+			dataKey, e := kms.UnwrapKey(*key.WrappedKey)
+			if e != nil {
+				return model.NewInternalError("app.domain_key.unwrap.app_error", fmt.Sprintf("domain key %d: %s", key.Id, e.Error()))
+			}
+
+			wrapped, e := kms.WrapKey(dataKey)
+			if e != nil {
+				return model.NewInternalError("app.domain_key.wrap.app_error", fmt.Sprintf("domain key %d: %s", key.Id, e.Error()))
+			}
+
+			if ok, err = app.Store.DomainKey().Rewrap(key.Id, *key.WrappedKey, wrapped); err != nil {
+				return err
+			}
+
+			if ok {
+				wlog.Debug(fmt.Sprintf("domain %d, key %d wrapped with \"%s\"", key.DomainId, key.Id, prefix))
+			}
+		}
+
+		if len(keys) < domainKeyRewrapLimit {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package app
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/webitel/storage/model"
+	"github.com/webitel/wlog"
+)
+
+// FileArchiveIds returns the ids of the files of the archive, the removed files are skipped
+func (app *App) FileArchiveIds(ctx context.Context, domainId int64, archive *model.FileArchive) ([]int64, model.AppError) {
+	search := archive.Search
+	search.Fields = []string{"id"}
+	search.Sort = "id"
+	search.PerPage = model.FileArchiveMaxFiles
+	search.Removed = model.NewBool(false)
+
+	ids := make([]int64, 0, len(search.Ids))
+	for page := 1; ; page++ {
+		search.Page = page
+		files, end, err := app.SearchFiles(ctx, domainId, &search)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range files {
+			ids = append(ids, f.Id)
+		}
+		if len(ids) > model.FileArchiveMaxFiles {
+			return nil, model.NewCustomCodeError("app.file_archive.limit", fmt.Sprintf("the archive is limited to %d files", model.FileArchiveMaxFiles), http.StatusBadRequest)
+		}
+		if end {
+			break
+		}
+	}
+
+	if len(ids) == 0 {
+		return nil, model.NewNotFoundError("app.file_archive.not_found", "files not found")
+	}
+
+	return ids, nil
+}
+
+// WriteFileArchive streams the zip archive of the files to w, each entry is read from the backend of the file
+// through the download policy. The file that is not allowed or can't be read is listed in the errors.json entry
+// of the archive, returns the ids of the archived files
+func (app *App) WriteFileArchive(ctx context.Context, domainId int64, ids []int64, w io.Writer, allow func(file *model.File) model.AppError) ([]int64, model.AppError) {
+	zw := zip.NewWriter(w)
+	names := make(map[string]struct{}, len(ids))
+	archived := make([]int64, 0, len(ids))
+	var failed []model.FileArchiveError
+	// the name of the errors entry is reserved
+	names[model.FileArchiveErrors] = struct{}{}
+
+	for _, id := range ids {
+		if ctx.Err() != nil {
+			return archived, model.NewInternalError("app.file_archive.canceled", ctx.Err().Error())
+		}
+
+		file, src, err := app.fileArchiveReader(domainId, id, allow)
+		if err != nil {
+			wlog.Warn(fmt.Sprintf("archive file %d, skip: %s", id, err.Error()))
+			failed = append(failed, model.FileArchiveError{Id: id, Error: err.Error()})
+			continue
+		}
+
+		err = writeArchiveEntry(zw, fileArchiveEntryName(names, file), file, src)
+		src.Close()
+		if err != nil {
+			return archived, err
+		}
+		archived = append(archived, id)
+	}
+
+	if len(failed) != 0 {
+		ew, e := zw.Create(model.FileArchiveErrors)
+		if e == nil {
+			e = json.NewEncoder(ew).Encode(failed)
+		}
+		if e != nil {
+			return archived, model.NewInternalError("app.file_archive.errors", e.Error())
+		}
+	}
+
+	if e := zw.Close(); e != nil {
+		return archived, model.NewInternalError("app.file_archive.close", e.Error())
+	}
+
+	return archived, nil
+}
+
+func (app *App) fileArchiveReader(domainId int64, id int64, allow func(file *model.File) model.AppError) (*model.File, io.ReadCloser, model.AppError) {
+	file, backend, err := app.GetFileWithProfile(domainId, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if allow != nil {
+		if err = allow(file); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	reader, err := backend.Reader(file, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	src, err := app.FilePolicyForDownload(domainId, &file.BaseFile, reader)
+	if err != nil {
+		reader.Close()
+		return nil, nil, err
+	}
+
+	return file, src, nil
+}
+
+func writeArchiveEntry(zw *zip.Writer, name string, file *model.File, src io.Reader) model.AppError {
+	w, err := zw.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   fileArchiveMethod(file.MimeType),
+		Modified: time.UnixMilli(file.CreatedAt),
+	})
+	if err == nil {
+		_, err = io.Copy(w, src)
+	}
+	if err != nil {
+		return model.NewInternalError("app.file_archive.write", fmt.Sprintf("file %d: %s", file.Id, err.Error()))
+	}
+
+	return nil
+}
+
+// fileArchiveEntryName returns the unique name of the file in the archive
+func fileArchiveEntryName(names map[string]struct{}, file *model.File) string {
+	name := path.Base(file.GetViewName())
+	if _, ok := names[name]; ok || name == "." || name == "/" {
+		name = fmt.Sprintf("%d_%s", file.Id, name)
+	}
+	names[name] = struct{}{}
+
+	return name
+}
+
+// fileArchiveMethod stores the media files that are already compressed
+func fileArchiveMethod(mimeType string) uint16 {
+	if strings.HasPrefix(mimeType, "audio/") || strings.HasPrefix(mimeType, "video/") || strings.HasPrefix(mimeType, "image/") {
+		return zip.Store
+	}
+
+	return zip.Deflate
+}
@@ -0,0 +1,228 @@
+package app
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/webitel/storage/model"
+	"github.com/webitel/storage/utils"
+	"github.com/webitel/wlog"
+)
+
+// FileConversionReader returns the file converted to the format by ffmpeg and the size of the converted file,
+// the size is -1 when the file is converted on the fly. When FileConversionCache is enabled the converted file
+// is stored next to the source, so the next downloads of the format read it from the backend
+func (app *App) FileConversionReader(file *model.File, backend utils.FileBackend, format *model.AudioFormat) (io.ReadCloser, int64, model.AppError) {
+	if !strings.HasPrefix(file.MimeType, "audio/") && !strings.HasPrefix(file.MimeType, "video/") {
+		return nil, 0, model.NewBadRequestError("app.file_conversion.mime_type", fmt.Sprintf("file %s can't be converted to %s", file.MimeType, format.String()))
+	}
+
+	cache := app.Config().FileConversionCache
+	if cache {
+		conversion, err := app.Store.File().GetConversion(file.Id, format.String())
+		if err != nil {
+			return nil, 0, err
+		}
+
+		if conversion != nil {
+			reader, err := app.storedConversionReader(file, conversion)
+			if err == nil {
+				// the converted file is sent by the policy of the file as the file converted on the fly
+				policyReader, err := app.FilePolicyForDownload(file.DomainId, &file.BaseFile, reader)
+				if err != nil {
+					reader.Close()
+					return nil, 0, err
+				}
+				return policyReader, conversion.Size, nil
+			}
+			wlog.Warn(fmt.Sprintf("file %d, read conversion %s error: %s", file.Id, conversion.Format, err.Error()))
+		}
+	}
+
+	src, err := backend.Reader(file, 0)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	policyReader, err := app.FilePolicyForDownload(file.DomainId, &file.BaseFile, src)
+	if err != nil {
+		src.Close()
+		return nil, 0, err
+	}
+	src = policyReader
+
+	pr, pw := io.Pipe()
+	tr, e := utils.NewAudioTranscoding(src, pw, format)
+	if e == nil {
+		e = tr.Start()
+	}
+	if e != nil {
+		src.Close()
+		return nil, 0, model.NewInternalError("app.file_conversion.start", e.Error())
+	}
+
+	go func() {
+		e := tr.Wait()
+		src.Close()
+		if e != nil {
+			pw.CloseWithError(fmt.Errorf("convert to %s: %w", format.String(), e))
+		} else {
+			pw.Close()
+		}
+	}()
+
+	if !cache {
+		return pr, -1, nil
+	}
+
+	reader := &conversionReader{
+		app:     app,
+		r:       pr,
+		backend: backend,
+		file:    file,
+		format:  format,
+	}
+	reader.tmp, e = os.CreateTemp(app.Config().TempDir, "conversion_")
+	if e != nil {
+		wlog.Error(fmt.Sprintf("file %d, conversion cache error: %s", file.Id, e.Error()))
+		return pr, -1, nil
+	}
+
+	return reader, -1, nil
+}
+
+func (app *App) storedConversionReader(file *model.File, conversion *model.FileConversion) (io.ReadCloser, model.AppError) {
+	store, err := app.GetFileBackendStore(conversion.ProfileId, conversion.ProfileUpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return store.Reader(conversion.File(file), 0)
+}
+
+// RemoveFileConversions removes the stored conversions of the file
+func (app *App) RemoveFileConversions(file *model.File, fileId int64) {
+	conversions, err := app.Store.File().GetConversions(fileId)
+	if err != nil {
+		wlog.Error(fmt.Sprintf("file %d, get conversions error: %s", fileId, err.Error()))
+		return
+	}
+
+	app.RemoveConversionObjects(file, fileId, conversions)
+
+	if len(conversions) != 0 {
+		if err = app.Store.File().DeleteConversions(fileId); err != nil {
+			wlog.Error(fmt.Sprintf("file %d, delete conversions error: %s", fileId, err.Error()))
+		}
+	}
+}
+
+// RemoveConversionObjects removes the stored conversions of the file that is already deleted with its conversions
+func (app *App) RemoveConversionObjects(file *model.File, fileId int64, conversions []*model.FileConversion) {
+	for _, conversion := range conversions {
+		store, err := app.GetFileBackendStore(conversion.ProfileId, conversion.ProfileUpdatedAt)
+		if err != nil {
+			wlog.Error(fmt.Sprintf("file %d, conversion %s error: %s", fileId, conversion.Format, err.Error()))
+			continue
+		}
+
+		if err = store.Remove(conversion.File(file)); err != nil {
+			wlog.Error(fmt.Sprintf("file %d, remove conversion %s from \"%s\" error: %s", fileId, conversion.Format, store.Name(), err.Error()))
+		}
+	}
+}
+
+// conversionReader keeps the converted bytes in the temp file, the file is stored as the conversion
+// when the whole output of ffmpeg is read
+type conversionReader struct {
+	app     *App
+	r       *io.PipeReader
+	tmp     *os.File
+	backend utils.FileBackend
+	file    *model.File
+	format  *model.AudioFormat
+	size    int64
+	end     bool
+}
+
+func (c *conversionReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	if n > 0 {
+		if _, e := c.tmp.Write(p[:n]); e != nil {
+			return n, e
+		}
+		c.size += int64(n)
+	}
+	if err == io.EOF {
+		c.end = true
+	}
+
+	return n, err
+}
+
+func (c *conversionReader) Close() error {
+	c.r.Close()
+	if !c.end || c.size == 0 {
+		c.tmp.Close()
+		os.Remove(c.tmp.Name())
+		return nil
+	}
+
+	go c.app.storeConversion(c.backend, c.file, c.format, c.tmp, c.size)
+
+	return nil
+}
+
+func (app *App) storeConversion(backend utils.FileBackend, file *model.File, format *model.AudioFormat, tmp *os.File, size int64) {
+	defer func() {
+		tmp.Close()
+		os.Remove(tmp.Name())
+	}()
+
+	if format.Codec == model.AudioFormatWav {
+		if e := utils.PatchWavSizes(tmp, size); e != nil {
+			wlog.Error(fmt.Sprintf("file %d, store conversion %s error: %s", file.Id, format.String(), e.Error()))
+			return
+		}
+	}
+
+	if _, e := tmp.Seek(0, io.SeekStart); e != nil {
+		wlog.Error(fmt.Sprintf("file %d, store conversion %s error: %s", file.Id, format.String(), e.Error()))
+		return
+	}
+
+	conversion := &model.FileConversion{
+		FileId:    file.Id,
+		Format:    format.String(),
+		ProfileId: backend.Id(),
+		Name:      model.NewId()[:5] + "_" + format.FileName(file.Name),
+		MimeType:  format.MimeType(),
+		Size:      size,
+	}
+
+	// the conversion is encrypted as the source file
+	dst := conversion.File(file)
+	dst.SetEncrypted(file.IsEncrypted())
+
+	if _, err := backend.Write(tmp, dst); err != nil {
+		wlog.Error(fmt.Sprintf("file %d, store conversion %s error: %s", file.Id, conversion.Format, err.Error()))
+		return
+	}
+	conversion.Properties = dst.Properties
+
+	saved, err := app.Store.File().SaveConversion(conversion)
+	if err != nil || !saved {
+		// the conversion is stored by a concurrent download or the file is removed
+		if err != nil {
+			wlog.Error(fmt.Sprintf("file %d, save conversion %s error: %s", file.Id, conversion.Format, err.Error()))
+		}
+		if err = backend.Remove(dst); err != nil {
+			wlog.Error(fmt.Sprintf("file %d, remove conversion %s error: %s", file.Id, conversion.Format, err.Error()))
+		}
+		return
+	}
+
+	wlog.Debug(fmt.Sprintf("file %d stored conversion %s \"%s\" in store \"%s\", size %d", file.Id, conversion.Format, dst.Name, backend.Name(), size))
+}
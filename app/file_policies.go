@@ -54,6 +54,7 @@ func (app *App) UpdateFilePolicy(ctx context.Context, domainId int64, id int32,
 	oldPolicy.RetentionDays = policy.RetentionDays
 	oldPolicy.MaxUploadSize = policy.MaxUploadSize
 	oldPolicy.Encrypt = policy.Encrypt
+	oldPolicy.MalwareScan = policy.MalwareScan
 
 	updatedPolicy, err := app.Store.FilePolicies().Update(ctx, domainId, oldPolicy)
 	if err != nil {
@@ -117,15 +118,38 @@ func (app *App) DeleteFilePolicy(ctx context.Context, domainId int64, id int32)
 	return policy, nil
 }
 
-func (app *App) ApplyFilePolicy(ctx context.Context, domainId int64, id int32, applyToNullChannel bool) (int64, model.AppError) {
+// ApplyFilePolicy sets the retention of the files of the policy, with applyEncryption it also creates the jobs
+// that encrypt or decrypt the existing files by the Encrypt flag of the policy, returns the count of the files and the jobs
+func (app *App) ApplyFilePolicy(ctx context.Context, domainId int64, id int32, applyToNullChannel, applyEncryption bool) (int64, int64, model.AppError) {
+	var count, jobs int64
 	policy, err := app.GetFilePolicy(ctx, domainId, id)
 	if err != nil {
-		return 0, err
+		return 0, 0, err
 	}
 
 	if policy.RetentionDays < 0 {
-		return 0, model.NewBadRequestError("file_policy.apply.valid.retention_days", "retention_days must be an integer greater than or equal to 0")
+		return 0, 0, model.NewBadRequestError("file_policy.apply.valid.retention_days", "retention_days must be an integer greater than or equal to 0")
 	}
 
-	return app.Store.FilePolicies().SetRetentionDay(ctx, domainId, policy, applyToNullChannel)
+	if count, err = app.Store.FilePolicies().SetRetentionDay(ctx, domainId, policy, applyToNullChannel); err != nil {
+		return 0, 0, err
+	}
+
+	if applyEncryption {
+		if jobs, err = app.Store.FilePolicies().SetEncryptionJobs(ctx, domainId, policy, applyToNullChannel); err != nil {
+			return 0, 0, err
+		}
+	}
+
+	return count, jobs, nil
+}
+
+// FilePolicyEncryptionProgress returns the progress of the jobs created by ApplyFilePolicy with applyEncryption
+func (app *App) FilePolicyEncryptionProgress(ctx context.Context, domainId int64, id int32, applyToNullChannel bool) (*model.FilePolicyEncryption, model.AppError) {
+	policy, err := app.GetFilePolicy(ctx, domainId, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return app.Store.FilePolicies().EncryptionProgress(ctx, domainId, policy, applyToNullChannel)
 }
@@ -38,6 +38,7 @@ type FilePolicy struct {
 	maxUploadSize int64
 	retentionDays int
 	crypto        bool
+	malwareScan   bool
 }
 
 type PoliciesHub struct {
@@ -71,6 +72,54 @@ func (app *App) FilePolicyForUpload(domainId int64, file *model.BaseFile, src io
 	return app.filePolicies.policyReaderForUpload(domainId, file, src)
 }
 
+// FilePolicyForDirectUpload applies the upload policy to the file that the client writes to the backend
+// bypassing the service, returns the maximum size of the policy
+func (app *App) FilePolicyForDirectUpload(domainId int64, file *model.BaseFile) (int64, model.AppError) {
+	v, err := app.cachedPolicyHub(domainId)
+	if err != nil {
+		return 0, err
+	}
+	policy, err := v.Policy(file.Channel, file.MimeType)
+	if err != nil {
+		return 0, err
+	}
+
+	if policy == FilePolicyAllowAll {
+		return 0, nil
+	}
+
+	if policy.crypto && (file.Channel == nil || *file.Channel != model.UploadFileChannelMedia) {
+		return 0, model.NewBadRequestError("app.file_policy.direct_upload.encrypt", "policy "+policy.name+" encrypts files, upload the file through the service")
+	}
+
+	file.SetPolicyId(policy.id)
+
+	if policy.retentionDays > 0 {
+		t := time.Now().AddDate(0, 0, policy.retentionDays)
+		file.RetentionUntil = &t
+	}
+
+	return policy.maxUploadSize, nil
+}
+
+// FilePolicyCheckUpload checks the declared type and size of the file by the upload policy before the content is received
+func (app *App) FilePolicyCheckUpload(domainId int64, file *model.BaseFile) model.AppError {
+	v, err := app.cachedPolicyHub(domainId)
+	if err != nil {
+		return err
+	}
+	policy, err := v.Policy(file.Channel, file.MimeType)
+	if err != nil {
+		return err
+	}
+
+	if policy.maxUploadSize > 0 && file.Size > policy.maxUploadSize {
+		return model.PolicyErrorMaxLimit
+	}
+
+	return nil
+}
+
 func (app *App) policiesHub(domainId int64) (*PoliciesHub, model.AppError) {
 	policies, err := app.Store.FilePolicies().AllByDomainId(context.Background(), domainId)
 	if err != nil {
@@ -103,6 +152,7 @@ func (app *App) newPoliciesHub(domainId int64, policies []model.FilePolicy) *Pol
 			mime:          v.MimeTypes,
 			retentionDays: int(v.RetentionDays),
 			crypto:        v.Encrypt,
+			malwareScan:   v.MalwareScan,
 		}
 
 		h.appendPolicy(v.Channels, &p)
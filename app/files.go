@@ -52,7 +52,7 @@ func (app *App) GetFileWithProfile(domainId, id int64) (*model.File, utils.FileB
 		return nil, nil, FileMalwareErr
 	}
 
-	if backend, err = app.GetFileBackendStore(file.ProfileId, file.ProfileUpdatedAt); err != nil {
+	if backend, err = app.fileBackendWithFailover(file.ProfileId, file.ProfileUpdatedAt, file.Id); err != nil {
 		return nil, nil, err
 	}
 
@@ -73,7 +73,7 @@ func (app *App) GetFileByUuidWithProfile(domainId int64, uuid string) (*model.Fi
 		return nil, nil, FileMalwareErr
 	}
 
-	if backend, err = app.GetFileBackendStore(file.ProfileId, file.ProfileUpdatedAt); err != nil {
+	if backend, err = app.fileBackendWithFailover(file.ProfileId, file.ProfileUpdatedAt, file.Id); err != nil {
 		return nil, nil, err
 	}
 	//is bug ?
@@ -81,6 +81,10 @@ func (app *App) GetFileByUuidWithProfile(domainId int64, uuid string) (*model.Fi
 }
 
 func (app *App) RemoveFiles(domainId int64, ids []int64) model.AppError {
+	if err := app.CheckLegalHold(context.Background(), domainId, ids); err != nil {
+		return err
+	}
+
 	files, err := app.Store.File().GetAllPage(context.Background(), domainId, &model.SearchFile{
 		Ids:      ids,
 		Channels: []string{model.UploadFileChannelCase},
@@ -120,6 +124,10 @@ func (app *App) RemoveQuarantineFiles(domainId int64, ids []int64) model.AppErro
 }
 
 func (app *App) RemoveFilesByChannels(ctx context.Context, domainId int64, ids []int64, channels []string) model.AppError {
+	if err := app.CheckLegalHold(ctx, domainId, ids); err != nil {
+		return err
+	}
+
 	if err := app.Store.File().MarkRemoveByChannels(ctx, domainId, ids, channels); err != nil {
 		return err
 	}
@@ -6,6 +6,7 @@ import (
 	"net"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/webitel/engine/pkg/wbt/auth_manager"
@@ -14,6 +15,7 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
 )
 
@@ -158,6 +160,30 @@ func tokenFromGrpcContext(ctx context.Context) (string, model.AppError) {
 	}
 }
 
+// IpFromGrpcContext returns the address of the client by the forwarded headers or by the peer of the connection
+func IpFromGrpcContext(ctx context.Context) string {
+	if info, ok := metadata.FromIncomingContext(ctx); ok {
+		if v := info.Get(model.HEADER_FORWARDED); len(v) > 0 {
+			if addresses := strings.Fields(v[0]); len(addresses) > 0 {
+				return strings.TrimRight(addresses[0], ",")
+			}
+		}
+		if v := info.Get(model.HEADER_REAL_IP); len(v) > 0 && v[0] != "" {
+			return v[0]
+		}
+	}
+
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		address, _, err := net.SplitHostPort(p.Addr.String())
+		if err != nil {
+			return p.Addr.String()
+		}
+		return address
+	}
+
+	return ""
+}
+
 func (a *App) GetSessionFromCtx(ctx context.Context) (*auth_manager.Session, model.AppError) {
 	var session *auth_manager.Session
 	var err model.AppError
@@ -0,0 +1,273 @@
+// Package icap is a minimal ICAP client (RFC 3507) to check the content of the files with an antivirus or DLP gateway
+package icap
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"net/textproto"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	MethodRespMod = "RESPMOD"
+	MethodReqMod  = "REQMOD"
+
+	DefaultPort = "1344"
+
+	chunkSize = 32 * 1024
+)
+
+// the headers of the gateways that report the found threat
+var threatHeaders = []string{"X-Infection-Found", "X-Virus-Id", "X-Violations-Found", "X-Blocked-Reason"}
+
+type Client struct {
+	url     *url.URL
+	method  string
+	timeout time.Duration
+}
+
+type Response struct {
+	StatusCode int
+	Status     string
+	Header     textproto.MIMEHeader
+	// HttpStatusCode is the status of the encapsulated http response, 0 when the service returned no http response
+	HttpStatusCode int
+}
+
+type Result struct {
+	Infected bool
+	Threat   string
+	// ISTag is the tag of the service state, it changes when the signatures of the service are updated
+	ISTag string
+}
+
+// NewClient creates the client of the service address icap://host:1344/service, method is RESPMOD or REQMOD
+func NewClient(address, method string, timeout time.Duration) (*Client, error) {
+	u, err := url.Parse(address)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "icap" || u.Host == "" {
+		return nil, fmt.Errorf("bad icap address \"%s\"", address)
+	}
+	if u.Port() == "" {
+		u.Host = net.JoinHostPort(u.Hostname(), DefaultPort)
+	}
+
+	switch method = strings.ToUpper(method); method {
+	case "":
+		method = MethodRespMod
+	case MethodRespMod, MethodReqMod:
+	default:
+		return nil, fmt.Errorf("bad icap method \"%s\"", method)
+	}
+
+	return &Client{
+		url:     u,
+		method:  method,
+		timeout: timeout,
+	}, nil
+}
+
+// Options requests the capabilities of the service
+func (c *Client) Options() (*Response, error) {
+	conn, err := c.dial()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if _, err = io.WriteString(conn, c.requestLine("OPTIONS")+"Encapsulated: null-body=0\r\n\r\n"); err != nil {
+		return nil, err
+	}
+
+	return readResponse(bufio.NewReader(conn))
+}
+
+// Scan sends the content to the service, the content is infected when the service reports the threat
+// in the headers or replaces the content with the http error response (block page)
+func (c *Client) Scan(src io.Reader) (*Result, error) {
+	conn, err := c.dial()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	var hdr string
+	var section string
+	if c.method == MethodReqMod {
+		hdr = "POST / HTTP/1.1\r\nHost: " + c.url.Hostname() + "\r\nTransfer-Encoding: chunked\r\n\r\n"
+		section = "req-hdr=0, req-body=" + strconv.Itoa(len(hdr))
+	} else {
+		hdr = "HTTP/1.1 200 OK\r\nTransfer-Encoding: chunked\r\n\r\n"
+		section = "res-hdr=0, res-body=" + strconv.Itoa(len(hdr))
+	}
+
+	w := bufio.NewWriterSize(conn, chunkSize+16)
+	w.WriteString(c.requestLine(c.method))
+	w.WriteString("Allow: 204\r\n")
+	w.WriteString("Encapsulated: " + section + "\r\n\r\n")
+	w.WriteString(hdr)
+	if err = writeChunked(w, src); err != nil {
+		return nil, err
+	}
+	if err = w.Flush(); err != nil {
+		return nil, err
+	}
+
+	res, err := readResponse(bufio.NewReader(conn))
+	if err != nil {
+		return nil, err
+	}
+
+	return res.result()
+}
+
+func (c *Client) dial() (net.Conn, error) {
+	conn, err := net.DialTimeout("tcp", c.url.Host, c.timeout)
+	if err != nil {
+		return nil, err
+	}
+	if c.timeout > 0 {
+		return &idleConn{Conn: conn, timeout: c.timeout}, nil
+	}
+
+	return conn, nil
+}
+
+// idleConn moves the deadline on every read and write, the timeout limits the idle time of the connection
+// and not the whole scan, so the content of the large file is not cut off
+type idleConn struct {
+	net.Conn
+	timeout time.Duration
+}
+
+func (c *idleConn) Read(p []byte) (int, error) {
+	if err := c.Conn.SetDeadline(time.Now().Add(c.timeout)); err != nil {
+		return 0, err
+	}
+
+	return c.Conn.Read(p)
+}
+
+func (c *idleConn) Write(p []byte) (int, error) {
+	if err := c.Conn.SetDeadline(time.Now().Add(c.timeout)); err != nil {
+		return 0, err
+	}
+
+	return c.Conn.Write(p)
+}
+
+func (c *Client) requestLine(method string) string {
+	return fmt.Sprintf("%s %s ICAP/1.0\r\nHost: %s\r\n", method, c.url.String(), c.url.Host)
+}
+
+func writeChunked(w *bufio.Writer, src io.Reader) error {
+	buf := make([]byte, chunkSize)
+	for {
+		n, err := src.Read(buf)
+		if n > 0 {
+			if _, e := fmt.Fprintf(w, "%x\r\n", n); e != nil {
+				return e
+			}
+			if _, e := w.Write(buf[:n]); e != nil {
+				return e
+			}
+			if _, e := w.WriteString("\r\n"); e != nil {
+				return e
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+	_, err := w.WriteString("0\r\n\r\n")
+
+	return err
+}
+
+func readResponse(r *bufio.Reader) (*Response, error) {
+	tp := textproto.NewReader(r)
+	line, err := tp.ReadLine()
+	if err != nil {
+		return nil, err
+	}
+
+	proto, status, ok := strings.Cut(line, " ")
+	if !ok || !strings.HasPrefix(proto, "ICAP/") {
+		return nil, fmt.Errorf("bad icap response \"%s\"", line)
+	}
+	res := &Response{Status: status}
+	code, _, _ := strings.Cut(status, " ")
+	if res.StatusCode, err = strconv.Atoi(code); err != nil {
+		return nil, fmt.Errorf("bad icap response \"%s\"", line)
+	}
+
+	if res.Header, err = tp.ReadMIMEHeader(); err != nil {
+		return nil, err
+	}
+
+	// the encapsulated http response of the modified content, only the status line is needed
+	if strings.Contains(res.Header.Get("Encapsulated"), "res-hdr=0") {
+		if line, err = tp.ReadLine(); err == nil {
+			if _, status, ok = strings.Cut(line, " "); ok {
+				code, _, _ = strings.Cut(status, " ")
+				res.HttpStatusCode, _ = strconv.Atoi(code)
+			}
+		}
+	}
+
+	return res, nil
+}
+
+// ISTag returns the tag of the service state without the quotes
+func (res *Response) ISTag() string {
+	return strings.Trim(res.Header.Get("ISTag"), "\"")
+}
+
+func (res *Response) result() (*Result, error) {
+	r := &Result{
+		ISTag: res.ISTag(),
+	}
+
+	switch {
+	case res.StatusCode == 204:
+		return r, nil
+	case res.StatusCode != 200:
+		return nil, fmt.Errorf("icap error: %s", res.Status)
+	}
+
+	for _, h := range threatHeaders {
+		if v := res.Header.Get(h); v != "" {
+			r.Infected = true
+			r.Threat = parseThreat(v)
+			return r, nil
+		}
+	}
+
+	if res.HttpStatusCode >= 400 {
+		r.Infected = true
+		r.Threat = fmt.Sprintf("blocked, http status %d", res.HttpStatusCode)
+	}
+
+	return r, nil
+}
+
+// parseThreat returns the threat of X-Infection-Found "Type=0; Resolution=2; Threat=Eicar-Signature;" or the value of other headers
+func parseThreat(v string) string {
+	for _, p := range strings.Split(v, ";") {
+		if name, ok := strings.CutPrefix(strings.TrimSpace(p), "Threat="); ok {
+			return name
+		}
+	}
+
+	return strings.TrimSpace(v)
+}
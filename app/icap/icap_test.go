@@ -0,0 +1,212 @@
+package icap
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"net/http/httputil"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeServer answers as the antivirus gateway: the content with the "virus" marker is infected,
+// the content with the "block" marker is replaced with the block page
+type fakeServer struct {
+	ln     net.Listener
+	method string
+	body   []byte
+}
+
+func newFakeServer(t *testing.T) *fakeServer {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &fakeServer{ln: ln}
+	go s.serve()
+	t.Cleanup(func() { ln.Close() })
+
+	return s
+}
+
+func (s *fakeServer) address() string {
+	return "icap://" + s.ln.Addr().String() + "/avscan"
+}
+
+func (s *fakeServer) serve() {
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		s.handle(conn)
+		conn.Close()
+	}
+}
+
+func (s *fakeServer) handle(conn net.Conn) {
+	tp := textproto.NewReader(bufio.NewReader(conn))
+	line, err := tp.ReadLine()
+	if err != nil {
+		return
+	}
+	hdr, err := tp.ReadMIMEHeader()
+	if err != nil {
+		return
+	}
+	s.method, _, _ = strings.Cut(line, " ")
+
+	if s.method == "OPTIONS" {
+		fmt.Fprint(conn, "ICAP/1.0 200 OK\r\nISTag: \"sig-27000\"\r\nMethods: RESPMOD, REQMOD\r\nEncapsulated: null-body=0\r\n\r\n")
+		return
+	}
+
+	if !strings.Contains(hdr.Get("Encapsulated"), "-body=") {
+		fmt.Fprint(conn, "ICAP/1.0 400 Bad Request\r\nEncapsulated: null-body=0\r\n\r\n")
+		return
+	}
+	// the encapsulated http headers
+	if _, err = tp.ReadLine(); err != nil {
+		return
+	}
+	if _, err = tp.ReadMIMEHeader(); err != nil {
+		return
+	}
+	s.body, _ = io.ReadAll(httputil.NewChunkedReader(tp.R))
+
+	switch {
+	case bytes.Contains(s.body, []byte("virus")):
+		fmt.Fprint(conn, "ICAP/1.0 200 OK\r\nISTag: \"sig-27000\"\r\nX-Infection-Found: Type=0; Resolution=2; Threat=Eicar-Test-Signature;\r\nEncapsulated: null-body=0\r\n\r\n")
+	case bytes.Contains(s.body, []byte("block")):
+		fmt.Fprint(conn, "ICAP/1.0 200 OK\r\nISTag: \"sig-27000\"\r\nEncapsulated: res-hdr=0, res-body=45\r\n\r\nHTTP/1.1 403 Forbidden\r\nContent-Length: 0\r\n\r\n")
+	default:
+		fmt.Fprint(conn, "ICAP/1.0 204 No Content\r\nISTag: \"sig-27000\"\r\nEncapsulated: null-body=0\r\n\r\n")
+	}
+}
+
+func TestScan(t *testing.T) {
+	s := newFakeServer(t)
+
+	tests := []struct {
+		name     string
+		method   string
+		body     string
+		infected bool
+		threat   string
+	}{
+		{name: "clean", method: MethodRespMod, body: "clean content"},
+		{name: "infected", method: MethodRespMod, body: "content with virus", infected: true, threat: "Eicar-Test-Signature"},
+		{name: "blocked", method: MethodRespMod, body: "content to block", infected: true, threat: "blocked, http status 403"},
+		{name: "reqmod", method: MethodReqMod, body: "content with virus", infected: true, threat: "Eicar-Test-Signature"},
+		{name: "large", method: MethodRespMod, body: strings.Repeat("x", chunkSize*3+7)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := NewClient(s.address(), tt.method, time.Second*5)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			res, err := c.Scan(strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if s.method != tt.method {
+				t.Errorf("method %s, expected %s", s.method, tt.method)
+			}
+			if string(s.body) != tt.body {
+				t.Errorf("service received %d bytes, expected %d", len(s.body), len(tt.body))
+			}
+			if res.Infected != tt.infected || res.Threat != tt.threat {
+				t.Errorf("result %v %q, expected %v %q", res.Infected, res.Threat, tt.infected, tt.threat)
+			}
+			if res.ISTag != "sig-27000" {
+				t.Errorf("istag %q", res.ISTag)
+			}
+		})
+	}
+}
+
+func TestOptions(t *testing.T) {
+	s := newFakeServer(t)
+	c, err := NewClient(s.address(), "", time.Second*5)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := c.Options()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.StatusCode != 200 || res.ISTag() != "sig-27000" {
+		t.Errorf("response %d %v", res.StatusCode, res.Header)
+	}
+}
+
+func TestNewClient(t *testing.T) {
+	if _, err := NewClient("http://localhost/avscan", "", time.Second); err == nil {
+		t.Error("expected error of the scheme")
+	}
+	if _, err := NewClient("icap://localhost/avscan", "GET", time.Second); err == nil {
+		t.Error("expected error of the method")
+	}
+
+	c, err := NewClient("icap://localhost/avscan", "respmod", time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.url.Host != "localhost:1344" || c.method != MethodRespMod {
+		t.Errorf("client %s %s", c.url.Host, c.method)
+	}
+}
+
+// slowReader returns the content by chunks with the delay
+type slowReader struct {
+	chunks int
+	delay  time.Duration
+}
+
+func (r *slowReader) Read(p []byte) (int, error) {
+	if r.chunks == 0 {
+		return 0, io.EOF
+	}
+	r.chunks--
+	time.Sleep(r.delay)
+
+	return copy(p, "slow content "), nil
+}
+
+func TestScanIdleTimeout(t *testing.T) {
+	s := newFakeServer(t)
+	c, err := NewClient(s.address(), "", time.Millisecond*300)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the scan takes longer than the timeout, each chunk is sent within it
+	res, err := c.Scan(&slowReader{chunks: 6, delay: time.Millisecond * 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Infected || len(s.body) != 6*len("slow content ") {
+		t.Errorf("result %v, service received %d bytes", res.Infected, len(s.body))
+	}
+}
+
+type failWriter struct{}
+
+func (failWriter) Write(p []byte) (int, error) {
+	return 0, io.ErrClosedPipe
+}
+
+func TestWriteChunkedError(t *testing.T) {
+	w := bufio.NewWriterSize(failWriter{}, 16)
+	if err := writeChunked(w, strings.NewReader(strings.Repeat("x", 100))); err != io.ErrClosedPipe {
+		t.Errorf("error %v, expected %v", err, io.ErrClosedPipe)
+	}
+}
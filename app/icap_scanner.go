@@ -0,0 +1,75 @@
+package app
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/webitel/storage/app/icap"
+	"github.com/webitel/storage/model"
+	"github.com/webitel/wlog"
+)
+
+// Icap checks the content with the ICAP antivirus or DLP gateway
+type Icap struct {
+	*icap.Client
+}
+
+func NewIcap(cfg model.IcapSettings) (*Icap, error) {
+	c, err := icap.NewClient(cfg.Address, cfg.Method, cfg.Timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = c.Options(); err != nil {
+		wlog.Warn(fmt.Sprintf("icap options error: %s", err))
+	}
+
+	return &Icap{Client: c}, nil
+}
+
+func (s *Icap) Name() string {
+	return "icap"
+}
+
+func (s *Icap) Scan(src io.Reader) *model.MalwareScan {
+	now := time.Now()
+	ms := &model.MalwareScan{
+		Status:   model.MalwareStatusError,
+		ScanDate: &now,
+	}
+
+	res, err := s.Client.Scan(src)
+	if err != nil {
+		ms.Desc = model.NewString(err.Error())
+		return ms
+	}
+
+	if res.Infected {
+		ms.Status = model.MalwareStatusFound
+		ms.Found = true
+		ms.Desc = model.NewString(res.Threat)
+	} else {
+		ms.Status = model.MalwareStatusOk
+	}
+
+	return ms
+}
+
+// Signatures returns the ISTag of the service, the gateways change it when the signatures are updated
+func (s *Icap) Signatures() (string, error) {
+	res, err := s.Options()
+	if err != nil {
+		return "", err
+	}
+	if res.StatusCode != 200 {
+		return "", fmt.Errorf("icap options: %s", res.Status)
+	}
+
+	tag := res.ISTag()
+	if tag == "" {
+		return "", fmt.Errorf("icap options: no ISTag")
+	}
+
+	return tag, nil
+}
@@ -0,0 +1,79 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/webitel/storage/model"
+	"github.com/webitel/wlog"
+)
+
+// PlaceLegalHold places the holds, the files of the holds can't be removed until the holds are released
+func (app *App) PlaceLegalHold(ctx context.Context, domainId int64, userId int64, hold *model.PlaceLegalHold) ([]*model.LegalHold, model.AppError) {
+	holds, err := app.Store.LegalHold().Place(ctx, domainId, userId, hold)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(holds) == 0 {
+		return nil, model.NewNotFoundError("app.legal_hold.place.not_found", "not found files or call of the legal hold")
+	}
+
+	for _, h := range holds {
+		wlog.Info(fmt.Sprintf("domain %d, user %d placed legal hold %d (%s)", domainId, userId, h.Id, legalHoldTarget(h)))
+	}
+
+	return holds, nil
+}
+
+// ReleaseLegalHold releases the active holds, the released holds are kept as the audit trail
+func (app *App) ReleaseLegalHold(ctx context.Context, domainId int64, userId int64, release *model.ReleaseLegalHold) ([]*model.LegalHold, model.AppError) {
+	holds, err := app.Store.LegalHold().Release(ctx, domainId, userId, release)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, h := range holds {
+		wlog.Info(fmt.Sprintf("domain %d, user %d released legal hold %d (%s)", domainId, userId, h.Id, legalHoldTarget(h)))
+	}
+
+	return holds, nil
+}
+
+func (app *App) SearchLegalHolds(ctx context.Context, domainId int64, search *model.SearchLegalHold) ([]*model.LegalHold, bool, model.AppError) {
+	res, err := app.Store.LegalHold().GetAllPage(ctx, domainId, search)
+	if err != nil {
+		return nil, false, err
+	}
+	search.RemoveLastElemIfNeed(&res)
+	return res, search.EndOfList(), nil
+}
+
+// CheckLegalHold returns the conflict error when any of the files has an active legal hold
+func (app *App) CheckLegalHold(ctx context.Context, domainId int64, ids []int64) model.AppError {
+	held, err := app.Store.LegalHold().HeldFiles(ctx, domainId, ids)
+	if err != nil {
+		return err
+	}
+
+	if len(held) != 0 {
+		return model.NewCustomCodeError("app.legal_hold.held", fmt.Sprintf("files %v are under legal hold", held), http.StatusConflict)
+	}
+
+	return nil
+}
+
+func legalHoldTarget(h *model.LegalHold) string {
+	if h.FileId != nil {
+		return fmt.Sprintf("file %d", *h.FileId)
+	}
+	if h.CallId != nil {
+		return "call " + *h.CallId
+	}
+	if h.ReferenceId != nil {
+		return "reference " + *h.ReferenceId
+	}
+
+	return "unknown"
+}
@@ -0,0 +1,31 @@
+package app
+
+import (
+	"context"
+
+	"github.com/webitel/storage/model"
+)
+
+func (app *App) SearchQuarantineFiles(ctx context.Context, domainId int64, search *model.SearchQuarantineFile) ([]*model.QuarantineFile, bool, model.AppError) {
+	res, err := app.Store.File().GetQuarantinePage(ctx, domainId, search)
+	if err != nil {
+		return nil, false, err
+	}
+	search.RemoveLastElemIfNeed(&res)
+	return res, search.EndOfList(), nil
+}
+
+// ReleaseQuarantineFiles creates the restore jobs of the false positive files, the job moves the object
+// out of the quarantine path and clears the malware flag
+func (app *App) ReleaseQuarantineFiles(ctx context.Context, domainId int64, ids []int64, userId int64) (int, model.AppError) {
+	return app.Store.File().RestoreFile(ctx, domainId, ids, userId)
+}
+
+// RescanQuarantineFiles creates the rescan jobs of the files in quarantine, the files found clean are released
+func (app *App) RescanQuarantineFiles(ctx context.Context, domainId int64, ids []int64, userId int64) (int, model.AppError) {
+	if app.scanner == nil {
+		return 0, model.NewBadRequestError("app.quarantine.rescan.disabled", "malware scanner is not configured")
+	}
+
+	return app.Store.File().RescanQuarantine(ctx, domainId, ids, userId)
+}
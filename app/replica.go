@@ -0,0 +1,157 @@
+package app
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/webitel/storage/model"
+	"github.com/webitel/storage/utils"
+	"github.com/webitel/wlog"
+)
+
+// replicaObject is the file as it is stored in a replica profile
+type replicaObject struct {
+	utils.File
+	props model.StringInterface
+}
+
+func (r *replicaObject) GetPropertyString(name string) string {
+	return r.props.GetString(name)
+}
+
+func (r *replicaObject) SetPropertyString(name, value string) {
+	r.props[name] = value
+}
+
+// replicaFailoverBackend reads the file from its replicas when the primary backend fails
+type replicaFailoverBackend struct {
+	utils.FileBackend
+	app    *App
+	fileId int64
+}
+
+func (b *replicaFailoverBackend) Reader(file utils.File, offset int64) (io.ReadCloser, model.AppError) {
+	src, err := b.FileBackend.Reader(file, offset)
+	if err == nil {
+		return src, nil
+	}
+
+	replicas, appErr := b.app.Store.File().GetReplicas(b.fileId)
+	if appErr != nil {
+		wlog.Error(fmt.Sprintf("file %d, get replicas error: %s", b.fileId, appErr.Error()))
+		return nil, err
+	}
+
+	for _, replica := range replicas {
+		backend, e := b.app.GetFileBackendStore(&replica.ProfileId, replica.ProfileUpdatedAt)
+		if e != nil {
+			wlog.Error(fmt.Sprintf("file %d, replica profile %d error: %s", b.fileId, replica.ProfileId, e.Error()))
+			continue
+		}
+
+		src, e = backend.Reader(&replicaObject{File: file, props: replica.Properties}, offset)
+		if e != nil {
+			wlog.Error(fmt.Sprintf("file %d, read replica from \"%s\" error: %s", b.fileId, backend.Name(), e.Error()))
+			continue
+		}
+
+		wlog.Warn(fmt.Sprintf("file %d, primary store \"%s\" error: %s, read replica from \"%s\"", b.fileId, b.Name(), err.Error(), backend.Name()))
+		return src, nil
+	}
+
+	return nil, err
+}
+
+// replicaBackend reads the file from the replica when the primary backend can't be built, the replica isn't changed
+type replicaBackend struct {
+	utils.FileBackend
+	props model.StringInterface
+}
+
+func (b *replicaBackend) Reader(file utils.File, offset int64) (io.ReadCloser, model.AppError) {
+	return b.FileBackend.Reader(&replicaObject{File: file, props: b.props}, offset)
+}
+
+func (b *replicaBackend) Remove(file utils.File) model.AppError {
+	return replicaReadOnlyErr
+}
+
+func (b *replicaBackend) Write(src io.Reader, file utils.File) (int64, model.AppError) {
+	return 0, replicaReadOnlyErr
+}
+
+func (b *replicaBackend) CopyTo(file utils.File, toPathFn func(string) string) model.AppError {
+	return replicaReadOnlyErr
+}
+
+var replicaReadOnlyErr = model.NewBadRequestError("app.replica.read_only", "the file is read from the replica, the primary store is unavailable")
+
+// fileBackendWithFailover returns the backend of the file that reads the file from its replicas when the primary backend fails,
+// the first available replica is used when the primary backend can't be built
+func (app *App) fileBackendWithFailover(profileId *int, syncTime *int64, fileId int64) (utils.FileBackend, model.AppError) {
+	backend, err := app.GetFileBackendStore(profileId, syncTime)
+	if err == nil {
+		return &replicaFailoverBackend{
+			FileBackend: backend,
+			app:         app,
+			fileId:      fileId,
+		}, nil
+	}
+
+	replicas, appErr := app.Store.File().GetReplicas(fileId)
+	if appErr != nil {
+		wlog.Error(fmt.Sprintf("file %d, get replicas error: %s", fileId, appErr.Error()))
+		return nil, err
+	}
+
+	for _, replica := range replicas {
+		r, e := app.GetFileBackendStore(&replica.ProfileId, replica.ProfileUpdatedAt)
+		if e != nil {
+			wlog.Error(fmt.Sprintf("file %d, replica profile %d error: %s", fileId, replica.ProfileId, e.Error()))
+			continue
+		}
+
+		wlog.Warn(fmt.Sprintf("file %d, primary store error: %s, read replica from \"%s\"", fileId, err.Error(), r.Name()))
+		return &replicaBackend{FileBackend: r, props: replica.Properties}, nil
+	}
+
+	return nil, err
+}
+
+// ReplicateFile creates jobs that copy the file to the replica profiles of the store
+func (app *App) ReplicateFile(store utils.FileBackend, file *model.File) {
+	for _, profileId := range store.Replicas() {
+		err := app.Store.SyncFile().CreateJob(file.DomainId, file.Id, model.Replicate, map[string]any{
+			"profile_id": profileId,
+		})
+		if err != nil {
+			wlog.Error(fmt.Sprintf("file %d, create replicate job to profile %d error: %s", file.Id, profileId, err.Error()))
+		}
+	}
+}
+
+// RemoveFileReplicas removes copies of the file from the replica profiles
+func (app *App) RemoveFileReplicas(file utils.File, fileId int64) {
+	replicas, err := app.Store.File().GetReplicas(fileId)
+	if err != nil {
+		wlog.Error(fmt.Sprintf("file %d, get replicas error: %s", fileId, err.Error()))
+		return
+	}
+
+	app.RemoveReplicaObjects(file, fileId, replicas)
+}
+
+// RemoveReplicaObjects removes the replicas of the file that is already deleted with its replicas
+func (app *App) RemoveReplicaObjects(file utils.File, fileId int64, replicas []*model.FileReplica) {
+	for _, replica := range replicas {
+		backend, err := app.GetFileBackendStore(&replica.ProfileId, replica.ProfileUpdatedAt)
+		if err != nil {
+			wlog.Error(fmt.Sprintf("file %d, replica profile %d error: %s", fileId, replica.ProfileId, err.Error()))
+			continue
+		}
+
+		if err = backend.Remove(&replicaObject{File: file, props: replica.Properties}); err != nil {
+			wlog.Error(fmt.Sprintf("file %d, remove replica from \"%s\" error: %s", fileId, backend.Name(), err.Error()))
+		}
+	}
+}
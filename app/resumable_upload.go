@@ -0,0 +1,385 @@
+package app
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/webitel/storage/model"
+	"github.com/webitel/storage/utils"
+	"github.com/webitel/wlog"
+)
+
+// received bytes of the resumable uploads are kept as parts in the backend of the domain and the parts in the DB,
+// so the upload survives restarts and can be continued by any instance. The small last part is rewritten with the
+// bytes of the next request, so the small chunks don't leave an object per request
+const (
+	resumableUploadMinPartSize = 5 * 1024 * 1024
+
+	// the lock of the request that didn't release it (the instance is stopped) is taken over after the timeout,
+	// the offset is checked on saving the part, so the late request can't break the upload
+	resumableUploadLockTimeout = 10 * time.Minute
+
+	resumableUploadRemoveLimit = 100
+)
+
+func (app *App) CreateResumableUpload(upload *model.ResumableUpload) (*model.ResumableUpload, model.AppError) {
+	if err := upload.IsValid(); err != nil {
+		return nil, err
+	}
+
+	if max := app.MaxUploadFileSize(); max > 0 && upload.Size > max {
+		return nil, model.NewCustomCodeError("app.resumable_upload.create.size", fmt.Sprintf("upload size %d exceeds maximum %d", upload.Size, max), http.StatusRequestEntityTooLarge)
+	}
+
+	// the content of the file is checked on the completion, the declared type and size before the bytes are received
+	if err := app.FilePolicyCheckUpload(upload.DomainId, &model.BaseFile{
+		MimeType: upload.MimeType,
+		Size:     upload.Size,
+		Channel:  &upload.Channel,
+	}); err != nil {
+		return nil, err
+	}
+
+	store, err := app.domainStore(upload.DomainId)
+	if err != nil {
+		return nil, err
+	}
+
+	upload.Id = model.NewId()
+	upload.Name = model.NewId() + "_" + upload.ViewName
+	upload.ProfileId = store.Id()
+	upload.Instance = app.GetInstanceId()
+
+	res, err := app.Store.ResumableUpload().Create(upload)
+	if err != nil {
+		return nil, err
+	}
+
+	wlog.Debug(fmt.Sprintf("created resumable upload id=%s, name=%s, size=%d", res.Id, res.Name, res.Size))
+
+	return res, nil
+}
+
+func (app *App) GetResumableUpload(domainId int64, id string) (*model.ResumableUpload, model.AppError) {
+	return app.Store.ResumableUpload().Get(domainId, id)
+}
+
+// WriteResumableUpload appends src to the upload at the offset, the bytes that were received before the
+// connection is lost are kept. When all bytes are received the file is stored to the domain backend
+func (app *App) WriteResumableUpload(domainId int64, id string, offset int64, src io.Reader) (*model.ResumableUpload, model.AppError) {
+	upload, err := app.Store.ResumableUpload().Get(domainId, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if upload.Completed() {
+		return upload, nil
+	}
+
+	unlock, err := app.lockResumableUpload(upload)
+	if err != nil {
+		return upload, err
+	}
+	defer unlock()
+
+	// the upload could be changed by the request that held the lock
+	if upload, err = app.Store.ResumableUpload().Get(domainId, id); err != nil {
+		return nil, err
+	}
+
+	if upload.Completed() {
+		return upload, nil
+	}
+
+	if offset != upload.Offset {
+		return upload, model.NewCustomCodeError("app.resumable_upload.write.offset", fmt.Sprintf("Upload-Offset %d, expected %d", offset, upload.Offset), http.StatusConflict)
+	}
+
+	store, err := app.resumableUploadStore(upload)
+	if err != nil {
+		return upload, err
+	}
+
+	if upload.Offset < upload.Size {
+		if err = app.appendResumableUpload(store, upload, src); err != nil {
+			wlog.Debug(fmt.Sprintf("resumable upload id=%s interrupted at offset %d: %s", upload.Id, upload.Offset, err.Error()))
+			return upload, err
+		}
+	}
+
+	if upload.Offset < upload.Size {
+		return upload, nil
+	}
+
+	if err = app.completeResumableUpload(store, upload); err != nil {
+		return upload, err
+	}
+
+	return upload, nil
+}
+
+func (app *App) lockResumableUpload(upload *model.ResumableUpload) (func(), model.AppError) {
+	lockId := model.NewId()
+	ok, err := app.Store.ResumableUpload().Lock(upload.Id, lockId, model.GetMillis()-resumableUploadLockTimeout.Milliseconds())
+	if err != nil {
+		return nil, err
+	}
+
+	if !ok {
+		return nil, model.NewCustomCodeError("app.resumable_upload.write.locked", "upload is written by another request", http.StatusConflict)
+	}
+
+	return func() {
+		if err := app.Store.ResumableUpload().Unlock(upload.Id, lockId); err != nil {
+			wlog.Error(fmt.Sprintf("resumable upload id=%s, unlock error: %s", upload.Id, err.Error()))
+		}
+	}, nil
+}
+
+func (app *App) resumableUploadStore(upload *model.ResumableUpload) (utils.FileBackend, model.AppError) {
+	return app.GetFileBackendStore(upload.ProfileId, upload.ProfileUpdatedAt)
+}
+
+// appendResumableUpload writes the received bytes as the new part, the part is saved when the connection is lost
+// and the read error is returned after that. The last part that is smaller than resumableUploadMinPartSize is merged
+// into the new part and removed
+func (app *App) appendResumableUpload(store utils.FileBackend, upload *model.ResumableUpload, src io.Reader) model.AppError {
+	part := &model.ResumableUploadPart{
+		Offset: upload.Offset,
+		Name:   fmt.Sprintf("%s_%d_%s", upload.Id, upload.Offset, model.NewId()[:5]),
+	}
+	file := upload.PartFile(part)
+
+	var last *model.ResumableUploadPart
+	var prefix *utils.CountingReader
+	body := &resumablePartReader{r: utils.NewCountingReader(io.LimitReader(src, upload.Size-upload.Offset))}
+	var reader io.Reader = body
+
+	if n := len(upload.Parts); n > 0 && upload.Parts[n-1].Size < resumableUploadMinPartSize {
+		last = &upload.Parts[n-1]
+		lastSrc, err := store.Reader(upload.PartFile(last), 0)
+		if err != nil {
+			return err
+		}
+		defer lastSrc.Close()
+
+		part.Offset = last.Offset
+		prefix = utils.NewCountingReader(lastSrc)
+		reader = io.MultiReader(prefix, body)
+	}
+
+	if _, err := store.Write(reader, file); err != nil {
+		return err
+	}
+
+	part.Size = body.r.Count()
+	part.Properties = file.Properties
+
+	if part.Size == 0 {
+		app.removeResumableUploadPart(store, upload, part)
+	} else if last != nil {
+		if prefix.Count() != last.Size {
+			app.removeResumableUploadPart(store, upload, part)
+			return model.NewInternalError("app.resumable_upload.write.merge", fmt.Sprintf("read %d bytes of the part %d, expected %d", prefix.Count(), last.Offset, last.Size))
+		}
+		part.Size += last.Size
+
+		ok, err := app.Store.ResumableUpload().ReplaceLastPart(upload.Id, last.Name, part, app.GetInstanceId())
+		if err == nil && !ok {
+			err = model.NewCustomCodeError("app.resumable_upload.write.offset", "upload was changed by another request", http.StatusConflict)
+		}
+		if err != nil {
+			app.removeResumableUploadPart(store, upload, part)
+			return err
+		}
+
+		app.removeResumableUploadPart(store, upload, last)
+		upload.Offset = part.Offset + part.Size
+		*last = *part
+	} else {
+		ok, err := app.Store.ResumableUpload().AddPart(upload.Id, part, app.GetInstanceId())
+		if err == nil && !ok {
+			err = model.NewCustomCodeError("app.resumable_upload.write.offset", "upload was changed by another request", http.StatusConflict)
+		}
+		if err != nil {
+			app.removeResumableUploadPart(store, upload, part)
+			return err
+		}
+
+		upload.Offset += part.Size
+		upload.Parts = append(upload.Parts, *part)
+	}
+
+	if body.err != nil {
+		return model.NewCustomCodeError("app.resumable_upload.write.read", body.err.Error(), http.StatusBadRequest)
+	}
+
+	return nil
+}
+
+// completeResumableUpload stores the file from the parts, the file is marked by the upload, so the completion that is
+// repeated after the failure to save the file of the upload finds the stored file instead of creating another one
+func (app *App) completeResumableUpload(store utils.FileBackend, upload *model.ResumableUpload) model.AppError {
+	fileId, err := app.Store.ResumableUpload().LinkFile(upload.Id)
+	if err != nil {
+		return err
+	}
+
+	if fileId == nil {
+		src := &resumableUploadReader{store: store, upload: upload}
+		file := upload.JobUploadFile()
+		reader, err := app.FilePolicyForUpload(upload.DomainId, &file.BaseFile, src)
+		if err != nil {
+			src.Close()
+			return err
+		}
+		defer reader.Close()
+
+		if err = app.SyncUpload(reader, file); err != nil {
+			return err
+		}
+
+		if fileId, err = app.Store.ResumableUpload().LinkFile(upload.Id); err != nil {
+			return err
+		}
+
+		if fileId == nil {
+			return model.NewInternalError("app.resumable_upload.complete.file", fmt.Sprintf("file %d of the upload not found", file.Id))
+		}
+	}
+	upload.FileId = fileId
+
+	app.removeResumableUploadParts(store, upload)
+	wlog.Debug(fmt.Sprintf("completed resumable upload id=%s, file_id=%d, size=%d", upload.Id, *fileId, upload.Size))
+
+	return nil
+}
+
+func (app *App) RemoveResumableUpload(domainId int64, id string) model.AppError {
+	upload, err := app.Store.ResumableUpload().Get(domainId, id)
+	if err != nil {
+		return err
+	}
+
+	unlock, err := app.lockResumableUpload(upload)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err = app.Store.ResumableUpload().Delete(domainId, id); err != nil {
+		return err
+	}
+
+	app.cleanResumableUpload(upload)
+
+	return nil
+}
+
+// RemoveExpiredResumableUploads removes uploads that were not continued within expire
+func (app *App) RemoveExpiredResumableUploads(expire time.Duration) model.AppError {
+	uploads, err := app.Store.ResumableUpload().RemoveExpired(model.GetMillis()-expire.Milliseconds(), resumableUploadRemoveLimit)
+	if err != nil {
+		return err
+	}
+
+	for _, upload := range uploads {
+		app.cleanResumableUpload(upload)
+	}
+
+	if len(uploads) != 0 {
+		wlog.Debug(fmt.Sprintf("removed %d expired resumable uploads", len(uploads)))
+	}
+
+	return nil
+}
+
+// cleanResumableUpload removes the parts of the upload that was not completed, the parts of the completed upload are already removed
+func (app *App) cleanResumableUpload(upload *model.ResumableUpload) {
+	if upload.Completed() || len(upload.Parts) == 0 {
+		return
+	}
+
+	store, err := app.resumableUploadStore(upload)
+	if err != nil {
+		wlog.Error(fmt.Sprintf("resumable upload id=%s, profile error: %s", upload.Id, err.Error()))
+		return
+	}
+
+	app.removeResumableUploadParts(store, upload)
+}
+
+func (app *App) removeResumableUploadParts(store utils.FileBackend, upload *model.ResumableUpload) {
+	for i := range upload.Parts {
+		app.removeResumableUploadPart(store, upload, &upload.Parts[i])
+	}
+}
+
+func (app *App) removeResumableUploadPart(store utils.FileBackend, upload *model.ResumableUpload, part *model.ResumableUploadPart) {
+	if err := store.Remove(upload.PartFile(part)); err != nil && err.GetStatusCode() != http.StatusNotFound {
+		wlog.Error(fmt.Sprintf("resumable upload id=%s, remove part %d error: %s", upload.Id, part.Offset, err.Error()))
+	}
+}
+
+// resumablePartReader ends the part on the read error, so the backend keeps the bytes that were received
+type resumablePartReader struct {
+	r   *utils.CountingReader
+	err error
+}
+
+func (r *resumablePartReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if err != nil && err != io.EOF {
+		r.err = err
+		err = io.EOF
+	}
+
+	return n, err
+}
+
+// resumableUploadReader reads the parts of the upload one after another
+type resumableUploadReader struct {
+	store  utils.FileBackend
+	upload *model.ResumableUpload
+	next   int
+	part   io.ReadCloser
+}
+
+func (r *resumableUploadReader) Read(p []byte) (int, error) {
+	for {
+		if r.part == nil {
+			if r.next >= len(r.upload.Parts) {
+				return 0, io.EOF
+			}
+
+			src, err := r.store.Reader(r.upload.PartFile(&r.upload.Parts[r.next]), 0)
+			if err != nil {
+				return 0, err
+			}
+			r.part = src
+			r.next++
+		}
+
+		n, err := r.part.Read(p)
+		if err == io.EOF {
+			r.part.Close()
+			r.part = nil
+			if n == 0 {
+				continue
+			}
+			err = nil
+		}
+
+		return n, err
+	}
+}
+
+func (r *resumableUploadReader) Close() error {
+	if r.part != nil {
+		return r.part.Close()
+	}
+
+	return nil
+}
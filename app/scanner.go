@@ -0,0 +1,181 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/rabbitmq/amqp091-go"
+	"github.com/webitel/storage/model"
+	"github.com/webitel/wlog"
+)
+
+// MalwareScanner checks the content of the files on upload and on rescan (config clamav, icap)
+type MalwareScanner interface {
+	Name() string
+	// Scan reads the content to the end, the failed scan has the ERROR status
+	Scan(src io.Reader) *model.MalwareScan
+	// Signatures returns the version of the signatures, the stored files are rescanned when it changes
+	Signatures() (string, error)
+}
+
+// ScannerChain passes the content to all scanners at once, the content is infected when any scanner finds the threat
+type ScannerChain []MalwareScanner
+
+// NewMalwareScanner returns nil when no scanner is configured, the bad config of the scanner is the error,
+// so the files are not stored without the check
+func NewMalwareScanner(cfg *model.Config) (MalwareScanner, error) {
+	var chain ScannerChain
+	if cfg.Clamav.Address != "" {
+		chain = append(chain, NewClamav(cfg.Clamav))
+	}
+	if cfg.Icap.Address != "" {
+		s, err := NewIcap(cfg.Icap)
+		if err != nil {
+			return nil, fmt.Errorf("icap: %w", err)
+		}
+		chain = append(chain, s)
+	}
+
+	switch len(chain) {
+	case 0:
+		return nil, nil
+	case 1:
+		return chain[0], nil
+	default:
+		return chain, nil
+	}
+}
+
+func (c ScannerChain) Name() string {
+	names := make([]string, 0, len(c))
+	for _, s := range c {
+		names = append(names, s.Name())
+	}
+
+	return strings.Join(names, "+")
+}
+
+func (c ScannerChain) Scan(src io.Reader) *model.MalwareScan {
+	var wg sync.WaitGroup
+	res := make([]*model.MalwareScan, len(c))
+	writers := make([]*scannerWriter, len(c))
+
+	for i, s := range c {
+		r, w := io.Pipe()
+		writers[i] = &scannerWriter{w: w}
+		wg.Add(1)
+		go func(i int, s MalwareScanner) {
+			defer wg.Done()
+			res[i] = s.Scan(r)
+			// the scanner may stop reading on error, the rest of the content is dropped
+			r.Close()
+		}(i, s)
+	}
+
+	_, err := io.Copy(chainWriter(writers), src)
+	for _, w := range writers {
+		w.w.CloseWithError(err)
+	}
+	wg.Wait()
+
+	var ms *model.MalwareScan
+	for i, v := range res {
+		if v.Desc != nil {
+			v.Desc = model.NewString(c[i].Name() + ": " + *v.Desc)
+		}
+		switch {
+		case v.Found:
+			return v
+		case ms == nil || v.Status != model.MalwareStatusOk:
+			ms = v
+		}
+	}
+
+	return ms
+}
+
+// Signatures returns the versions of all scanners, the change of any version rescans the files
+func (c ScannerChain) Signatures() (string, error) {
+	versions := make([]string, 0, len(c))
+	for _, s := range c {
+		v, err := s.Signatures()
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", s.Name(), err)
+		}
+		versions = append(versions, s.Name()+"="+v)
+	}
+
+	return strings.Join(versions, ","), nil
+}
+
+type scannerWriter struct {
+	w      *io.PipeWriter
+	closed bool
+}
+
+type chainWriter []*scannerWriter
+
+func (c chainWriter) Write(p []byte) (int, error) {
+	for _, w := range c {
+		if w.closed {
+			continue
+		}
+		if _, err := w.w.Write(p); err != nil {
+			w.closed = true
+		}
+	}
+
+	return len(p), nil
+}
+
+// ScanMalware scans the content of the stored file with the configured scanners
+func (app *App) ScanMalware(src io.Reader) (*model.MalwareScan, model.AppError) {
+	if app.scanner == nil {
+		return nil, model.NewInternalError("app.scanner.scan.disabled", "malware scanner is not configured")
+	}
+
+	return app.scanner.Scan(src), nil
+}
+
+// MalwareQuarantine reports whether the detected files are moved to quarantine, in the skip mode the files are only marked
+func (app *App) MalwareQuarantine() bool {
+	return app.scanner != nil && app.Config().Clamav.Mode != ClamavModeSkip
+}
+
+// SetRescanFileJobs saves the current version of the signatures and creates the rescan jobs
+// of the files uploaded in the last days (config clam_rescan_days) when the version changes
+func (app *App) SetRescanFileJobs() model.AppError {
+	days := app.Config().Clamav.RescanDays
+	if app.scanner == nil || days <= 0 {
+		return nil
+	}
+
+	version, err := app.scanner.Signatures()
+	if err != nil {
+		return model.NewInternalError("app.scanner.signatures", err.Error())
+	}
+
+	return app.Store.SyncFile().SetRescanJobs(version, days)
+}
+
+// PublishMalwareScan publishes the result of the antivirus scan of the file to the message broker
+func (app *App) PublishMalwareScan(ctx context.Context, msg *model.MalwareAMQPMessage) {
+	if app.rabbitPublisher == nil || msg.Malware == nil {
+		return
+	}
+
+	data, err := json.Marshal(msg)
+	if err != nil {
+		wlog.Error(fmt.Sprintf("malware file %d, marshal error: %s", msg.FileId, err.Error()))
+		return
+	}
+
+	routingKey := fmt.Sprintf("storage.malware.%s.%d", msg.Result(), msg.DomainId)
+	if err = app.rabbitPublisher.Publish(ctx, routingKey, data, amqp091.Table{}); err != nil {
+		wlog.Error(fmt.Sprintf("malware file %d, publish error: %s", msg.FileId, err.Error()))
+	}
+}
@@ -47,12 +47,16 @@ func (cw *CorsWrapper) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	if r.Method == "OPTIONS" {
 		w.Header().Set(
 			"Access-Control-Allow-Methods",
-			strings.Join([]string{"GET", "POST", "PUT", "DELETE"}, ", "))
+			strings.Join([]string{"GET", "POST", "PUT", "DELETE", "HEAD", "PATCH"}, ", "))
 
 		w.Header().Set(
-			"Access-Control-Allow-Headers", "X-Webitel-Access, Accept, Content-Type, Content-Length, Accept-Encoding, Authorization")
+			"Access-Control-Allow-Headers", "X-Webitel-Access, Accept, Content-Type, Content-Length, Accept-Encoding, Authorization, "+
+				"Tus-Resumable, Upload-Length, Upload-Offset, Upload-Metadata, Range, If-None-Match, If-Modified-Since, If-Range")
 	}
 
+	w.Header().Set("Access-Control-Expose-Headers", "Location, Tus-Resumable, Upload-Length, Upload-Offset, Upload-File-Id, "+
+		"ETag, Accept-Ranges, Content-Range")
+
 	if r.Method == "OPTIONS" {
 		return
 	}
@@ -0,0 +1,371 @@
+package app
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"time"
+
+	"github.com/webitel/storage/model"
+	"github.com/webitel/wlog"
+)
+
+const (
+	// subjectRequestStuck is the time after which the active request without progress is taken again
+	subjectRequestStuck = int64(time.Hour / time.Millisecond)
+	// subjectExportRetentionDays is the retention of the export when the file policy has no retention
+	subjectExportRetentionDays = 7
+	subjectRequestPageSize     = 500
+	// subjectRequestHeartbeat is the max time between the progress updates of the active request
+	subjectRequestHeartbeat = time.Minute
+	// subjectReportSignaturePrefix separates the signatures of the reports from the signatures of the file links
+	subjectReportSignaturePrefix = "storage.subject_report:"
+)
+
+func (app *App) CreateSubjectRequest(ctx context.Context, req *model.SubjectRequest) (*model.SubjectRequest, model.AppError) {
+	req.PreSave()
+	if err := req.IsValid(); err != nil {
+		return nil, err
+	}
+
+	return app.Store.SubjectRequest().Create(ctx, req)
+}
+
+func (app *App) GetSubjectRequest(ctx context.Context, domainId int64, id int64) (*model.SubjectRequest, model.AppError) {
+	return app.Store.SubjectRequest().Get(ctx, domainId, id)
+}
+
+func (app *App) FetchSubjectRequest() (*model.SubjectRequest, model.AppError) {
+	return app.Store.SubjectRequest().Fetch(subjectRequestStuck)
+}
+
+// ExecuteSubjectRequest exports or erases the files of the subject and completes the request with the signed report
+func (app *App) ExecuteSubjectRequest(req *model.SubjectRequest) model.AppError {
+	ctx := context.Background()
+	report := &model.SubjectReport{
+		RequestId: req.Id,
+		DomainId:  req.DomainId,
+		Mode:      req.Mode,
+		Filter:    req.Filter,
+	}
+
+	files, err := app.subjectFiles(ctx, req)
+	if err == nil {
+		switch req.Mode {
+		case model.SubjectRequestExport:
+			err = app.exportSubject(ctx, req, files, report)
+		case model.SubjectRequestErase:
+			err = app.eraseSubject(ctx, req, files, report)
+		default:
+			err = model.NewBadRequestError("app.subject_request.mode", "bad mode "+req.Mode)
+		}
+	}
+
+	if err != nil {
+		wlog.Error(fmt.Sprintf("subject request %d, error: %s", req.Id, err.Error()))
+		req.State = model.SubjectRequestError
+		req.Error = model.NewString(err.Error())
+		return app.Store.SubjectRequest().Complete(req)
+	}
+
+	report.CompletedAt = model.GetMillis()
+	report.ExportFileId = req.ExportFileId
+	data, _ := json.Marshal(report)
+	signature, err := app.GenerateSignature(append([]byte(subjectReportSignaturePrefix), data...))
+	if err != nil {
+		return err
+	}
+
+	req.State = model.SubjectRequestDone
+	req.Files = int64(len(report.Files))
+	req.Report = model.NewString(string(data))
+	req.Signature = &signature
+	wlog.Debug(fmt.Sprintf("subject request %d [%s] completed, files %d", req.Id, req.Mode, req.Files))
+
+	return app.Store.SubjectRequest().Complete(req)
+}
+
+// subjectFiles returns all files of the subject, the list is read before the processing because the erasure changes the pages
+func (app *App) subjectFiles(ctx context.Context, req *model.SubjectRequest) ([]*model.File, model.AppError) {
+	var res []*model.File
+	search := req.Filter.Search()
+	search.Fields = []string{"id", "name", "size", "sha256sum", "reference_id"}
+	search.Sort = "id"
+	search.PerPage = subjectRequestPageSize
+
+	for page := 1; ; page++ {
+		search.Page = page
+		files, end, err := app.SearchFiles(ctx, req.DomainId, search)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, files...)
+		if end {
+			return res, nil
+		}
+	}
+}
+
+// subjectProgress keeps the active request from being taken as stuck, the progress is saved by the page of the files
+// or when the heartbeat is due
+type subjectProgress struct {
+	app  *App
+	id   int64
+	last time.Time
+}
+
+func (app *App) newSubjectProgress(id int64) *subjectProgress {
+	return &subjectProgress{
+		app:  app,
+		id:   id,
+		last: time.Now(),
+	}
+}
+
+func (p *subjectProgress) Done(files int) {
+	if files%subjectRequestPageSize != 0 && time.Since(p.last) < subjectRequestHeartbeat {
+		return
+	}
+
+	p.last = time.Now()
+	if err := p.app.Store.SubjectRequest().SetProgress(p.id, int64(files)); err != nil {
+		wlog.Error(err.Error())
+	}
+}
+
+func subjectReportFile(file *model.File, status string, err error) *model.SubjectReportFile {
+	f := &model.SubjectReportFile{
+		Id:        file.Id,
+		Name:      file.Name,
+		Size:      file.Size,
+		SHA256Sum: file.SHA256Sum,
+		Status:    status,
+	}
+	if file.ReferenceId != nil {
+		f.Uuid = *file.ReferenceId
+	}
+	if err != nil {
+		f.Status = model.SubjectFileError
+		f.Error = err.Error()
+	}
+
+	return f
+}
+
+func (app *App) exportSubject(ctx context.Context, req *model.SubjectRequest, files []*model.File, report *model.SubjectReport) model.AppError {
+	tmp, e := os.CreateTemp(app.Config().TempDir, "subject_export_")
+	if e != nil {
+		return model.NewInternalError("app.subject_request.export.tmp", e.Error())
+	}
+	defer func() {
+		tmp.Close()
+		os.Remove(tmp.Name())
+	}()
+
+	zw := zip.NewWriter(tmp)
+	metadata := make([]*model.File, 0, len(files))
+	ids := make([]int64, 0, len(files))
+	progress := app.newSubjectProgress(req.Id)
+
+	for i, f := range files {
+		file, err := app.exportSubjectFile(zw, req.DomainId, f.Id)
+		if file != nil {
+			metadata = append(metadata, file)
+		}
+		report.Files = append(report.Files, subjectReportFile(f, model.SubjectFileExported, err))
+		ids = append(ids, f.Id)
+		progress.Done(i + 1)
+	}
+
+	transcripts, err := app.Store.SubjectRequest().GetTranscripts(ctx, req.DomainId, ids)
+	if err != nil {
+		return err
+	}
+	for _, t := range transcripts {
+		if e = writeZipJson(zw, fmt.Sprintf("transcripts/%d_%d.json", t.FileId, t.Id), t); e != nil {
+			return model.NewInternalError("app.subject_request.export.transcript", e.Error())
+		}
+	}
+	report.Transcripts = len(transcripts)
+
+	if e = writeZipJson(zw, "metadata.json", metadata); e == nil {
+		e = zw.Close()
+	}
+	if e == nil {
+		_, e = tmp.Seek(0, io.SeekStart)
+	}
+	if e != nil {
+		return model.NewInternalError("app.subject_request.export.zip", e.Error())
+	}
+
+	retention := time.Now().AddDate(0, 0, subjectExportRetentionDays)
+	upload := &model.JobUploadFile{
+		BaseFile: model.BaseFile{
+			Name:           fmt.Sprintf("subject_request_%d.zip", req.Id),
+			MimeType:       "application/zip",
+			Channel:        model.NewString(model.UploadFileChannelUnknown),
+			RetentionUntil: &retention,
+		},
+		Uuid:     model.NewId(),
+		DomainId: req.DomainId,
+	}
+
+	reader, err := app.FilePolicyForUpload(req.DomainId, &upload.BaseFile, tmp)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	if err = app.SyncUpload(reader, upload); err != nil {
+		return err
+	}
+	req.ExportFileId = &upload.Id
+	app.AuditFiles(ctx, req.DomainId, req.CreatedById(), "", model.AuditActionExport, ids, nil)
+
+	return nil
+}
+
+// exportSubjectFile writes the decrypted content of the file to the zip, returns the metadata of the file
+func (app *App) exportSubjectFile(zw *zip.Writer, domainId int64, id int64) (*model.File, model.AppError) {
+	file, backend, err := app.GetFileWithProfile(domainId, id)
+	if err != nil {
+		return nil, err
+	}
+
+	reader, err := backend.Reader(file, 0)
+	if err != nil {
+		return file, err
+	}
+	src, err := app.FilePolicyForDownload(domainId, &file.BaseFile, reader)
+	if err != nil {
+		reader.Close()
+		return file, err
+	}
+	defer src.Close()
+
+	w, e := zw.Create(fmt.Sprintf("files/%d_%s", file.Id, path.Base(file.Name)))
+	if e == nil {
+		_, e = io.Copy(w, src)
+	}
+	if e != nil {
+		return file, model.NewInternalError("app.subject_request.export.file", e.Error())
+	}
+
+	return file, nil
+}
+
+func writeZipJson(zw *zip.Writer, name string, v interface{}) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+
+	return json.NewEncoder(w).Encode(v)
+}
+
+func (app *App) eraseSubject(ctx context.Context, req *model.SubjectRequest, files []*model.File, report *model.SubjectReport) model.AppError {
+	ids := make([]int64, 0, len(files))
+	for _, f := range files {
+		ids = append(ids, f.Id)
+	}
+
+	transcripts, err := app.Store.SubjectRequest().GetTranscripts(ctx, req.DomainId, ids)
+	if err != nil {
+		return err
+	}
+	report.Transcripts = len(transcripts)
+
+	erased := make([]int64, 0, len(files))
+	shared := make(map[int]*model.File)
+	progress := app.newSubjectProgress(req.Id)
+	for i, f := range files {
+		status, err := app.eraseSubjectFile(ctx, req.DomainId, f.Id)
+		if err == nil && status != model.SubjectFileHeld {
+			erased = append(erased, f.Id)
+		}
+		report.Files = append(report.Files, subjectReportFile(f, status, err))
+
+		if err == nil && status == model.SubjectFileShared {
+			shared[len(report.Files)-1] = f
+		} else if err == nil && status == model.SubjectFileErased {
+			// the object is removed with the last erased file of the subject that shares it
+			for n, s := range shared {
+				if s.SameObject(f) {
+					report.Files[n].Status = model.SubjectFileErased
+					delete(shared, n)
+				}
+			}
+		}
+		progress.Done(i + 1)
+	}
+
+	app.AuditFiles(ctx, req.DomainId, req.CreatedById(), "", model.AuditActionErase, erased, nil)
+
+	return nil
+}
+
+// eraseSubjectFile removes the objects of the file from the backends and deletes the file, returns SubjectFileHeld
+// when the file is held and SubjectFileShared when the object is kept for the other files
+func (app *App) eraseSubjectFile(ctx context.Context, domainId int64, id int64) (string, model.AppError) {
+	held, err := app.Store.LegalHold().HeldFiles(ctx, domainId, []int64{id})
+	if err != nil || len(held) != 0 {
+		return model.SubjectFileHeld, err
+	}
+
+	file, err := app.Store.File().GetFileWithProfile(domainId, id)
+	if err != nil {
+		return "", err
+	}
+
+	store, err := app.GetFileBackendStore(file.ProfileId, file.ProfileUpdatedAt)
+	if err != nil {
+		return "", err
+	}
+
+	// the replicas and the conversions are deleted with the file, the objects are removed after the erasure
+	replicas, err := app.Store.File().GetReplicas(id)
+	if err != nil {
+		return "", err
+	}
+
+	conversions, err := app.Store.File().GetConversions(id)
+	if err != nil {
+		return "", err
+	}
+
+	// the file is kept when the hold is placed after the check
+	ok, refs, err := app.Store.SubjectRequest().EraseFile(domainId, id)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return model.SubjectFileHeld, nil
+	}
+
+	if file.Thumbnail != nil {
+		thumbnail := file.File
+		thumbnail.BaseFile = file.Thumbnail.BaseFile
+		if err = store.Remove(&thumbnail); err != nil {
+			wlog.Error(fmt.Sprintf("file %d, remove thumbnail from \"%s\" error: %s", id, store.Name(), err.Error()))
+		}
+	}
+
+	if refs > 0 {
+		// the replicas and the conversions of the shared object are used by the other files
+		wlog.Debug(fmt.Sprintf("file %d keep object \"%s\" in store \"%s\", references %d", id, file.Name, store.Name(), refs))
+		return model.SubjectFileShared, nil
+	}
+
+	app.RemoveReplicaObjects(&file.File, id, replicas)
+	app.RemoveConversionObjects(&file.File, id, conversions)
+
+	if err = store.Remove(&file.File); err != nil {
+		return model.SubjectFileErased, err
+	}
+
+	return model.SubjectFileErased, nil
+}
@@ -2,6 +2,7 @@ package app
 
 import (
 	"encoding/json"
+	"fmt"
 	"io"
 	"strings"
 
@@ -18,6 +19,10 @@ const (
 	TtsYandex     = "Yandex"
 	TtsWebitel    = "Webitel"
 	TtsElevenLabs = "ElevenLabs"
+	TtsLocal      = "Local"
+
+	// HeaderTtsProvider is the provider that synthesized the speech, it differs from the profile provider on failover
+	HeaderTtsProvider = "X-TTS-Provider"
 )
 
 type ttsFunction func(tts2.TTSParams) (io.ReadCloser, *string, *int, error)
@@ -30,47 +35,138 @@ var (
 		strings.ToLower(TtsYandex):     tts2.Yandex,
 		strings.ToLower(TtsWebitel):    tts2.Webitel,
 		strings.ToLower(TtsElevenLabs): tts2.ElevenLabs,
+		// TtsLocal (tts2.Local) is registered when the ProviderType of the protos has the Local provider,
+		// until then the profiles API can't show the profile of the provider
 	}
 )
 
-func (a *App) TTS(provider string, params tts2.TTSParams) (out io.ReadCloser, t *string, size *int, err model.AppError) {
-	var ttsErr error
+// TTS synthesizes the speech by the provider of the profile, when the provider fails the fallback profiles
+// of the profile are used in order. The speech has the provider that answered
+func (a *App) TTS(provider string, params tts2.TTSParams) (*tts2.Speech, model.AppError) {
+	var candidates []tts2.CandidateFunc
+	var candidate *tts2.Candidate
+	var err model.AppError
 
 	if params.ProfileId > 0 && len(params.Key) == 0 {
 		var ttsProfile *model.TtsProfile
 		ttsProfile, err = a.Store.CognitiveProfile().SearchTtsProfile(int64(params.DomainId), params.ProfileId)
 		if err != nil {
-
-			return
+			return nil, err
 		}
 
 		if !ttsProfile.Enabled {
-			err = model.NewBadRequestError("tts.profile.disabled", "Profile is disabled")
+			return nil, model.NewBadRequestError("tts.profile.disabled", "Profile is disabled")
+		}
 
-			return
+		if candidate, err = a.ttsCandidate(ttsProfile.Provider, ttsProfile, params); err != nil {
+			return nil, err
 		}
+		candidates = append(candidates, tts2.Resolved(*candidate))
 
-		provider = ttsProfile.Provider
+		// the fallback profile is loaded only when the previous provider failed
+		for _, fallback := range ttsProfile.Fallback() {
+			candidates = append(candidates, func() *tts2.Candidate {
+				return a.ttsFallback(fallback, params)
+			})
+		}
+	} else {
+		if candidate, err = a.ttsCandidate(provider, nil, params); err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, tts2.Resolved(*candidate))
+	}
 
-		if jErr := json.Unmarshal(ttsProfile.Properties, &params); jErr != nil {
-			wlog.Error(jErr.Error())
+	speech, ttsErr := tts2.Failover(candidates, a.Config().TtsFailoverTimeout)
+	if ttsErr != nil {
+		switch ttsErr.(type) {
+		case model.AppError:
+			return nil, ttsErr.(model.AppError)
+		default:
+			return nil, model.NewInternalError("tts.app_error", ttsErr.Error())
 		}
+	}
+
+	return speech, nil
+}
+
+// ttsFallback returns the candidate of the fallback profile, the disabled or removed profile is skipped
+func (a *App) ttsFallback(fallback model.TtsFallback, params tts2.TTSParams) *tts2.Candidate {
+	profile, err := a.Store.CognitiveProfile().SearchTtsProfile(int64(params.DomainId), fallback.ProfileId)
+	if err != nil {
+		wlog.Warn(fmt.Sprintf("[tts] fallback profile %d error: %s", fallback.ProfileId, err.Error()))
+		return nil
+	}
+
+	if !profile.Enabled {
+		return nil
+	}
+
+	params.Voice = fallback.Voice(params.Voice)
+	candidate, err := a.ttsCandidate(profile.Provider, profile, params)
+	if err != nil {
+		wlog.Warn(fmt.Sprintf("[tts] fallback profile %d error: %s", fallback.ProfileId, err.Error()))
+		return nil
+	}
+
+	return candidate
+}
+
+// ttsCandidate prepares the request to the provider of the profile, the cached speech of the request is returned without the provider
+func (a *App) ttsCandidate(provider string, profile *model.TtsProfile, params tts2.TTSParams) (*tts2.Candidate, model.AppError) {
+	var syncTag int64
+	profileId := params.ProfileId
 
+	if profile != nil {
+		profileId = profile.Id
+		syncTag = profile.SyncTag
+		if jErr := json.Unmarshal(profile.Properties, &params); jErr != nil {
+			wlog.Error(jErr.Error())
+		}
 	}
+
 	provider = strings.ToLower(provider)
-	if fn, ok := ttsEngine[provider]; ok {
-		out, t, size, ttsErr = fn(params)
-		if ttsErr != nil {
-			switch ttsErr.(type) {
-			case model.AppError:
-				err = ttsErr.(model.AppError)
-			default:
-				err = model.NewInternalError("tts.app_error", ttsErr.Error())
+	fn, ok := ttsEngine[provider]
+	if !ok {
+		return nil, model.NewNotFoundError("tts.valid.not_found", "Not found provider")
+	}
+
+	if err := params.PrepareSSML(provider); err != nil {
+		return nil, model.NewBadRequestError("tts.valid.ssml", err.Error())
+	}
+
+	synth := tts2.Synthesizer(fn)
+	if chunk := a.Config().TtsStreamChunk; chunk > 0 {
+		synth = tts2.Chunked(synth, provider, chunk, a.Config().TtsStreamWorkers)
+	}
+
+	candidate := &tts2.Candidate{
+		Provider:  provider,
+		ProfileId: profileId,
+		Params:    params,
+		Synth:     synth,
+	}
+
+	if a.TtsCacheStore != nil {
+		entry := &model.TtsCacheEntry{
+			DomainId: int64(params.DomainId),
+			Key:      params.CacheKey(provider, profileId, syncTag),
+			Provider: provider,
+		}
+		candidate.Synth = func(p tts2.TTSParams) (io.ReadCloser, *string, *int, error) {
+			if cached, e := a.cachedTTS(entry.DomainId, entry.Key); cached != nil {
+				cachedSize := int(e.Size)
+				return cached, &e.MimeType, &cachedSize, nil
 			}
+
+			out, t, size, err := synth(p)
+			if err == nil && t != nil {
+				entry.MimeType = *t
+				out = a.newTtsCacheReader(out, entry)
+			}
+
+			return out, t, size, err
 		}
-	} else {
-		return nil, nil, nil, model.NewNotFoundError("tts.valid.not_found", "Not found provider")
 	}
 
-	return
+	return candidate, nil
 }
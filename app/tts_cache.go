@@ -0,0 +1,162 @@
+package app
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/webitel/storage/model"
+	"github.com/webitel/wlog"
+)
+
+const ttsCacheEvictLimit = 100
+
+// cachedTTS returns the stored speech of the request, the broken entry is removed and the request is synthesized again
+func (a *App) cachedTTS(domainId int64, key string) (io.ReadCloser, *model.TtsCacheEntry) {
+	entry, err := a.Store.TtsCache().Get(domainId, key, a.Config().TtsCache.TTL)
+	if err != nil {
+		wlog.Error(fmt.Sprintf("tts cache %s, get error: %s", key, err.Error()))
+		return nil, nil
+	}
+
+	if entry == nil {
+		return nil, nil
+	}
+
+	reader, err := a.TtsCacheStore.Reader(entry.File(), 0)
+	if err != nil {
+		wlog.Warn(fmt.Sprintf("tts cache %s, read \"%s\" error: %s", key, entry.Name, err.Error()))
+		if err = a.Store.TtsCache().Delete(domainId, key); err != nil {
+			wlog.Error(fmt.Sprintf("tts cache %s, delete error: %s", key, err.Error()))
+		}
+		return nil, nil
+	}
+
+	return reader, entry
+}
+
+// newTtsCacheReader streams the synthesized speech to the caller and keeps it in the temp file,
+// the speech is stored in the cache when the whole output of the provider is read
+func (a *App) newTtsCacheReader(src io.ReadCloser, entry *model.TtsCacheEntry) io.ReadCloser {
+	tmp, e := os.CreateTemp(a.Config().TempDir, "tts_")
+	if e != nil {
+		wlog.Error(fmt.Sprintf("tts cache %s, error: %s", entry.Key, e.Error()))
+		return src
+	}
+
+	return &ttsCacheReader{
+		app:   a,
+		r:     src,
+		tmp:   tmp,
+		entry: entry,
+		max:   a.Config().TtsCache.MaxEntrySize,
+	}
+}
+
+type ttsCacheReader struct {
+	app   *App
+	r     io.ReadCloser
+	tmp   *os.File
+	entry *model.TtsCacheEntry
+	size  int64
+	max   int64
+	end   bool
+	skip  bool
+}
+
+func (c *ttsCacheReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	if n > 0 && !c.skip {
+		// the large speech is not cached, the caller still gets the whole output
+		if c.max > 0 && c.size+int64(n) > c.max {
+			c.skip = true
+		} else if _, e := c.tmp.Write(p[:n]); e != nil {
+			wlog.Error(fmt.Sprintf("tts cache %s, error: %s", c.entry.Key, e.Error()))
+			c.skip = true
+		}
+		c.size += int64(n)
+	}
+	if err == io.EOF {
+		c.end = true
+	}
+
+	return n, err
+}
+
+func (c *ttsCacheReader) Close() error {
+	// the error of the provider on close means the speech may be truncated
+	err := c.r.Close()
+	if err != nil || !c.end || c.skip || c.size == 0 {
+		c.tmp.Close()
+		os.Remove(c.tmp.Name())
+		return err
+	}
+
+	c.entry.Size = c.size
+	go c.app.storeTTS(c.entry, c.tmp)
+
+	return err
+}
+
+func (a *App) storeTTS(entry *model.TtsCacheEntry, tmp *os.File) {
+	defer func() {
+		tmp.Close()
+		os.Remove(tmp.Name())
+	}()
+
+	if _, e := tmp.Seek(0, io.SeekStart); e != nil {
+		wlog.Error(fmt.Sprintf("tts cache %s, store error: %s", entry.Key, e.Error()))
+		return
+	}
+
+	entry.Name = model.NewId()[:5] + "_" + entry.Key
+	dst := entry.File()
+	if _, err := a.TtsCacheStore.Write(tmp, dst); err != nil {
+		wlog.Error(fmt.Sprintf("tts cache %s, store error: %s", entry.Key, err.Error()))
+		return
+	}
+	entry.Properties = dst.Properties
+
+	saved, err := a.Store.TtsCache().Save(entry)
+	if err != nil || !saved {
+		// the request is cached by a concurrent synthesis
+		if err != nil {
+			wlog.Error(fmt.Sprintf("tts cache %s, save error: %s", entry.Key, err.Error()))
+		}
+		if err = a.TtsCacheStore.Remove(dst); err != nil {
+			wlog.Error(fmt.Sprintf("tts cache %s, remove error: %s", entry.Key, err.Error()))
+		}
+		return
+	}
+
+	wlog.Debug(fmt.Sprintf("tts cache %s stored \"%s\" in store \"%s\", size %d", entry.Key, entry.Name, a.TtsCacheStore.Name(), entry.Size))
+}
+
+// EvictTtsCache removes the expired entries of the TTS cache and the least recently used entries above the size limit (config tts_cache_max_size)
+func (a *App) EvictTtsCache() model.AppError {
+	if a.TtsCacheStore == nil {
+		return nil
+	}
+
+	settings := a.Config().TtsCache
+	for {
+		entries, err := a.Store.TtsCache().Evict(settings.TTL, settings.MaxSize, ttsCacheEvictLimit)
+		if err != nil {
+			return err
+		}
+
+		for _, entry := range entries {
+			if err = a.TtsCacheStore.Remove(entry.File()); err != nil {
+				wlog.Error(fmt.Sprintf("tts cache %s, remove \"%s\" error: %s", entry.Key, entry.Name, err.Error()))
+			}
+		}
+
+		if len(entries) != 0 {
+			wlog.Debug(fmt.Sprintf("tts cache evicted %d entries", len(entries)))
+		}
+
+		if len(entries) < ttsCacheEvictLimit {
+			return nil
+		}
+	}
+}
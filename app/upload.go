@@ -1,6 +1,7 @@
 package app
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/json"
 	"fmt"
@@ -110,8 +111,27 @@ func (app *App) SyncUploadToProfile(src io.Reader, profileId int, file *model.Jo
 	return app.upload(src, &profileId, store, file)
 }
 
-func (app *App) useClamd(file *model.BaseFile) bool {
-	return app.clamd != nil && file.Channel != nil && *file.Channel == model.UploadFileChannelChat
+// useMalwareScan reports whether the file is scanned by antivirus: files of the chat channel are always scanned,
+// files of other channels when the file policy of the domain enables the malware scan
+func (app *App) useMalwareScan(domainId int64, file *model.BaseFile) bool {
+	if app.scanner == nil {
+		return false
+	}
+	if file.Channel != nil && *file.Channel == model.UploadFileChannelChat {
+		return true
+	}
+
+	h, err := app.cachedPolicyHub(domainId)
+	if err != nil {
+		app.Log.Error(err.Error())
+		return false
+	}
+	policy, err := h.Policy(file.Channel, file.MimeType)
+	if err != nil {
+		return false
+	}
+
+	return policy.malwareScan
 }
 
 func (app *App) upload(src io.Reader, profileId *int, store utils.FileBackend, file *model.JobUploadFile) model.AppError {
@@ -121,9 +141,9 @@ func (app *App) upload(src io.Reader, profileId *int, store utils.FileBackend, f
 	var err model.AppError
 	var ms *model.MalwareScan
 
-	if app.useClamd(&file.BaseFile) {
+	if app.useMalwareScan(file.DomainId, &file.BaseFile) {
 		ms = &model.MalwareScan{
-			Status:   "ERROR",
+			Status:   model.MalwareStatusError,
 			ScanDate: nil,
 		}
 
@@ -139,23 +159,12 @@ func (app *App) upload(src io.Reader, profileId *int, store utils.FileBackend, f
 			_ = os.Remove(fn)
 		}()
 
-		cancel := make(chan bool)
-		defer close(cancel)
-
 		rc := io.TeeReader(src, fSrc) // todo подумати використати io_clam, із мінусів: ми будемо генерувати Thumbnail якщо буде потреба
 
-		scanResultChan, errScan := app.clamd.ScanStream(rc, cancel)
-		if errScan != nil {
-			ms.Desc = model.NewString(errScan.Error())
-			goto endScan
-		}
-
-		scanResult := <-scanResultChan
-
-		ms.Status = scanResult.Status
-		if scanResult.Description != "" {
-			ms.Found = true
-			ms.Desc = &scanResult.Description
+		ms = app.scanner.Scan(rc)
+		// the failed scanner may stop reading, the rest of the content is kept for the upload
+		if _, errCl = io.Copy(io.Discard, rc); errCl != nil {
+			return model.NewInternalError("app.upload.scan", errCl.Error())
 		}
 
 		fSrc.Seek(0, 0)
@@ -166,9 +175,9 @@ endScan:
 
 	if ms != nil {
 
-		switch app.clamd.mode {
+		switch app.Config().Clamav.Mode {
 		case ClamavModeAggressive:
-			if ms.Status == "ERROR" || ms.Found {
+			if ms.Status == model.MalwareStatusError || ms.Found {
 				return FileMalwareErr // todo err
 			}
 
@@ -221,14 +230,88 @@ endScan:
 		file.Thumbnail = sf.Thumbnail
 	}
 
-	file.Id, err = app.storeFile(store, sf)
+	own := app.DeduplicateFile(sf)
+
+	file.Id, err = app.storeFile(store, sf, own)
 	if err != nil {
+		if own != nil {
+			store.Remove(own)
+		}
 		return err
 	}
 
+	if ms != nil && ms.Found {
+		app.PublishMalwareScan(context.Background(), &model.MalwareAMQPMessage{
+			FileId:     file.Id,
+			DomainId:   file.DomainId,
+			Uuid:       file.Uuid,
+			Name:       file.GetViewName(),
+			MimeType:   file.MimeType,
+			Channel:    file.Channel,
+			Source:     model.MalwareSourceUpload,
+			Quarantine: ms.Quarantine,
+			Malware:    ms,
+		})
+	}
+
 	return nil
 }
 
+// DeduplicateFile links the file to the object of an existing file of the domain with the same content, so the backend
+// keeps one copy. Returns the just written object, it is kept until the reference is stored and confirmed by ConfirmDeduplicate
+func (app *App) DeduplicateFile(file *model.File) *model.File {
+	if !app.Config().FileDeduplication || file.SHA256Sum == nil || file.Size == 0 || file.IsQuarantine() {
+		return nil
+	}
+
+	// the origin must be encrypted with the key the file was just written with, the reference is decrypted by the key of the origin
+	origin, err := app.Store.File().FindObject(file.DomainId, file.ProfileId, *file.SHA256Sum, file.IsEncrypted(),
+		file.GetPropertyString(utils.KeyVersionProperty), file.GetPropertyString(utils.DomainKeyProperty))
+	if err != nil {
+		wlog.Error(fmt.Sprintf("deduplicate %s error: %s", file.Name, err.Error()))
+		return nil
+	}
+
+	if origin == nil || origin.Size != file.Size || origin.SameObject(file) {
+		return nil
+	}
+
+	own := *file
+	own.Properties = file.Properties.Copy()
+	file.LinkObject(origin)
+
+	return &own
+}
+
+// ConfirmDeduplicate removes the written duplicate of the stored reference. The origin row is locked as by the remove job,
+// so the job either counts the reference or has already deleted the origin, then the file is switched back to the written object
+func (app *App) ConfirmDeduplicate(store utils.FileBackend, file *model.File, own *model.File) {
+	if own == nil {
+		return
+	}
+
+	originId, _ := file.Properties["origin_id"].(int64)
+	exists, err := app.Store.File().LockObject(originId)
+	if err != nil {
+		wlog.Error(fmt.Sprintf("deduplicate %s error: %s", own.Name, err.Error()))
+	}
+
+	if err == nil && exists {
+		if err = store.Remove(own); err != nil {
+			wlog.Error(fmt.Sprintf("deduplicate %s, remove duplicate error: %s", own.Name, err.Error()))
+		}
+		wlog.Debug(fmt.Sprintf("file %d is a duplicate of %d, stored as reference", file.Id, originId))
+		return
+	}
+
+	if _, err = app.Store.File().ReplaceObject(file.Id, file.ProfileId, file.Name, own.Name, own.Properties, own.SHA256Sum); err != nil {
+		wlog.Error(fmt.Sprintf("file %d, restore object %s error: %s", file.Id, own.Name, err.Error()))
+		return
+	}
+	file.Name = own.Name
+	file.Properties = own.Properties
+}
+
 // setupThumbnail налаштовує мініатюру для файлу, якщо це зображення або відео
 func (app *App) setupThumbnail(src io.Reader, store utils.FileBackend, file *model.JobUploadFile) (io.Reader, *utils.Thumbnail, chan model.AppError, model.AppError) {
 	if !utils.IsSupportThumbnail(file.MimeType) {
@@ -307,7 +390,7 @@ func (app *App) syncUpload(store utils.FileBackend, src io.Reader, file *model.J
 }
 
 // storeFile зберігає інформацію про файл у базі даних
-func (app *App) storeFile(store utils.FileBackend, file *model.File) (int64, model.AppError) {
+func (app *App) storeFile(store utils.FileBackend, file *model.File, own *model.File) (int64, model.AppError) {
 	res := <-app.Store.File().Create(file)
 	if res.Err != nil {
 		return 0, res.Err
@@ -315,7 +398,10 @@ func (app *App) storeFile(store utils.FileBackend, file *model.File) (int64, mod
 
 	file.Id, _ = res.Data.(int64)
 
+	app.ConfirmDeduplicate(store, file, own)
+
 	wlog.Debug(fmt.Sprintf("stored %s in %s, %d bytes [encrypted=%v, SHA256=%v, clamd=%v]", file.GetStoreName(), store.Name(), file.Size, file.IsEncrypted(), file.SHA256Sum != nil, file.BaseFile.StringMalware()))
+	app.ReplicateFile(store, file)
 
 	//TODO
 	if file.Channel != nil && *file.Channel == model.UploadFileChannelCase {
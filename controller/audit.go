@@ -0,0 +1,17 @@
+package controller
+
+import (
+	"context"
+
+	"github.com/webitel/engine/pkg/wbt/auth_manager"
+	"github.com/webitel/storage/model"
+)
+
+func (c *Controller) SearchAuditEvents(ctx context.Context, session *auth_manager.Session, search *model.SearchAuditEvent) ([]*model.AuditEvent, bool, model.AppError) {
+	permission := session.GetPermission(model.PermissionScopeFilePolicy)
+	if !permission.CanRead() {
+		return nil, false, c.app.MakePermissionError(session, permission, auth_manager.PERMISSION_ACCESS_READ)
+	}
+
+	return c.app.SearchAuditEvents(ctx, session.Domain(0), search)
+}
@@ -68,6 +68,25 @@ func (c *Controller) GetCognitiveProfile(session *auth_manager.Session, id int64
 	return c.app.GetCognitiveProfile(id, session.Domain(domainId))
 }
 
+func (c *Controller) SearchCognitiveProfileVoices(session *auth_manager.Session, id int64, domainId int64, q string) ([]*model.CognitiveProfileVoice, model.AppError) {
+	var err model.AppError
+	permission := session.GetPermission(model.PermissionScopeCognitiveProfile)
+	if !permission.CanRead() {
+		return nil, c.app.MakePermissionError(session, permission, auth_manager.PERMISSION_ACCESS_READ)
+	}
+
+	if session.UseRBAC(auth_manager.PERMISSION_ACCESS_READ, permission) {
+		var perm bool
+		if perm, err = c.app.CognitiveProfileCheckAccess(session.Domain(domainId), id, session.RoleIds, auth_manager.PERMISSION_ACCESS_READ); err != nil {
+			return nil, err
+		} else if !perm {
+			return nil, c.app.MakeResourcePermissionError(session, id, permission, auth_manager.PERMISSION_ACCESS_READ)
+		}
+	}
+
+	return c.app.SearchCognitiveProfileVoices(id, session.Domain(domainId), q)
+}
+
 func (c *Controller) UpdateCognitiveProfile(session *auth_manager.Session, profile *model.CognitiveProfile) (*model.CognitiveProfile, model.AppError) {
 	var err model.AppError
 	permission := session.GetPermission(model.PermissionScopeCognitiveProfile)
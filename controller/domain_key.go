@@ -0,0 +1,29 @@
+package controller
+
+import (
+	"github.com/webitel/engine/pkg/wbt/auth_manager"
+	"github.com/webitel/storage/model"
+)
+
+func (c *Controller) GetDomainKey(session *auth_manager.Session) (*model.DomainKey, model.AppError) {
+	permission := session.GetPermission(model.PermissionScopeFilePolicy)
+	if !permission.CanRead() {
+		return nil, c.app.MakePermissionError(session, permission, auth_manager.PERMISSION_ACCESS_READ)
+	}
+
+	return c.app.GetDomainKey(session.Domain(0))
+}
+
+// DestroyDomainKey crypto-shreds the files of the domain encrypted with the active key
+func (c *Controller) DestroyDomainKey(session *auth_manager.Session) (*model.DomainKey, model.AppError) {
+	permission := session.GetPermission(model.PermissionScopeFilePolicy)
+	if !permission.CanRead() {
+		return nil, c.app.MakePermissionError(session, permission, auth_manager.PERMISSION_ACCESS_READ)
+	}
+
+	if !permission.CanDelete() {
+		return nil, c.app.MakePermissionError(session, permission, auth_manager.PERMISSION_ACCESS_DELETE)
+	}
+
+	return c.app.DestroyDomainKey(session.Domain(0))
+}
@@ -0,0 +1,42 @@
+package controller
+
+import (
+	"context"
+	"io"
+
+	"github.com/webitel/engine/pkg/wbt/auth_manager"
+	"github.com/webitel/storage/model"
+)
+
+var errNoPermissionFileArchive = model.NewForbiddenError("file_archive.access.forbidden", "Not allow")
+
+// FileArchiveIds returns the files of the archive, the access to each file is checked by its channel on writing the archive
+func (c *Controller) FileArchiveIds(ctx context.Context, session *auth_manager.Session, archive *model.FileArchive) ([]int64, model.AppError) {
+	if err := archive.IsValid(); err != nil {
+		return nil, err
+	}
+
+	return c.app.FileArchiveIds(ctx, session.Domain(0), archive)
+}
+
+// WriteFileArchive writes the files to the archive, the files are checked as the download of the single file:
+// the call record by the access to the record file, the other channels by the session. The files that can't
+// be read are listed in the errors of the archive
+func (c *Controller) WriteFileArchive(ctx context.Context, session *auth_manager.Session, ids []int64, w io.Writer) ([]int64, model.AppError) {
+	return c.app.WriteFileArchive(ctx, session.Domain(0), ids, w, func(file *model.File) model.AppError {
+		if file.Channel == nil || *file.Channel != model.UploadFileChannelCall {
+			return nil
+		}
+
+		ok, err := c.CanReadRecordFile(ctx, session, file.Id)
+		if err != nil {
+			return err
+		}
+
+		if !ok || !c.AllowTimeLimited(ctx, session, file.CreatedAt) {
+			return errNoPermissionFileArchive
+		}
+
+		return nil
+	})
+}
@@ -116,15 +116,24 @@ func (c *Controller) ChangePositionFilePolicy(ctx context.Context, session *auth
 	return c.app.ChangePositionFilePolicy(ctx, session.Domain(0), fromId, toId)
 }
 
-func (c *Controller) ApplyFilePolicy(ctx context.Context, session *auth_manager.Session, policyId int32, applyToNullChannel bool) (int64, model.AppError) {
+func (c *Controller) ApplyFilePolicy(ctx context.Context, session *auth_manager.Session, policyId int32, applyToNullChannel, applyEncryption bool) (int64, int64, model.AppError) {
 	permission := session.GetPermission(model.PermissionScopeFilePolicy)
 	if !permission.CanRead() {
-		return 0, c.app.MakePermissionError(session, permission, auth_manager.PERMISSION_ACCESS_READ)
+		return 0, 0, c.app.MakePermissionError(session, permission, auth_manager.PERMISSION_ACCESS_READ)
 	}
 
 	if !permission.CanUpdate() {
-		return 0, c.app.MakePermissionError(session, permission, auth_manager.PERMISSION_ACCESS_DELETE)
+		return 0, 0, c.app.MakePermissionError(session, permission, auth_manager.PERMISSION_ACCESS_DELETE)
 	}
 
-	return c.app.ApplyFilePolicy(ctx, session.Domain(0), policyId, applyToNullChannel)
+	return c.app.ApplyFilePolicy(ctx, session.Domain(0), policyId, applyToNullChannel, applyEncryption)
+}
+
+func (c *Controller) FilePolicyEncryptionProgress(ctx context.Context, session *auth_manager.Session, policyId int32, applyToNullChannel bool) (*model.FilePolicyEncryption, model.AppError) {
+	permission := session.GetPermission(model.PermissionScopeFilePolicy)
+	if !permission.CanRead() {
+		return nil, c.app.MakePermissionError(session, permission, auth_manager.PERMISSION_ACCESS_READ)
+	}
+
+	return c.app.FilePolicyEncryptionProgress(ctx, session.Domain(0), policyId, applyToNullChannel)
 }
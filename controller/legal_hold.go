@@ -0,0 +1,51 @@
+package controller
+
+import (
+	"context"
+
+	"github.com/webitel/engine/pkg/wbt/auth_manager"
+	"github.com/webitel/storage/model"
+)
+
+func (c *Controller) PlaceLegalHold(ctx context.Context, session *auth_manager.Session, hold *model.PlaceLegalHold) ([]*model.LegalHold, model.AppError) {
+	permission := session.GetPermission(model.PERMISSION_SCOPE_RECORD_FILE)
+	if !permission.CanRead() {
+		return nil, c.app.MakePermissionError(session, permission, auth_manager.PERMISSION_ACCESS_READ)
+	}
+
+	if !permission.CanUpdate() {
+		return nil, c.app.MakePermissionError(session, permission, auth_manager.PERMISSION_ACCESS_UPDATE)
+	}
+
+	if err := hold.IsValid(); err != nil {
+		return nil, err
+	}
+
+	return c.app.PlaceLegalHold(ctx, session.Domain(0), session.UserId, hold)
+}
+
+func (c *Controller) ReleaseLegalHold(ctx context.Context, session *auth_manager.Session, release *model.ReleaseLegalHold) ([]*model.LegalHold, model.AppError) {
+	permission := session.GetPermission(model.PERMISSION_SCOPE_RECORD_FILE)
+	if !permission.CanRead() {
+		return nil, c.app.MakePermissionError(session, permission, auth_manager.PERMISSION_ACCESS_READ)
+	}
+
+	if !permission.CanUpdate() {
+		return nil, c.app.MakePermissionError(session, permission, auth_manager.PERMISSION_ACCESS_UPDATE)
+	}
+
+	if err := release.IsValid(); err != nil {
+		return nil, err
+	}
+
+	return c.app.ReleaseLegalHold(ctx, session.Domain(0), session.UserId, release)
+}
+
+func (c *Controller) SearchLegalHolds(ctx context.Context, session *auth_manager.Session, search *model.SearchLegalHold) ([]*model.LegalHold, bool, model.AppError) {
+	permission := session.GetPermission(model.PERMISSION_SCOPE_RECORD_FILE)
+	if !permission.CanRead() {
+		return nil, false, c.app.MakePermissionError(session, permission, auth_manager.PERMISSION_ACCESS_READ)
+	}
+
+	return c.app.SearchLegalHolds(ctx, session.Domain(0), search)
+}
@@ -0,0 +1,51 @@
+package controller
+
+import (
+	"context"
+
+	"github.com/webitel/engine/pkg/wbt/auth_manager"
+	"github.com/webitel/storage/model"
+)
+
+func (c *Controller) SearchQuarantineFiles(ctx context.Context, session *auth_manager.Session, search *model.SearchQuarantineFile) ([]*model.QuarantineFile, bool, model.AppError) {
+	permission := session.GetPermission(model.PERMISSION_SCOPE_RECORD_FILE)
+	if !permission.CanRead() {
+		return nil, false, c.app.MakePermissionError(session, permission, auth_manager.PERMISSION_ACCESS_READ)
+	}
+
+	return c.app.SearchQuarantineFiles(ctx, session.Domain(0), search)
+}
+
+func (c *Controller) ReleaseQuarantineFiles(ctx context.Context, session *auth_manager.Session, ids []int64) (int, model.AppError) {
+	permission := session.GetPermission(model.PERMISSION_SCOPE_RECORD_FILE)
+	if !permission.CanRead() {
+		return 0, c.app.MakePermissionError(session, permission, auth_manager.PERMISSION_ACCESS_READ)
+	}
+
+	if !permission.CanUpdate() {
+		return 0, c.app.MakePermissionError(session, permission, auth_manager.PERMISSION_ACCESS_UPDATE)
+	}
+
+	if len(ids) == 0 {
+		return 0, model.NewBadRequestError("controller.quarantine.id", "id is required")
+	}
+
+	return c.app.ReleaseQuarantineFiles(ctx, session.Domain(0), ids, session.UserId)
+}
+
+func (c *Controller) RescanQuarantineFiles(ctx context.Context, session *auth_manager.Session, ids []int64) (int, model.AppError) {
+	permission := session.GetPermission(model.PERMISSION_SCOPE_RECORD_FILE)
+	if !permission.CanRead() {
+		return 0, c.app.MakePermissionError(session, permission, auth_manager.PERMISSION_ACCESS_READ)
+	}
+
+	if !permission.CanUpdate() {
+		return 0, c.app.MakePermissionError(session, permission, auth_manager.PERMISSION_ACCESS_UPDATE)
+	}
+
+	if len(ids) == 0 {
+		return 0, model.NewBadRequestError("controller.quarantine.id", "id is required")
+	}
+
+	return c.app.RescanQuarantineFiles(ctx, session.Domain(0), ids, session.UserId)
+}
@@ -1,7 +1,9 @@
 package controller
 
 import (
+	"context"
 	"io"
+	"time"
 
 	"github.com/webitel/engine/pkg/wbt/auth_manager"
 	"github.com/webitel/storage/model"
@@ -18,6 +20,41 @@ func (c *Controller) GetFileWithProfile(session *auth_manager.Session, domainId,
 	return c.app.GetFileWithProfile(session.Domain(domainId), id)
 }
 
+// CanReadRecordFile checks the access to the call record file by RBAC of the calls,
+// the session with the record file actions reads all files
+func (c *Controller) CanReadRecordFile(ctx context.Context, session *auth_manager.Session, fileId int64) (bool, model.AppError) {
+	if session.HasAction(auth_manager.PermissionRecordFile) || session.HasAction(auth_manager.PermissionTimeLimitedRecordFile) {
+		return true, nil
+	}
+
+	permission := session.GetPermission(model.PERMISSION_SCOPE_RECORD_FILE)
+	if !permission.CanRead() {
+		return false, nil
+	}
+
+	if session.UseRBAC(auth_manager.PERMISSION_ACCESS_READ, permission) {
+		return c.app.CheckCallRecordPermissions(ctx, int(fileId), session.UserId, session.DomainId, session.RoleIds)
+	}
+
+	return true, nil
+}
+
+// AllowTimeLimited reports whether the session can read the call record created at createdAt
+func (c *Controller) AllowTimeLimited(ctx context.Context, session *auth_manager.Session, createdAt int64) bool {
+	if session.HasAction(auth_manager.PermissionRecordFile) {
+		return true
+	}
+
+	if session.HasAction(auth_manager.PermissionTimeLimitedRecordFile) {
+		if showFilePeriodDay, _ := c.app.GetCachedSystemSetting(ctx, session.Domain(0), model.SysNamePeriodToPlaybackRecord); showFilePeriodDay.Int() != nil {
+			t := time.Now().Add(-(time.Hour * 24 * time.Duration(*showFilePeriodDay.Int())))
+			return time.Unix(0, createdAt*int64(time.Millisecond)).After(t)
+		}
+	}
+
+	return false
+}
+
 func (c *Controller) UploadFileStream(src io.ReadCloser, file *model.JobUploadFile) model.AppError {
 	src2, err := c.app.FilePolicyForUpload(file.DomainId, &file.BaseFile, src)
 	if err != nil {
@@ -48,3 +85,7 @@ func (c *Controller) GeneratePreSignedResourceSignatureBulk(id int64, domainId i
 func (c *Controller) InsecureGetFileWithProfile(domainId, id int64) (*model.File, utils.FileBackend, model.AppError) {
 	return c.app.GetFileWithProfile(domainId, id)
 }
+
+func (c *Controller) InsecureFileConversionReader(file *model.File, backend utils.FileBackend, format *model.AudioFormat) (io.ReadCloser, int64, model.AppError) {
+	return c.app.FileConversionReader(file, backend, format)
+}
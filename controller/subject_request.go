@@ -0,0 +1,36 @@
+package controller
+
+import (
+	"context"
+
+	"github.com/webitel/engine/pkg/wbt/auth_manager"
+	"github.com/webitel/storage/model"
+)
+
+func (c *Controller) CreateSubjectRequest(ctx context.Context, session *auth_manager.Session, req *model.SubjectRequest) (*model.SubjectRequest, model.AppError) {
+	permission := session.GetPermission(model.PERMISSION_SCOPE_RECORD_FILE)
+	if !permission.CanRead() {
+		return nil, c.app.MakePermissionError(session, permission, auth_manager.PERMISSION_ACCESS_READ)
+	}
+
+	// the erasure bypasses the soft delete
+	if req.Mode == model.SubjectRequestErase && !permission.CanDelete() {
+		return nil, c.app.MakePermissionError(session, permission, auth_manager.PERMISSION_ACCESS_DELETE)
+	}
+
+	req.DomainId = session.Domain(0)
+	req.CreatedBy = &model.Lookup{
+		Id: int(session.UserId),
+	}
+
+	return c.app.CreateSubjectRequest(ctx, req)
+}
+
+func (c *Controller) GetSubjectRequest(ctx context.Context, session *auth_manager.Session, id int64) (*model.SubjectRequest, model.AppError) {
+	permission := session.GetPermission(model.PERMISSION_SCOPE_RECORD_FILE)
+	if !permission.CanRead() {
+		return nil, c.app.MakePermissionError(session, permission, auth_manager.PERMISSION_ACCESS_READ)
+	}
+
+	return c.app.GetSubjectRequest(ctx, session.Domain(0), id)
+}
@@ -0,0 +1,557 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.10
+// 	protoc        (unknown)
+// source: legal_hold.proto
+
+package storage
+
+import (
+	engine "github.com/webitel/storage/gen/engine"
+	_ "google.golang.org/genproto/googleapis/api/annotations"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type LegalHold struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	FileId        int64                  `protobuf:"varint,2,opt,name=file_id,json=fileId,proto3" json:"file_id,omitempty"`
+	ReferenceId   string                 `protobuf:"bytes,3,opt,name=reference_id,json=referenceId,proto3" json:"reference_id,omitempty"`
+	CallId        string                 `protobuf:"bytes,4,opt,name=call_id,json=callId,proto3" json:"call_id,omitempty"`
+	Reason        string                 `protobuf:"bytes,5,opt,name=reason,proto3" json:"reason,omitempty"`
+	CreatedAt     int64                  `protobuf:"varint,6,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	CreatedBy     *engine.Lookup         `protobuf:"bytes,7,opt,name=created_by,json=createdBy,proto3" json:"created_by,omitempty"`
+	ReleasedAt    int64                  `protobuf:"varint,8,opt,name=released_at,json=releasedAt,proto3" json:"released_at,omitempty"`
+	ReleasedBy    *engine.Lookup         `protobuf:"bytes,9,opt,name=released_by,json=releasedBy,proto3" json:"released_by,omitempty"`
+	ReleaseReason string                 `protobuf:"bytes,10,opt,name=release_reason,json=releaseReason,proto3" json:"release_reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LegalHold) Reset() {
+	*x = LegalHold{}
+	mi := &file_legal_hold_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LegalHold) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LegalHold) ProtoMessage() {}
+
+func (x *LegalHold) ProtoReflect() protoreflect.Message {
+	mi := &file_legal_hold_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LegalHold.ProtoReflect.Descriptor instead.
+func (*LegalHold) Descriptor() ([]byte, []int) {
+	return file_legal_hold_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *LegalHold) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *LegalHold) GetFileId() int64 {
+	if x != nil {
+		return x.FileId
+	}
+	return 0
+}
+
+func (x *LegalHold) GetReferenceId() string {
+	if x != nil {
+		return x.ReferenceId
+	}
+	return ""
+}
+
+func (x *LegalHold) GetCallId() string {
+	if x != nil {
+		return x.CallId
+	}
+	return ""
+}
+
+func (x *LegalHold) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *LegalHold) GetCreatedAt() int64 {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return 0
+}
+
+func (x *LegalHold) GetCreatedBy() *engine.Lookup {
+	if x != nil {
+		return x.CreatedBy
+	}
+	return nil
+}
+
+func (x *LegalHold) GetReleasedAt() int64 {
+	if x != nil {
+		return x.ReleasedAt
+	}
+	return 0
+}
+
+func (x *LegalHold) GetReleasedBy() *engine.Lookup {
+	if x != nil {
+		return x.ReleasedBy
+	}
+	return nil
+}
+
+func (x *LegalHold) GetReleaseReason() string {
+	if x != nil {
+		return x.ReleaseReason
+	}
+	return ""
+}
+
+type PlaceLegalHoldRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	FileId        []int64                `protobuf:"varint,1,rep,packed,name=file_id,json=fileId,proto3" json:"file_id,omitempty"`
+	CallId        string                 `protobuf:"bytes,2,opt,name=call_id,json=callId,proto3" json:"call_id,omitempty"`
+	ReferenceId   string                 `protobuf:"bytes,3,opt,name=reference_id,json=referenceId,proto3" json:"reference_id,omitempty"`
+	Reason        string                 `protobuf:"bytes,4,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PlaceLegalHoldRequest) Reset() {
+	*x = PlaceLegalHoldRequest{}
+	mi := &file_legal_hold_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PlaceLegalHoldRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PlaceLegalHoldRequest) ProtoMessage() {}
+
+func (x *PlaceLegalHoldRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_legal_hold_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PlaceLegalHoldRequest.ProtoReflect.Descriptor instead.
+func (*PlaceLegalHoldRequest) Descriptor() ([]byte, []int) {
+	return file_legal_hold_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *PlaceLegalHoldRequest) GetFileId() []int64 {
+	if x != nil {
+		return x.FileId
+	}
+	return nil
+}
+
+func (x *PlaceLegalHoldRequest) GetCallId() string {
+	if x != nil {
+		return x.CallId
+	}
+	return ""
+}
+
+func (x *PlaceLegalHoldRequest) GetReferenceId() string {
+	if x != nil {
+		return x.ReferenceId
+	}
+	return ""
+}
+
+func (x *PlaceLegalHoldRequest) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+type ReleaseLegalHoldRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            []int64                `protobuf:"varint,1,rep,packed,name=id,proto3" json:"id,omitempty"`
+	FileId        []int64                `protobuf:"varint,2,rep,packed,name=file_id,json=fileId,proto3" json:"file_id,omitempty"`
+	CallId        string                 `protobuf:"bytes,3,opt,name=call_id,json=callId,proto3" json:"call_id,omitempty"`
+	ReferenceId   string                 `protobuf:"bytes,4,opt,name=reference_id,json=referenceId,proto3" json:"reference_id,omitempty"`
+	Reason        string                 `protobuf:"bytes,5,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReleaseLegalHoldRequest) Reset() {
+	*x = ReleaseLegalHoldRequest{}
+	mi := &file_legal_hold_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReleaseLegalHoldRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReleaseLegalHoldRequest) ProtoMessage() {}
+
+func (x *ReleaseLegalHoldRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_legal_hold_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReleaseLegalHoldRequest.ProtoReflect.Descriptor instead.
+func (*ReleaseLegalHoldRequest) Descriptor() ([]byte, []int) {
+	return file_legal_hold_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ReleaseLegalHoldRequest) GetId() []int64 {
+	if x != nil {
+		return x.Id
+	}
+	return nil
+}
+
+func (x *ReleaseLegalHoldRequest) GetFileId() []int64 {
+	if x != nil {
+		return x.FileId
+	}
+	return nil
+}
+
+func (x *ReleaseLegalHoldRequest) GetCallId() string {
+	if x != nil {
+		return x.CallId
+	}
+	return ""
+}
+
+func (x *ReleaseLegalHoldRequest) GetReferenceId() string {
+	if x != nil {
+		return x.ReferenceId
+	}
+	return ""
+}
+
+func (x *ReleaseLegalHoldRequest) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+type SearchLegalHoldsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Page          int32                  `protobuf:"varint,1,opt,name=page,proto3" json:"page,omitempty"`
+	Size          int32                  `protobuf:"varint,2,opt,name=size,proto3" json:"size,omitempty"`
+	Q             string                 `protobuf:"bytes,3,opt,name=q,proto3" json:"q,omitempty"`
+	Sort          string                 `protobuf:"bytes,4,opt,name=sort,proto3" json:"sort,omitempty"`
+	Fields        []string               `protobuf:"bytes,5,rep,name=fields,proto3" json:"fields,omitempty"`
+	Id            []int64                `protobuf:"varint,6,rep,packed,name=id,proto3" json:"id,omitempty"`
+	FileId        []int64                `protobuf:"varint,7,rep,packed,name=file_id,json=fileId,proto3" json:"file_id,omitempty"`
+	ReferenceId   []string               `protobuf:"bytes,8,rep,name=reference_id,json=referenceId,proto3" json:"reference_id,omitempty"`
+	Active        bool                   `protobuf:"varint,9,opt,name=active,proto3" json:"active,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchLegalHoldsRequest) Reset() {
+	*x = SearchLegalHoldsRequest{}
+	mi := &file_legal_hold_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchLegalHoldsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchLegalHoldsRequest) ProtoMessage() {}
+
+func (x *SearchLegalHoldsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_legal_hold_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchLegalHoldsRequest.ProtoReflect.Descriptor instead.
+func (*SearchLegalHoldsRequest) Descriptor() ([]byte, []int) {
+	return file_legal_hold_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *SearchLegalHoldsRequest) GetPage() int32 {
+	if x != nil {
+		return x.Page
+	}
+	return 0
+}
+
+func (x *SearchLegalHoldsRequest) GetSize() int32 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *SearchLegalHoldsRequest) GetQ() string {
+	if x != nil {
+		return x.Q
+	}
+	return ""
+}
+
+func (x *SearchLegalHoldsRequest) GetSort() string {
+	if x != nil {
+		return x.Sort
+	}
+	return ""
+}
+
+func (x *SearchLegalHoldsRequest) GetFields() []string {
+	if x != nil {
+		return x.Fields
+	}
+	return nil
+}
+
+func (x *SearchLegalHoldsRequest) GetId() []int64 {
+	if x != nil {
+		return x.Id
+	}
+	return nil
+}
+
+func (x *SearchLegalHoldsRequest) GetFileId() []int64 {
+	if x != nil {
+		return x.FileId
+	}
+	return nil
+}
+
+func (x *SearchLegalHoldsRequest) GetReferenceId() []string {
+	if x != nil {
+		return x.ReferenceId
+	}
+	return nil
+}
+
+func (x *SearchLegalHoldsRequest) GetActive() bool {
+	if x != nil {
+		return x.Active
+	}
+	return false
+}
+
+type ListLegalHold struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Next          bool                   `protobuf:"varint,1,opt,name=next,proto3" json:"next,omitempty"`
+	Items         []*LegalHold           `protobuf:"bytes,2,rep,name=items,proto3" json:"items,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListLegalHold) Reset() {
+	*x = ListLegalHold{}
+	mi := &file_legal_hold_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListLegalHold) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListLegalHold) ProtoMessage() {}
+
+func (x *ListLegalHold) ProtoReflect() protoreflect.Message {
+	mi := &file_legal_hold_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListLegalHold.ProtoReflect.Descriptor instead.
+func (*ListLegalHold) Descriptor() ([]byte, []int) {
+	return file_legal_hold_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ListLegalHold) GetNext() bool {
+	if x != nil {
+		return x.Next
+	}
+	return false
+}
+
+func (x *ListLegalHold) GetItems() []*LegalHold {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+var File_legal_hold_proto protoreflect.FileDescriptor
+
+const file_legal_hold_proto_rawDesc = "" +
+	"\n" +
+	"\x10legal_hold.proto\x12\astorage\x1a\vconst.proto\x1a\x1cgoogle/api/annotations.proto\"\xcf\x02\n" +
+	"\tLegalHold\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x17\n" +
+	"\afile_id\x18\x02 \x01(\x03R\x06fileId\x12!\n" +
+	"\freference_id\x18\x03 \x01(\tR\vreferenceId\x12\x17\n" +
+	"\acall_id\x18\x04 \x01(\tR\x06callId\x12\x16\n" +
+	"\x06reason\x18\x05 \x01(\tR\x06reason\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\x06 \x01(\x03R\tcreatedAt\x12-\n" +
+	"\n" +
+	"created_by\x18\a \x01(\v2\x0e.engine.LookupR\tcreatedBy\x12\x1f\n" +
+	"\vreleased_at\x18\b \x01(\x03R\n" +
+	"releasedAt\x12/\n" +
+	"\vreleased_by\x18\t \x01(\v2\x0e.engine.LookupR\n" +
+	"releasedBy\x12%\n" +
+	"\x0erelease_reason\x18\n" +
+	" \x01(\tR\rreleaseReason\"\x84\x01\n" +
+	"\x15PlaceLegalHoldRequest\x12\x17\n" +
+	"\afile_id\x18\x01 \x03(\x03R\x06fileId\x12\x17\n" +
+	"\acall_id\x18\x02 \x01(\tR\x06callId\x12!\n" +
+	"\freference_id\x18\x03 \x01(\tR\vreferenceId\x12\x16\n" +
+	"\x06reason\x18\x04 \x01(\tR\x06reason\"\x96\x01\n" +
+	"\x17ReleaseLegalHoldRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x03(\x03R\x02id\x12\x17\n" +
+	"\afile_id\x18\x02 \x03(\x03R\x06fileId\x12\x17\n" +
+	"\acall_id\x18\x03 \x01(\tR\x06callId\x12!\n" +
+	"\freference_id\x18\x04 \x01(\tR\vreferenceId\x12\x16\n" +
+	"\x06reason\x18\x05 \x01(\tR\x06reason\"\xdf\x01\n" +
+	"\x17SearchLegalHoldsRequest\x12\x12\n" +
+	"\x04page\x18\x01 \x01(\x05R\x04page\x12\x12\n" +
+	"\x04size\x18\x02 \x01(\x05R\x04size\x12\f\n" +
+	"\x01q\x18\x03 \x01(\tR\x01q\x12\x12\n" +
+	"\x04sort\x18\x04 \x01(\tR\x04sort\x12\x16\n" +
+	"\x06fields\x18\x05 \x03(\tR\x06fields\x12\x0e\n" +
+	"\x02id\x18\x06 \x03(\x03R\x02id\x12\x17\n" +
+	"\afile_id\x18\a \x03(\x03R\x06fileId\x12!\n" +
+	"\freference_id\x18\b \x03(\tR\vreferenceId\x12\x16\n" +
+	"\x06active\x18\t \x01(\bR\x06active\"M\n" +
+	"\rListLegalHold\x12\x12\n" +
+	"\x04next\x18\x01 \x01(\bR\x04next\x12(\n" +
+	"\x05items\x18\x02 \x03(\v2\x12.storage.LegalHoldR\x05items2\xe0\x02\n" +
+	"\x10LegalHoldService\x12i\n" +
+	"\x0ePlaceLegalHold\x12\x1e.storage.PlaceLegalHoldRequest\x1a\x16.storage.ListLegalHold\"\x1f\x82\xd3\xe4\x93\x02\x19:\x01*\"\x14/storage/legal_holds\x12u\n" +
+	"\x10ReleaseLegalHold\x12 .storage.ReleaseLegalHoldRequest\x1a\x16.storage.ListLegalHold\"'\x82\xd3\xe4\x93\x02!:\x01*2\x1c/storage/legal_holds/release\x12j\n" +
+	"\x10SearchLegalHolds\x12 .storage.SearchLegalHoldsRequest\x1a\x16.storage.ListLegalHold\"\x1c\x82\xd3\xe4\x93\x02\x16\x12\x14/storage/legal_holdsB|\n" +
+	"\vcom.storageB\x0eLegalHoldProtoP\x01Z!github.com/webitel/protos/storage\xa2\x02\x03SXX\xaa\x02\aStorage\xca\x02\aStorage\xe2\x02\x13Storage\\GPBMetadata\xea\x02\aStorageb\x06proto3"
+
+var (
+	file_legal_hold_proto_rawDescOnce sync.Once
+	file_legal_hold_proto_rawDescData []byte
+)
+
+func file_legal_hold_proto_rawDescGZIP() []byte {
+	file_legal_hold_proto_rawDescOnce.Do(func() {
+		file_legal_hold_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_legal_hold_proto_rawDesc), len(file_legal_hold_proto_rawDesc)))
+	})
+	return file_legal_hold_proto_rawDescData
+}
+
+var file_legal_hold_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
+var file_legal_hold_proto_goTypes = []any{
+	(*LegalHold)(nil),               // 0: storage.LegalHold
+	(*PlaceLegalHoldRequest)(nil),   // 1: storage.PlaceLegalHoldRequest
+	(*ReleaseLegalHoldRequest)(nil), // 2: storage.ReleaseLegalHoldRequest
+	(*SearchLegalHoldsRequest)(nil), // 3: storage.SearchLegalHoldsRequest
+	(*ListLegalHold)(nil),           // 4: storage.ListLegalHold
+	(*engine.Lookup)(nil),           // 5: engine.Lookup
+}
+var file_legal_hold_proto_depIdxs = []int32{
+	5, // 0: storage.LegalHold.created_by:type_name -> engine.Lookup
+	5, // 1: storage.LegalHold.released_by:type_name -> engine.Lookup
+	0, // 2: storage.ListLegalHold.items:type_name -> storage.LegalHold
+	1, // 3: storage.LegalHoldService.PlaceLegalHold:input_type -> storage.PlaceLegalHoldRequest
+	2, // 4: storage.LegalHoldService.ReleaseLegalHold:input_type -> storage.ReleaseLegalHoldRequest
+	3, // 5: storage.LegalHoldService.SearchLegalHolds:input_type -> storage.SearchLegalHoldsRequest
+	4, // 6: storage.LegalHoldService.PlaceLegalHold:output_type -> storage.ListLegalHold
+	4, // 7: storage.LegalHoldService.ReleaseLegalHold:output_type -> storage.ListLegalHold
+	4, // 8: storage.LegalHoldService.SearchLegalHolds:output_type -> storage.ListLegalHold
+	6, // [6:9] is the sub-list for method output_type
+	3, // [3:6] is the sub-list for method input_type
+	3, // [3:3] is the sub-list for extension type_name
+	3, // [3:3] is the sub-list for extension extendee
+	0, // [0:3] is the sub-list for field type_name
+}
+
+func init() { file_legal_hold_proto_init() }
+func file_legal_hold_proto_init() {
+	if File_legal_hold_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_legal_hold_proto_rawDesc), len(file_legal_hold_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   5,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_legal_hold_proto_goTypes,
+		DependencyIndexes: file_legal_hold_proto_depIdxs,
+		MessageInfos:      file_legal_hold_proto_msgTypes,
+	}.Build()
+	File_legal_hold_proto = out.File
+	file_legal_hold_proto_goTypes = nil
+	file_legal_hold_proto_depIdxs = nil
+}
@@ -17,6 +17,11 @@ type API struct {
 	fileTranscript   *fileTranscript
 	importTemplate   *importTemplate
 	filePolicies     *filePolicies
+	legalHold        *legalHold
+	quarantine       *quarantine
+	audit            *audit
+	subjectRequest   *subjectRequest
+	fileArchive      *fileArchive
 }
 
 func Init(a *app.App, server *grpc.Server) {
@@ -32,6 +37,11 @@ func Init(a *app.App, server *grpc.Server) {
 	api.fileTranscript = NewFileTranscriptApi(ctrl)
 	api.importTemplate = NewImportTemplateApi(ctrl)
 	api.filePolicies = NewFilePoliciesApi(ctrl)
+	api.legalHold = NewLegalHoldApi(ctrl)
+	api.quarantine = NewQuarantineApi(ctrl)
+	api.audit = NewAuditApi(ctrl)
+	api.subjectRequest = NewSubjectRequestApi(ctrl)
+	api.fileArchive = NewFileArchiveApi(ctrl)
 
 	storage.RegisterBackendProfileServiceServer(server, api.backendProfiles)
 	storage.RegisterMediaFileServiceServer(server, api.media)
@@ -40,4 +50,9 @@ func Init(a *app.App, server *grpc.Server) {
 	storage.RegisterFileTranscriptServiceServer(server, api.fileTranscript)
 	storage.RegisterImportTemplateServiceServer(server, api.importTemplate)
 	storage.RegisterFilePoliciesServiceServer(server, api.filePolicies)
+	storage.RegisterLegalHoldServiceServer(server, api.legalHold)
+	storage.RegisterQuarantineServiceServer(server, api.quarantine)
+	storage.RegisterAuditServiceServer(server, api.audit)
+	storage.RegisterSubjectRequestServiceServer(server, api.subjectRequest)
+	storage.RegisterFileArchiveServiceServer(server, api.fileArchive)
 }
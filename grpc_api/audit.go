@@ -0,0 +1,73 @@
+package grpc_api
+
+import (
+	"context"
+
+	"github.com/webitel/storage/controller"
+	"github.com/webitel/storage/gen/storage"
+	"github.com/webitel/storage/model"
+)
+
+type audit struct {
+	ctrl *controller.Controller
+	storage.UnsafeAuditServiceServer
+}
+
+func NewAuditApi(c *controller.Controller) *audit {
+	return &audit{ctrl: c}
+}
+
+func (api *audit) SearchAuditEvents(ctx context.Context, in *storage.SearchAuditEventsRequest) (*storage.ListAuditEvent, error) {
+	session, err := api.ctrl.GetSessionFromCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	search := &model.SearchAuditEvent{
+		ListRequest: model.ListRequest{
+			Q:       in.GetQ(),
+			Page:    int(in.GetPage()),
+			PerPage: int(in.GetSize()),
+			Fields:  in.Fields,
+			Sort:    in.Sort,
+		},
+		CreatedAt: GetFilterBetween(in.GetCreatedAt()),
+		FileIds:   in.FileId,
+		UserIds:   in.UserId,
+		Actions:   in.Action,
+		Results:   in.Result,
+	}
+
+	list, endOfData, err := api.ctrl.SearchAuditEvents(ctx, session, search)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]*storage.AuditEvent, 0, len(list))
+	for _, v := range list {
+		items = append(items, toGrpcAuditEvent(v))
+	}
+
+	return &storage.ListAuditEvent{
+		Next:  !endOfData,
+		Items: items,
+	}, nil
+}
+
+func toGrpcAuditEvent(src *model.AuditEvent) *storage.AuditEvent {
+	res := &storage.AuditEvent{
+		Id:        src.Id,
+		CreatedAt: src.CreatedAt,
+		User:      GetProtoLookup(src.User),
+		FileId:    src.FileId,
+		Ip:        src.Ip,
+		Action:    src.Action,
+		Result:    src.Result,
+	}
+
+	if src.Error != nil {
+		res.Error = *src.Error
+	}
+
+	return res
+}
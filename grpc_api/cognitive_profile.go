@@ -21,9 +21,29 @@ type cognitiveProfile struct {
 	storage.UnsafeCognitiveProfileServiceServer
 }
 
-func (api *cognitiveProfile) SearchCognitiveProfileVoices(ctx context.Context, request *storage.SearchCognitiveProfileVoicesRequest) (*storage.ListCognitiveProfileVoices, error) {
-	//TODO implement me
-	panic("implement me")
+func (api *cognitiveProfile) SearchCognitiveProfileVoices(ctx context.Context, in *storage.SearchCognitiveProfileVoicesRequest) (*storage.ListCognitiveProfileVoices, error) {
+	session, err := api.ctrl.GetSessionFromCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var list []*model.CognitiveProfileVoice
+	list, err = api.ctrl.SearchCognitiveProfileVoices(session, in.GetId(), 0, in.GetQ())
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]*storage.CognitiveProfileVoice, 0, len(list))
+	for _, v := range list {
+		items = append(items, &storage.CognitiveProfileVoice{
+			Id:   v.Id,
+			Name: v.Name,
+		})
+	}
+
+	return &storage.ListCognitiveProfileVoices{
+		Items: items,
+	}, nil
 }
 
 func NewCognitiveProfileApi(c *controller.Controller) *cognitiveProfile {
@@ -211,17 +231,13 @@ func toGrpcCognitiveProfile(src *model.CognitiveProfile) *storage.CognitiveProfi
 	}
 }
 
+// getProvider returns the enum value of the provider, the provider that is not in the enum of the protos yet is DefaultProvider
 func getProvider(p string) storage.ProviderType {
-	switch p {
-	case storage.ProviderType_Microsoft.String():
-		return storage.ProviderType_Microsoft
-	case storage.ProviderType_Google.String():
-		return storage.ProviderType_Google
-	case storage.ProviderType_ElevenLabs.String():
-		return storage.ProviderType_ElevenLabs
-	default:
-		return storage.ProviderType_DefaultProvider
+	if v, ok := storage.ProviderType_value[p]; ok {
+		return storage.ProviderType(v)
 	}
+
+	return storage.ProviderType_DefaultProvider
 }
 func getService(s string) storage.ServiceType {
 	switch s {
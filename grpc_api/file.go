@@ -12,6 +12,7 @@ import (
 	"strings"
 
 	"github.com/h2non/filetype"
+	"github.com/webitel/engine/pkg/wbt/auth_manager"
 
 	"github.com/webitel/wlog"
 
@@ -19,6 +20,7 @@ import (
 	"github.com/webitel/storage/controller"
 	"github.com/webitel/storage/gen/storage"
 	"github.com/webitel/storage/model"
+	"github.com/webitel/storage/utils"
 )
 
 var ErrCancel = errors.New("cancel")
@@ -183,6 +185,9 @@ func getMalware(in *model.MalwareScan) *storage.FileMalwareScan {
 
 func (api *file) GenerateFileLink(ctx context.Context, in *storage.GenerateFileLinkRequest) (*storage.GenerateFileLinkResponse, error) {
 	uri, err := api.ctrl.GeneratePreSignedResourceSignatureBulk(in.GetFileId(), in.GetDomainId(), model.AnyFileRouteName, in.GetAction(), in.GetSource(), in.GetQuery())
+	if in.GetSource() != "media" {
+		api.auditFiles(ctx, nil, in.GetDomainId(), model.AuditActionGenerateLink, []int64{in.GetFileId()}, err)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -221,9 +226,11 @@ func (api *file) BulkGenerateFileLink(ctx context.Context, in *storage.BulkGener
 	items := make([]*storage.GenerateFileLinkResponse, l, l)
 	var uri string
 	var err error
+	var audit fileLinkAudit
 
 	for k, v := range in.GetFiles() {
 		uri, err = api.ctrl.GeneratePreSignetResourceSignature(model.AnyFileRouteName, v.GetAction(), v.GetFileId(), v.GetDomainId())
+		audit.add(v.GetDomainId(), v.GetFileId(), err)
 		if err == nil {
 			items[k] = &storage.GenerateFileLinkResponse{
 				Url:     uri,
@@ -232,12 +239,62 @@ func (api *file) BulkGenerateFileLink(ctx context.Context, in *storage.BulkGener
 		}
 	}
 
+	// one event for the files of the domain with the same result
+	if len(audit) != 0 {
+		session, _ := api.ctrl.GetSessionFromCtx(ctx)
+		if session == nil {
+			session = &auth_manager.Session{}
+		}
+		for _, e := range audit {
+			api.auditFiles(ctx, session, e.domainId, model.AuditActionGenerateLink, e.ids, e.err)
+		}
+	}
+
 	return &storage.BulkGenerateFileLinkResponse{
 		Links: items,
 	}, nil
 }
 
+type fileLinkAuditEvent struct {
+	domainId int64
+	err      error
+	ids      []int64
+}
+
+// fileLinkAudit groups the generated links by the domain and the result
+type fileLinkAudit []*fileLinkAuditEvent
+
+func (a *fileLinkAudit) add(domainId int64, id int64, err error) {
+	for _, e := range *a {
+		if e.domainId == domainId && sameError(e.err, err) {
+			e.ids = append(e.ids, id)
+			return
+		}
+	}
+
+	*a = append(*a, &fileLinkAuditEvent{
+		domainId: domainId,
+		err:      err,
+		ids:      []int64{id},
+	})
+}
+
+func sameError(a, b error) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return a.Error() == b.Error()
+}
+
 func (api *file) DownloadFile(in *storage.DownloadFileRequest, stream storage.FileService_DownloadFileServer) error {
+	err := api.downloadFile(in, stream)
+	api.auditFiles(stream.Context(), nil, in.DomainId, model.AuditActionDownload, []int64{in.Id}, err)
+
+	return err
+}
+
+func (api *file) downloadFile(in *storage.DownloadFileRequest, stream storage.FileService_DownloadFileServer) error {
 	var sFile io.ReadCloser
 	var err error
 	var buf []byte
@@ -248,6 +305,10 @@ func (api *file) DownloadFile(in *storage.DownloadFileRequest, stream storage.Fi
 		return appErr
 	}
 
+	if in.Format != "" {
+		return api.downloadConvertedFile(in, f, backend, stream)
+	}
+
 	if in.Metadata {
 		d := &storage.StreamFile_Metadata_{
 			Metadata: &storage.StreamFile_Metadata{
@@ -318,6 +379,65 @@ func (api *file) DownloadFile(in *storage.DownloadFileRequest, stream storage.Fi
 	return nil
 }
 
+// downloadConvertedFile streams the file converted to the format, the size in the metadata is 0 when the file
+// is converted on the fly
+func (api *file) downloadConvertedFile(in *storage.DownloadFileRequest, f *model.File, backend utils.FileBackend, stream storage.FileService_DownloadFileServer) error {
+	var bufferSize int64 = 4 * 1024
+
+	format, appErr := model.ParseAudioFormat(in.Format)
+	if appErr != nil {
+		return appErr
+	}
+
+	if in.Offset > 0 || in.FetchThumbnail {
+		return model.NewBadRequestError("grpc.file.download.format", "offset and fetch_thumbnail are not supported with format")
+	}
+
+	reader, size, appErr := api.ctrl.InsecureFileConversionReader(f, backend, format)
+	if appErr != nil {
+		return appErr
+	}
+	defer reader.Close()
+
+	if in.Metadata {
+		err := stream.Send(&storage.StreamFile{
+			Data: &storage.StreamFile_Metadata_{
+				Metadata: &storage.StreamFile_Metadata{
+					Id:       f.Id,
+					Name:     format.FileName(f.GetViewName()),
+					MimeType: format.MimeType(),
+					Uuid:     f.Uuid,
+					Size:     max(size, 0),
+				},
+			},
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if in.BufferSize > 0 {
+		bufferSize = in.BufferSize
+	}
+
+	buf := make([]byte, bufferSize)
+	for {
+		n, err := reader.Read(buf)
+		if n > 0 {
+			if e := stream.Send(&storage.StreamFile{Data: &storage.StreamFile_Chunk{Chunk: buf[:n]}}); e != nil {
+				return e
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			wlog.Error(fmt.Sprintf("DownloadFile \"%s\" format %s error: %s", f.Name, format.String(), err.Error()))
+			return err
+		}
+	}
+}
+
 func (api *file) UploadFileUrl(ctx context.Context, in *storage.UploadFileUrlRequest) (*storage.UploadFileUrlResponse, error) {
 	var err model.AppError
 	var publicUrl string
@@ -427,6 +547,7 @@ func (api *file) DeleteFiles(ctx context.Context, in *storage.DeleteFilesRequest
 	}
 
 	err = api.ctrl.DeleteFiles(ctx, session, in.Id)
+	api.auditFiles(ctx, session, 0, model.AuditActionDelete, in.Id, err)
 	if err != nil {
 		return nil, err
 	}
@@ -441,6 +562,7 @@ func (api *file) RestoreFiles(ctx context.Context, in *storage.RestoreFilesReque
 	}
 
 	err = api.ctrl.RestoreFiles(ctx, session, in.Id)
+	api.auditFiles(ctx, session, 0, model.AuditActionRestore, in.Id, err)
 	if err != nil {
 		return nil, err
 	}
@@ -455,6 +577,7 @@ func (api *file) DeleteQuarantineFiles(ctx context.Context, in *storage.DeleteQu
 	}
 
 	err = api.ctrl.DeleteQuarantineFiles(session, in.Id)
+	api.auditFiles(ctx, session, 0, model.AuditActionDelete, in.Id, err)
 	if err != nil {
 		return nil, err
 	}
@@ -637,6 +760,10 @@ func (api *file) SearchFiles(ctx context.Context, in *storage.SearchFilesRequest
 		}
 	}
 
+	if in.LegalHold {
+		search.LegalHold = model.NewBool(true)
+	}
+
 	list, endOfData, err = api.ctrl.SearchFile(ctx, session, search)
 	if err != nil {
 		return nil, err
@@ -964,3 +1091,20 @@ func channelTypeGrpc(channel string) storage.UploadFileChannel {
 
 	}
 }
+
+// auditFiles records the action with the files, the user is taken from the session of the request when it's set
+func (api *file) auditFiles(ctx context.Context, session *auth_manager.Session, domainId int64, action string, ids []int64, err error) {
+	if session == nil {
+		session, _ = api.ctrl.GetSessionFromCtx(ctx)
+	}
+
+	var userId int64
+	if session != nil {
+		userId = session.UserId
+		if domainId == 0 {
+			domainId = session.Domain(0)
+		}
+	}
+
+	api.ctrl.App().AuditFiles(ctx, domainId, userId, app.IpFromGrpcContext(ctx), action, ids, err)
+}
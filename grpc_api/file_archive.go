@@ -0,0 +1,105 @@
+package grpc_api
+
+import (
+	"bufio"
+	"fmt"
+
+	"github.com/webitel/storage/app"
+	"github.com/webitel/storage/controller"
+	"github.com/webitel/storage/gen/storage"
+	"github.com/webitel/storage/model"
+	"github.com/webitel/wlog"
+)
+
+type fileArchive struct {
+	ctrl *controller.Controller
+	storage.UnsafeFileArchiveServiceServer
+}
+
+func NewFileArchiveApi(c *controller.Controller) *fileArchive {
+	return &fileArchive{ctrl: c}
+}
+
+// archiveStreamWriter sends the written bytes as the chunks of the stream
+type archiveStreamWriter struct {
+	stream storage.FileArchiveService_DownloadFilesArchiveServer
+}
+
+func (w *archiveStreamWriter) Write(p []byte) (int, error) {
+	err := w.stream.Send(&storage.StreamFile{
+		Data: &storage.StreamFile_Chunk{
+			Chunk: p,
+		},
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+func (api *fileArchive) DownloadFilesArchive(in *storage.DownloadFilesArchiveRequest, stream storage.FileArchiveService_DownloadFilesArchiveServer) error {
+	var bufferSize int64 = 32 * 1024
+	ctx := stream.Context()
+
+	session, appErr := api.ctrl.GetSessionFromCtx(ctx)
+	if appErr != nil {
+		return appErr
+	}
+
+	archive := &model.FileArchive{
+		Name: in.Name,
+		Search: model.SearchFile{
+			Ids:          in.Id,
+			ReferenceIds: in.ReferenceId,
+			UploadedBy:   in.UploadedBy,
+			Channels:     channelsType(in.Channel),
+			CallId:       optionalString(in.CallId),
+		},
+	}
+
+	if in.UploadedAt != nil {
+		archive.Search.UploadedAt = &model.FilterBetween{
+			From: in.GetUploadedAt().GetFrom(),
+			To:   in.GetUploadedAt().GetTo(),
+		}
+	}
+
+	ids, appErr := api.ctrl.FileArchiveIds(ctx, session, archive)
+	if appErr != nil {
+		return appErr
+	}
+
+	err := stream.Send(&storage.StreamFile{
+		Data: &storage.StreamFile_Metadata_{
+			Metadata: &storage.StreamFile_Metadata{
+				Name:     archive.FileName(),
+				MimeType: model.FileArchiveMimeType,
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	if in.BufferSize > 0 {
+		bufferSize = in.BufferSize
+	}
+
+	buf := bufio.NewWriterSize(&archiveStreamWriter{stream: stream}, int(bufferSize))
+	archived, appErr := api.ctrl.WriteFileArchive(ctx, session, ids, buf)
+	if appErr == nil {
+		if err = buf.Flush(); err != nil {
+			appErr = model.NewInternalError("grpc.file_archive.flush", err.Error())
+		}
+	}
+
+	api.ctrl.App().AuditFiles(ctx, session.Domain(0), session.UserId, app.IpFromGrpcContext(ctx), model.AuditActionDownload, archived, appErr)
+
+	if appErr != nil {
+		wlog.Error(fmt.Sprintf("DownloadFilesArchive error: %s", appErr.Error()))
+		return appErr
+	}
+
+	return nil
+}
@@ -57,6 +57,7 @@ func (api *filePolicies) CreateFilePolicy(ctx context.Context, in *storage.Creat
 		RetentionDays: in.RetentionDays,
 		MaxUploadSize: in.MaxUploadSize,
 		Encrypt:       in.Encrypt,
+		MalwareScan:   in.MalwareScan,
 	}
 
 	policy, err = api.ctrl.CreateFilePolicy(ctx, session, policy)
@@ -137,6 +138,7 @@ func (api *filePolicies) UpdateFilePolicy(ctx context.Context, in *storage.Updat
 		RetentionDays: in.RetentionDays,
 		MaxUploadSize: in.MaxUploadSize,
 		Encrypt:       in.Encrypt,
+		MalwareScan:   in.MalwareScan,
 	}
 
 	policy, err = api.ctrl.UpdateFilePolicy(ctx, session, in.Id, policy)
@@ -179,6 +181,8 @@ func (api *filePolicies) PatchFilePolicy(ctx context.Context, in *storage.PatchF
 			patch.MaxUploadSize = &in.MaxUploadSize
 		case "encrypt":
 			patch.Encrypt = &in.Encrypt
+		case "malware_scan":
+			patch.MalwareScan = &in.MalwareScan
 		}
 	}
 
@@ -223,18 +227,18 @@ func (api *filePolicies) MovePositionFilePolicy(ctx context.Context, in *storage
 }
 
 func (api *filePolicies) FilePolicyApply(ctx context.Context, in *storage.FilePolicyApplyRequest) (*storage.FilePolicyApplyResponse, error) {
-	var count int64
+	var count, jobs int64
 	session, err := api.ctrl.GetSessionFromCtx(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	count, err = api.ctrl.ApplyFilePolicy(ctx, session, in.GetId(), in.GetApplyToNullChannel())
+	count, jobs, err = api.ctrl.ApplyFilePolicy(ctx, session, in.GetId(), in.GetApplyToNullChannel(), in.GetApplyEncryption())
 	if err != nil {
 		return nil, err
 	}
 
-	return &storage.FilePolicyApplyResponse{Count: count}, nil
+	return &storage.FilePolicyApplyResponse{Count: count, EncryptionJobs: jobs}, nil
 }
 
 func toGrpcFilePolicy(src *model.FilePolicy) *storage.FilePolicy {
@@ -255,6 +259,7 @@ func toGrpcFilePolicy(src *model.FilePolicy) *storage.FilePolicy {
 		MaxUploadSize: src.MaxUploadSize,
 		Position:      src.Position, //
 		Encrypt:       src.Encrypt,
+		MalwareScan:   src.MalwareScan,
 	}
 
 }
@@ -0,0 +1,140 @@
+package grpc_api
+
+import (
+	"context"
+
+	"github.com/webitel/storage/controller"
+	"github.com/webitel/storage/gen/storage"
+	"github.com/webitel/storage/model"
+)
+
+type legalHold struct {
+	ctrl *controller.Controller
+	storage.UnsafeLegalHoldServiceServer
+}
+
+func NewLegalHoldApi(c *controller.Controller) *legalHold {
+	return &legalHold{ctrl: c}
+}
+
+func (api *legalHold) PlaceLegalHold(ctx context.Context, in *storage.PlaceLegalHoldRequest) (*storage.ListLegalHold, error) {
+	session, err := api.ctrl.GetSessionFromCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	hold := &model.PlaceLegalHold{
+		FileIds:     in.FileId,
+		CallId:      optionalString(in.CallId),
+		ReferenceId: optionalString(in.ReferenceId),
+		Reason:      in.Reason,
+	}
+
+	list, err := api.ctrl.PlaceLegalHold(ctx, session, hold)
+	if err != nil {
+		return nil, err
+	}
+
+	return toGrpcLegalHolds(list, false), nil
+}
+
+func (api *legalHold) ReleaseLegalHold(ctx context.Context, in *storage.ReleaseLegalHoldRequest) (*storage.ListLegalHold, error) {
+	session, err := api.ctrl.GetSessionFromCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	release := &model.ReleaseLegalHold{
+		Ids:         in.Id,
+		FileIds:     in.FileId,
+		CallId:      optionalString(in.CallId),
+		ReferenceId: optionalString(in.ReferenceId),
+		Reason:      in.Reason,
+	}
+
+	list, err := api.ctrl.ReleaseLegalHold(ctx, session, release)
+	if err != nil {
+		return nil, err
+	}
+
+	return toGrpcLegalHolds(list, false), nil
+}
+
+func (api *legalHold) SearchLegalHolds(ctx context.Context, in *storage.SearchLegalHoldsRequest) (*storage.ListLegalHold, error) {
+	session, err := api.ctrl.GetSessionFromCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	search := &model.SearchLegalHold{
+		ListRequest: model.ListRequest{
+			Q:       in.GetQ(),
+			Page:    int(in.GetPage()),
+			PerPage: int(in.GetSize()),
+			Fields:  in.Fields,
+			Sort:    in.Sort,
+		},
+		Ids:          in.Id,
+		FileIds:      in.FileId,
+		ReferenceIds: in.ReferenceId,
+	}
+
+	if in.Active {
+		search.Active = model.NewBool(true)
+	}
+
+	list, endOfData, err := api.ctrl.SearchLegalHolds(ctx, session, search)
+	if err != nil {
+		return nil, err
+	}
+
+	return toGrpcLegalHolds(list, !endOfData), nil
+}
+
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+
+	return &s
+}
+
+func toGrpcLegalHolds(list []*model.LegalHold, next bool) *storage.ListLegalHold {
+	items := make([]*storage.LegalHold, 0, len(list))
+	for _, v := range list {
+		items = append(items, toGrpcLegalHold(v))
+	}
+
+	return &storage.ListLegalHold{
+		Next:  next,
+		Items: items,
+	}
+}
+
+func toGrpcLegalHold(src *model.LegalHold) *storage.LegalHold {
+	res := &storage.LegalHold{
+		Id:        src.Id,
+		Reason:    src.Reason,
+		CreatedAt: src.CreatedAt,
+		CreatedBy: GetProtoLookup(src.CreatedBy),
+	}
+
+	if src.FileId != nil {
+		res.FileId = *src.FileId
+	}
+	if src.ReferenceId != nil {
+		res.ReferenceId = *src.ReferenceId
+	}
+	if src.CallId != nil {
+		res.CallId = *src.CallId
+	}
+	if src.ReleasedAt != nil {
+		res.ReleasedAt = *src.ReleasedAt
+		res.ReleasedBy = GetProtoLookup(src.ReleasedBy)
+	}
+	if src.ReleaseReason != nil {
+		res.ReleaseReason = *src.ReleaseReason
+	}
+
+	return res
+}
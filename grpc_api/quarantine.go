@@ -0,0 +1,120 @@
+package grpc_api
+
+import (
+	"context"
+
+	"github.com/webitel/engine/pkg/wbt/auth_manager"
+	"github.com/webitel/storage/app"
+	"github.com/webitel/storage/controller"
+	"github.com/webitel/storage/gen/storage"
+	"github.com/webitel/storage/model"
+)
+
+type quarantine struct {
+	ctrl *controller.Controller
+	storage.UnsafeQuarantineServiceServer
+}
+
+func NewQuarantineApi(c *controller.Controller) *quarantine {
+	return &quarantine{ctrl: c}
+}
+
+func (api *quarantine) SearchQuarantineFiles(ctx context.Context, in *storage.SearchQuarantineFilesRequest) (*storage.ListQuarantineFile, error) {
+	session, err := api.ctrl.GetSessionFromCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	search := &model.SearchQuarantineFile{
+		ListRequest: model.ListRequest{
+			Q:       in.GetQ(),
+			Page:    int(in.GetPage()),
+			PerPage: int(in.GetSize()),
+			Fields:  in.Fields,
+			Sort:    in.Sort,
+		},
+		Ids:      in.Id,
+		Channels: channelsType(in.Channel),
+	}
+
+	if in.UploadedAt != nil {
+		search.UploadedAt = &model.FilterBetween{
+			From: in.GetUploadedAt().GetFrom(),
+			To:   in.GetUploadedAt().GetTo(),
+		}
+	}
+
+	list, endOfData, err := api.ctrl.SearchQuarantineFiles(ctx, session, search)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]*storage.QuarantineFile, 0, len(list))
+	for _, v := range list {
+		items = append(items, toGrpcQuarantineFile(v))
+	}
+
+	return &storage.ListQuarantineFile{
+		Next:  !endOfData,
+		Items: items,
+	}, nil
+}
+
+func (api *quarantine) ReleaseQuarantineFiles(ctx context.Context, in *storage.ReleaseQuarantineFilesRequest) (*storage.QuarantineFilesResponse, error) {
+	session, err := api.ctrl.GetSessionFromCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cnt, err := api.ctrl.ReleaseQuarantineFiles(ctx, session, in.Id)
+	api.audit(ctx, session, model.AuditActionRelease, in.Id, err)
+	if err != nil {
+		return nil, err
+	}
+
+	return &storage.QuarantineFilesResponse{Count: int64(cnt)}, nil
+}
+
+func (api *quarantine) RescanQuarantineFiles(ctx context.Context, in *storage.RescanQuarantineFilesRequest) (*storage.QuarantineFilesResponse, error) {
+	session, err := api.ctrl.GetSessionFromCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cnt, err := api.ctrl.RescanQuarantineFiles(ctx, session, in.Id)
+	api.audit(ctx, session, model.AuditActionRescan, in.Id, err)
+	if err != nil {
+		return nil, err
+	}
+
+	return &storage.QuarantineFilesResponse{Count: int64(cnt)}, nil
+}
+
+func (api *quarantine) audit(ctx context.Context, session *auth_manager.Session, action string, ids []int64, err error) {
+	api.ctrl.App().AuditFiles(ctx, session.Domain(0), session.UserId, app.IpFromGrpcContext(ctx), action, ids, err)
+}
+
+func toGrpcQuarantineFile(src *model.QuarantineFile) *storage.QuarantineFile {
+	res := &storage.QuarantineFile{
+		Id:         src.Id,
+		Uuid:       src.Uuid,
+		Name:       src.Name,
+		MimeType:   src.MimeType,
+		Size:       src.Size,
+		UploadedAt: model.TimeToInt64(src.UploadedAt),
+		UploadedBy: GetProtoLookup(src.UploadedBy),
+		ScannedAt:  model.TimeToInt64(src.ScannedAt),
+	}
+
+	if src.Channel != nil {
+		res.Channel = channelTypeGrpc(*src.Channel)
+	}
+	if src.MalwareStatus != nil {
+		res.MalwareStatus = *src.MalwareStatus
+	}
+	if src.MalwareDescription != nil {
+		res.MalwareDescription = *src.MalwareDescription
+	}
+
+	return res
+}
@@ -0,0 +1,94 @@
+package grpc_api
+
+import (
+	"context"
+
+	"github.com/webitel/storage/controller"
+	"github.com/webitel/storage/gen/storage"
+	"github.com/webitel/storage/model"
+)
+
+type subjectRequest struct {
+	ctrl *controller.Controller
+	storage.UnsafeSubjectRequestServiceServer
+}
+
+func NewSubjectRequestApi(c *controller.Controller) *subjectRequest {
+	return &subjectRequest{ctrl: c}
+}
+
+func (api *subjectRequest) CreateSubjectRequest(ctx context.Context, in *storage.CreateSubjectRequestRequest) (*storage.SubjectRequest, error) {
+	session, err := api.ctrl.GetSessionFromCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &model.SubjectRequest{
+		Mode: in.Mode,
+	}
+
+	if in.Filter != nil {
+		req.Filter = &model.SubjectFilter{
+			ReferenceIds: in.Filter.ReferenceIds,
+			CallId:       optionalString(in.Filter.CallId),
+			UploadedBy:   in.Filter.UploadedBy,
+		}
+	}
+
+	res, err := api.ctrl.CreateSubjectRequest(ctx, session, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return toGrpcSubjectRequest(res), nil
+}
+
+func (api *subjectRequest) ReadSubjectRequest(ctx context.Context, in *storage.ReadSubjectRequestRequest) (*storage.SubjectRequest, error) {
+	session, err := api.ctrl.GetSessionFromCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := api.ctrl.GetSubjectRequest(ctx, session, in.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	return toGrpcSubjectRequest(res), nil
+}
+
+func toGrpcSubjectRequest(src *model.SubjectRequest) *storage.SubjectRequest {
+	res := &storage.SubjectRequest{
+		Id:        src.Id,
+		Mode:      src.Mode,
+		State:     src.State,
+		CreatedAt: src.CreatedAt,
+		CreatedBy: GetProtoLookup(src.CreatedBy),
+		UpdatedAt: src.UpdatedAt,
+		Files:     src.Files,
+	}
+
+	if src.Filter != nil {
+		res.Filter = &storage.SubjectFilter{
+			ReferenceIds: src.Filter.ReferenceIds,
+			UploadedBy:   src.Filter.UploadedBy,
+		}
+		if src.Filter.CallId != nil {
+			res.Filter.CallId = *src.Filter.CallId
+		}
+	}
+	if src.ExportFileId != nil {
+		res.ExportFileId = *src.ExportFileId
+	}
+	if src.Report != nil {
+		res.Report = *src.Report
+	}
+	if src.Signature != nil {
+		res.Signature = *src.Signature
+	}
+	if src.Error != nil {
+		res.Error = *src.Error
+	}
+
+	return res
+}
@@ -3,3 +3,41 @@ package model
 type FileAMQPMessage struct {
 	File *File `json:"file"`
 }
+
+type AuditAMQPMessage struct {
+	Event   *AuditEvent `json:"event"`
+	FileIds []int64     `json:"file_ids"`
+}
+
+const (
+	MalwareSourceUpload = "upload"
+	MalwareSourceRescan = "rescan"
+
+	MalwareResultFound = "found"
+	MalwareResultClean = "clean"
+	MalwareResultError = "error"
+)
+
+type MalwareAMQPMessage struct {
+	FileId     int64        `json:"file_id"`
+	DomainId   int64        `json:"domain_id"`
+	Uuid       string       `json:"uuid"`
+	Name       string       `json:"name"`
+	MimeType   string       `json:"mime_type"`
+	Channel    *string      `json:"channel"`
+	Source     string       `json:"source"`
+	Quarantine bool         `json:"quarantine"`
+	Malware    *MalwareScan `json:"malware"`
+}
+
+// Result returns the result of the scan for the routing key of the event
+func (m *MalwareAMQPMessage) Result() string {
+	switch {
+	case m.Malware.Found:
+		return MalwareResultFound
+	case m.Malware.Status == MalwareStatusOk:
+		return MalwareResultClean
+	default:
+		return MalwareResultError
+	}
+}
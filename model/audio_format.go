@@ -0,0 +1,78 @@
+package model
+
+import (
+	"strings"
+)
+
+const (
+	AudioFormatMp3 = "mp3"
+	AudioFormatOgg = "ogg"
+	AudioFormatWav = "wav"
+
+	AudioChannelsMono   = "mono"
+	AudioChannelsStereo = "stereo"
+	AudioChannelsLeft   = "left"
+	AudioChannelsRight  = "right"
+)
+
+// AudioFormat is the format of the file converted on download: "mp3", "ogg", "wav",
+// optionally with the channels "mp3:mono", "wav:left", "ogg:stereo"
+type AudioFormat struct {
+	Codec    string
+	Channels string
+}
+
+func ParseAudioFormat(s string) (*AudioFormat, AppError) {
+	codec, channels, _ := strings.Cut(strings.ToLower(strings.TrimSpace(s)), ":")
+	f := &AudioFormat{
+		Codec:    codec,
+		Channels: channels,
+	}
+
+	switch f.Codec {
+	case AudioFormatMp3, AudioFormatOgg, AudioFormatWav:
+	case "opus":
+		f.Codec = AudioFormatOgg
+	default:
+		return nil, NewBadRequestError("model.audio_format.codec.app_error", "format "+codec+" is not supported, use mp3, ogg or wav")
+	}
+
+	switch f.Channels {
+	case "", AudioChannelsMono, AudioChannelsStereo, AudioChannelsLeft, AudioChannelsRight:
+	default:
+		return nil, NewBadRequestError("model.audio_format.channels.app_error", "channels "+channels+" are not supported, use mono, stereo, left or right")
+	}
+
+	return f, nil
+}
+
+func (f *AudioFormat) String() string {
+	if f.Channels == "" {
+		return f.Codec
+	}
+
+	return f.Codec + ":" + f.Channels
+}
+
+func (f *AudioFormat) MimeType() string {
+	switch f.Codec {
+	case AudioFormatMp3:
+		return "audio/mpeg"
+	case AudioFormatOgg:
+		return "audio/ogg"
+	default:
+		return "audio/wav"
+	}
+}
+
+// FileName returns the name of the source file with the extension of the format
+func (f *AudioFormat) FileName(name string) string {
+	if i := strings.LastIndex(name, "."); i > 0 {
+		name = name[:i]
+	}
+	if f.Channels != "" {
+		name += "_" + f.Channels
+	}
+
+	return name + "." + f.Codec
+}
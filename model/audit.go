@@ -0,0 +1,78 @@
+package model
+
+import "net/http"
+
+const (
+	AuditActionStream       = "stream"
+	AuditActionDownload     = "download"
+	AuditActionGenerateLink = "generate_link"
+	AuditActionDelete       = "delete"
+	AuditActionRestore      = "restore"
+	AuditActionExport       = "export"
+	AuditActionErase        = "erase"
+	AuditActionRelease      = "release"
+	AuditActionRescan       = "rescan"
+)
+
+const (
+	AuditResultSuccess = "success"
+	AuditResultDenied  = "denied"
+	AuditResultError   = "error"
+)
+
+// AuditEvent is the record of the access to the file or of the file mutation, the records are never changed
+type AuditEvent struct {
+	Id        int64   `json:"id" db:"id"`
+	CreatedAt int64   `json:"created_at" db:"created_at"`
+	DomainId  int64   `json:"-" db:"domain_id"`
+	UserId    *int64  `json:"user_id,omitempty" db:"user_id"`
+	User      *Lookup `json:"user" db:"user"`
+	FileId    int64   `json:"file_id" db:"file_id"`
+	Ip        string  `json:"ip" db:"ip"`
+	Action    string  `json:"action" db:"action"`
+	Result    string  `json:"result" db:"result"`
+	Error     *string `json:"error,omitempty" db:"error"`
+}
+
+type SearchAuditEvent struct {
+	ListRequest
+	CreatedAt *FilterBetween
+	FileIds   []int64
+	UserIds   []int64
+	Actions   []string
+	Results   []string
+}
+
+// SetResult sets the result of the event by the error of the action
+func (e *AuditEvent) SetResult(err error) {
+	if err == nil {
+		e.Result = AuditResultSuccess
+		return
+	}
+
+	e.Result = AuditResultError
+	if appErr, ok := err.(AppError); ok {
+		switch appErr.GetStatusCode() {
+		case http.StatusUnauthorized, http.StatusForbidden:
+			e.Result = AuditResultDenied
+		}
+	}
+
+	e.Error = NewString(err.Error())
+}
+
+func (AuditEvent) DefaultOrder() string {
+	return "-created_at"
+}
+
+func (AuditEvent) AllowFields() []string {
+	return []string{"id", "created_at", "user", "file_id", "ip", "action", "result", "error"}
+}
+
+func (AuditEvent) DefaultFields() []string {
+	return []string{"id", "created_at", "user", "file_id", "ip", "action", "result"}
+}
+
+func (AuditEvent) EntityName() string {
+	return "file_audit_list"
+}
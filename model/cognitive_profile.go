@@ -27,6 +27,12 @@ type CognitiveProfile struct {
 	SyncTag     int64           `json:"-" db:"-"`
 }
 
+// CognitiveProfileVoice is the voice of the TTS engine of the profile
+type CognitiveProfileVoice struct {
+	Id   string `json:"id"`
+	Name string `json:"name"`
+}
+
 type SearchCognitiveProfile struct {
 	ListRequest
 	Ids     []int64
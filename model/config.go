@@ -74,16 +74,66 @@ type Config struct {
 	Thumbnail          ThumbnailSettings      `json:"thumbnail"`
 	Log                LogSettings            `json:"log"`
 	TtsEndpoint        string                 `json:"tts_endpoint" flag:"wbt_tts_endpoint||Offline TTS endpoint" env:"WBT_TTS_ENDPOINT"`
+	TtsFailoverTimeout time.Duration          `json:"tts_failover_timeout" flag:"tts_failover_timeout|10s|Timeout of the TTS provider, the next fallback profile is used on timeout" env:"TTS_FAILOVER_TIMEOUT"`
+	TtsStreamChunk     int                    `json:"tts_stream_chunk" flag:"tts_stream_chunk|0|Maximum length of the text chunk synthesized separately, the long text is streamed after the first sentence (0 - disabled)" env:"TTS_STREAM_CHUNK"`
+	TtsStreamWorkers   int                    `json:"tts_stream_workers" flag:"tts_stream_workers|3|Number of the text chunks synthesized concurrently" env:"TTS_STREAM_WORKERS"`
+	TtsLocal           TtsLocalSettings       `json:"tts_local"`
 	MessageBroker      MessageBrokerSettings  `json:"message_broker"`
 	TriggerWatcher     TriggerWatcherSettings `json:"trigger_watcher"`
 	LoggerWatcher      LoggerWatcherSettings  `json:"logger_watcher"`
 	Clamav             ClamavSettings         `json:"clamav"`
+	Icap               IcapSettings           `json:"icap"`
+	TtsCache           TtsCacheSettings       `json:"tts_cache"`
 	WatchersEnabled    bool                   `json:"watchers_enabled,omitempty" flag:"watchers_enabled|1|Enable watcher" env:"WATCHERS_ENABLED"`
+	FileDeduplication  bool                   `json:"file_deduplication" flag:"file_deduplication|0|Store files with the same content once per domain" env:"FILE_DEDUPLICATION"`
+
+	ResumableUploadExpire time.Duration `json:"resumable_upload_expire" flag:"resumable_upload_expire|24h|Remove resumable uploads that were not continued" env:"RESUMABLE_UPLOAD_EXPIRE"`
+	DirectUploadExpire    time.Duration `json:"direct_upload_expire" flag:"direct_upload_expire|1h|Expire of presigned direct upload urls" env:"DIRECT_UPLOAD_EXPIRE"`
+	FileConversionCache   bool          `json:"file_conversion_cache" flag:"file_conversion_cache|false|Store files converted on download to another format" env:"FILE_CONVERSION_CACHE"`
+	CryptoKeyring         string        `json:"crypto_keyring" flag:"crypto_keyring||Directory of rotated crypto key files <version>.key, files are encrypted with the newest version" env:"CRYPTO_KEYRING"`
+	CryptoKms             string        `json:"crypto_kms" flag:"crypto_kms||KMS of the per-domain data keys: local, or the url of the Vault transit key http://vault:8200/transit/storage" env:"CRYPTO_KMS"`
+	CryptoKmsToken        string        `json:"crypto_kms_token" flag:"crypto_kms_token||Token of the Vault transit KMS" env:"CRYPTO_KMS_TOKEN"`
+	AuditPublish          bool          `json:"audit_publish" flag:"audit_publish|false|Publish the audit events of the files to the message broker" env:"AUDIT_PUBLISH"`
 }
 
 type ClamavSettings struct {
-	Address string `json:"address" flag:"clam_address||Clam address" env:"CLAM_ADDRESS"`
-	Mode    string `json:"mode" flag:"clam_mode|quarantine|Clam mode - aggressive (not save), quarantine (default), skip" env:"CLAM_MODE"` // aggressive (not save), quarantine, skip
+	Address    string `json:"address" flag:"clam_address||Clam address" env:"CLAM_ADDRESS"`
+	Mode       string `json:"mode" flag:"clam_mode|quarantine|Clam mode - aggressive (not save), quarantine (default), skip, the mode of all scanners" env:"CLAM_MODE"` // aggressive (not save), quarantine, skip
+	RescanDays int    `json:"rescan_days" flag:"clam_rescan_days|0|Rescan files uploaded in the last days when the signatures are updated, 0 - disabled" env:"CLAM_RESCAN_DAYS"`
+}
+
+// IcapSettings of the ICAP antivirus or DLP gateway, the content is checked by clamav and icap when both are set
+type IcapSettings struct {
+	Address string        `json:"address" flag:"icap_address||ICAP service url icap://host:1344/avscan" env:"ICAP_ADDRESS"`
+	Method  string        `json:"method" flag:"icap_method|RESPMOD|ICAP method - RESPMOD (default), REQMOD" env:"ICAP_METHOD"`
+	Timeout time.Duration `json:"timeout" flag:"icap_timeout|60s|ICAP request timeout" env:"ICAP_TIMEOUT"`
+}
+
+// TtsLocalSettings of the offline TTS engine of the Local provider: the url of the Piper or Coqui HTTP server,
+// or the path of the piper binary that reads the text from stdin and synthesizes it by the voice model of the directory
+type TtsLocalSettings struct {
+	Address string        `json:"address" flag:"tts_local_address||Local TTS engine: url of the Piper or Coqui HTTP server, or path of the piper binary" env:"TTS_LOCAL_ADDRESS"`
+	Api     string        `json:"api" flag:"tts_local_api|piper|API of the local TTS HTTP server: piper, coqui" env:"TTS_LOCAL_API"`
+	Models  string        `json:"models" flag:"tts_local_models||Directory of the piper voice models <voice>.onnx and <voice>.onnx.json" env:"TTS_LOCAL_MODELS"`
+	Voice   string        `json:"voice" flag:"tts_local_voice||Default voice of the local TTS engine" env:"TTS_LOCAL_VOICE"`
+	Timeout time.Duration `json:"timeout" flag:"tts_local_timeout|60s|Timeout of the local TTS synthesis" env:"TTS_LOCAL_TIMEOUT"`
+}
+
+// TtsCacheSettings of the store of the synthesized speech, the same request of the profile is synthesized once
+// until the entry expires or is evicted by the size limit. The local store must be shared by all nodes
+type TtsCacheSettings struct {
+	Type               string          `json:"type" flag:"tts_cache_type||TTS cache store type: local, s3, gcs, azure (empty - disabled)" env:"TTS_CACHE_TYPE"`
+	PropsString        string          `json:"-" flag:"tts_cache_props||TTS cache store props" env:"TTS_CACHE_PROPS"`
+	Props              StringInterface `json:"props"`
+	TTL                time.Duration   `json:"ttl" flag:"tts_cache_ttl|720h|Expire of the TTS cache entry" env:"TTS_CACHE_TTL"`
+	MaxSizeString      string          `json:"max_size" flag:"tts_cache_max_size|10GB|Maximum size of the TTS cache, the least recently used entries are evicted" env:"TTS_CACHE_MAX_SIZE"`
+	MaxSize            int64           `json:"-"`
+	MaxEntrySizeString string          `json:"max_entry_size" flag:"tts_cache_max_entry_size|10MB|Maximum size of the cached TTS audio" env:"TTS_CACHE_MAX_ENTRY_SIZE"`
+	MaxEntrySize       int64           `json:"-"`
+}
+
+func (s *TtsCacheSettings) Enabled() bool {
+	return s.Type != ""
 }
 
 type MessageBrokerSettings struct {
@@ -0,0 +1,138 @@
+package model
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+const (
+	DirectUploadPartSize = 64 * 1024 * 1024 // 64 MB
+	DirectUploadMaxParts = 10000
+)
+
+// DirectUploadRequest is the file that the client wants to upload to the backend bypassing the service
+type DirectUploadRequest struct {
+	Name     string  `json:"name"`
+	MimeType string  `json:"mime_type"`
+	Size     int64   `json:"size"`
+	Channel  *string `json:"channel"`
+}
+
+type DirectUploadPart struct {
+	Number int    `json:"part_number"`
+	Size   int64  `json:"size,omitempty"`
+	Url    string `json:"url,omitempty"`
+	ETag   string `json:"etag,omitempty"`
+}
+
+type DirectUploadComplete struct {
+	Parts []DirectUploadPart `json:"parts"`
+}
+
+// DirectUpload is the object that is written by the client to the backend with presigned urls,
+// the file is created on the completion
+type DirectUpload struct {
+	Id               string                `json:"id" db:"id"`
+	DomainId         int64                 `json:"-" db:"domain_id"`
+	Uuid             string                `json:"uuid" db:"uuid"`
+	Name             string                `json:"-" db:"name"`
+	ViewName         string                `json:"name" db:"view_name"`
+	MimeType         string                `json:"mime_type" db:"mime_type"`
+	Channel          string                `json:"channel" db:"channel"`
+	Size             int64                 `json:"size" db:"size"`
+	ProfileId        int                   `json:"-" db:"profile_id"`
+	ProfileUpdatedAt *int64                `json:"-" db:"profile_updated_at"`
+	Properties       StringInterface       `json:"-" db:"properties"`
+	MultipartId      *string               `json:"-" db:"multipart_id"`
+	PartSize         int64                 `json:"part_size,omitempty" db:"part_size"`
+	RetentionUntil   *time.Time            `json:"-" db:"retention_until"`
+	CustomProperties *CustomFileProperties `json:"-" db:"custom_properties"`
+	UploadedBy       *int64                `json:"-" db:"uploaded_by"`
+	CreatedAt        int64                 `json:"created_at" db:"created_at"`
+	ExpiresAt        int64                 `json:"expires_at" db:"expires_at"`
+
+	Url     string             `json:"url,omitempty" db:"-"`
+	Method  string             `json:"method,omitempty" db:"-"`
+	Headers map[string]string  `json:"headers,omitempty" db:"-"`
+	Parts   []DirectUploadPart `json:"parts,omitempty" db:"-"`
+}
+
+func (r *DirectUploadRequest) IsValid() AppError {
+	if r.Name == "" {
+		return NewBadRequestError("model.direct_upload.name.app_error", "name is required")
+	}
+
+	if r.MimeType == "" {
+		return NewBadRequestError("model.direct_upload.mime_type.app_error", "mime_type is required")
+	}
+
+	if r.Size <= 0 {
+		return NewBadRequestError("model.direct_upload.size.app_error", "size is required")
+	}
+
+	if r.Size > DirectUploadPartSize*DirectUploadMaxParts {
+		return NewBadRequestError("model.direct_upload.size.app_error", "size is too large")
+	}
+
+	return nil
+}
+
+func (u *DirectUpload) PreSave() {
+	if u.Id == "" {
+		u.Id = NewId()
+	}
+	u.CreatedAt = GetMillis()
+}
+
+func (u *DirectUpload) IsMultipart() bool {
+	return u.MultipartId != nil
+}
+
+// File is the file row of the completed upload
+func (u *DirectUpload) File() *File {
+	f := &File{
+		DomainId:  u.DomainId,
+		Uuid:      u.Uuid,
+		CreatedAt: GetMillis(),
+		BaseFile: BaseFile{
+			Size:             u.Size,
+			Name:             u.Name,
+			ViewName:         NewString(u.ViewName),
+			MimeType:         u.MimeType,
+			Properties:       u.Properties.Copy(),
+			Channel:          NewString(u.Channel),
+			RetentionUntil:   u.RetentionUntil,
+			CustomProperties: u.CustomProperties,
+		},
+		ProfileId: &u.ProfileId,
+	}
+	if u.UploadedBy != nil {
+		f.UploadedBy = &Lookup{Id: int(*u.UploadedBy)}
+	}
+
+	return f
+}
+
+func (u *DirectUpload) ToJson() string {
+	b, _ := json.Marshal(u)
+	return string(b)
+}
+
+func DirectUploadRequestFromJson(data io.Reader) *DirectUploadRequest {
+	var req DirectUploadRequest
+	if err := json.NewDecoder(data).Decode(&req); err == nil {
+		return &req
+	} else {
+		return nil
+	}
+}
+
+func DirectUploadCompleteFromJson(data io.Reader) *DirectUploadComplete {
+	var req DirectUploadComplete
+	if err := json.NewDecoder(data).Decode(&req); err == nil {
+		return &req
+	} else {
+		return nil
+	}
+}
@@ -0,0 +1,29 @@
+package model
+
+import "encoding/json"
+
+// DomainKey is the data key of the domain wrapped by the KMS, the files of the domain are encrypted with the key.
+// The destroyed key has no wrapped key, the files encrypted with it can't be read (crypto-shredding)
+type DomainKey struct {
+	Id          int64   `json:"id" db:"id"`
+	DomainId    int64   `json:"-" db:"domain_id"`
+	Kms         string  `json:"kms" db:"kms"`
+	WrappedKey  *string `json:"-" db:"wrapped_key"`
+	CreatedAt   int64   `json:"created_at" db:"created_at"`
+	DestroyedAt *int64  `json:"destroyed_at,omitempty" db:"destroyed_at"`
+}
+
+func (k *DomainKey) PreSave() {
+	if k.CreatedAt == 0 {
+		k.CreatedAt = GetMillis()
+	}
+}
+
+func (k *DomainKey) IsDestroyed() bool {
+	return k.DestroyedAt != nil || k.WrappedKey == nil
+}
+
+func (k *DomainKey) ToJson() string {
+	b, _ := json.Marshal(k)
+	return string(b)
+}
@@ -25,6 +25,7 @@ type SearchFile struct {
 	AgentIds       []int
 	MimeType       *string
 	CallId         *string
+	LegalHold      *bool
 }
 
 type CustomFileProperties struct {
@@ -49,6 +50,12 @@ type BaseFile struct {
 	CustomProperties *CustomFileProperties `db:"custom_properties" json:"custom_properties"`
 }
 
+const (
+	MalwareStatusOk    = "OK"
+	MalwareStatusFound = "FOUND"
+	MalwareStatusError = "ERROR"
+)
+
 type MalwareScan struct {
 	Found      bool       `db:"found" json:"found"`
 	Status     string     `db:"status" json:"status"`
@@ -196,6 +203,42 @@ type FileWithProfile struct {
 	ProfileUpdatedAt *int64 `db:"profile_updated_at"`
 }
 
+// FileReplica is a copy of the file object in a replica backend profile
+type FileReplica struct {
+	FileId           int64           `json:"file_id" db:"file_id"`
+	ProfileId        int             `json:"profile_id" db:"profile_id"`
+	ProfileUpdatedAt *int64          `json:"-" db:"profile_updated_at"`
+	Properties       StringInterface `json:"properties" db:"properties"`
+}
+
+// FileConversion is the file converted on download to another format, it's stored when FileConversionCache is enabled
+type FileConversion struct {
+	FileId           int64           `json:"file_id" db:"file_id"`
+	Format           string          `json:"format" db:"format"`
+	ProfileId        *int            `json:"profile_id" db:"profile_id"`
+	ProfileUpdatedAt *int64          `json:"-" db:"profile_updated_at"`
+	Name             string          `json:"name" db:"name"`
+	MimeType         string          `json:"mime_type" db:"mime_type"`
+	Size             int64           `json:"size" db:"size"`
+	Properties       StringInterface `json:"properties" db:"properties"`
+}
+
+// File is the object of the conversion in the backend
+func (c *FileConversion) File(source *File) *File {
+	f := *source
+	f.BaseFile = BaseFile{
+		Name:       c.Name,
+		Size:       c.Size,
+		MimeType:   c.MimeType,
+		Properties: c.Properties,
+		Channel:    source.Channel,
+	}
+	f.ProfileId = c.ProfileId
+	f.Thumbnail = nil
+
+	return &f
+}
+
 func (f *File) ToJson() string {
 	b, _ := json.Marshal(f)
 	return string(b)
@@ -221,6 +264,51 @@ func (f *File) GetStoreName() string {
 	return f.Name // need uuid ?
 }
 
+// objectProperties are the properties that backends use to locate and decrypt a stored object
+var objectProperties = []string{"directory", "location", "key_version", "domain_key"}
+
+// LinkObject makes the file a reference to the backend object of origin instead of owning a copy
+func (f *File) LinkObject(origin *File) {
+	if f.ViewName == nil {
+		name := f.Name
+		f.ViewName = &name
+	}
+	f.Name = origin.Name
+
+	if f.Properties == nil {
+		f.Properties = StringInterface{}
+	}
+	for _, k := range objectProperties {
+		if v, ok := origin.Properties[k]; ok {
+			f.Properties[k] = v
+		} else {
+			delete(f.Properties, k)
+		}
+	}
+	f.Properties["origin_id"] = origin.Id
+}
+
+// IsObjectReference reports whether the file refers to the object of another file
+func (f *File) IsObjectReference() bool {
+	_, ok := f.Properties["origin_id"]
+	return ok
+}
+
+// SameObject reports whether both files point at the same backend object
+func (f *File) SameObject(o *File) bool {
+	if f.Name != o.Name || f.DomainId != o.DomainId {
+		return false
+	}
+
+	for _, k := range objectProperties {
+		if f.GetPropertyString(k) != o.GetPropertyString(k) {
+			return false
+		}
+	}
+
+	return true
+}
+
 func (f File) DefaultOrder() string {
 	return "-uploaded_at"
 }
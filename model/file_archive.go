@@ -0,0 +1,53 @@
+package model
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+const (
+	FileArchiveMaxFiles  = 1000
+	FileArchiveMimeType  = "application/zip"
+	FileArchiveErrors    = "errors.json"
+	fileArchiveDefault   = "files"
+	fileArchiveExtension = ".zip"
+)
+
+// FileArchive is the zip archive of the files selected by the ids or by the filter of SearchFile
+type FileArchive struct {
+	Name   string
+	Search SearchFile
+}
+
+func (a *FileArchive) IsValid() AppError {
+	s := &a.Search
+	if len(s.Ids) == 0 && len(s.ReferenceIds) == 0 && s.CallId == nil && len(s.UploadedBy) == 0 && s.UploadedAt == nil {
+		return NewBadRequestError("model.file_archive.filter", "id or reference_id or call_id or uploaded_by or uploaded_at must be set")
+	}
+
+	if len(s.Ids) > FileArchiveMaxFiles {
+		return NewBadRequestError("model.file_archive.ids", fmt.Sprintf("the archive is limited to %d files", FileArchiveMaxFiles))
+	}
+
+	return nil
+}
+
+// FileName returns the name of the archive with the zip extension
+func (a *FileArchive) FileName() string {
+	name := strings.TrimSpace(path.Base(a.Name))
+	if name == "" || name == "." || name == "/" {
+		name = fileArchiveDefault
+	}
+	if !strings.HasSuffix(strings.ToLower(name), fileArchiveExtension) {
+		name += fileArchiveExtension
+	}
+
+	return name
+}
+
+// FileArchiveError is the file that is not in the archive, the errors are listed in the errors.json entry
+type FileArchiveError struct {
+	Id    int64  `json:"id"`
+	Error string `json:"error"`
+}
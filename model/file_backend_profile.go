@@ -3,11 +3,14 @@ package model
 import (
 	"encoding/json"
 	"io"
+	"strconv"
 )
 
 const (
 	BackendCacheSize             = 1000
 	BackendProfileAccessKeyField = "access_key"
+	BackendProfileReplicasField  = "replicas"
+	BackendProfileTieringField   = "tiering"
 )
 
 type BackendProfileType string
@@ -18,8 +21,17 @@ const (
 	FileDriverS3      BackendProfileType = "s3"
 	FileDriverGDrive  BackendProfileType = "g_drive"
 	FileDriverDropBox BackendProfileType = "drop_box"
+	FileDriverAzure   BackendProfileType = "azure_blob"
+	FileDriverGCS     BackendProfileType = "gcs"
 )
 
+// TieringRule moves files of the profile older than AfterDays to the profile ProfileId
+type TieringRule struct {
+	AfterDays int     `json:"after_days"`
+	Channel   *string `json:"channel,omitempty"`
+	ProfileId int     `json:"profile_id"`
+}
+
 type FileBackendProfileType struct {
 	Id   int    `db:"id" json:"id"`
 	Name string `db:"name" json:"name"`
@@ -87,6 +99,20 @@ type GDriveProperties struct {
 	Directory  string `json:"directory"`
 }
 
+type AzureBlobProperties struct {
+	AccountName string `json:"account_name"`
+	AccountKey  string `json:"account_key"`
+	SasToken    string `json:"sas_token"`
+	Container   string `json:"container"`
+	Endpoint    string `json:"endpoint"`
+}
+
+type GCSProperties struct {
+	BucketName  string `json:"bucket_name"`
+	Credentials string `json:"credentials"`
+	Endpoint    string `json:"endpoint"`
+}
+
 type S3Region string
 type DORegion string
 
@@ -151,6 +177,27 @@ func (f *FileBackendProfile) IsValid() AppError {
 		return NewBadRequestError("model.file_backend_profile.name.app_error", "")
 	}
 
+	for _, id := range f.ReplicaIds() {
+		if id == int(f.Id) {
+			return NewBadRequestError("model.file_backend_profile.replicas.app_error", "profile can't be a replica of itself")
+		}
+	}
+
+	if _, ok := f.Properties[BackendProfileTieringField]; ok {
+		rules, err := f.TieringRules()
+		if err != nil {
+			return NewBadRequestError("model.file_backend_profile.tiering.app_error", err.Error())
+		}
+		for _, r := range rules {
+			if r.AfterDays < 1 || r.ProfileId < 1 {
+				return NewBadRequestError("model.file_backend_profile.tiering.app_error", "after_days and profile_id are required")
+			}
+			if r.ProfileId == int(f.Id) {
+				return NewBadRequestError("model.file_backend_profile.tiering.app_error", "profile can't move files to itself")
+			}
+		}
+	}
+
 	//FIXME
 	//if f.TypeId != 1 {
 	//	return NewBadRequestError("model.file_backend_profile.type_id.app_error", "")
@@ -158,6 +205,47 @@ func (f *FileBackendProfile) IsValid() AppError {
 	return nil
 }
 
+// ReplicaIds returns profiles that keep copies of files uploaded to this profile
+func (f *FileBackendProfile) ReplicaIds() []int {
+	list, _ := f.Properties[BackendProfileReplicasField].([]interface{})
+	ids := make([]int, 0, len(list))
+
+	for _, v := range list {
+		switch id := v.(type) {
+		case float64:
+			ids = append(ids, int(id))
+		case int:
+			ids = append(ids, id)
+		case string:
+			if i, err := strconv.Atoi(id); err == nil {
+				ids = append(ids, i)
+			}
+		}
+	}
+
+	return ids
+}
+
+// TieringRules returns rules of moving aging files to other profiles
+func (f *FileBackendProfile) TieringRules() ([]TieringRule, error) {
+	var rules []TieringRule
+	v, ok := f.Properties[BackendProfileTieringField]
+	if !ok || v == nil {
+		return rules, nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = json.Unmarshal(data, &rules); err != nil {
+		return nil, err
+	}
+
+	return rules, nil
+}
+
 func (f *FileBackendProfile) ToJson() string {
 	b, _ := json.Marshal(f)
 	return string(b)
@@ -235,6 +323,12 @@ func StorageBackendTypeFromString(t string) BackendProfileType {
 
 	case FileDriverDropBox.String():
 		return FileDriverDropBox
+
+	case FileDriverAzure.String():
+		return FileDriverAzure
+
+	case FileDriverGCS.String():
+		return FileDriverGCS
 	default:
 		return FileDriverUnknown
 
@@ -37,6 +37,7 @@ type FilePolicy struct {
 	Position      int32       `json:"position" db:"position"`
 	Max           *time.Time  `json:"max" db:"max"`
 	Encrypt       bool        `json:"encrypt" db:"encrypt"`
+	MalwareScan   bool        `json:"malware_scan" db:"malware_scan"`
 }
 
 type FilePolicyPath struct {
@@ -53,6 +54,7 @@ type FilePolicyPath struct {
 	RetentionDays *int32      `json:"retention_days" db:"retention_days"`
 	MaxUploadSize *int64      `json:"max_upload_size" db:"max_upload_size"`
 	Encrypt       *bool       `json:"encrypt" db:"encrypt"`
+	MalwareScan   *bool       `json:"malware_scan" db:"malware_scan"`
 }
 
 func (p *FilePolicy) Patch(path *FilePolicyPath) {
@@ -86,6 +88,9 @@ func (p *FilePolicy) Patch(path *FilePolicyPath) {
 	if path.Encrypt != nil {
 		p.Encrypt = *path.Encrypt
 	}
+	if path.MalwareScan != nil {
+		p.MalwareScan = *path.MalwareScan
+	}
 }
 
 type SearchFilePolicy struct {
@@ -101,6 +106,7 @@ func (FilePolicy) AllowFields() []string {
 	return []string{
 		"id", "created_at", "created_by", "updated_at", "updated_by", "position", "max_upload_size",
 		"name", "description", "enabled", "mime_types", "channels", "speed_download", "speed_upload", "retention_days", "encrypt",
+		"malware_scan",
 	}
 }
 
@@ -143,3 +149,19 @@ func IsFilePolicyError(err error) bool {
 		return false
 	}
 }
+
+// EncryptFiles reports whether the files of the policy are kept encrypted
+func (p *FilePolicy) EncryptFiles() bool {
+	return p.Enabled && p.Encrypt
+}
+
+// FilePolicyEncryption is the progress of the jobs that encrypt or decrypt the files of the policy
+// after the Encrypt flag is changed
+type FilePolicyEncryption struct {
+	PolicyId int32 `json:"policy_id" db:"policy_id"`
+	Encrypt  bool  `json:"encrypt" db:"encrypt"`
+	Total    int64 `json:"total" db:"total"`
+	Done     int64 `json:"done" db:"done"`
+	Pending  int64 `json:"pending" db:"pending"`
+	Failed   int64 `json:"failed" db:"failed"`
+}
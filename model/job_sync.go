@@ -5,6 +5,12 @@ const (
 	SyncJobSTT    = "STT"
 	Transcoding   = "transcoding"
 	Restore       = "restore"
+	Replicate     = "replicate"
+	Migrate       = "migrate"
+	ReEncrypt     = "re_encrypt"
+	Encrypt       = "encrypt"
+	Decrypt       = "decrypt"
+	Rescan        = "rescan"
 )
 
 type SyncJob struct {
@@ -0,0 +1,89 @@
+package model
+
+import "strings"
+
+// LegalHold blocks the removal of the file (FileId) or of the files of the reference (ReferenceId) while it's active.
+// The released hold is kept as the audit trail
+type LegalHold struct {
+	Id            int64   `json:"id" db:"id"`
+	DomainId      int64   `json:"-" db:"domain_id"`
+	FileId        *int64  `json:"file_id,omitempty" db:"file_id"`
+	ReferenceId   *string `json:"reference_id,omitempty" db:"reference_id"`
+	CallId        *string `json:"call_id,omitempty" db:"call_id"`
+	Reason        string  `json:"reason" db:"reason"`
+	CreatedAt     int64   `json:"created_at" db:"created_at"`
+	CreatedBy     *Lookup `json:"created_by" db:"created_by"`
+	ReleasedAt    *int64  `json:"released_at,omitempty" db:"released_at"`
+	ReleasedBy    *Lookup `json:"released_by,omitempty" db:"released_by"`
+	ReleaseReason *string `json:"release_reason,omitempty" db:"release_reason"`
+}
+
+// PlaceLegalHold places the holds on the files, on the reference id or on the files of the call
+type PlaceLegalHold struct {
+	FileIds     []int64
+	CallId      *string
+	ReferenceId *string
+	Reason      string
+}
+
+// ReleaseLegalHold releases the active holds by id, by file id, by reference id or by call id
+type ReleaseLegalHold struct {
+	Ids         []int64
+	FileIds     []int64
+	CallId      *string
+	ReferenceId *string
+	Reason      string
+}
+
+type SearchLegalHold struct {
+	ListRequest
+	Ids          []int64
+	FileIds      []int64
+	ReferenceIds []string
+	Active       *bool
+}
+
+func (p *PlaceLegalHold) IsValid() AppError {
+	p.Reason = strings.TrimSpace(p.Reason)
+	if p.Reason == "" {
+		return NewBadRequestError("model.legal_hold.reason", "reason is required")
+	}
+
+	if len(p.FileIds) == 0 && p.CallId == nil && p.ReferenceId == nil {
+		return NewBadRequestError("model.legal_hold.target", "file_id or call_id or reference_id must be set")
+	}
+
+	return nil
+}
+
+func (r *ReleaseLegalHold) IsValid() AppError {
+	r.Reason = strings.TrimSpace(r.Reason)
+	if r.Reason == "" {
+		return NewBadRequestError("model.legal_hold.reason", "reason is required")
+	}
+
+	if len(r.Ids) == 0 && len(r.FileIds) == 0 && r.CallId == nil && r.ReferenceId == nil {
+		return NewBadRequestError("model.legal_hold.target", "id or file_id or call_id or reference_id must be set")
+	}
+
+	return nil
+}
+
+func (LegalHold) DefaultOrder() string {
+	return "-created_at"
+}
+
+func (LegalHold) AllowFields() []string {
+	return []string{
+		"id", "file_id", "reference_id", "call_id", "reason", "created_at", "created_by",
+		"released_at", "released_by", "release_reason",
+	}
+}
+
+func (LegalHold) DefaultFields() []string {
+	return []string{"id", "file_id", "reference_id", "call_id", "reason", "created_at", "created_by", "released_at", "released_by"}
+}
+
+func (LegalHold) EntityName() string {
+	return "file_legal_holds_list"
+}
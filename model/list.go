@@ -100,3 +100,17 @@ func GetBetweenToTime(src *FilterBetween) *time.Time {
 	t := time.Unix(0, src.To*int64(time.Millisecond))
 	return &t
 }
+
+func GetBetweenFrom(src *FilterBetween) *int64 {
+	if src == nil || src.From == 0 {
+		return nil
+	}
+	return &src.From
+}
+
+func GetBetweenTo(src *FilterBetween) *int64 {
+	if src == nil || src.To == 0 {
+		return nil
+	}
+	return &src.To
+}
@@ -0,0 +1,45 @@
+package model
+
+import "time"
+
+// QuarantineFile is the file flagged by the malware scanner, in the quarantine mode the object is stored under $DOMAIN/quarantine
+type QuarantineFile struct {
+	Id                 int64      `json:"id" db:"id"`
+	DomainId           int64      `json:"-" db:"domain_id"`
+	Uuid               string     `json:"uuid" db:"uuid"`
+	Name               string     `json:"name" db:"name"`
+	MimeType           string     `json:"mime_type" db:"mime_type"`
+	Size               int64      `json:"size" db:"size"`
+	Channel            *string    `json:"channel" db:"channel"`
+	UploadedAt         *time.Time `json:"uploaded_at" db:"uploaded_at"`
+	UploadedBy         *Lookup    `json:"uploaded_by" db:"uploaded_by"`
+	MalwareStatus      *string    `json:"malware_status" db:"malware_status"`
+	MalwareDescription *string    `json:"malware_description" db:"malware_description"`
+	ScannedAt          *time.Time `json:"scanned_at" db:"scanned_at"`
+}
+
+type SearchQuarantineFile struct {
+	ListRequest
+	Ids        []int64
+	Channels   []string
+	UploadedAt *FilterBetween
+}
+
+func (QuarantineFile) DefaultOrder() string {
+	return "-uploaded_at"
+}
+
+func (QuarantineFile) AllowFields() []string {
+	return []string{
+		"id", "uuid", "name", "mime_type", "size", "channel", "uploaded_at", "uploaded_by",
+		"malware_status", "malware_description", "scanned_at",
+	}
+}
+
+func (QuarantineFile) DefaultFields() []string {
+	return []string{"id", "name", "mime_type", "size", "channel", "uploaded_at", "malware_description"}
+}
+
+func (QuarantineFile) EntityName() string {
+	return "files_quarantine_list"
+}
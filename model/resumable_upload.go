@@ -0,0 +1,104 @@
+package model
+
+import "encoding/json"
+
+const (
+	ResumableUploadProtocolVersion = "1.0.0"
+	ResumableUploadProperty        = "resumable_upload_id"
+)
+
+// ResumableUploadPart is the object in the backend with the bytes of the upload received by one request
+type ResumableUploadPart struct {
+	Offset     int64           `json:"offset"`
+	Size       int64           `json:"size"`
+	Name       string          `json:"name"`
+	Properties StringInterface `json:"properties,omitempty"`
+}
+
+func (p *ResumableUploadPart) ToJson() string {
+	b, _ := json.Marshal(p)
+	return string(b)
+}
+
+// ResumableUpload is the state of a chunked upload that can be continued after the connection is lost
+type ResumableUpload struct {
+	Id                string                `json:"id" db:"id"`
+	DomainId          int64                 `json:"domain_id" db:"domain_id"`
+	Uuid              string                `json:"uuid" db:"uuid"`
+	Name              string                `json:"name" db:"name"`
+	ViewName          string                `json:"view_name" db:"view_name"`
+	MimeType          string                `json:"mime_type" db:"mime_type"`
+	Channel           string                `json:"channel" db:"channel"`
+	Size              int64                 `json:"size" db:"size"`
+	Offset            int64                 `json:"offset" db:"upload_offset"`
+	ProfileId         *int                  `json:"-" db:"profile_id"`
+	ProfileUpdatedAt  *int64                `json:"-" db:"profile_updated_at"`
+	Parts             []ResumableUploadPart `json:"-" db:"parts"`
+	Instance          string                `json:"-" db:"instance"`
+	GenerateThumbnail bool                  `json:"generate_thumbnail" db:"generate_thumbnail"`
+	CustomProperties  *CustomFileProperties `json:"custom_properties" db:"custom_properties"`
+	UploadedBy        *int64                `json:"uploaded_by" db:"uploaded_by"`
+	FileId            *int64                `json:"file_id" db:"file_id"`
+	CreatedAt         int64                 `json:"created_at" db:"created_at"`
+	UpdatedAt         int64                 `json:"updated_at" db:"updated_at"`
+}
+
+func (u *ResumableUpload) PreSave() {
+	if u.Id == "" {
+		u.Id = NewId()
+	}
+	if u.CreatedAt == 0 {
+		u.CreatedAt = GetMillis()
+	}
+	u.UpdatedAt = GetMillis()
+}
+
+func (u *ResumableUpload) IsValid() AppError {
+	if u.Size < 0 {
+		return NewBadRequestError("model.resumable_upload.size.app_error", "Upload-Length is required")
+	}
+
+	if u.Uuid == "" {
+		return NewBadRequestError("model.resumable_upload.uuid.app_error", "")
+	}
+
+	return nil
+}
+
+func (u *ResumableUpload) Completed() bool {
+	return u.FileId != nil
+}
+
+// PartFile is the object of the part in the backend
+func (u *ResumableUpload) PartFile(part *ResumableUploadPart) *File {
+	file := &File{
+		DomainId: u.DomainId,
+		Uuid:     u.Uuid,
+	}
+	file.Name = part.Name
+	file.MimeType = "application/octet-stream"
+	file.Size = part.Size
+	file.Properties = part.Properties
+
+	return file
+}
+
+// JobUploadFile is the request to store the received file
+func (u *ResumableUpload) JobUploadFile() *JobUploadFile {
+	file := &JobUploadFile{}
+	file.ViewName = NewString(u.ViewName)
+	file.Name = u.Name
+	file.MimeType = u.MimeType
+	file.DomainId = u.DomainId
+	file.Uuid = u.Uuid
+	file.GenerateThumbnail = u.GenerateThumbnail
+	file.Channel = NewString(u.Channel)
+	file.CustomProperties = u.CustomProperties
+	// the file is found by the upload when the completion is repeated
+	file.SetPropertyString(ResumableUploadProperty, u.Id)
+	if u.UploadedBy != nil {
+		file.UploadedBy = &Lookup{Id: int(*u.UploadedBy)}
+	}
+
+	return file
+}
@@ -0,0 +1,129 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	SubjectRequestExport = "export"
+	SubjectRequestErase  = "erase"
+)
+
+const (
+	SubjectRequestPending = "pending"
+	SubjectRequestActive  = "active"
+	SubjectRequestDone    = "done"
+	SubjectRequestError   = "error"
+)
+
+const (
+	SubjectFileExported = "exported"
+	SubjectFileErased   = "erased"
+	SubjectFileHeld     = "held"
+	SubjectFileError    = "error"
+	// the file is erased, its object is kept for the other files with the same content
+	SubjectFileShared = "shared"
+)
+
+// SubjectFilter selects the files of the data subject, the fields are the same as in SearchFile
+type SubjectFilter struct {
+	ReferenceIds []string `json:"reference_ids,omitempty"`
+	CallId       *string  `json:"call_id,omitempty"`
+	UploadedBy   []int64  `json:"uploaded_by,omitempty"`
+}
+
+// SubjectRequest is the tracked job of the export or of the erasure of the data subject (GDPR).
+// The export is stored as the zip file ExportFileId, the report is signed by the presign key
+type SubjectRequest struct {
+	Id           int64          `json:"id" db:"id"`
+	DomainId     int64          `json:"-" db:"domain_id"`
+	Mode         string         `json:"mode" db:"mode"`
+	Filter       *SubjectFilter `json:"filter" db:"filter"`
+	State        string         `json:"state" db:"state"`
+	CreatedAt    int64          `json:"created_at" db:"created_at"`
+	CreatedBy    *Lookup        `json:"created_by" db:"created_by"`
+	UpdatedAt    int64          `json:"updated_at" db:"updated_at"`
+	Files        int64          `json:"files" db:"files"`
+	ExportFileId *int64         `json:"export_file_id,omitempty" db:"export_file_id"`
+	Report       *string        `json:"report,omitempty" db:"report"`
+	Signature    *string        `json:"signature,omitempty" db:"signature"`
+	Error        *string        `json:"error,omitempty" db:"error"`
+}
+
+// SubjectReport is the completion report of the request, Signature of the request is the signature of the report json
+// with the prefix "storage.subject_report:", so the report signature is never valid for the file links
+type SubjectReport struct {
+	RequestId    int64                `json:"request_id"`
+	DomainId     int64                `json:"domain_id"`
+	Mode         string               `json:"mode"`
+	Filter       *SubjectFilter       `json:"filter"`
+	CompletedAt  int64                `json:"completed_at"`
+	ExportFileId *int64               `json:"export_file_id,omitempty"`
+	Transcripts  int                  `json:"transcripts"`
+	Files        []*SubjectReportFile `json:"files"`
+}
+
+type SubjectReportFile struct {
+	Id        int64   `json:"id"`
+	Uuid      string  `json:"uuid"`
+	Name      string  `json:"name"`
+	Size      int64   `json:"size"`
+	SHA256Sum *string `json:"sha256sum,omitempty"`
+	Status    string  `json:"status"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// SubjectTranscript is the transcript of the file in the export
+type SubjectTranscript struct {
+	Id         int64           `json:"id" db:"id"`
+	FileId     int64           `json:"file_id" db:"file_id"`
+	Locale     string          `json:"locale" db:"locale"`
+	Transcript string          `json:"transcript" db:"transcript"`
+	Phrases    json.RawMessage `json:"phrases,omitempty" db:"phrases"`
+	CreatedAt  time.Time       `json:"created_at" db:"created_at"`
+}
+
+func (r *SubjectRequest) IsValid() AppError {
+	switch r.Mode {
+	case SubjectRequestExport, SubjectRequestErase:
+	default:
+		return NewBadRequestError("model.subject_request.mode", "mode must be export or erase")
+	}
+
+	if r.Filter == nil || (len(r.Filter.ReferenceIds) == 0 && r.Filter.CallId == nil && len(r.Filter.UploadedBy) == 0) {
+		return NewBadRequestError("model.subject_request.filter", "reference_id or call_id or uploaded_by must be set")
+	}
+
+	return nil
+}
+
+func (r *SubjectRequest) PreSave() {
+	if r.CreatedAt == 0 {
+		r.CreatedAt = GetMillis()
+	}
+	r.UpdatedAt = r.CreatedAt
+	r.State = SubjectRequestPending
+}
+
+func (r *SubjectRequest) CreatedById() int64 {
+	if r.CreatedBy == nil {
+		return 0
+	}
+
+	return int64(r.CreatedBy.Id)
+}
+
+// Search returns the search of the files of the subject
+func (f *SubjectFilter) Search() *SearchFile {
+	return &SearchFile{
+		ReferenceIds: f.ReferenceIds,
+		CallId:       f.CallId,
+		UploadedBy:   f.UploadedBy,
+	}
+}
+
+func (f *SubjectFilter) ToJson() string {
+	b, _ := json.Marshal(f)
+	return string(b)
+}
@@ -5,6 +5,9 @@ import (
 	"strconv"
 )
 
+// SysNamePeriodToPlaybackRecord is the days the call records are available to the time limited access
+const SysNamePeriodToPlaybackRecord = "period_to_playback_records"
+
 type SysValue json.RawMessage
 
 func (v *SysValue) Int() *int {
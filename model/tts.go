@@ -1,9 +1,73 @@
 package model
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"strings"
+)
 
 type TtsProfile struct {
+	Id         int             `json:"id" db:"id"`
 	Enabled    bool            `json:"enabled" db:"enabled"`
 	Provider   string          `json:"provider" db:"provider"`
 	Properties json.RawMessage `json:"properties" db:"properties"`
+	SyncTag    int64           `json:"-" db:"sync_tag"`
+}
+
+// TtsFallback is the profile used when the provider of the profile fails, properties "fallback" of the TTS profile:
+// [{"profile_id": 2, "voices": {"en-US-JennyNeural": "en-US-Wavenet-F", "*": "FEMALE"}}]
+type TtsFallback struct {
+	ProfileId int               `json:"profile_id"`
+	Voices    map[string]string `json:"voices,omitempty"`
+}
+
+// Fallback returns the ordered fallback profiles
+func (p *TtsProfile) Fallback() []TtsFallback {
+	var props struct {
+		Fallback []TtsFallback `json:"fallback"`
+	}
+	if len(p.Properties) == 0 || json.Unmarshal(p.Properties, &props) != nil {
+		return nil
+	}
+
+	return props.Fallback
+}
+
+// Voice maps the voice of the request to the voice of the fallback provider, "*" maps any voice,
+// the voice without the rule is not changed
+func (f *TtsFallback) Voice(voice string) string {
+	for k, v := range f.Voices {
+		if strings.EqualFold(k, voice) {
+			return v
+		}
+	}
+	if v, ok := f.Voices["*"]; ok {
+		return v
+	}
+
+	return voice
+}
+
+// TtsCacheEntry is the synthesized speech stored in the TTS cache (config tts_cache_type),
+// the key is the hash of the normalized request
+type TtsCacheEntry struct {
+	DomainId   int64           `json:"domain_id" db:"domain_id"`
+	Key        string          `json:"key" db:"key"`
+	Provider   string          `json:"provider" db:"provider"`
+	Name       string          `json:"name" db:"name"`
+	MimeType   string          `json:"mime_type" db:"mime_type"`
+	Size       int64           `json:"size" db:"size"`
+	Properties StringInterface `json:"properties" db:"properties"`
+}
+
+// File is the object of the entry in the cache store
+func (e *TtsCacheEntry) File() *File {
+	return &File{
+		DomainId: e.DomainId,
+		BaseFile: BaseFile{
+			Name:       e.Name,
+			Size:       e.Size,
+			MimeType:   e.MimeType,
+			Properties: e.Properties,
+		},
+	}
 }
@@ -84,3 +84,31 @@ func (s *LayeredStore) FilePolicies() FilePoliciesStore {
 func (s *LayeredStore) SystemSettings() SystemSettingsStore {
 	return s.DatabaseLayer.SystemSettings()
 }
+
+func (s *LayeredStore) ResumableUpload() ResumableUploadStore {
+	return s.DatabaseLayer.ResumableUpload()
+}
+
+func (s *LayeredStore) DirectUpload() DirectUploadStore {
+	return s.DatabaseLayer.DirectUpload()
+}
+
+func (s *LayeredStore) DomainKey() DomainKeyStore {
+	return s.DatabaseLayer.DomainKey()
+}
+
+func (s *LayeredStore) LegalHold() LegalHoldStore {
+	return s.DatabaseLayer.LegalHold()
+}
+
+func (s *LayeredStore) Audit() AuditStore {
+	return s.DatabaseLayer.Audit()
+}
+
+func (s *LayeredStore) SubjectRequest() SubjectRequestStore {
+	return s.DatabaseLayer.SubjectRequest()
+}
+
+func (s *LayeredStore) TtsCache() TtsCacheStore {
+	return s.DatabaseLayer.TtsCache()
+}
@@ -0,0 +1,73 @@
+package sqlstore
+
+import (
+	"context"
+
+	"github.com/lib/pq"
+	"github.com/webitel/storage/model"
+	"github.com/webitel/storage/store"
+)
+
+type SqlAuditStore struct {
+	SqlStore
+}
+
+func NewSqlAuditStore(sqlStore SqlStore) store.AuditStore {
+	us := &SqlAuditStore{sqlStore}
+	return us
+}
+
+// Create appends the event of each file
+func (s SqlAuditStore) Create(ctx context.Context, event *model.AuditEvent, fileIds []int64) model.AppError {
+	_, err := s.GetMaster().WithContext(ctx).Exec(`insert into storage.file_audit (created_at, domain_id, user_id, file_id, ip, action, result, error)
+select :CreatedAt::int8, :DomainId::int8, :UserId::int8, f.id, :Ip::varchar, :Action::varchar, :Result::varchar, :Error::text
+from unnest(:FileIds::int8[]) f(id)`, map[string]interface{}{
+		"CreatedAt": event.CreatedAt,
+		"DomainId":  event.DomainId,
+		"UserId":    event.UserId,
+		"FileIds":   pq.Array(fileIds),
+		"Ip":        event.Ip,
+		"Action":    event.Action,
+		"Result":    event.Result,
+		"Error":     event.Error,
+	})
+
+	if err != nil {
+		return model.NewCustomCodeError("store.sql_audit.create.app_error", err.Error(), extractCodeFromErr(err))
+	}
+
+	return nil
+}
+
+func (s SqlAuditStore) GetAllPage(ctx context.Context, domainId int64, search *model.SearchAuditEvent) ([]*model.AuditEvent, model.AppError) {
+	var events []*model.AuditEvent
+
+	f := map[string]interface{}{
+		"DomainId": domainId,
+		"From":     model.GetBetweenFrom(search.CreatedAt),
+		"To":       model.GetBetweenTo(search.CreatedAt),
+		"FileIds":  pq.Array(search.FileIds),
+		"UserIds":  pq.Array(search.UserIds),
+		"Actions":  pq.Array(search.Actions),
+		"Results":  pq.Array(search.Results),
+		"Q":        search.GetQ(),
+	}
+
+	err := s.ListQueryCtx(ctx, &events, search.ListRequest,
+		`domain_id = :DomainId
+				and (:From::int8 isnull or created_at >= :From::int8)
+				and (:To::int8 isnull or created_at <= :To::int8)
+				and (:FileIds::int8[] isnull or file_id = any(:FileIds))
+				and (:UserIds::int8[] isnull or user_id = any(:UserIds))
+				and (:Actions::varchar[] isnull or action = any(:Actions))
+				and (:Results::varchar[] isnull or result = any(:Results))
+				and (:Q::varchar isnull or ip ilike :Q::varchar)
+		`,
+		model.AuditEvent{}, f)
+
+	if err != nil {
+		return nil, model.NewCustomCodeError("store.sql_audit.get_all.app_error", err.Error(), extractCodeFromErr(err))
+	}
+
+	return events, nil
+}
@@ -262,7 +262,7 @@ where p.id = :Id`, map[string]interface{}{
 
 func (s SqlCognitiveProfileStore) SearchTtsProfile(domainId int64, profileId int) (*model.TtsProfile, model.AppError) {
 	var profile *model.TtsProfile
-	err := s.GetMaster().SelectOne(&profile, `select p.enabled, p.provider, p.properties
+	err := s.GetMaster().SelectOne(&profile, `select p.id, p.enabled, p.provider, p.properties, p.updated_at as sync_tag
 from storage.cognitive_profile_services p
 where p.domain_id = :DomainId::int8
     and case when :Id::int = 0 then p."default" else p.id = :Id::int end
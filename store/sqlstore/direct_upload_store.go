@@ -0,0 +1,123 @@
+package sqlstore
+
+import (
+	"fmt"
+
+	"github.com/webitel/storage/model"
+	"github.com/webitel/storage/store"
+)
+
+type SqlDirectUploadStore struct {
+	SqlStore
+}
+
+func NewSqlDirectUploadStore(sqlStore SqlStore) store.DirectUploadStore {
+	us := &SqlDirectUploadStore{sqlStore}
+	return us
+}
+
+func (s *SqlDirectUploadStore) Create(upload *model.DirectUpload) (*model.DirectUpload, model.AppError) {
+	upload.PreSave()
+	_, err := s.GetMaster().Exec(`insert into storage.direct_uploads (id, domain_id, uuid, name, view_name, mime_type, channel, size,
+                                    profile_id, properties, multipart_id, part_size, retention_until,
+                                    custom_properties, uploaded_by, created_at, expires_at)
+values (:Id, :DomainId, :Uuid, :Name, :ViewName, :MimeType, :Channel, :Size, :ProfileId, :Properties::jsonb,
+        :MultipartId, :PartSize, :RetentionUntil, :CustomProperties::jsonb, :UploadedBy, :CreatedAt, :ExpiresAt)`, map[string]interface{}{
+		"Id":               upload.Id,
+		"DomainId":         upload.DomainId,
+		"Uuid":             upload.Uuid,
+		"Name":             upload.Name,
+		"ViewName":         upload.ViewName,
+		"MimeType":         upload.MimeType,
+		"Channel":          upload.Channel,
+		"Size":             upload.Size,
+		"ProfileId":        upload.ProfileId,
+		"Properties":       upload.Properties.ToJson(),
+		"MultipartId":      upload.MultipartId,
+		"PartSize":         upload.PartSize,
+		"RetentionUntil":   upload.RetentionUntil,
+		"CustomProperties": upload.CustomProperties.ToJson(),
+		"UploadedBy":       upload.UploadedBy,
+		"CreatedAt":        upload.CreatedAt,
+		"ExpiresAt":        upload.ExpiresAt,
+	})
+
+	if err != nil {
+		return nil, model.NewCustomCodeError("store.sql_direct_upload.create.app_error", err.Error(), extractCodeFromErr(err))
+	}
+
+	return upload, nil
+}
+
+func (s *SqlDirectUploadStore) Get(domainId int64, id string) (*model.DirectUpload, model.AppError) {
+	var upload *model.DirectUpload
+	err := s.GetMaster().SelectOne(&upload, `select u.id, u.domain_id, u.uuid, u.name, u.view_name, u.mime_type, u.channel, u.size,
+       u.profile_id, p.updated_at as profile_updated_at, u.properties, u.multipart_id, coalesce(u.part_size, 0) as part_size,
+       u.retention_until, u.custom_properties, u.uploaded_by, u.created_at, u.expires_at
+from storage.direct_uploads u
+    inner join storage.file_backend_profiles p on p.id = u.profile_id
+where u.id = :Id
+  and u.domain_id = :DomainId`, map[string]interface{}{
+		"Id":       id,
+		"DomainId": domainId,
+	})
+
+	if err != nil {
+		return nil, model.NewCustomCodeError("store.sql_direct_upload.get.app_error", fmt.Sprintf("Id=%s %s", id, err.Error()), extractCodeFromErr(err))
+	}
+
+	return upload, nil
+}
+
+func (s *SqlDirectUploadStore) Delete(domainId int64, id string) (bool, model.AppError) {
+	res, err := s.GetMaster().Exec(`delete
+from storage.direct_uploads
+where id = :Id
+  and domain_id = :DomainId`, map[string]interface{}{
+		"Id":       id,
+		"DomainId": domainId,
+	})
+
+	if err != nil {
+		return false, model.NewCustomCodeError("store.sql_direct_upload.delete.app_error", err.Error(), extractCodeFromErr(err))
+	}
+
+	cnt, err := res.RowsAffected()
+	if err != nil {
+		return false, model.NewCustomCodeError("store.sql_direct_upload.delete.app_error", err.Error(), extractCodeFromErr(err))
+	}
+
+	return cnt > 0, nil
+}
+
+// RemoveExpired removes the uploads that were not completed in time and returns them to clean up the backend
+func (s *SqlDirectUploadStore) RemoveExpired(limit int) ([]*model.DirectUpload, model.AppError) {
+	var uploads []*model.DirectUpload
+	_, err := s.GetMaster().Select(&uploads, `with del as (
+    delete
+    from storage.direct_uploads
+    where id in (
+        select id
+        from storage.direct_uploads
+        where expires_at < :Now
+        order by expires_at
+        limit :Limit
+        for update skip locked
+    )
+    returning *
+)
+select del.id, del.domain_id, del.uuid, del.name, del.view_name, del.mime_type, del.channel, del.size,
+       del.profile_id, p.updated_at as profile_updated_at, del.properties, del.multipart_id,
+       coalesce(del.part_size, 0) as part_size, del.created_at, del.expires_at
+from del
+    inner join storage.file_backend_profiles p on p.id = del.profile_id`, map[string]interface{}{
+		"Now":   model.GetMillis(),
+		"Limit": limit,
+	})
+
+	if err != nil {
+		return nil, model.NewCustomCodeError("store.sql_direct_upload.remove_expired.app_error", err.Error(), extractCodeFromErr(err))
+	}
+
+	return uploads, nil
+}
@@ -0,0 +1,150 @@
+package sqlstore
+
+import (
+	"database/sql"
+
+	"github.com/webitel/storage/model"
+	"github.com/webitel/storage/store"
+)
+
+type SqlDomainKeyStore struct {
+	SqlStore
+}
+
+func NewSqlDomainKeyStore(sqlStore SqlStore) store.DomainKeyStore {
+	us := &SqlDomainKeyStore{sqlStore}
+	return us
+}
+
+// Create saves the key as the active key of the domain, returns the key of a concurrent instance if it's created first
+func (s *SqlDomainKeyStore) Create(key *model.DomainKey) (*model.DomainKey, model.AppError) {
+	key.PreSave()
+	_, err := s.GetMaster().Exec(`insert into storage.domain_keys (domain_id, kms, wrapped_key, created_at)
+values (:DomainId, :Kms, :WrappedKey, :CreatedAt)
+on conflict (domain_id) where destroyed_at is null do nothing`, map[string]interface{}{
+		"DomainId":   key.DomainId,
+		"Kms":        key.Kms,
+		"WrappedKey": key.WrappedKey,
+		"CreatedAt":  key.CreatedAt,
+	})
+
+	if err != nil {
+		return nil, model.NewCustomCodeError("store.sql_domain_key.create.app_error", err.Error(), extractCodeFromErr(err))
+	}
+
+	active, appErr := s.GetActive(key.DomainId)
+	if appErr != nil {
+		return nil, appErr
+	}
+	if active == nil {
+		return nil, model.NewInternalError("store.sql_domain_key.create.app_error", "domain key was destroyed")
+	}
+
+	return active, nil
+}
+
+// GetActive returns the key the new files of the domain are encrypted with, nil if the domain has no key
+func (s *SqlDomainKeyStore) GetActive(domainId int64) (*model.DomainKey, model.AppError) {
+	var key *model.DomainKey
+	err := s.GetMaster().SelectOne(&key, `select k.id, k.domain_id, k.kms, k.wrapped_key, k.created_at, k.destroyed_at
+from storage.domain_keys k
+where k.domain_id = :DomainId
+  and k.destroyed_at is null`, map[string]interface{}{
+		"DomainId": domainId,
+	})
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, model.NewCustomCodeError("store.sql_domain_key.get_active.app_error", err.Error(), extractCodeFromErr(err))
+	}
+
+	return key, nil
+}
+
+func (s *SqlDomainKeyStore) Get(domainId int64, id int64) (*model.DomainKey, model.AppError) {
+	var key *model.DomainKey
+	err := s.GetMaster().SelectOne(&key, `select k.id, k.domain_id, k.kms, k.wrapped_key, k.created_at, k.destroyed_at
+from storage.domain_keys k
+where k.id = :Id
+  and k.domain_id = :DomainId`, map[string]interface{}{
+		"Id":       id,
+		"DomainId": domainId,
+	})
+
+	if err != nil {
+		return nil, model.NewCustomCodeError("store.sql_domain_key.get.app_error", err.Error(), extractCodeFromErr(err))
+	}
+
+	return key, nil
+}
+
+// Destroy removes the wrapped active key of the domain, returns the destroyed key
+func (s *SqlDomainKeyStore) Destroy(domainId int64) (*model.DomainKey, model.AppError) {
+	var key *model.DomainKey
+	err := s.GetMaster().SelectOne(&key, `update storage.domain_keys k
+set wrapped_key = null,
+    destroyed_at = :DestroyedAt
+where k.domain_id = :DomainId
+  and k.destroyed_at is null
+returning k.id, k.domain_id, k.kms, k.wrapped_key, k.created_at, k.destroyed_at`, map[string]interface{}{
+		"DomainId":    domainId,
+		"DestroyedAt": model.GetMillis(),
+	})
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, model.NewNotFoundError("store.sql_domain_key.destroy.not_found", "domain has no active key")
+		}
+		return nil, model.NewCustomCodeError("store.sql_domain_key.destroy.app_error", err.Error(), extractCodeFromErr(err))
+	}
+
+	return key, nil
+}
+
+// GetNotWrappedWith returns the keys of the kms that are not wrapped with the prefix
+func (s *SqlDomainKeyStore) GetNotWrappedWith(kms string, prefix string, limit int) ([]*model.DomainKey, model.AppError) {
+	var keys []*model.DomainKey
+	_, err := s.GetMaster().Select(&keys, `select k.id, k.domain_id, k.kms, k.wrapped_key, k.created_at, k.destroyed_at
+from storage.domain_keys k
+where k.kms = :Kms
+  and k.destroyed_at is null
+  and not starts_with(k.wrapped_key, :Prefix)
+order by k.id
+limit :Limit`, map[string]interface{}{
+		"Kms":    kms,
+		"Prefix": prefix,
+		"Limit":  limit,
+	})
+
+	if err != nil {
+		return nil, model.NewCustomCodeError("store.sql_domain_key.get_not_wrapped.app_error", err.Error(), extractCodeFromErr(err))
+	}
+
+	return keys, nil
+}
+
+// Rewrap replaces the wrapped key if the key is not changed (destroyed or wrapped by another instance)
+func (s *SqlDomainKeyStore) Rewrap(id int64, wrapped string, newWrapped string) (bool, model.AppError) {
+	res, err := s.GetMaster().Exec(`update storage.domain_keys
+set wrapped_key = :NewWrappedKey
+where id = :Id
+  and wrapped_key = :WrappedKey
+  and destroyed_at is null`, map[string]interface{}{
+		"Id":            id,
+		"WrappedKey":    wrapped,
+		"NewWrappedKey": newWrapped,
+	})
+
+	if err != nil {
+		return false, model.NewCustomCodeError("store.sql_domain_key.rewrap.app_error", err.Error(), extractCodeFromErr(err))
+	}
+
+	cnt, err := res.RowsAffected()
+	if err != nil {
+		return false, model.NewCustomCodeError("store.sql_domain_key.rewrap.app_error", err.Error(), extractCodeFromErr(err))
+	}
+
+	return cnt > 0, nil
+}
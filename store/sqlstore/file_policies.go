@@ -23,9 +23,9 @@ func NewSqlFilePoliciesStore(sqlStore SqlStore) store.FilePoliciesStore {
 func (s *SqlFilePoliciesStore) Create(ctx context.Context, domainId int64, policy *model.FilePolicy) (*model.FilePolicy, model.AppError) {
 	err := s.GetMaster().WithContext(ctx).SelectOne(&policy, `with p as (
     insert into storage.file_policies (domain_id, created_at, created_by, updated_at, updated_by, name, enabled, mime_types,
-                                       speed_download, speed_upload, description, channels, retention_days, max_upload_size, encrypt)
+                                       speed_download, speed_upload, description, channels, retention_days, max_upload_size, encrypt, malware_scan)
     values (:DomainId, :CreatedAt, :CreatedBy, :UpdatedAt, :UpdatedBy, :Name, :Enabled, :MimeTypes,
-            :SpeedDownload, :SpeedUpload, :Description, :Channels, :RetentionDays, :MaxUploadSize, :Encrypt)
+            :SpeedDownload, :SpeedUpload, :Description, :Channels, :RetentionDays, :MaxUploadSize, :Encrypt, :MalwareScan)
    returning *
 )
 SELECT p.id,
@@ -42,7 +42,8 @@ SELECT p.id,
        p.speed_upload,
        p.retention_days,
        p.max_upload_size,
-	   p.encrypt
+	   p.encrypt,
+	   p.malware_scan
 FROM p
          LEFT JOIN directory.wbt_user c ON c.id = p.created_by
          LEFT JOIN directory.wbt_user u ON u.id = p.updated_by;`, map[string]interface{}{
@@ -61,6 +62,7 @@ FROM p
 		"RetentionDays": policy.RetentionDays,
 		"MaxUploadSize": policy.MaxUploadSize,
 		"Encrypt":       policy.Encrypt,
+		"MalwareScan":   policy.MalwareScan,
 	})
 
 	if err != nil {
@@ -108,7 +110,8 @@ func (s *SqlFilePoliciesStore) Get(ctx context.Context, domainId int64, id int32
        p.speed_upload,
        p.retention_days,
        p.max_upload_size,
-       p.encrypt
+       p.encrypt,
+       p.malware_scan
 FROM storage.file_policies p
          LEFT JOIN directory.wbt_user c ON c.id = p.created_by
          LEFT JOIN directory.wbt_user u ON u.id = p.updated_by
@@ -139,7 +142,8 @@ func (s *SqlFilePoliciesStore) Update(ctx context.Context, domainId int64, polic
             channels = :Channels,
 			retention_days = :RetentionDays,
 			max_upload_size = :MaxUploadSize,
-			encrypt = :Encrypt
+			encrypt = :Encrypt,
+			malware_scan = :MalwareScan
         where domain_id = :DomainId and id = :Id
 		returning *
 )
@@ -157,7 +161,8 @@ SELECT p.id,
        p.speed_upload,
 	   p.retention_days,
        p.max_upload_size,
-	   p.encrypt
+	   p.encrypt,
+	   p.malware_scan
 FROM p
          LEFT JOIN directory.wbt_user c ON c.id = p.created_by
          LEFT JOIN directory.wbt_user u ON u.id = p.updated_by`, map[string]interface{}{
@@ -174,6 +179,7 @@ FROM p
 		"RetentionDays": policy.RetentionDays,
 		"MaxUploadSize": policy.MaxUploadSize,
 		"Encrypt":       policy.Encrypt,
+		"MalwareScan":   policy.MalwareScan,
 
 		"DomainId": domainId,
 		"Id":       policy.Id,
@@ -231,7 +237,7 @@ func (s *SqlFilePoliciesStore) ChangePosition(ctx context.Context, domainId int6
 func (s *SqlFilePoliciesStore) AllByDomainId(ctx context.Context, domainId int64) ([]model.FilePolicy, model.AppError) {
 	var list []model.FilePolicy
 	_, err := s.GetReplica().WithContext(ctx).Select(&list, `select id, channels, mime_types, p.name, p.speed_download,
-       p.speed_upload, p.retention_days, p.max_upload_size, p.encrypt, max(updated_at) over (), name
+       p.speed_upload, p.retention_days, p.max_upload_size, p.encrypt, p.malware_scan, max(updated_at) over (), name
 from storage.file_policies p
 where p.domain_id = :DomainId
     and p.enabled
@@ -286,6 +292,94 @@ func (s *SqlFilePoliciesStore) SetRetentionDay(ctx context.Context, domainId int
 	return u, nil
 }
 
+// SetEncryptionJobs creates the jobs that encrypt or decrypt the files of the policy, which encryption differs from the policy
+func (s *SqlFilePoliciesStore) SetEncryptionJobs(ctx context.Context, domainId int64, policy *model.FilePolicy, applyToNullChannel bool) (int64, model.AppError) {
+	action := model.Decrypt
+	if policy.EncryptFiles() {
+		action = model.Encrypt
+	}
+
+	const query = `
+		insert into storage.file_jobs (file_id, action, config)
+		select f.id, :Action, jsonb_build_object('policy_id', :PolicyId::int)
+		from storage.files f
+		where f.domain_id = :DomainId
+		and (
+			f.channel = any(:Channels::varchar [])
+			or (:ApplyToNullChannel and f.channel is null)
+		)
+		and f.channel is distinct from :MediaChannel
+		and f.mime_type ilike any(:Mime::varchar [])
+		and f.removed is not true
+		and coalesce((f.properties ->> 'encrypted')::bool, false) <> :Encrypt
+		and not exists(select 1 from storage.file_jobs j where j.file_id = f.id)`
+
+	res, err := s.GetMaster().WithContext(ctx).Exec(query, s.encryptionArgs(domainId, policy, applyToNullChannel, action))
+	if err != nil {
+		return 0, model.NewCustomCodeError("store.sql_file_policy.set_encryption_jobs.app_error", err.Error(), extractCodeFromErr(err))
+	}
+
+	u, err := res.RowsAffected()
+	if err != nil {
+		return 0, model.NewCustomCodeError("store.sql_file_policy.set_encryption_jobs.app_error", err.Error(), extractCodeFromErr(err))
+	}
+
+	return u, nil
+}
+
+// EncryptionProgress counts the files of the policy by the encryption and the jobs created by SetEncryptionJobs
+func (s *SqlFilePoliciesStore) EncryptionProgress(ctx context.Context, domainId int64, policy *model.FilePolicy, applyToNullChannel bool) (*model.FilePolicyEncryption, model.AppError) {
+	var progress *model.FilePolicyEncryption
+	action := model.Decrypt
+	if policy.EncryptFiles() {
+		action = model.Encrypt
+	}
+
+	const query = `
+		select :PolicyId::int as policy_id,
+		       :Encrypt::bool as encrypt,
+		       count(*) as total,
+		       count(*) filter ( where coalesce((f.properties ->> 'encrypted')::bool, false) = :Encrypt ) as done,
+		       count(j.id) filter ( where j.state <> 3 ) as pending,
+		       count(j.id) filter ( where j.state = 3 ) as failed
+		from storage.files f
+		    left join storage.file_jobs j on j.file_id = f.id and j.action = :Action
+		        and (j.config ->> 'policy_id')::int = :PolicyId::int
+		where f.domain_id = :DomainId
+		and (
+			f.channel = any(:Channels::varchar [])
+			or (:ApplyToNullChannel and f.channel is null)
+		)
+		and f.channel is distinct from :MediaChannel
+		and f.mime_type ilike any(:Mime::varchar [])
+		and f.removed is not true`
+
+	err := s.GetReplica().WithContext(ctx).SelectOne(&progress, query, s.encryptionArgs(domainId, policy, applyToNullChannel, action))
+	if err != nil {
+		return nil, model.NewCustomCodeError("store.sql_file_policy.encryption_progress.app_error", err.Error(), extractCodeFromErr(err))
+	}
+
+	return progress, nil
+}
+
+func (s *SqlFilePoliciesStore) encryptionArgs(domainId int64, policy *model.FilePolicy, applyToNullChannel bool, action string) map[string]any {
+	m := make([]string, 0, len(policy.MimeTypes))
+	for _, v := range policy.MimeTypes {
+		m = append(m, policyMaskToLike(v))
+	}
+
+	return map[string]any{
+		"DomainId":           domainId,
+		"PolicyId":           policy.Id,
+		"Channels":           pq.Array(policy.Channels),
+		"Mime":               pq.Array(m),
+		"MediaChannel":       model.UploadFileChannelMedia,
+		"ApplyToNullChannel": applyToNullChannel,
+		"Encrypt":            policy.EncryptFiles(),
+		"Action":             action,
+	}
+}
+
 // CreateDefaultPolicies creates default file policies for a domain if they do not already exist.
 func (s *SqlFilePoliciesStore) CreateDefaultPolicies(ctx context.Context, domainId int64) model.AppError {
 	var (
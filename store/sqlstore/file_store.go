@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 	"github.com/webitel/wlog"
-	"time"
 
 	"github.com/lib/pq"
 	"github.com/webitel/engine/pkg/wbt/auth_manager"
@@ -41,6 +40,7 @@ func (self *SqlFileStore) GetAllPage(ctx context.Context, domainId int64, search
 		"Removed":      search.Removed,
 		"AgentIds":     pq.Array(search.AgentIds),
 		"MimeType":     search.MimeType,
+		"LegalHold":    search.LegalHold,
 	}
 
 	err := self.ListQueryCtx(ctx, &files, search.ListRequest,
@@ -58,6 +58,7 @@ func (self *SqlFileStore) GetAllPage(ctx context.Context, domainId int64, search
 						and a.id = any (:AgentIds::int[])))
 				)
 				and (:MimeType::varchar isnull or mime_type like :MimeType::varchar || '%')
+				and (:LegalHold::bool isnull or `+activeLegalHold("t")+` = :LegalHold::bool)
 				and (:CallId::varchar isnull or (uuid = (select x.id
                                            from (select coalesce(c.parent_id, c.id)::varchar id
                                                  from call_center.cc_calls c
@@ -172,7 +173,8 @@ func (self SqlFileStore) Create(file *model.File) store.StoreChannel {
 func (self *SqlFileStore) MarkRemove(domainId int64, ids []int64) model.AppError {
 	_, err := self.GetMaster().Exec(`update storage.files
 set removed = true
-where domain_id = :DomainId and id = any(:Ids::int8[])`, map[string]interface{}{
+where domain_id = :DomainId and id = any(:Ids::int8[])
+  and not `+activeLegalHold("files"), map[string]interface{}{
 		"DomainId": domainId,
 		"Ids":      pq.Array(ids),
 	})
@@ -188,9 +190,9 @@ func (self *SqlFileStore) MarkRemoveQuarantine(domainId int64, ids []int64) mode
 	res, err := self.GetMaster().Exec(`update storage.files
 set removed = true
 where domain_id = :DomainId
-  and channel = 'chat' --TODO
   and (malware->'found')::bool
-  and (:Ids::int8[] isnull or id = any (:Ids::int8[]))`, map[string]interface{}{
+  and (:Ids::int8[] isnull or id = any (:Ids::int8[]))
+  and not `+activeLegalHold("files"), map[string]interface{}{
 		"DomainId": domainId,
 		"Ids":      pq.Array(ids),
 	})
@@ -212,7 +214,8 @@ func (self *SqlFileStore) MarkRemoveByChannels(ctx context.Context, domainId int
 set removed = true
 where domain_id = :DomainId
   and id = any (:Ids::int8[])
-  and (:Channels::text[] isnull or channel = any(:Channels))`, map[string]interface{}{
+  and (:Channels::text[] isnull or channel = any(:Channels))
+  and not `+activeLegalHold("files"), map[string]interface{}{
 		"DomainId": domainId,
 		"Ids":      pq.Array(ids),
 		"Channels": pq.Array(channels),
@@ -226,18 +229,26 @@ where domain_id = :DomainId
 }
 
 // TODO reference tables ?
-func (self SqlFileStore) MoveFromJob(jobId int64, profileId *int, properties model.StringInterface, retentionUntil *time.Time) store.StoreChannel {
+func (self SqlFileStore) MoveFromJob(jobId int64, file *model.File) store.StoreChannel {
 	return store.Do(func(result *store.StoreResult) {
 		_, err := self.GetMaster().Exec(`with del as (
   delete from storage.upload_file_jobs
-  where id = $1
-  returning id, name, uuid, size, domain_id, mime_type, created_at, instance, view_name, channel
+  where id = :JobId
+  returning id, name, uuid, size, domain_id, mime_type, created_at, instance, view_name, channel, sha256sum
 )
 insert into storage.files(id, name, uuid, profile_id, size, domain_id, mime_type, properties, created_at, instance, view_name,
-	channel, retention_until)
-select del.id, del.name, del.uuid, $2, del.size, del.domain_id, del.mime_type, $3, del.created_at, del.instance, del.view_name,
-	del.channel, $4::timestamptz
-from del`, jobId, profileId, properties.ToJson(), retentionUntil)
+	channel, retention_until, sha256sum)
+select del.id, coalesce(:Name, del.name), del.uuid, :ProfileId, del.size, del.domain_id, del.mime_type, :Props, del.created_at, del.instance,
+	coalesce(del.view_name, :VName), del.channel, :RetentionUntil::timestamptz, coalesce(:SHA256Sum, del.sha256sum)
+from del`, map[string]interface{}{
+			"JobId":          jobId,
+			"Name":           model.NewString(file.Name),
+			"ProfileId":      file.ProfileId,
+			"Props":          file.Properties.ToJson(),
+			"VName":          file.ViewName,
+			"RetentionUntil": file.RetentionUntil,
+			"SHA256Sum":      file.SHA256Sum,
+		})
 
 		if err != nil {
 			result.Err = model.NewInternalError("store.sql_file.move_from_job.app_error", err.Error())
@@ -295,7 +306,8 @@ func (s SqlFileStore) GetFileWithProfile(domainId, id int64) (*model.FileWithPro
        f.channel,
        f.thumbnail,
        p.updated_at as profile_updated_at,
-       f.malware
+       f.malware,
+       f.sha256sum
 FROM storage.files f
          left join storage.file_backend_profiles p on p.id = f.profile_id
 	WHERE f.id = :Id
@@ -399,3 +411,348 @@ where (malware->'found')::bool
 
 	return int(cnt), nil
 }
+
+func (s *SqlFileStore) GetQuarantinePage(ctx context.Context, domainId int64, search *model.SearchQuarantineFile) ([]*model.QuarantineFile, model.AppError) {
+	var files []*model.QuarantineFile
+
+	f := map[string]interface{}{
+		"DomainId": domainId,
+		"Ids":      pq.Array(search.Ids),
+		"Channels": pq.Array(search.Channels),
+		"From":     model.GetBetweenFromTime(search.UploadedAt),
+		"To":       model.GetBetweenToTime(search.UploadedAt),
+		"Q":        search.GetQ(),
+	}
+
+	err := s.ListQueryCtx(ctx, &files, search.ListRequest,
+		`domain_id = :DomainId
+				and ( :From::timestamptz isnull or uploaded_at >= :From::timestamptz )
+				and ( :To::timestamptz isnull or uploaded_at <= :To::timestamptz )
+				and (:Ids::int8[] isnull or id = any(:Ids))
+				and (:Channels::varchar[] isnull or channel = any(:Channels::varchar[]))
+				and (:Q::varchar isnull or (name ilike :Q::varchar or malware_description ilike :Q::varchar))
+		`,
+		model.QuarantineFile{}, f)
+
+	if err != nil {
+		return nil, model.NewCustomCodeError("store.sql_file.get_quarantine.app_error", err.Error(), extractCodeFromErr(err))
+	}
+
+	return files, nil
+}
+
+// RescanQuarantine creates the rescan jobs of the files in quarantine, the clean files are released by the job
+func (s *SqlFileStore) RescanQuarantine(ctx context.Context, domainId int64, fileIds []int64, userId int64) (int, model.AppError) {
+	r, err := s.GetMaster().WithContext(ctx).Exec(`insert into storage.file_jobs (file_id, action, config)
+select id, :Action, jsonb_build_object('user_id', :UserId::int8)
+from storage.files f
+where (f.malware->'found')::bool
+    and f.domain_id = :DomainId
+    and (:Ids::int8[] isnull or f.id = any(:Ids::int8[]))
+    and f.removed is not true
+    and not exists(select 1 from storage.file_jobs j where j.file_id = f.id)`, map[string]any{
+		"Action":   model.Rescan,
+		"DomainId": domainId,
+		"UserId":   userId,
+		"Ids":      pq.Array(fileIds),
+	})
+	if err != nil {
+		return 0, model.NewCustomCodeError("store.sql_file.rescan_quarantine.app_error", err.Error(), extractCodeFromErr(err))
+	}
+
+	cnt, err := r.RowsAffected()
+	if err != nil {
+		return 0, model.NewCustomCodeError("store.sql_file.rescan_quarantine.app_error", err.Error(), extractCodeFromErr(err))
+	}
+
+	return int(cnt), nil
+}
+
+// FindObject returns a live file of the domain whose stored object has the same content and is encrypted with the same key,
+// the objects of the destroyed domain keys are skipped
+func (s SqlFileStore) FindObject(domainId int64, profileId *int, sha256sum string, encrypted bool, keyVersion, domainKey string) (*model.File, model.AppError) {
+	var files []*model.File
+	_, err := s.GetMaster().Select(&files, `select f.id, f.name, f.domain_id, f.size, f.properties, f.profile_id
+from storage.files f
+where f.domain_id = :DomainId
+  and f.sha256sum = :SHA256Sum
+  and f.profile_id is not distinct from :ProfileId::int
+  and coalesce((f.properties->>'encrypted')::bool, false) = :Encrypted::bool
+  and coalesce(f.properties->>'key_version', '') = :KeyVersion
+  and coalesce(f.properties->>'domain_key', '') = :DomainKey
+  and not exists(select 1
+                 from storage.domain_keys k
+                 where k.id = nullif(f.properties->>'domain_key', '')::int8
+                   and k.destroyed_at notnull)
+  and not f.removed is true
+  and not coalesce((f.malware->>'found')::bool, false)
+  and (f.retention_until isnull or f.retention_until > now())
+  and not exists(select 1 from storage.file_jobs j where j.file_id = f.id and j.action = :Remove)
+order by f.id
+limit 1`, map[string]interface{}{
+		"DomainId":   domainId,
+		"SHA256Sum":  sha256sum,
+		"ProfileId":  profileId,
+		"Encrypted":  encrypted,
+		"KeyVersion": keyVersion,
+		"DomainKey":  domainKey,
+		"Remove":     model.SyncJobRemove,
+	})
+
+	if err != nil {
+		return nil, model.NewCustomCodeError("store.sql_file.find_object.app_error", err.Error(), extractCodeFromErr(err))
+	}
+
+	if len(files) == 0 {
+		return nil, nil
+	}
+
+	return files[0], nil
+}
+
+// objectReferencesSql counts the other files that share the stored object with the file :Id. The removed files are counted
+// until they are purged, they can be restored
+const objectReferencesSql = `select count(*)
+from storage.files f
+    inner join storage.files o on o.domain_id = f.domain_id
+        and o.id <> f.id
+        and o.sha256sum = f.sha256sum
+        and o.name = f.name
+        and o.profile_id is not distinct from f.profile_id
+        and o.properties ->> 'location' is not distinct from f.properties ->> 'location'
+        and o.properties ->> 'directory' is not distinct from f.properties ->> 'directory'
+        and coalesce(o.properties ->> 'key_version', '') = coalesce(f.properties ->> 'key_version', '')
+        and coalesce(o.properties ->> 'domain_key', '') = coalesce(f.properties ->> 'domain_key', '')
+where f.id = :Id
+  and (o.retention_until isnull or o.retention_until > now())
+  and not exists(select 1 from storage.file_jobs j where j.file_id = o.id and j.action = :Remove)`
+
+// ObjectReferences counts the other files that share the stored object with the file
+func (s SqlFileStore) ObjectReferences(fileId int64) (int64, model.AppError) {
+	cnt, err := s.GetMaster().SelectInt(objectReferencesSql, map[string]interface{}{
+		"Id":     fileId,
+		"Remove": model.SyncJobRemove,
+	})
+
+	if err != nil {
+		return 0, model.NewCustomCodeError("store.sql_file.object_references.app_error", err.Error(), extractCodeFromErr(err))
+	}
+
+	return cnt, nil
+}
+
+// LockObject waits for the remove job of the file that holds the lock of the row, returns false when the file is deleted
+func (s SqlFileStore) LockObject(fileId int64) (bool, model.AppError) {
+	var ids []int64
+	_, err := s.GetMaster().Select(&ids, `select f.id
+from storage.files f
+where f.id = :Id
+for update`, map[string]interface{}{
+		"Id": fileId,
+	})
+
+	if err != nil {
+		return false, model.NewCustomCodeError("store.sql_file.lock_object.app_error", err.Error(), extractCodeFromErr(err))
+	}
+
+	return len(ids) > 0, nil
+}
+
+func (s SqlFileStore) SaveReplica(fileId int64, profileId int, props model.StringInterface) model.AppError {
+	_, err := s.GetMaster().Exec(`insert into storage.file_replicas (file_id, profile_id, properties)
+values (:FileId, :ProfileId, :Props::jsonb)
+on conflict (file_id, profile_id) do update
+    set properties = excluded.properties,
+        created_at = now()`, map[string]interface{}{
+		"FileId":    fileId,
+		"ProfileId": profileId,
+		"Props":     props.ToJson(),
+	})
+
+	if err != nil {
+		return model.NewCustomCodeError("store.sql_file.save_replica.app_error", err.Error(), extractCodeFromErr(err))
+	}
+
+	return nil
+}
+
+// MoveToProfile switches the file to the object written in another profile, if the file still has the object oldName in fromProfileId
+func (s SqlFileStore) MoveToProfile(fileId int64, fromProfileId *int, toProfileId int, oldName, name string, props model.StringInterface, sha256sum *string) (bool, model.AppError) {
+	res, err := s.GetMaster().Exec(`update storage.files
+set profile_id = :ToProfileId,
+    name = :Name,
+    view_name = coalesce(view_name, :OldName),
+    properties = :Props::jsonb,
+    sha256sum = coalesce(sha256sum, :SHA256Sum)
+where id = :Id
+  and name = :OldName
+  and profile_id is not distinct from :FromProfileId
+  and not coalesce(removed, false)`, map[string]interface{}{
+		"Id":            fileId,
+		"FromProfileId": fromProfileId,
+		"ToProfileId":   toProfileId,
+		"OldName":       oldName,
+		"Name":          name,
+		"Props":         props.ToJson(),
+		"SHA256Sum":     sha256sum,
+	})
+
+	if err != nil {
+		return false, model.NewCustomCodeError("store.sql_file.move_to_profile.app_error", err.Error(), extractCodeFromErr(err))
+	}
+
+	cnt, err := res.RowsAffected()
+	if err != nil {
+		return false, model.NewCustomCodeError("store.sql_file.move_to_profile.app_error", err.Error(), extractCodeFromErr(err))
+	}
+
+	return cnt > 0, nil
+}
+
+// ReplaceObject switches the file to the new object written in the same profile, if the file still has the object oldName
+func (s SqlFileStore) ReplaceObject(fileId int64, profileId *int, oldName, name string, props model.StringInterface, sha256sum *string) (bool, model.AppError) {
+	res, err := s.GetMaster().Exec(`update storage.files
+set name = :Name,
+    view_name = coalesce(view_name, :OldName),
+    properties = :Props::jsonb,
+    sha256sum = coalesce(sha256sum, :SHA256Sum)
+where id = :Id
+  and name = :OldName
+  and profile_id is not distinct from :ProfileId::int
+  and not coalesce(removed, false)`, map[string]interface{}{
+		"Id":        fileId,
+		"ProfileId": profileId,
+		"OldName":   oldName,
+		"Name":      name,
+		"Props":     props.ToJson(),
+		"SHA256Sum": sha256sum,
+	})
+
+	if err != nil {
+		return false, model.NewCustomCodeError("store.sql_file.replace_object.app_error", err.Error(), extractCodeFromErr(err))
+	}
+
+	cnt, err := res.RowsAffected()
+	if err != nil {
+		return false, model.NewCustomCodeError("store.sql_file.replace_object.app_error", err.Error(), extractCodeFromErr(err))
+	}
+
+	return cnt > 0, nil
+}
+
+func (s SqlFileStore) SetMalware(fileId int64, ms *model.MalwareScan) model.AppError {
+	_, err := s.GetMaster().Exec(`update storage.files
+set malware = :Malware::jsonb
+where id = :Id`, map[string]interface{}{
+		"Id":      fileId,
+		"Malware": ms.ToJson(),
+	})
+
+	if err != nil {
+		return model.NewCustomCodeError("store.sql_file.set_malware.app_error", err.Error(), extractCodeFromErr(err))
+	}
+
+	return nil
+}
+
+func (s SqlFileStore) GetReplicas(fileId int64) ([]*model.FileReplica, model.AppError) {
+	var replicas []*model.FileReplica
+	_, err := s.GetReplica().Select(&replicas, `select r.file_id, r.profile_id, p.updated_at as profile_updated_at, r.properties
+from storage.file_replicas r
+    inner join storage.file_backend_profiles p on p.id = r.profile_id
+where r.file_id = :FileId
+  and not p.disabled
+order by p.priority desc, r.created_at`, map[string]interface{}{
+		"FileId": fileId,
+	})
+
+	if err != nil {
+		return nil, model.NewCustomCodeError("store.sql_file.get_replicas.app_error", err.Error(), extractCodeFromErr(err))
+	}
+
+	return replicas, nil
+}
+
+func (s SqlFileStore) GetConversion(fileId int64, format string) (*model.FileConversion, model.AppError) {
+	var conversions []*model.FileConversion
+	_, err := s.GetReplica().Select(&conversions, `select c.file_id, c.format, c.profile_id, p.updated_at as profile_updated_at,
+       c.name, c.mime_type, c.size, c.properties
+from storage.file_conversions c
+    left join storage.file_backend_profiles p on p.id = c.profile_id
+where c.file_id = :FileId
+  and c.format = :Format
+  and p.disabled is not true`, map[string]interface{}{
+		"FileId": fileId,
+		"Format": format,
+	})
+
+	if err != nil {
+		return nil, model.NewCustomCodeError("store.sql_file.get_conversion.app_error", err.Error(), extractCodeFromErr(err))
+	}
+
+	if len(conversions) == 0 {
+		return nil, nil
+	}
+
+	return conversions[0], nil
+}
+
+// SaveConversion returns false when the conversion of the file to the format is already stored
+func (s SqlFileStore) SaveConversion(conversion *model.FileConversion) (bool, model.AppError) {
+	res, err := s.GetMaster().Exec(`insert into storage.file_conversions (file_id, format, profile_id, name, mime_type, size, properties)
+select f.id, :Format, :ProfileId::int, :Name, :MimeType, :Size, :Props::jsonb
+from storage.files f
+where f.id = :FileId
+  and f.removed is not true
+on conflict (file_id, format) do nothing`, map[string]interface{}{
+		"FileId":    conversion.FileId,
+		"Format":    conversion.Format,
+		"ProfileId": conversion.ProfileId,
+		"Name":      conversion.Name,
+		"MimeType":  conversion.MimeType,
+		"Size":      conversion.Size,
+		"Props":     conversion.Properties.ToJson(),
+	})
+
+	if err != nil {
+		return false, model.NewCustomCodeError("store.sql_file.save_conversion.app_error", err.Error(), extractCodeFromErr(err))
+	}
+
+	cnt, err := res.RowsAffected()
+	if err != nil {
+		return false, model.NewCustomCodeError("store.sql_file.save_conversion.app_error", err.Error(), extractCodeFromErr(err))
+	}
+
+	return cnt > 0, nil
+}
+
+func (s SqlFileStore) GetConversions(fileId int64) ([]*model.FileConversion, model.AppError) {
+	var conversions []*model.FileConversion
+	_, err := s.GetReplica().Select(&conversions, `select c.file_id, c.format, c.profile_id, p.updated_at as profile_updated_at,
+       c.name, c.mime_type, c.size, c.properties
+from storage.file_conversions c
+    left join storage.file_backend_profiles p on p.id = c.profile_id
+where c.file_id = :FileId`, map[string]interface{}{
+		"FileId": fileId,
+	})
+
+	if err != nil {
+		return nil, model.NewCustomCodeError("store.sql_file.get_conversions.app_error", err.Error(), extractCodeFromErr(err))
+	}
+
+	return conversions, nil
+}
+
+func (s SqlFileStore) DeleteConversions(fileId int64) model.AppError {
+	_, err := s.GetMaster().Exec(`delete
+from storage.file_conversions c
+where c.file_id = :FileId`, map[string]interface{}{
+		"FileId": fileId,
+	})
+
+	if err != nil {
+		return model.NewCustomCodeError("store.sql_file.delete_conversions.app_error", err.Error(), extractCodeFromErr(err))
+	}
+
+	return nil
+}
@@ -0,0 +1,184 @@
+package sqlstore
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lib/pq"
+	"github.com/webitel/storage/model"
+	"github.com/webitel/storage/store"
+)
+
+type SqlLegalHoldStore struct {
+	SqlStore
+}
+
+func NewSqlLegalHoldStore(sqlStore SqlStore) store.LegalHoldStore {
+	us := &SqlLegalHoldStore{sqlStore}
+	return us
+}
+
+// activeLegalHold is the condition of the file (table alias) that has an active legal hold
+func activeLegalHold(alias string) string {
+	return fmt.Sprintf(`exists(select 1
+       from storage.file_legal_holds lh
+       where lh.released_at isnull
+         and lh.domain_id = %[1]s.domain_id
+         and (lh.file_id = %[1]s.id or lh.uuid = %[1]s.uuid))`, alias)
+}
+
+// legalHoldCall resolves the call id (:CallId) to the reference id of the call files
+const legalHoldCall = `call as (
+    select x.id
+    from (select coalesce(c.parent_id, c.id)::varchar id
+          from call_center.cc_calls c
+          where c.id = :CallId::uuid
+            and c.domain_id = :DomainId::int8
+          union all
+          select coalesce(c.parent_id, c.id)::varchar id
+          from call_center.cc_calls_history c
+          where c.id = :CallId::uuid
+            and c.domain_id = :DomainId::int8) x
+    limit 1
+)`
+
+const legalHoldColumns = `select h.id,
+       h.file_id,
+       h.uuid as reference_id,
+       h.call_id,
+       h.reason,
+       h.created_at,
+       storage.get_lookup(c.id, coalesce(c.name, c.username::text)::character varying) as created_by,
+       h.released_at,
+       storage.get_lookup(r.id, coalesce(r.name, r.username::text)::character varying) as released_by,
+       h.release_reason
+from h
+         left join directory.wbt_user c on c.id = h.created_by
+         left join directory.wbt_user r on r.id = h.released_by
+order by h.id`
+
+// Place creates the hold of each file of the domain, the hold of the reference id and the hold of the call.
+// The held files are locked, so the hold waits for the remove of the file that has already checked the holds
+func (s SqlLegalHoldStore) Place(ctx context.Context, domainId int64, userId int64, hold *model.PlaceLegalHold) ([]*model.LegalHold, model.AppError) {
+	var holds []*model.LegalHold
+	_, err := s.GetMaster().WithContext(ctx).Select(&holds, `with `+legalHoldCall+`,
+locked as (
+    select f.id
+    from storage.files f
+    where f.domain_id = :DomainId
+      and (f.id = any (:FileIds::int8[]) or f.uuid = :ReferenceId::varchar or f.uuid in (select id from call))
+    for share
+),
+h as (
+    insert into storage.file_legal_holds (domain_id, file_id, uuid, call_id, reason, created_at, created_by)
+    select :DomainId::int8, f.id, null::varchar, null::varchar, :Reason::text, :CreatedAt::int8, :UserId::int8
+    from locked f
+    where f.id = any (:FileIds::int8[])
+    union all
+    select :DomainId::int8, null::int8, :ReferenceId::varchar, null::varchar, :Reason::text, :CreatedAt::int8, :UserId::int8
+    where :ReferenceId::varchar notnull
+    union all
+    select :DomainId::int8, null::int8, call.id, :CallId::varchar, :Reason::text, :CreatedAt::int8, :UserId::int8
+    from call
+    returning *
+)
+`+legalHoldColumns, map[string]interface{}{
+		"DomainId":    domainId,
+		"UserId":      userId,
+		"FileIds":     pq.Array(hold.FileIds),
+		"ReferenceId": hold.ReferenceId,
+		"CallId":      hold.CallId,
+		"Reason":      hold.Reason,
+		"CreatedAt":   model.GetMillis(),
+	})
+
+	if err != nil {
+		return nil, model.NewCustomCodeError("store.sql_legal_hold.place.app_error", err.Error(), extractCodeFromErr(err))
+	}
+
+	return holds, nil
+}
+
+// Release releases the active holds matching any of the ids, the file ids, the reference id or the call
+func (s SqlLegalHoldStore) Release(ctx context.Context, domainId int64, userId int64, release *model.ReleaseLegalHold) ([]*model.LegalHold, model.AppError) {
+	var holds []*model.LegalHold
+	_, err := s.GetMaster().WithContext(ctx).Select(&holds, `with `+legalHoldCall+`,
+h as (
+    update storage.file_legal_holds h
+    set released_at = :ReleasedAt,
+        released_by = :UserId,
+        release_reason = :Reason
+    where h.domain_id = :DomainId
+      and h.released_at isnull
+      and (h.id = any (:Ids::int8[])
+        or h.file_id = any (:FileIds::int8[])
+        or h.uuid = :ReferenceId::varchar
+        or h.uuid = (select call.id from call))
+    returning h.*
+)
+`+legalHoldColumns, map[string]interface{}{
+		"DomainId":    domainId,
+		"UserId":      userId,
+		"Ids":         pq.Array(release.Ids),
+		"FileIds":     pq.Array(release.FileIds),
+		"ReferenceId": release.ReferenceId,
+		"CallId":      release.CallId,
+		"Reason":      release.Reason,
+		"ReleasedAt":  model.GetMillis(),
+	})
+
+	if err != nil {
+		return nil, model.NewCustomCodeError("store.sql_legal_hold.release.app_error", err.Error(), extractCodeFromErr(err))
+	}
+
+	return holds, nil
+}
+
+func (s SqlLegalHoldStore) GetAllPage(ctx context.Context, domainId int64, search *model.SearchLegalHold) ([]*model.LegalHold, model.AppError) {
+	var holds []*model.LegalHold
+
+	f := map[string]interface{}{
+		"DomainId":     domainId,
+		"Ids":          pq.Array(search.Ids),
+		"FileIds":      pq.Array(search.FileIds),
+		"ReferenceIds": pq.Array(search.ReferenceIds),
+		"Active":       search.Active,
+		"Q":            search.GetQ(),
+	}
+
+	err := s.ListQueryCtx(ctx, &holds, search.ListRequest,
+		`domain_id = :DomainId
+				and (:Ids::int8[] isnull or id = any(:Ids))
+				and (:FileIds::int8[] isnull or file_id = any(:FileIds))
+				and (:ReferenceIds::varchar[] isnull or reference_id = any(:ReferenceIds))
+				and (:Active::bool isnull or active = :Active::bool)
+				and (:Q::varchar isnull or (reason ilike :Q::varchar or release_reason ilike :Q::varchar))
+		`,
+		model.LegalHold{}, f)
+
+	if err != nil {
+		return nil, model.NewCustomCodeError("store.sql_legal_hold.get_all.app_error", err.Error(), extractCodeFromErr(err))
+	}
+
+	return holds, nil
+}
+
+// HeldFiles returns the ids of the files that have an active hold
+func (s SqlLegalHoldStore) HeldFiles(ctx context.Context, domainId int64, ids []int64) ([]int64, model.AppError) {
+	var held []int64
+	_, err := s.GetMaster().WithContext(ctx).Select(&held, `select f.id
+from storage.files f
+where f.domain_id = :DomainId
+  and f.id = any (:Ids::int8[])
+  and `+activeLegalHold("f")+`
+order by f.id`, map[string]interface{}{
+		"DomainId": domainId,
+		"Ids":      pq.Array(ids),
+	})
+
+	if err != nil {
+		return nil, model.NewCustomCodeError("store.sql_legal_hold.held_files.app_error", err.Error(), extractCodeFromErr(err))
+	}
+
+	return held, nil
+}
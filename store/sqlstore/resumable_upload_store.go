@@ -0,0 +1,246 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/webitel/storage/model"
+	"github.com/webitel/storage/store"
+)
+
+type SqlResumableUploadStore struct {
+	SqlStore
+}
+
+func NewSqlResumableUploadStore(sqlStore SqlStore) store.ResumableUploadStore {
+	us := &SqlResumableUploadStore{sqlStore}
+	return us
+}
+
+func (s *SqlResumableUploadStore) Create(upload *model.ResumableUpload) (*model.ResumableUpload, model.AppError) {
+	upload.PreSave()
+	_, err := s.GetMaster().Exec(`insert into storage.resumable_uploads (id, domain_id, uuid, name, view_name, mime_type, channel,
+                                       size, upload_offset, profile_id, instance, generate_thumbnail,
+                                       custom_properties, uploaded_by, created_at, updated_at)
+values (:Id, :DomainId, :Uuid, :Name, :ViewName, :MimeType, :Channel, :Size, 0, :ProfileId, :Instance,
+        :GenerateThumbnail, :CustomProperties::jsonb, :UploadedBy, :CreatedAt, :UpdatedAt)`, map[string]interface{}{
+		"Id":                upload.Id,
+		"DomainId":          upload.DomainId,
+		"Uuid":              upload.Uuid,
+		"Name":              upload.Name,
+		"ViewName":          upload.ViewName,
+		"MimeType":          upload.MimeType,
+		"Channel":           upload.Channel,
+		"Size":              upload.Size,
+		"ProfileId":         upload.ProfileId,
+		"Instance":          upload.Instance,
+		"GenerateThumbnail": upload.GenerateThumbnail,
+		"CustomProperties":  upload.CustomProperties.ToJson(),
+		"UploadedBy":        upload.UploadedBy,
+		"CreatedAt":         upload.CreatedAt,
+		"UpdatedAt":         upload.UpdatedAt,
+	})
+
+	if err != nil {
+		return nil, model.NewCustomCodeError("store.sql_resumable_upload.create.app_error", err.Error(), extractCodeFromErr(err))
+	}
+
+	return upload, nil
+}
+
+func (s *SqlResumableUploadStore) Get(domainId int64, id string) (*model.ResumableUpload, model.AppError) {
+	var upload *model.ResumableUpload
+	err := s.GetMaster().SelectOne(&upload, `select u.id, u.domain_id, u.uuid, u.name, u.view_name, u.mime_type, u.channel, u.size,
+       u.upload_offset, u.profile_id, p.updated_at as profile_updated_at, u.parts, u.instance, u.generate_thumbnail,
+       u.custom_properties, u.uploaded_by, u.file_id, u.created_at, u.updated_at
+from storage.resumable_uploads u
+    left join storage.file_backend_profiles p on p.id = u.profile_id
+where u.id = :Id
+  and u.domain_id = :DomainId`, map[string]interface{}{
+		"Id":       id,
+		"DomainId": domainId,
+	})
+
+	if err != nil {
+		return nil, model.NewCustomCodeError("store.sql_resumable_upload.get.app_error", fmt.Sprintf("Id=%s %s", id, err.Error()), extractCodeFromErr(err))
+	}
+
+	return upload, nil
+}
+
+// Lock takes the upload for the request if it isn't taken by another one, the lock that is older than lockedBefore is stale
+func (s *SqlResumableUploadStore) Lock(id, lockId string, lockedBefore int64) (bool, model.AppError) {
+	res, err := s.GetMaster().Exec(`update storage.resumable_uploads
+set locked_by = :LockId,
+    locked_at = :LockedAt
+where id = :Id
+  and (locked_by isnull or locked_at < :LockedBefore)`, map[string]interface{}{
+		"Id":           id,
+		"LockId":       lockId,
+		"LockedAt":     model.GetMillis(),
+		"LockedBefore": lockedBefore,
+	})
+
+	if err != nil {
+		return false, model.NewCustomCodeError("store.sql_resumable_upload.lock.app_error", err.Error(), extractCodeFromErr(err))
+	}
+
+	cnt, err := res.RowsAffected()
+	if err != nil {
+		return false, model.NewCustomCodeError("store.sql_resumable_upload.lock.app_error", err.Error(), extractCodeFromErr(err))
+	}
+
+	return cnt > 0, nil
+}
+
+func (s *SqlResumableUploadStore) Unlock(id, lockId string) model.AppError {
+	_, err := s.GetMaster().Exec(`update storage.resumable_uploads
+set locked_by = null,
+    locked_at = null
+where id = :Id
+  and locked_by = :LockId`, map[string]interface{}{
+		"Id":     id,
+		"LockId": lockId,
+	})
+
+	if err != nil {
+		return model.NewCustomCodeError("store.sql_resumable_upload.unlock.app_error", err.Error(), extractCodeFromErr(err))
+	}
+
+	return nil
+}
+
+// AddPart appends the part at the offset and moves the offset if nobody else has changed it
+func (s *SqlResumableUploadStore) AddPart(id string, part *model.ResumableUploadPart, instance string) (bool, model.AppError) {
+	res, err := s.GetMaster().Exec(`update storage.resumable_uploads
+set upload_offset = upload_offset + :Size,
+    parts = parts || jsonb_build_array(:Part::jsonb),
+    instance = :Instance,
+    updated_at = :UpdatedAt
+where id = :Id
+  and upload_offset = :Offset`, map[string]interface{}{
+		"Id":        id,
+		"Offset":    part.Offset,
+		"Size":      part.Size,
+		"Part":      part.ToJson(),
+		"Instance":  instance,
+		"UpdatedAt": model.GetMillis(),
+	})
+
+	if err != nil {
+		return false, model.NewCustomCodeError("store.sql_resumable_upload.add_part.app_error", err.Error(), extractCodeFromErr(err))
+	}
+
+	cnt, err := res.RowsAffected()
+	if err != nil {
+		return false, model.NewCustomCodeError("store.sql_resumable_upload.add_part.app_error", err.Error(), extractCodeFromErr(err))
+	}
+
+	return cnt > 0, nil
+}
+
+// ReplaceLastPart replaces the last part with the part that contains its bytes and the received ones,
+// if nobody else has changed the upload
+func (s *SqlResumableUploadStore) ReplaceLastPart(id string, lastName string, part *model.ResumableUploadPart, instance string) (bool, model.AppError) {
+	res, err := s.GetMaster().Exec(`update storage.resumable_uploads
+set upload_offset = :Offset + :Size,
+    parts = (parts - -1) || jsonb_build_array(:Part::jsonb),
+    instance = :Instance,
+    updated_at = :UpdatedAt
+where id = :Id
+  and parts -> -1 ->> 'name' = :LastName`, map[string]interface{}{
+		"Id":        id,
+		"LastName":  lastName,
+		"Offset":    part.Offset,
+		"Size":      part.Size,
+		"Part":      part.ToJson(),
+		"Instance":  instance,
+		"UpdatedAt": model.GetMillis(),
+	})
+
+	if err != nil {
+		return false, model.NewCustomCodeError("store.sql_resumable_upload.replace_last_part.app_error", err.Error(), extractCodeFromErr(err))
+	}
+
+	cnt, err := res.RowsAffected()
+	if err != nil {
+		return false, model.NewCustomCodeError("store.sql_resumable_upload.replace_last_part.app_error", err.Error(), extractCodeFromErr(err))
+	}
+
+	return cnt > 0, nil
+}
+
+// LinkFile sets the file that was stored by the completion of the upload, returns nil if there is no such file
+func (s *SqlResumableUploadStore) LinkFile(id string) (*int64, model.AppError) {
+	var fileId *int64
+	err := s.GetMaster().SelectOne(&fileId, `update storage.resumable_uploads u
+set file_id = f.id,
+    updated_at = :UpdatedAt
+from storage.files f
+where u.id = :Id
+  and f.domain_id = u.domain_id
+  and f.uuid = u.uuid
+  and f.properties ->> :Property = u.id
+returning u.file_id`, map[string]interface{}{
+		"Id":        id,
+		"Property":  model.ResumableUploadProperty,
+		"UpdatedAt": model.GetMillis(),
+	})
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, model.NewCustomCodeError("store.sql_resumable_upload.link_file.app_error", err.Error(), extractCodeFromErr(err))
+	}
+
+	return fileId, nil
+}
+
+func (s *SqlResumableUploadStore) Delete(domainId int64, id string) model.AppError {
+	_, err := s.GetMaster().Exec(`delete
+from storage.resumable_uploads
+where id = :Id
+  and domain_id = :DomainId`, map[string]interface{}{
+		"Id":       id,
+		"DomainId": domainId,
+	})
+
+	if err != nil {
+		return model.NewCustomCodeError("store.sql_resumable_upload.delete.app_error", err.Error(), extractCodeFromErr(err))
+	}
+
+	return nil
+}
+
+// RemoveExpired removes the uploads that were not changed since updatedBefore and returns them to clean up the backend
+func (s *SqlResumableUploadStore) RemoveExpired(updatedBefore int64, limit int) ([]*model.ResumableUpload, model.AppError) {
+	var uploads []*model.ResumableUpload
+	_, err := s.GetMaster().Select(&uploads, `with del as (
+    delete
+    from storage.resumable_uploads
+    where id in (
+        select id
+        from storage.resumable_uploads
+        where updated_at < :UpdatedBefore
+        order by updated_at
+        limit :Limit
+        for update skip locked
+    )
+    returning *
+)
+select del.id, del.domain_id, del.uuid, del.name, del.view_name, del.mime_type, del.channel, del.size,
+       del.upload_offset, del.profile_id, p.updated_at as profile_updated_at, del.parts, del.instance,
+       del.generate_thumbnail, del.custom_properties, del.uploaded_by, del.file_id, del.created_at, del.updated_at
+from del
+    left join storage.file_backend_profiles p on p.id = del.profile_id`, map[string]interface{}{
+		"UpdatedBefore": updatedBefore,
+		"Limit":         limit,
+	})
+
+	if err != nil {
+		return nil, model.NewCustomCodeError("store.sql_resumable_upload.remove_expired.app_error", err.Error(), extractCodeFromErr(err))
+	}
+
+	return uploads, nil
+}
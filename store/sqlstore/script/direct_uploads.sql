@@ -0,0 +1,26 @@
+-- objects that clients upload to the backend with presigned urls, the file is created on the completion
+create table if not exists storage.direct_uploads
+(
+    id                varchar(36)  not null
+        constraint direct_uploads_pk primary key,
+    domain_id         int8         not null,
+    uuid              varchar(256) not null,
+    name              varchar(256) not null,
+    view_name         varchar(256),
+    mime_type         varchar(120) not null,
+    channel           varchar(50),
+    size              int8         not null,
+    profile_id        int4         not null
+        constraint direct_uploads_file_backend_profiles_id_fk references storage.file_backend_profiles on delete cascade,
+    properties        jsonb        not null,
+    multipart_id      varchar(1024),
+    part_size         int8,
+    retention_until   timestamp with time zone,
+    custom_properties jsonb,
+    uploaded_by       int8,
+    created_at        int8         not null,
+    expires_at        int8         not null
+);
+
+create index if not exists direct_uploads_expires_at_index
+    on storage.direct_uploads (expires_at);
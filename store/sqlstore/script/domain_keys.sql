@@ -0,0 +1,15 @@
+-- data keys of the domains wrapped by the KMS (config crypto_kms), the destroyed key has no wrapped key
+create table if not exists storage.domain_keys
+(
+    id           bigserial
+        constraint domain_keys_pk primary key,
+    domain_id    int8 not null,
+    kms          varchar(20) not null,
+    wrapped_key  text,
+    created_at   int8 not null,
+    destroyed_at int8
+);
+
+create unique index if not exists domain_keys_domain_id_uindex
+    on storage.domain_keys (domain_id)
+    where destroyed_at is null;
@@ -0,0 +1,50 @@
+-- audit of the access to the files and of the file mutations, the table is append-only
+create table if not exists storage.file_audit
+(
+    id         bigserial
+        constraint file_audit_pk primary key,
+    created_at int8        not null,
+    domain_id  int8        not null,
+    user_id    int8,
+    file_id    int8        not null,
+    ip         varchar,
+    action     varchar(30) not null,
+    result     varchar(20) not null,
+    error      text
+);
+
+create index if not exists file_audit_domain_id_created_at_index
+    on storage.file_audit (domain_id, created_at desc);
+
+create index if not exists file_audit_file_id_index
+    on storage.file_audit (file_id);
+
+create or replace function storage.file_audit_immutable() returns trigger
+    language plpgsql
+as
+$$
+begin
+    raise exception 'storage.file_audit is append-only';
+end;
+$$;
+
+drop trigger if exists file_audit_immutable_tg on storage.file_audit;
+create trigger file_audit_immutable_tg
+    before update or delete or truncate
+    on storage.file_audit
+    for each statement
+execute procedure storage.file_audit_immutable();
+
+create or replace view storage.file_audit_list as
+select a.id,
+       a.created_at,
+       a.domain_id,
+       a.user_id,
+       storage.get_lookup(u.id, coalesce(u.name, u.username::text)::character varying) as "user",
+       a.file_id,
+       a.ip,
+       a.action,
+       a.result,
+       a.error
+from storage.file_audit a
+         left join directory.wbt_user u on u.id = a.user_id;
@@ -0,0 +1,18 @@
+-- files converted on download to another format (config file_conversion_cache)
+create table if not exists storage.file_conversions
+(
+    file_id    int8                                   not null
+        constraint file_conversions_files_id_fk references storage.files on delete cascade,
+    format     varchar(20)                            not null,
+    profile_id int4
+        constraint file_conversions_file_backend_profiles_id_fk references storage.file_backend_profiles on delete cascade,
+    name       varchar                                not null,
+    mime_type  varchar                                not null,
+    size       int8                                   not null,
+    properties jsonb                                  not null,
+    created_at timestamp with time zone default now() not null,
+    constraint file_conversions_pk primary key (file_id, format)
+);
+
+create index if not exists file_conversions_profile_id_index
+    on storage.file_conversions (profile_id);
@@ -0,0 +1,4 @@
+-- lookup of stored objects by content for deduplication and reference counting
+create index concurrently if not exists files_domain_sha256sum_index
+    on storage.files (domain_id, sha256sum)
+    where sha256sum is not null;
@@ -0,0 +1,4 @@
+-- lookup of files encrypted with an old key for the re-encryption jobs (config crypto_keyring)
+create index concurrently if not exists files_key_version_index
+    on storage.files ((coalesce(nullif(properties ->> 'key_version', '')::int, 0)))
+    where removed is not true and coalesce((properties ->> 'encrypted')::bool, false);
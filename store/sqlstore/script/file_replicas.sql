@@ -0,0 +1,14 @@
+-- copies of files in the replica backend profiles (properties "replicas" of the profile)
+create table if not exists storage.file_replicas
+(
+    file_id    int8                                   not null
+        constraint file_replicas_files_id_fk references storage.files on delete cascade,
+    profile_id int4                                   not null
+        constraint file_replicas_file_backend_profiles_id_fk references storage.file_backend_profiles on delete cascade,
+    properties jsonb                                  not null,
+    created_at timestamp with time zone default now() not null,
+    constraint file_replicas_pk primary key (file_id, profile_id)
+);
+
+create index if not exists file_replicas_profile_id_index
+    on storage.file_replicas (profile_id);
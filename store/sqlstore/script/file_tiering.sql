@@ -0,0 +1,4 @@
+-- lookup of aging files of the profile for the tiering rules (properties "tiering" of the profile)
+create index concurrently if not exists files_profile_id_created_at_index
+    on storage.files (profile_id, created_at)
+    where removed is not true;
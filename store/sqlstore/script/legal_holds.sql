@@ -0,0 +1,43 @@
+-- legal holds of files: a file with an active hold (released_at is null) can't be removed.
+-- The hold is placed on the file id or on the reference id (uuid, the call id is resolved to the uuid), the released holds are kept as the audit trail
+create table if not exists storage.file_legal_holds
+(
+    id             bigserial
+        constraint file_legal_holds_pk primary key,
+    domain_id      int8    not null,
+    file_id        int8,
+    uuid           varchar,
+    call_id        varchar,
+    reason         text    not null,
+    created_at     int8    not null,
+    created_by     int8,
+    released_at    int8,
+    released_by    int8,
+    release_reason text,
+    constraint file_legal_holds_target_check check (file_id is not null or uuid is not null)
+);
+
+create index if not exists file_legal_holds_file_id_index
+    on storage.file_legal_holds (file_id)
+    where released_at is null;
+
+create index if not exists file_legal_holds_domain_id_uuid_index
+    on storage.file_legal_holds (domain_id, uuid)
+    where released_at is null;
+
+create or replace view storage.file_legal_holds_list as
+select h.id,
+       h.domain_id,
+       h.file_id,
+       h.uuid                                                                          as reference_id,
+       h.call_id,
+       h.reason,
+       h.created_at,
+       storage.get_lookup(c.id, coalesce(c.name, c.username::text)::character varying) as created_by,
+       h.released_at,
+       storage.get_lookup(r.id, coalesce(r.name, r.username::text)::character varying) as released_by,
+       h.release_reason,
+       h.released_at isnull                                                            as active
+from storage.file_legal_holds h
+         left join directory.wbt_user c on c.id = h.created_by
+         left join directory.wbt_user r on r.id = h.released_by;
@@ -0,0 +1,35 @@
+-- antivirus scan of the files matched by the policy (config clamav), the files of the chat channel are always scanned
+alter table storage.file_policies
+    add column if not exists malware_scan bool default false not null;
+
+drop view if exists storage.file_policies_view;
+create view storage.file_policies_view as
+select p.id,
+       p.created_at,
+       storage.get_lookup(c.id, coalesce(c.name, c.username::text)::character varying) as created_by,
+       p.updated_at,
+       storage.get_lookup(u.id, coalesce(u.name, u.username::text)::character varying) as updated_by,
+       p.enabled,
+       p.name,
+       p.description,
+       p.channels,
+       p.mime_types,
+       p.speed_download,
+       p.speed_upload,
+       p.retention_days,
+       p.max_upload_size,
+       p.encrypt,
+       p.malware_scan,
+       p.domain_id,
+       p.position
+from storage.file_policies p
+         left join directory.wbt_user c on c.id = p.created_by
+         left join directory.wbt_user u on u.id = p.updated_by;
+
+-- versions of the antivirus signatures, the files are rescanned when a new version appears
+create table if not exists storage.malware_signatures
+(
+    version    varchar not null
+        constraint malware_signatures_pk primary key,
+    created_at int8    not null
+);
@@ -0,0 +1,18 @@
+-- files flagged by the malware scanner for the quarantine management
+create or replace view storage.files_quarantine_list as
+select f.id,
+       f.domain_id,
+       f.uuid,
+       coalesce(f.view_name, f.name)                                                   as name,
+       f.mime_type,
+       f.size,
+       f.channel,
+       f.uploaded_at,
+       storage.get_lookup(u.id, coalesce(u.name, u.username::text)::character varying) as uploaded_by,
+       f.malware ->> 'status'                                                          as malware_status,
+       f.malware ->> 'description'                                                     as malware_description,
+       (f.malware ->> 'scan_date')::timestamptz                                        as scanned_at
+from storage.files f
+         left join directory.wbt_user u on u.id = f.uploaded_by
+where (f.malware ->> 'found')::bool
+  and f.removed is not true;
@@ -0,0 +1,28 @@
+-- state of the resumable (chunked) uploads, received bytes are kept as parts in the backend of the domain
+create table if not exists storage.resumable_uploads
+(
+    id                 varchar(36)           not null
+        constraint resumable_uploads_pk primary key,
+    domain_id          int8                  not null,
+    uuid               varchar(256)          not null,
+    name               varchar(256)          not null,
+    view_name          varchar(256),
+    mime_type          varchar(120),
+    channel            varchar(50),
+    size               int8                  not null,
+    upload_offset      int8    default 0     not null,
+    profile_id         int4,
+    parts              jsonb   default '[]'  not null,
+    instance           varchar(50)           not null,
+    locked_by          varchar(36),
+    locked_at          int8,
+    generate_thumbnail boolean default false not null,
+    custom_properties  jsonb,
+    uploaded_by        int8,
+    file_id            int8,
+    created_at         int8                  not null,
+    updated_at         int8                  not null
+);
+
+create index if not exists resumable_uploads_updated_at_index
+    on storage.resumable_uploads (updated_at);
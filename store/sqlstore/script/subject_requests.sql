@@ -0,0 +1,22 @@
+-- export and erasure requests of the data subjects (GDPR), processed by the synchronizer
+create table if not exists storage.subject_requests
+(
+    id             bigserial
+        constraint subject_requests_pk primary key,
+    domain_id      int8        not null,
+    mode           varchar(10) not null,
+    filter         jsonb       not null,
+    state          varchar(10) not null,
+    created_at     int8        not null,
+    created_by     int8,
+    updated_at     int8        not null,
+    files          int8 default 0 not null,
+    export_file_id int8,
+    report         text,
+    signature      text,
+    error          text
+);
+
+create index if not exists subject_requests_state_index
+    on storage.subject_requests (state, id)
+    where state in ('pending', 'active');
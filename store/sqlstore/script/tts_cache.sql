@@ -0,0 +1,18 @@
+-- synthesized speech of the TTS cache (config tts_cache_type), the audio is kept in the cache store
+create table if not exists storage.tts_cache
+(
+    domain_id    int8                                   not null,
+    key          varchar(64)                            not null,
+    provider     varchar(50)                            not null,
+    name         varchar                                not null,
+    mime_type    varchar                                not null,
+    size         int8                                   not null,
+    properties   jsonb                                  not null,
+    hits         int8                     default 0     not null,
+    created_at   timestamp with time zone default now() not null,
+    last_used_at timestamp with time zone default now() not null,
+    constraint tts_cache_pk primary key (domain_id, key)
+);
+
+create index if not exists tts_cache_last_used_at_index
+    on storage.tts_cache (last_used_at desc);
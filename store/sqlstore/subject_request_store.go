@@ -0,0 +1,228 @@
+package sqlstore
+
+import (
+	"context"
+
+	"github.com/lib/pq"
+	"github.com/webitel/storage/model"
+	"github.com/webitel/storage/store"
+)
+
+type SqlSubjectRequestStore struct {
+	SqlStore
+}
+
+func NewSqlSubjectRequestStore(sqlStore SqlStore) store.SubjectRequestStore {
+	us := &SqlSubjectRequestStore{sqlStore}
+	return us
+}
+
+const subjectRequestColumns = `r.id, r.domain_id, r.mode, r.filter, r.state, r.created_at,
+       storage.get_lookup(u.id, coalesce(u.name, u.username::text)::character varying) as created_by,
+       r.updated_at, r.files, r.export_file_id, r.report, r.signature, r.error`
+
+func (s SqlSubjectRequestStore) Create(ctx context.Context, req *model.SubjectRequest) (*model.SubjectRequest, model.AppError) {
+	var res *model.SubjectRequest
+	err := s.GetMaster().WithContext(ctx).SelectOne(&res, `with r as (
+    insert into storage.subject_requests (domain_id, mode, filter, state, created_at, created_by, updated_at)
+    values (:DomainId, :Mode, :Filter::jsonb, :State, :CreatedAt, :CreatedBy, :UpdatedAt)
+    returning *
+)
+select `+subjectRequestColumns+`
+from r
+         left join directory.wbt_user u on u.id = r.created_by`, map[string]interface{}{
+		"DomainId":  req.DomainId,
+		"Mode":      req.Mode,
+		"Filter":    req.Filter.ToJson(),
+		"State":     req.State,
+		"CreatedAt": req.CreatedAt,
+		"CreatedBy": req.CreatedBy.GetSafeId(),
+		"UpdatedAt": req.UpdatedAt,
+	})
+
+	if err != nil {
+		return nil, model.NewCustomCodeError("store.sql_subject_request.create.app_error", err.Error(), extractCodeFromErr(err))
+	}
+
+	return res, nil
+}
+
+func (s SqlSubjectRequestStore) Get(ctx context.Context, domainId int64, id int64) (*model.SubjectRequest, model.AppError) {
+	var res *model.SubjectRequest
+	err := s.GetReplica().WithContext(ctx).SelectOne(&res, `select `+subjectRequestColumns+`
+from storage.subject_requests r
+         left join directory.wbt_user u on u.id = r.created_by
+where r.id = :Id
+  and r.domain_id = :DomainId`, map[string]interface{}{
+		"Id":       id,
+		"DomainId": domainId,
+	})
+
+	if err != nil {
+		return nil, model.NewCustomCodeError("store.sql_subject_request.get.app_error", err.Error(), extractCodeFromErr(err))
+	}
+
+	return res, nil
+}
+
+// Fetch takes the pending request, the active request without progress longer than stuckAfter is taken again
+func (s SqlSubjectRequestStore) Fetch(stuckAfter int64) (*model.SubjectRequest, model.AppError) {
+	var res []*model.SubjectRequest
+	_, err := s.GetMaster().Select(&res, `with r as (
+    update storage.subject_requests u
+    set state = :Active,
+        updated_at = :Now
+    where u.id = (select x.id
+                  from storage.subject_requests x
+                  where x.state = :Pending
+                     or (x.state = :Active and x.updated_at < :Now - :StuckAfter::int8)
+                  order by x.id
+                  limit 1 for update skip locked)
+    returning u.*
+)
+select `+subjectRequestColumns+`
+from r
+         left join directory.wbt_user u on u.id = r.created_by`, map[string]interface{}{
+		"Active":     model.SubjectRequestActive,
+		"Pending":    model.SubjectRequestPending,
+		"Now":        model.GetMillis(),
+		"StuckAfter": stuckAfter,
+	})
+
+	if err != nil {
+		return nil, model.NewCustomCodeError("store.sql_subject_request.fetch.app_error", err.Error(), extractCodeFromErr(err))
+	}
+
+	if len(res) == 0 {
+		return nil, nil
+	}
+
+	return res[0], nil
+}
+
+func (s SqlSubjectRequestStore) SetProgress(id int64, files int64) model.AppError {
+	_, err := s.GetMaster().Exec(`update storage.subject_requests
+set files = :Files,
+    updated_at = :UpdatedAt
+where id = :Id`, map[string]interface{}{
+		"Id":        id,
+		"Files":     files,
+		"UpdatedAt": model.GetMillis(),
+	})
+
+	if err != nil {
+		return model.NewCustomCodeError("store.sql_subject_request.set_progress.app_error", err.Error(), extractCodeFromErr(err))
+	}
+
+	return nil
+}
+
+func (s SqlSubjectRequestStore) Complete(req *model.SubjectRequest) model.AppError {
+	_, err := s.GetMaster().Exec(`update storage.subject_requests
+set state = :State,
+    files = :Files,
+    export_file_id = :ExportFileId,
+    report = :Report,
+    signature = :Signature,
+    error = :Error,
+    updated_at = :UpdatedAt
+where id = :Id`, map[string]interface{}{
+		"Id":           req.Id,
+		"State":        req.State,
+		"Files":        req.Files,
+		"ExportFileId": req.ExportFileId,
+		"Report":       req.Report,
+		"Signature":    req.Signature,
+		"Error":        req.Error,
+		"UpdatedAt":    model.GetMillis(),
+	})
+
+	if err != nil {
+		return model.NewCustomCodeError("store.sql_subject_request.complete.app_error", err.Error(), extractCodeFromErr(err))
+	}
+
+	return nil
+}
+
+// GetTranscripts returns the transcripts of the files
+func (s SqlSubjectRequestStore) GetTranscripts(ctx context.Context, domainId int64, fileIds []int64) ([]*model.SubjectTranscript, model.AppError) {
+	var res []*model.SubjectTranscript
+	_, err := s.GetReplica().WithContext(ctx).Select(&res, `select t.id, t.file_id, coalesce(t.locale, '') as locale, coalesce(t.transcript, '') as transcript, t.phrases, t.created_at
+from storage.file_transcript t
+where t.domain_id = :DomainId
+  and t.file_id = any (:FileIds::int8[])
+order by t.file_id, t.id`, map[string]interface{}{
+		"DomainId": domainId,
+		"FileIds":  pq.Array(fileIds),
+	})
+
+	if err != nil {
+		return nil, model.NewCustomCodeError("store.sql_subject_request.get_transcripts.app_error", err.Error(), extractCodeFromErr(err))
+	}
+
+	return res, nil
+}
+
+// EraseFile deletes the file with all its jobs and transcripts, bypassing the soft delete. The held file is kept.
+// Returns the other files that share the object of the file, they are counted under the lock of the file row
+// as by the remove job
+func (s SqlSubjectRequestStore) EraseFile(domainId int64, fileId int64) (bool, int64, model.AppError) {
+	tx, err := s.GetMaster().Begin()
+	if err != nil {
+		return false, 0, model.NewInternalError("store.sql_subject_request.erase_file.app_error", err.Error())
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.Exec(`select f.id
+from storage.files f
+where f.id = :Id
+for update`, map[string]interface{}{
+		"Id": fileId,
+	}); err != nil {
+		return false, 0, model.NewCustomCodeError("store.sql_subject_request.erase_file.app_error", err.Error(), extractCodeFromErr(err))
+	}
+
+	refs, err := tx.SelectInt(objectReferencesSql, map[string]interface{}{
+		"Id":     fileId,
+		"Remove": model.SyncJobRemove,
+	})
+	if err != nil {
+		return false, 0, model.NewCustomCodeError("store.sql_subject_request.erase_file.app_error", err.Error(), extractCodeFromErr(err))
+	}
+
+	var ids []int64
+	_, err = tx.Select(&ids, `with f as (
+    select f.id
+    from storage.files f
+    where f.id = :Id
+      and f.domain_id = :DomainId
+      and not `+activeLegalHold("f")+`
+),
+     jobs as (
+         delete
+         from storage.file_jobs j
+         where j.file_id in (select f.id from f)
+     ),
+     transcripts as (
+         delete
+         from storage.file_transcript t
+         where t.file_id in (select f.id from f)
+     )
+delete
+from storage.files d
+where d.id in (select f.id from f)
+returning d.id`, map[string]interface{}{
+		"Id":       fileId,
+		"DomainId": domainId,
+	})
+
+	if err != nil {
+		return false, 0, model.NewCustomCodeError("store.sql_subject_request.erase_file.app_error", err.Error(), extractCodeFromErr(err))
+	}
+
+	if err = tx.Commit(); err != nil {
+		return false, 0, model.NewCustomCodeError("store.sql_subject_request.erase_file.app_error", err.Error(), extractCodeFromErr(err))
+	}
+
+	return len(ids) > 0, refs, nil
+}
@@ -44,6 +44,13 @@ type SqlSupplierOldStores struct {
 	importTemplate     store.ImportTemplateStore
 	filePolicies       store.FilePoliciesStore
 	sysSettings        store.SystemSettingsStore
+	resumableUpload    store.ResumableUploadStore
+	directUpload       store.DirectUploadStore
+	domainKey          store.DomainKeyStore
+	legalHold          store.LegalHoldStore
+	audit              store.AuditStore
+	subjectRequest     store.SubjectRequestStore
+	ttsCache           store.TtsCacheStore
 }
 
 type SqlSupplier struct {
@@ -79,6 +86,13 @@ func NewSqlSupplier(settings model.SqlSettings) *SqlSupplier {
 	supplier.oldStores.importTemplate = NewSqlImportTemplateStore(supplier)
 	supplier.oldStores.filePolicies = NewSqlFilePoliciesStore(supplier)
 	supplier.oldStores.sysSettings = NewSqlSysSettingsStore(supplier)
+	supplier.oldStores.resumableUpload = NewSqlResumableUploadStore(supplier)
+	supplier.oldStores.directUpload = NewSqlDirectUploadStore(supplier)
+	supplier.oldStores.domainKey = NewSqlDomainKeyStore(supplier)
+	supplier.oldStores.legalHold = NewSqlLegalHoldStore(supplier)
+	supplier.oldStores.audit = NewSqlAuditStore(supplier)
+	supplier.oldStores.subjectRequest = NewSqlSubjectRequestStore(supplier)
+	supplier.oldStores.ttsCache = NewSqlTtsCacheStore(supplier)
 
 	err := supplier.GetMaster().CreateTablesIfNotExists()
 	if err != nil {
@@ -194,7 +208,7 @@ func (me typeConverter) ToDb(val interface{}) (interface{}, error) {
 func (me typeConverter) FromDb(target interface{}) (gorp.CustomScanner, bool) {
 	switch target.(type) {
 
-	case **model.Thumbnail, **model.MalwareScan:
+	case **model.Thumbnail, **model.MalwareScan, **model.CustomFileProperties, **model.SubjectFilter:
 		binder := func(holder, target interface{}) error {
 			s, ok := holder.(*[]byte)
 			if !ok {
@@ -242,7 +256,7 @@ func (me typeConverter) FromDb(target interface{}) (gorp.CustomScanner, bool) {
 		}
 		return gorp.CustomScanner{Holder: new(model.JSON), Target: target, Binder: binder}, true
 
-	case *[]model.StringInterface, *[]model.TranscriptPhrase, *[]model.TranscriptChannel:
+	case *[]model.StringInterface, *[]model.TranscriptPhrase, *[]model.TranscriptChannel, *[]model.ResumableUploadPart:
 		binder := func(holder, target interface{}) error {
 			s, ok := holder.(*model.JSON)
 			if !ok {
@@ -395,3 +409,31 @@ func (ss *SqlSupplier) FilePolicies() store.FilePoliciesStore {
 func (ss *SqlSupplier) SystemSettings() store.SystemSettingsStore {
 	return ss.oldStores.sysSettings
 }
+
+func (ss *SqlSupplier) ResumableUpload() store.ResumableUploadStore {
+	return ss.oldStores.resumableUpload
+}
+
+func (ss *SqlSupplier) DirectUpload() store.DirectUploadStore {
+	return ss.oldStores.directUpload
+}
+
+func (ss *SqlSupplier) DomainKey() store.DomainKeyStore {
+	return ss.oldStores.domainKey
+}
+
+func (ss *SqlSupplier) LegalHold() store.LegalHoldStore {
+	return ss.oldStores.legalHold
+}
+
+func (ss *SqlSupplier) Audit() store.AuditStore {
+	return ss.oldStores.audit
+}
+
+func (ss *SqlSupplier) SubjectRequest() store.SubjectRequestStore {
+	return ss.oldStores.subjectRequest
+}
+
+func (ss *SqlSupplier) TtsCache() store.TtsCacheStore {
+	return ss.oldStores.ttsCache
+}
@@ -72,6 +72,7 @@ from (
     select id
     from storage.files
     where retention_until < now()
+      and not `+activeLegalHold("files")+`
     order by retention_until
     limit 1000
  ) f
@@ -82,6 +83,7 @@ from (
     from storage.files f
     where f.removed
         and not exists(select 1 from storage.file_jobs j where j.file_id = f.id)
+        and not `+activeLegalHold("f")+`
     order by f.created_at
 	limit 1000
 ) t;`, map[string]interface{}{
@@ -96,6 +98,114 @@ from (
 	return nil
 }
 
+// SetMigrateJobs creates jobs for files matching the tiering rules of their profile
+func (s SqlSyncFileStore) SetMigrateJobs() model.AppError {
+	_, err := s.GetMaster().Exec(`insert into storage.file_jobs (file_id, action, config)
+select distinct on (t.id) t.id, :Action, jsonb_build_object('profile_id', t.to_profile_id)
+from storage.file_backend_profiles p,
+     jsonb_array_elements(p.properties -> 'tiering') r,
+     lateral (
+         select f.id, (r ->> 'profile_id')::int4 as to_profile_id
+         from storage.files f
+         where f.profile_id = p.id
+           and f.created_at < (extract(epoch from now() - make_interval(days => (r ->> 'after_days')::int)) * 1000)::int8
+           and (r ->> 'channel' isnull or f.channel = r ->> 'channel')
+           and f.removed is not true
+           and not exists(select 1 from storage.file_jobs j where j.file_id = f.id)
+         order by f.created_at
+         limit 1000
+     ) t
+where not p.disabled
+  and jsonb_typeof(p.properties -> 'tiering') = 'array'
+  and exists(select 1 from storage.file_backend_profiles d
+             where d.id = (r ->> 'profile_id')::int4 and d.domain_id = p.domain_id and not d.disabled)
+order by t.id`, map[string]interface{}{
+		"Action": model.Migrate,
+	})
+
+	if err != nil {
+		return model.NewInternalError("store.sql_sync_file_job.set_migrate.app_error", err.Error())
+	}
+
+	return nil
+}
+
+// SetReEncryptJobs creates jobs for encrypted files that are written with a key older than keyVersion
+func (s SqlSyncFileStore) SetReEncryptJobs(keyVersion int) model.AppError {
+	_, err := s.GetMaster().Exec(`insert into storage.file_jobs (file_id, action)
+select f.id, :Action
+from storage.files f
+where coalesce((f.properties ->> 'encrypted')::bool, false)
+  and coalesce(nullif(f.properties ->> 'key_version', '')::int, 0) < :KeyVersion
+  and coalesce(f.properties ->> 'domain_key', '') = ''
+  and f.removed is not true
+  and not exists(select 1 from storage.file_jobs j where j.file_id = f.id)
+order by f.id
+limit 1000`, map[string]interface{}{
+		"Action":     model.ReEncrypt,
+		"KeyVersion": keyVersion,
+	})
+
+	if err != nil {
+		return model.NewInternalError("store.sql_sync_file_job.set_re_encrypt.app_error", err.Error())
+	}
+
+	return nil
+}
+
+// SetRescanJobs saves the version of the antivirus signatures and creates jobs for files uploaded in the last days
+// that were scanned before the latest version appeared. The first saved version has nothing to compare with, so it is only saved.
+// The files of the chat channel are always in scope, the files of other channels when the matched file policy enables the malware scan
+func (s SqlSyncFileStore) SetRescanJobs(signatures string, days int) model.AppError {
+	_, err := s.GetMaster().Exec(`insert into storage.malware_signatures (version, created_at)
+values (:Version, :CreatedAt)
+on conflict (version) do nothing`, map[string]interface{}{
+		"Version":   signatures,
+		"CreatedAt": model.GetMillis(),
+	})
+
+	if err != nil {
+		return model.NewInternalError("store.sql_sync_file_job.set_rescan.app_error", err.Error())
+	}
+
+	_, err = s.GetMaster().Exec(`with sig as (
+    select to_timestamp(max(created_at) / 1000.0) as created_at
+    from storage.malware_signatures
+    having count(*) > 1
+)
+insert into storage.file_jobs (file_id, action)
+select f.id, :Action
+from storage.files f,
+     sig
+where f.uploaded_at > now() - make_interval(days => :Days::int)
+  and f.uploaded_at < sig.created_at
+  and coalesce((f.malware ->> 'scan_date')::timestamptz, f.uploaded_at) < sig.created_at
+  and not coalesce((f.malware ->> 'found')::bool, false)
+  and f.removed is not true
+  and not exists(select 1 from storage.file_jobs j where j.file_id = f.id)
+  and (f.channel = 'chat' or coalesce((select p.malware_scan
+                                       from storage.file_policies p
+                                       where p.domain_id = f.domain_id
+                                         and p.enabled
+                                         and f.channel = any (p.channels)
+                                         and exists(select 1
+                                                    from unnest(p.mime_types) m
+                                                    where f.mime_type ilike replace(replace(m, '*', '%'), '?', '_'))
+                                       order by p.position desc
+                                       limit 1), false))
+order by f.id
+limit 1000`, map[string]interface{}{
+		"Action": model.Rescan,
+		"Days":   days,
+	})
+
+	if err != nil {
+		return model.NewInternalError("store.sql_sync_file_job.set_rescan.app_error", err.Error())
+	}
+
+	return nil
+}
+
 func (s SqlSyncFileStore) Clean(jobId int64) model.AppError {
 	_, err := s.GetMaster().Exec(`with del as (
     delete
@@ -105,7 +215,8 @@ func (s SqlSyncFileStore) Clean(jobId int64) model.AppError {
 )
 delete
 from storage.files f
-where f.id = (select del.file_id from del )`, map[string]interface{}{
+where f.id = (select del.file_id from del )
+  and not `+activeLegalHold("f"), map[string]interface{}{
 		"Id": jobId,
 	})
 
@@ -116,6 +227,57 @@ where f.id = (select del.file_id from del )`, map[string]interface{}{
 	return nil
 }
 
+// RemoveFile deletes the file of the remove job under the lock of the row, the references to the object are counted
+// after the lock is taken, so the reference that is linked to the file at the same time is either counted or sees the deleted file.
+// Returns false when the file is kept, the job is deleted in both cases
+func (s SqlSyncFileStore) RemoveFile(jobId, fileId int64) (bool, int64, model.AppError) {
+	tx, err := s.GetMaster().Begin()
+	if err != nil {
+		return false, 0, model.NewInternalError("store.sql_sync_file_job.remove_file.app_error", err.Error())
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.Exec(`select f.id
+from storage.files f
+where f.id = :Id
+for update`, map[string]interface{}{
+		"Id": fileId,
+	}); err != nil {
+		return false, 0, model.NewInternalError("store.sql_sync_file_job.remove_file.app_error", err.Error())
+	}
+
+	refs, err := tx.SelectInt(objectReferencesSql, map[string]interface{}{
+		"Id":     fileId,
+		"Remove": model.SyncJobRemove,
+	})
+	if err != nil {
+		return false, 0, model.NewInternalError("store.sql_sync_file_job.remove_file.app_error", err.Error())
+	}
+
+	if _, err = tx.Exec(`delete from storage.file_jobs where id = :Id`, map[string]interface{}{
+		"Id": jobId,
+	}); err != nil {
+		return false, 0, model.NewInternalError("store.sql_sync_file_job.remove_file.app_error", err.Error())
+	}
+
+	var ids []int64
+	if _, err = tx.Select(&ids, `delete
+from storage.files f
+where f.id = :Id
+  and not `+activeLegalHold("f")+`
+returning f.id`, map[string]interface{}{
+		"Id": fileId,
+	}); err != nil {
+		return false, 0, model.NewInternalError("store.sql_sync_file_job.remove_file.app_error", err.Error())
+	}
+
+	if err = tx.Commit(); err != nil {
+		return false, 0, model.NewInternalError("store.sql_sync_file_job.remove_file.app_error", err.Error())
+	}
+
+	return len(ids) > 0, refs, nil
+}
+
 func (s SqlSyncFileStore) Remove(jobId int64) model.AppError {
 	_, err := s.GetMaster().Exec(`    delete
     from storage.file_jobs rj
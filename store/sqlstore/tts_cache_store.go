@@ -0,0 +1,117 @@
+package sqlstore
+
+import (
+	"time"
+
+	"github.com/webitel/storage/model"
+	"github.com/webitel/storage/store"
+)
+
+type SqlTtsCacheStore struct {
+	SqlStore
+}
+
+func NewSqlTtsCacheStore(sqlStore SqlStore) store.TtsCacheStore {
+	us := &SqlTtsCacheStore{sqlStore}
+	return us
+}
+
+// Get returns the entry that is not expired and marks it as used, nil when the request is not cached
+func (s SqlTtsCacheStore) Get(domainId int64, key string, ttl time.Duration) (*model.TtsCacheEntry, model.AppError) {
+	var entries []*model.TtsCacheEntry
+	_, err := s.GetMaster().Select(&entries, `update storage.tts_cache c
+set last_used_at = now(),
+    hits = c.hits + 1
+where c.domain_id = :DomainId
+  and c.key = :Key
+  and c.created_at > now() - (:Ttl::int8 * interval '1 second')
+returning c.domain_id, c.key, c.provider, c.name, c.mime_type, c.size, c.properties`, map[string]interface{}{
+		"DomainId": domainId,
+		"Key":      key,
+		"Ttl":      int64(ttl.Seconds()),
+	})
+
+	if err != nil {
+		return nil, model.NewCustomCodeError("store.sql_tts_cache.get.app_error", err.Error(), extractCodeFromErr(err))
+	}
+
+	if len(entries) == 0 {
+		return nil, nil
+	}
+
+	return entries[0], nil
+}
+
+// Save returns false when the request is already cached by a concurrent synthesis
+func (s SqlTtsCacheStore) Save(entry *model.TtsCacheEntry) (bool, model.AppError) {
+	res, err := s.GetMaster().Exec(`insert into storage.tts_cache (domain_id, key, provider, name, mime_type, size, properties)
+values (:DomainId, :Key, :Provider, :Name, :MimeType, :Size, :Props::jsonb)
+on conflict (domain_id, key) do nothing`, map[string]interface{}{
+		"DomainId": entry.DomainId,
+		"Key":      entry.Key,
+		"Provider": entry.Provider,
+		"Name":     entry.Name,
+		"MimeType": entry.MimeType,
+		"Size":     entry.Size,
+		"Props":    entry.Properties.ToJson(),
+	})
+
+	if err != nil {
+		return false, model.NewCustomCodeError("store.sql_tts_cache.save.app_error", err.Error(), extractCodeFromErr(err))
+	}
+
+	cnt, err := res.RowsAffected()
+	if err != nil {
+		return false, model.NewCustomCodeError("store.sql_tts_cache.save.app_error", err.Error(), extractCodeFromErr(err))
+	}
+
+	return cnt > 0, nil
+}
+
+func (s SqlTtsCacheStore) Delete(domainId int64, key string) model.AppError {
+	_, err := s.GetMaster().Exec(`delete from storage.tts_cache c
+where c.domain_id = :DomainId
+  and c.key = :Key`, map[string]interface{}{
+		"DomainId": domainId,
+		"Key":      key,
+	})
+
+	if err != nil {
+		return model.NewCustomCodeError("store.sql_tts_cache.delete.app_error", err.Error(), extractCodeFromErr(err))
+	}
+
+	return nil
+}
+
+// Evict removes the expired entries and the least recently used entries above the size of the cache,
+// the removed entries are returned to remove the audio from the cache store
+func (s SqlTtsCacheStore) Evict(ttl time.Duration, maxSize int64, limit int) ([]*model.TtsCacheEntry, model.AppError) {
+	var entries []*model.TtsCacheEntry
+	_, err := s.GetMaster().Select(&entries, `with e as (
+    select t.domain_id, t.key
+    from (select c.domain_id,
+                 c.key,
+                 c.created_at,
+                 sum(c.size) over (order by c.last_used_at desc, c.domain_id, c.key) as total
+          from storage.tts_cache c) t
+    where t.created_at <= now() - (:Ttl::int8 * interval '1 second')
+       or (:MaxSize::int8 > 0 and t.total > :MaxSize::int8)
+    limit :Limit
+)
+delete
+from storage.tts_cache c
+    using e
+where c.domain_id = e.domain_id
+  and c.key = e.key
+returning c.domain_id, c.key, c.provider, c.name, c.mime_type, c.size, c.properties`, map[string]interface{}{
+		"Ttl":     int64(ttl.Seconds()),
+		"MaxSize": maxSize,
+		"Limit":   limit,
+	})
+
+	if err != nil {
+		return nil, model.NewCustomCodeError("store.sql_tts_cache.evict.app_error", err.Error(), extractCodeFromErr(err))
+	}
+
+	return entries, nil
+}
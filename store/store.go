@@ -54,6 +54,13 @@ type StoreData interface {
 	ImportTemplate() ImportTemplateStore
 	FilePolicies() FilePoliciesStore
 	SystemSettings() SystemSettingsStore
+	ResumableUpload() ResumableUploadStore
+	DirectUpload() DirectUploadStore
+	DomainKey() DomainKeyStore
+	LegalHold() LegalHoldStore
+	Audit() AuditStore
+	SubjectRequest() SubjectRequestStore
+	TtsCache() TtsCacheStore
 }
 
 type UploadJobStore interface {
@@ -68,7 +75,11 @@ type UploadJobStore interface {
 type SyncFileStore interface {
 	FetchJobs(limit int) ([]*model.SyncJob, model.AppError)
 	SetRemoveJobs(localExpDay int) model.AppError
+	SetMigrateJobs() model.AppError
+	SetReEncryptJobs(keyVersion int) model.AppError
+	SetRescanJobs(signatures string, days int) model.AppError
 	Clean(jobId int64) model.AppError
+	RemoveFile(jobId, fileId int64) (bool, int64, model.AppError)
 	Remove(jobId int64) model.AppError
 	CreateJob(domainId, fileId int64, action string, config map[string]any) model.AppError
 
@@ -100,10 +111,27 @@ type FileStore interface {
 	MarkRemoveByChannels(ctx context.Context, domainId int64, ids []int64, channels []string) model.AppError
 	Metadata(domainId int64, id int64) (model.BaseFile, model.AppError)
 
-	MoveFromJob(jobId int64, profileId *int, properties model.StringInterface, retentionUntil *time.Time) StoreChannel
+	MoveFromJob(jobId int64, file *model.File) StoreChannel
 	CheckCallRecordPermissions(ctx context.Context, fileId int, currentUserId int64, domainId int64, groups []int) (bool, model.AppError)
 	RestoreFile(ctx context.Context, domainId int64, fileIds []int64, userId int64) (int, model.AppError)
+	GetQuarantinePage(ctx context.Context, domainId int64, search *model.SearchQuarantineFile) ([]*model.QuarantineFile, model.AppError)
+	RescanQuarantine(ctx context.Context, domainId int64, fileIds []int64, userId int64) (int, model.AppError)
 	Restored(fileId int64, props model.StringInterface, uploadedBy *int64) model.AppError
+
+	FindObject(domainId int64, profileId *int, sha256sum string, encrypted bool, keyVersion, domainKey string) (*model.File, model.AppError)
+	ObjectReferences(fileId int64) (int64, model.AppError)
+	LockObject(fileId int64) (bool, model.AppError)
+	MoveToProfile(fileId int64, fromProfileId *int, toProfileId int, oldName, name string, props model.StringInterface, sha256sum *string) (bool, model.AppError)
+	ReplaceObject(fileId int64, profileId *int, oldName, name string, props model.StringInterface, sha256sum *string) (bool, model.AppError)
+	SetMalware(fileId int64, ms *model.MalwareScan) model.AppError
+
+	SaveReplica(fileId int64, profileId int, props model.StringInterface) model.AppError
+	GetReplicas(fileId int64) ([]*model.FileReplica, model.AppError)
+
+	GetConversion(fileId int64, format string) (*model.FileConversion, model.AppError)
+	SaveConversion(conversion *model.FileConversion) (bool, model.AppError)
+	GetConversions(fileId int64) ([]*model.FileConversion, model.AppError)
+	DeleteConversions(fileId int64) model.AppError
 }
 
 type MediaFileStore interface {
@@ -185,9 +213,68 @@ type FilePoliciesStore interface {
 	// AllByDomainId internal
 	AllByDomainId(ctx context.Context, domainId int64) ([]model.FilePolicy, model.AppError)
 	SetRetentionDay(ctx context.Context, domainId int64, policy *model.FilePolicy, applyToNullChannel bool) (int64, model.AppError)
+	SetEncryptionJobs(ctx context.Context, domainId int64, policy *model.FilePolicy, applyToNullChannel bool) (int64, model.AppError)
+	EncryptionProgress(ctx context.Context, domainId int64, policy *model.FilePolicy, applyToNullChannel bool) (*model.FilePolicyEncryption, model.AppError)
 	CreateDefaultPolicies(ctx context.Context, domainId int64) model.AppError
 }
 
+type ResumableUploadStore interface {
+	Create(upload *model.ResumableUpload) (*model.ResumableUpload, model.AppError)
+	Get(domainId int64, id string) (*model.ResumableUpload, model.AppError)
+	Lock(id, lockId string, lockedBefore int64) (bool, model.AppError)
+	Unlock(id, lockId string) model.AppError
+	AddPart(id string, part *model.ResumableUploadPart, instance string) (bool, model.AppError)
+	ReplaceLastPart(id string, lastName string, part *model.ResumableUploadPart, instance string) (bool, model.AppError)
+	LinkFile(id string) (*int64, model.AppError)
+	Delete(domainId int64, id string) model.AppError
+	RemoveExpired(updatedBefore int64, limit int) ([]*model.ResumableUpload, model.AppError)
+}
+
+type DirectUploadStore interface {
+	Create(upload *model.DirectUpload) (*model.DirectUpload, model.AppError)
+	Get(domainId int64, id string) (*model.DirectUpload, model.AppError)
+	Delete(domainId int64, id string) (bool, model.AppError)
+	RemoveExpired(limit int) ([]*model.DirectUpload, model.AppError)
+}
+
 type SystemSettingsStore interface {
 	ValueByName(ctx context.Context, domainId int64, name string) (model.SysValue, model.AppError)
 }
+
+type DomainKeyStore interface {
+	Create(key *model.DomainKey) (*model.DomainKey, model.AppError)
+	GetActive(domainId int64) (*model.DomainKey, model.AppError)
+	Get(domainId int64, id int64) (*model.DomainKey, model.AppError)
+	Destroy(domainId int64) (*model.DomainKey, model.AppError)
+	GetNotWrappedWith(kms string, prefix string, limit int) ([]*model.DomainKey, model.AppError)
+	Rewrap(id int64, wrapped string, newWrapped string) (bool, model.AppError)
+}
+
+type LegalHoldStore interface {
+	Place(ctx context.Context, domainId int64, userId int64, hold *model.PlaceLegalHold) ([]*model.LegalHold, model.AppError)
+	Release(ctx context.Context, domainId int64, userId int64, release *model.ReleaseLegalHold) ([]*model.LegalHold, model.AppError)
+	GetAllPage(ctx context.Context, domainId int64, search *model.SearchLegalHold) ([]*model.LegalHold, model.AppError)
+	HeldFiles(ctx context.Context, domainId int64, ids []int64) ([]int64, model.AppError)
+}
+
+type AuditStore interface {
+	Create(ctx context.Context, event *model.AuditEvent, fileIds []int64) model.AppError
+	GetAllPage(ctx context.Context, domainId int64, search *model.SearchAuditEvent) ([]*model.AuditEvent, model.AppError)
+}
+
+type SubjectRequestStore interface {
+	Create(ctx context.Context, req *model.SubjectRequest) (*model.SubjectRequest, model.AppError)
+	Get(ctx context.Context, domainId int64, id int64) (*model.SubjectRequest, model.AppError)
+	Fetch(stuckAfter int64) (*model.SubjectRequest, model.AppError)
+	SetProgress(id int64, files int64) model.AppError
+	Complete(req *model.SubjectRequest) model.AppError
+	GetTranscripts(ctx context.Context, domainId int64, fileIds []int64) ([]*model.SubjectTranscript, model.AppError)
+	EraseFile(domainId int64, fileId int64) (bool, int64, model.AppError)
+}
+
+type TtsCacheStore interface {
+	Get(domainId int64, key string, ttl time.Duration) (*model.TtsCacheEntry, model.AppError)
+	Save(entry *model.TtsCacheEntry) (bool, model.AppError)
+	Delete(domainId int64, key string) model.AppError
+	Evict(ttl time.Duration, maxSize int64, limit int) ([]*model.TtsCacheEntry, model.AppError)
+}
@@ -0,0 +1,89 @@
+package synchronizer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/webitel/storage/model"
+	"github.com/webitel/storage/utils"
+	"github.com/webitel/wlog"
+)
+
+type MigrateConfig struct {
+	ProfileId int `json:"profile_id"`
+}
+
+// migrateFileJob moves the file to another profile by the tiering rules
+type migrateFileJob struct {
+	fileJob
+}
+
+func (j *migrateFileJob) Execute() {
+	var file *model.FileWithProfile
+	var src, dst utils.FileBackend
+	var reader io.ReadCloser
+	var moved bool
+	var err model.AppError
+	app := j.app
+
+	log := app.Log.With(wlog.Int64("file_id", j.file.FileId),
+		wlog.String("action", model.Migrate),
+	)
+
+	var conf MigrateConfig
+	if e := json.Unmarshal(j.file.Config, &conf); e != nil || conf.ProfileId == 0 {
+		log.Error(fmt.Sprintf("[migrate] file %d, bad config: %s", j.file.FileId, string(j.file.Config)))
+		j.setError(model.NewBadRequestError("synchronizer.migrate.config", "profile_id is required"))
+		return
+	}
+
+	if file, err = app.Store.File().GetFileWithProfile(j.file.DomainId, j.file.FileId); err != nil {
+		log.Error(fmt.Sprintf("[migrate] file %d, error: %s", j.file.FileId, err.Error()))
+		j.setError(err)
+		return
+	}
+
+	if file.ProfileId != nil && *file.ProfileId == conf.ProfileId {
+		j.done()
+		return
+	}
+
+	if src, err = app.GetFileBackendStore(file.ProfileId, file.ProfileUpdatedAt); err != nil {
+		log.Error(fmt.Sprintf("[migrate] file %d, error: %s", j.file.FileId, err.Error()))
+		j.setError(err)
+		return
+	}
+
+	if dst, err = app.GetFileBackendStoreById(j.file.DomainId, conf.ProfileId); err != nil {
+		log.Error(fmt.Sprintf("[migrate] file %d, profile %d error: %s", j.file.FileId, conf.ProfileId, err.Error()))
+		j.setError(err)
+		return
+	}
+
+	if reader, err = src.Reader(&file.File, 0); err != nil {
+		log.Error(fmt.Sprintf("[migrate] file %d, read error: %s", j.file.FileId, err.Error()))
+		j.setError(err)
+		return
+	}
+	defer reader.Close()
+
+	target := objectTarget(file)
+	moved, err = j.replaceObject(file, src, dst, reader, &target, func(target *model.File, sum string) (bool, model.AppError) {
+		return app.Store.File().MoveToProfile(file.Id, file.ProfileId, conf.ProfileId, file.Name, target.Name, target.Properties, &sum)
+	})
+	if err != nil {
+		log.Error(fmt.Sprintf("[migrate] file %d, move to \"%s\" error: %s", j.file.FileId, dst.Name(), err.Error()))
+		j.setError(err)
+		return
+	}
+
+	if !moved {
+		wlog.Debug(fmt.Sprintf("file %d changed during migration, skip", j.file.FileId))
+		j.done()
+		return
+	}
+
+	j.done()
+	wlog.Debug(fmt.Sprintf("file %d migrated \"%s\" from store \"%s\" to \"%s\"", j.file.FileId, file.Name, src.Name(), dst.Name()))
+}
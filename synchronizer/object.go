@@ -0,0 +1,123 @@
+package synchronizer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+
+	"github.com/webitel/storage/app"
+	"github.com/webitel/storage/model"
+	"github.com/webitel/storage/utils"
+	"github.com/webitel/wlog"
+)
+
+// fileJob is the state of the sync job of the file shared by the jobs
+type fileJob struct {
+	file model.SyncJob
+	app  *app.App
+}
+
+// objectUpdate links the written object to the file, returns false when the file is changed
+type objectUpdate func(target *model.File, sum string) (bool, model.AppError)
+
+func (j *fileJob) done() {
+	if err := j.app.Store.SyncFile().Remove(j.file.Id); err != nil {
+		wlog.Error(err.Error())
+	}
+}
+
+func (j *fileJob) setError(err model.AppError) {
+	if e := j.app.Store.SyncFile().SetError(j.file.Id, err); e != nil {
+		wlog.Error(e.Error())
+	}
+}
+
+// objectTarget returns the new object of the file, the object is written under the unique name,
+// the object with the name of the file may belong to another file
+func objectTarget(file *model.FileWithProfile) model.File {
+	target := file.File
+	target.ViewName = model.NewString(file.GetViewName())
+	target.Name = model.NewId()[:5] + "_" + file.GetViewName()
+	target.Properties = file.Properties.Copy()
+	target.Properties.Remove("directory")
+	target.Properties.Remove("location")
+
+	return target
+}
+
+// replaceObject writes src to dst as the target and replaces the object of the file by update when the written
+// object is verified. The old object is removed from the store when it isn't shared with other files (deduplication),
+// the written object is removed on the error or when the file is changed, then false is returned
+func (j *fileJob) replaceObject(file *model.FileWithProfile, store, dst utils.FileBackend, src io.Reader, target *model.File, update objectUpdate) (bool, model.AppError) {
+	var refs int64
+	var replaced bool
+	var err model.AppError
+
+	h := sha256.New()
+	body := utils.NewCountingReader(io.TeeReader(src, h))
+	if _, err = dst.Write(body, target); err != nil {
+		return false, err
+	}
+
+	sum := hex.EncodeToString(h.Sum(nil))
+	if err = verifySource(file, body.Count(), sum); err == nil {
+		err = verifyTarget(dst, target, body.Count(), sum)
+	}
+	if err == nil {
+		refs, err = j.app.Store.File().ObjectReferences(file.Id)
+	}
+	if err == nil {
+		replaced, err = update(target, sum)
+	}
+
+	if err != nil || !replaced {
+		dst.Remove(target)
+		return false, err
+	}
+
+	if refs == 0 {
+		if err = store.Remove(&file.File); err != nil {
+			wlog.Error(fmt.Sprintf("file %d, remove from \"%s\" error: %s", j.file.FileId, store.Name(), err.Error()))
+		}
+	}
+
+	return true, nil
+}
+
+func verifySource(file *model.FileWithProfile, size int64, sum string) model.AppError {
+	if size != file.Size {
+		return model.NewInternalError("synchronizer.verify.size", fmt.Sprintf("read %d bytes, expected %d", size, file.Size))
+	}
+
+	if file.SHA256Sum != nil && *file.SHA256Sum != "" && *file.SHA256Sum != sum {
+		return model.NewInternalError("synchronizer.verify.hash", fmt.Sprintf("source sha256 %s, expected %s", sum, *file.SHA256Sum))
+	}
+
+	return nil
+}
+
+// verifyTarget reads back the written object
+func verifyTarget(dst utils.FileBackend, target utils.File, size int64, sum string) model.AppError {
+	reader, err := dst.Reader(target, 0)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	h := sha256.New()
+	n, e := io.Copy(h, reader)
+	if e != nil {
+		return model.NewInternalError("synchronizer.verify.read", e.Error())
+	}
+
+	if n != size {
+		return model.NewInternalError("synchronizer.verify.size", fmt.Sprintf("written %d bytes, expected %d", n, size))
+	}
+
+	if s := hex.EncodeToString(h.Sum(nil)); s != sum {
+		return model.NewInternalError("synchronizer.verify.hash", fmt.Sprintf("written sha256 %s, expected %s", s, sum))
+	}
+
+	return nil
+}
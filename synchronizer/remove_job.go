@@ -22,23 +22,54 @@ func (j *removeFileJob) Execute() {
 		return
 	}
 
-	err = store.Remove(&model.File{
+	var conversions []*model.FileConversion
+	var removed bool
+	var refs int64
+
+	// the replicas and the conversions are deleted with the file, the objects are removed after the file
+	replicas, err := j.app.Store.File().GetReplicas(j.file.FileId)
+	if err == nil {
+		conversions, err = j.app.Store.File().GetConversions(j.file.FileId)
+	}
+	if err == nil {
+		// the file under legal hold is kept, the reference that is linked at the same time keeps the object
+		removed, refs, err = j.app.Store.SyncFile().RemoveFile(j.file.Id, j.file.FileId)
+	}
+	if err != nil {
+		wlog.Error(fmt.Sprintf("file %d, error: %s", j.file.FileId, err.Error()))
+		if err = j.app.Store.SyncFile().SetError(j.file.Id, err); err != nil {
+			wlog.Error(err.Error())
+		}
+		return
+	}
+
+	if !removed {
+		// the file is under the legal hold placed after the job is created or is already removed
+		wlog.Debug(fmt.Sprintf("file %d is kept, skip remove", j.file.FileId))
+		return
+	}
+
+	file := &model.File{
 		BaseFile:  j.file.BaseFile,
 		Id:        j.file.Id,
 		DomainId:  j.file.DomainId,
 		Uuid:      "",
 		ProfileId: j.file.ProfileId,
 		CreatedAt: 0,
-	})
+	}
 
-	if err != nil {
-		wlog.Error(fmt.Sprintf("file %d, error: %s", j.file.FileId, err.Error()))
+	if refs > 0 {
+		// the replicas and the conversions of the shared object are used by the other files
+		wlog.Debug(fmt.Sprintf("file %d keep object \"%s\" in store \"%s\", references %d", j.file.FileId, j.file.Name, store.Name(), refs))
+		return
 	}
 
-	err = j.app.Store.SyncFile().Clean(j.file.Id)
-	if err != nil {
+	if err = store.Remove(file); err != nil {
 		wlog.Error(fmt.Sprintf("file %d, error: %s", j.file.FileId, err.Error()))
 	}
 
+	j.app.RemoveReplicaObjects(file, j.file.FileId, replicas)
+	j.app.RemoveConversionObjects(file, j.file.FileId, conversions)
+
 	wlog.Debug(fmt.Sprintf("file %d removed \"%s\" from store \"%s\"", j.file.FileId, j.file.Name, store.Name()))
 }
@@ -0,0 +1,116 @@
+package synchronizer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/webitel/storage/model"
+	"github.com/webitel/storage/utils"
+	"github.com/webitel/wlog"
+)
+
+type ReplicateConfig struct {
+	ProfileId int `json:"profile_id"`
+}
+
+type replicateFileJob struct {
+	fileJob
+}
+
+func (j *replicateFileJob) Execute() {
+	var file *model.FileWithProfile
+	var src, dst utils.FileBackend
+	var reader io.ReadCloser
+	var err model.AppError
+	app := j.app
+
+	log := app.Log.With(wlog.Int64("file_id", j.file.FileId),
+		wlog.String("action", model.Replicate),
+	)
+
+	var conf ReplicateConfig
+	if e := json.Unmarshal(j.file.Config, &conf); e != nil || conf.ProfileId == 0 {
+		log.Error(fmt.Sprintf("[replicate] file %d, bad config: %s", j.file.FileId, string(j.file.Config)))
+		j.setError(model.NewBadRequestError("synchronizer.replicate.config", "profile_id is required"))
+		return
+	}
+
+	if file, err = app.Store.File().GetFileWithProfile(j.file.DomainId, j.file.FileId); err != nil {
+		log.Error(fmt.Sprintf("[replicate] file %d, error: %s", j.file.FileId, err.Error()))
+		j.setError(err)
+		return
+	}
+
+	if src, err = app.GetFileBackendStore(file.ProfileId, file.ProfileUpdatedAt); err != nil {
+		log.Error(fmt.Sprintf("[replicate] file %d, error: %s", j.file.FileId, err.Error()))
+		j.setError(err)
+		return
+	}
+
+	if dst, err = app.GetFileBackendStoreById(j.file.DomainId, conf.ProfileId); err != nil {
+		log.Error(fmt.Sprintf("[replicate] file %d, replica profile %d error: %s", j.file.FileId, conf.ProfileId, err.Error()))
+		j.setError(err)
+		return
+	}
+
+	if reader, err = src.Reader(&file.File, 0); err != nil {
+		log.Error(fmt.Sprintf("[replicate] file %d, read error: %s", j.file.FileId, err.Error()))
+		j.setError(err)
+		return
+	}
+	defer reader.Close()
+
+	replica := file.File
+	replica.Properties = file.Properties.Copy()
+	replica.Properties.Remove("directory")
+	replica.Properties.Remove("location")
+
+	h := sha256.New()
+	body := utils.NewCountingReader(io.TeeReader(reader, h))
+	_, err = dst.Write(body, &replica)
+	exists := err != nil && err.GetId() == utils.ErrFileWriteExistsId
+	if err != nil && !exists {
+		log.Error(fmt.Sprintf("[replicate] file %d, write to \"%s\" error: %s", j.file.FileId, dst.Name(), err.Error()))
+		j.setError(err)
+		return
+	}
+
+	if exists {
+		// the object is left by an interrupted attempt or belongs to another file, it's the replica only with the content of the file
+		if _, e := io.Copy(io.Discard, body); e != nil {
+			err = model.NewInternalError("synchronizer.replicate.read", e.Error())
+			log.Error(fmt.Sprintf("[replicate] file %d, read error: %s", j.file.FileId, err.Error()))
+			j.setError(err)
+			return
+		}
+	}
+
+	sum := hex.EncodeToString(h.Sum(nil))
+	if err = verifySource(file, body.Count(), sum); err == nil && exists {
+		err = verifyTarget(dst, &replica, body.Count(), sum)
+	}
+	if err != nil {
+		log.Error(fmt.Sprintf("[replicate] file %d, verify \"%s\" error: %s", j.file.FileId, dst.Name(), err.Error()))
+		if !exists {
+			dst.Remove(&replica)
+		}
+		j.setError(err)
+		return
+	}
+
+	if err = app.Store.File().SaveReplica(file.Id, conf.ProfileId, replica.Properties); err != nil {
+		log.Error(fmt.Sprintf("[replicate] file %d, save replica error: %s", j.file.FileId, err.Error()))
+		if !exists {
+			dst.Remove(&replica)
+		}
+		j.setError(err)
+		return
+	}
+
+	j.done()
+
+	wlog.Debug(fmt.Sprintf("file %d replicated \"%s\" from store \"%s\" to \"%s\"", j.file.FileId, file.Name, src.Name(), dst.Name()))
+}
@@ -0,0 +1,207 @@
+package synchronizer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/webitel/storage/model"
+	"github.com/webitel/storage/utils"
+	"github.com/webitel/wlog"
+)
+
+// rescanFileJob scans the stored file again after the antivirus signatures are updated or on the request of the quarantine api.
+// The clean file gets the new scan date, the infected file is written to the quarantine path of the domain
+// and replaces the old object, the same way as the file infected on upload is stored
+type rescanFileJob struct {
+	fileJob
+}
+
+func (j *rescanFileJob) Execute() {
+	var file *model.FileWithProfile
+	var store utils.FileBackend
+	var reader io.ReadCloser
+	var ms *model.MalwareScan
+	var err model.AppError
+	app := j.app
+
+	log := app.Log.With(wlog.Int64("file_id", j.file.FileId),
+		wlog.String("action", model.Rescan),
+	)
+
+	if file, err = app.Store.File().GetFileWithProfile(j.file.DomainId, j.file.FileId); err != nil {
+		log.Error(fmt.Sprintf("[rescan] file %d, error: %s", j.file.FileId, err.Error()))
+		j.setError(err)
+		return
+	}
+
+	// the files in quarantine are rescanned on the request of the quarantine api
+	quarantined := file.Malware != nil && file.Malware.Found
+
+	if store, err = app.GetFileBackendStore(file.ProfileId, file.ProfileUpdatedAt); err != nil {
+		log.Error(fmt.Sprintf("[rescan] file %d, error: %s", j.file.FileId, err.Error()))
+		j.setError(err)
+		return
+	}
+
+	if reader, err = store.Reader(&file.File, 0); err != nil {
+		log.Error(fmt.Sprintf("[rescan] file %d, read error: %s", j.file.FileId, err.Error()))
+		j.setError(err)
+		return
+	}
+	defer reader.Close()
+
+	// the content is kept to write the quarantine copy without the second read of the backend
+	tmp, e := os.CreateTemp(app.Config().TempDir, "rescan_")
+	if e != nil {
+		err = model.NewInternalError("synchronizer.rescan.tmp", e.Error())
+		log.Error(fmt.Sprintf("[rescan] file %d, error: %s", j.file.FileId, err.Error()))
+		j.setError(err)
+		return
+	}
+	defer func() {
+		tmp.Close()
+		os.Remove(tmp.Name())
+	}()
+
+	if ms, err = app.ScanMalware(io.TeeReader(reader, tmp)); err == nil && ms.Status == model.MalwareStatusError {
+		err = model.NewInternalError("synchronizer.rescan.scan", malwareDesc(ms))
+	}
+	if err != nil {
+		log.Error(fmt.Sprintf("[rescan] file %d, scan error: %s", j.file.FileId, err.Error()))
+		j.setError(err)
+		return
+	}
+
+	if quarantined {
+		j.rescanQuarantine(file, ms)
+		return
+	}
+
+	if !ms.Found {
+		if err = app.Store.File().SetMalware(file.Id, ms); err != nil {
+			log.Error(fmt.Sprintf("[rescan] file %d, update error: %s", j.file.FileId, err.Error()))
+			j.setError(err)
+			return
+		}
+		j.done()
+		j.publish(file, ms)
+		return
+	}
+
+	log.Warn(fmt.Sprintf("[rescan] virus detected in file %d \"%s\". Signature: %s", file.Id, file.GetViewName(), malwareDesc(ms)))
+
+	if app.MalwareQuarantine() {
+		var moved bool
+		ms.Quarantine = true
+		if moved, err = j.quarantine(file, store, tmp, ms); err != nil {
+			log.Error(fmt.Sprintf("[rescan] file %d, quarantine error: %s", j.file.FileId, err.Error()))
+			j.setError(err)
+			return
+		}
+		if !moved {
+			j.done()
+			return
+		}
+	} else if err = app.Store.File().SetMalware(file.Id, ms); err != nil {
+		log.Error(fmt.Sprintf("[rescan] file %d, update error: %s", j.file.FileId, err.Error()))
+		j.setError(err)
+		return
+	}
+
+	j.done()
+	j.publish(file, ms)
+}
+
+// quarantine writes the scanned content to the quarantine path and replaces the object of the file,
+// returns false when the file is changed during the rescan
+func (j *rescanFileJob) quarantine(file *model.FileWithProfile, store utils.FileBackend, src io.ReadSeeker, ms *model.MalwareScan) (bool, model.AppError) {
+	var replaced bool
+	var err model.AppError
+	app := j.app
+
+	if _, e := src.Seek(0, io.SeekStart); e != nil {
+		return false, model.NewInternalError("synchronizer.rescan.seek", e.Error())
+	}
+
+	target := objectTarget(file)
+	target.Properties.Remove(utils.KeyVersionProperty)
+	target.Properties.Remove(utils.DomainKeyProperty)
+	target.Malware = ms
+
+	// the files that share the old object are moved by their own jobs
+	replaced, err = j.replaceObject(file, store, store, src, &target, func(target *model.File, sum string) (bool, model.AppError) {
+		return app.Store.File().ReplaceObject(file.Id, file.ProfileId, file.Name, target.Name, target.Properties, &sum)
+	})
+	if err != nil {
+		return false, err
+	}
+
+	if !replaced {
+		wlog.Debug(fmt.Sprintf("[rescan] file %d changed during rescan, skip", j.file.FileId))
+		return false, nil
+	}
+
+	if err = app.Store.File().SetMalware(file.Id, ms); err != nil {
+		return false, err
+	}
+
+	// the infected content must not stay in the replicas and stored conversions
+	app.RemoveFileReplicas(&file.File, file.Id)
+	app.RemoveFileConversions(&file.File, file.Id)
+
+	wlog.Debug(fmt.Sprintf("[rescan] file %d moved to quarantine \"%s\" in store \"%s\"", j.file.FileId, target.Name, store.Name()))
+
+	return true, nil
+}
+
+// rescanQuarantine updates the scan result of the file in quarantine, the clean file is released by the restore job
+func (j *rescanFileJob) rescanQuarantine(file *model.FileWithProfile, ms *model.MalwareScan) {
+	var err model.AppError
+	app := j.app
+
+	if ms.Found {
+		ms.Quarantine = true
+		err = app.Store.File().SetMalware(file.Id, ms)
+	} else {
+		var conf RestoreConfig
+		json.Unmarshal(j.file.Config, &conf)
+		// the restore job is created before this job is removed, the file always has a job
+		err = app.Store.SyncFile().CreateJob(file.DomainId, file.Id, model.Restore, map[string]any{
+			"user_id": conf.UserId,
+		})
+	}
+
+	if err != nil {
+		wlog.Error(fmt.Sprintf("[rescan] file %d, quarantine error: %s", j.file.FileId, err.Error()))
+		j.setError(err)
+		return
+	}
+
+	wlog.Debug(fmt.Sprintf("[rescan] file %d in quarantine rescanned, found %v", j.file.FileId, ms.Found))
+	j.done()
+	j.publish(file, ms)
+}
+
+func (j *rescanFileJob) publish(file *model.FileWithProfile, ms *model.MalwareScan) {
+	j.app.PublishMalwareScan(context.Background(), &model.MalwareAMQPMessage{
+		FileId:     file.Id,
+		DomainId:   file.DomainId,
+		Uuid:       file.Uuid,
+		Name:       file.GetViewName(),
+		MimeType:   file.MimeType,
+		Channel:    file.Channel,
+		Source:     model.MalwareSourceRescan,
+		Quarantine: ms.Quarantine,
+		Malware:    ms,
+	})
+}
+
+func malwareDesc(ms *model.MalwareScan) string {
+	if ms.Desc == nil {
+		return ""
+	}
+	return *ms.Desc
+}
@@ -0,0 +1,99 @@
+package synchronizer
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/webitel/storage/model"
+	"github.com/webitel/storage/utils"
+	"github.com/webitel/wlog"
+)
+
+// rewriteFileJob writes the file again in the same profile: re_encrypt moves the encrypted file to the current key
+// of the keyring, encrypt and decrypt apply the Encrypt flag of the file policy to the existing files.
+// The new object is written next to the old one and replaces it when verified, then the old object is removed
+type rewriteFileJob struct {
+	fileJob
+}
+
+func (j *rewriteFileJob) Execute() {
+	var file *model.FileWithProfile
+	var store utils.FileBackend
+	var reader io.ReadCloser
+	var replaced bool
+	var err model.AppError
+	app := j.app
+
+	action := j.file.Action
+	log := app.Log.With(wlog.Int64("file_id", j.file.FileId),
+		wlog.String("action", action),
+	)
+
+	if file, err = app.Store.File().GetFileWithProfile(j.file.DomainId, j.file.FileId); err != nil {
+		log.Error(fmt.Sprintf("[%s] file %d, error: %s", action, j.file.FileId, err.Error()))
+		j.setError(err)
+		return
+	}
+
+	if j.skip(file) {
+		j.done()
+		return
+	}
+
+	if store, err = app.GetFileBackendStore(file.ProfileId, file.ProfileUpdatedAt); err != nil {
+		log.Error(fmt.Sprintf("[%s] file %d, error: %s", action, j.file.FileId, err.Error()))
+		j.setError(err)
+		return
+	}
+
+	if reader, err = store.Reader(&file.File, 0); err != nil {
+		log.Error(fmt.Sprintf("[%s] file %d, read error: %s", action, j.file.FileId, err.Error()))
+		j.setError(err)
+		return
+	}
+	defer reader.Close()
+
+	target := objectTarget(file)
+	target.Properties.Remove(utils.KeyVersionProperty)
+	target.Properties.Remove(utils.DomainKeyProperty)
+	target.SetEncrypted(action != model.Decrypt)
+
+	// the files that share the old object are rewritten by their own jobs
+	replaced, err = j.replaceObject(file, store, store, reader, &target, func(target *model.File, sum string) (bool, model.AppError) {
+		return app.Store.File().ReplaceObject(file.Id, file.ProfileId, file.Name, target.Name, target.Properties, &sum)
+	})
+	if err != nil {
+		log.Error(fmt.Sprintf("[%s] file %d, rewrite in \"%s\" error: %s", action, j.file.FileId, store.Name(), err.Error()))
+		j.setError(err)
+		return
+	}
+
+	if !replaced {
+		wlog.Debug(fmt.Sprintf("[%s] file %d changed during rewrite, skip", action, j.file.FileId))
+		j.done()
+		return
+	}
+
+	// replicas and stored conversions are encrypted as the old object
+	app.RemoveFileReplicas(&file.File, file.Id)
+	app.ReplicateFile(store, &target)
+	app.RemoveFileConversions(&file.File, file.Id)
+
+	j.done()
+	wlog.Debug(fmt.Sprintf("[%s] file %d rewritten \"%s\" in store \"%s\", encrypted %v", action, j.file.FileId, target.Name,
+		store.Name(), target.IsEncrypted()))
+}
+
+// skip reports whether the file is already written as the job requires
+func (j *rewriteFileJob) skip(file *model.FileWithProfile) bool {
+	switch j.file.Action {
+	case model.Encrypt:
+		return file.IsEncrypted()
+	case model.Decrypt:
+		return !file.IsEncrypted()
+	default:
+		// the files encrypted with the data key of the domain don't depend on the keyring
+		_, ok := utils.FileDomainKey(&file.File)
+		return ok || !file.IsEncrypted() || utils.FileKeyVersion(&file.File) >= j.app.CurrentKeyVersion()
+	}
+}
@@ -0,0 +1,26 @@
+package synchronizer
+
+import (
+	"fmt"
+
+	"github.com/webitel/storage/app"
+	"github.com/webitel/storage/model"
+	"github.com/webitel/wlog"
+)
+
+type subjectRequestJob struct {
+	app  *app.App
+	req  *model.SubjectRequest
+	done chan struct{}
+}
+
+func (j *subjectRequestJob) Execute() {
+	defer func() {
+		<-j.done
+	}()
+
+	wlog.Debug(fmt.Sprintf("subject request %d [%s] started", j.req.Id, j.req.Mode))
+	if err := j.app.ExecuteSubjectRequest(j.req); err != nil {
+		wlog.Error(fmt.Sprintf("subject request %d, error: %s", j.req.Id, err.Error()))
+	}
+}
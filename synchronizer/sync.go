@@ -13,26 +13,43 @@ import (
 )
 
 type synchronizer struct {
-	App             *app.App
-	limit           int
-	schedule        chan struct{}
-	pollingInterval time.Duration
-	stopSignal      chan struct{}
-	pool            interfaces.PoolInterface
-	mx              sync.RWMutex
-	stopped         bool
+	App               *app.App
+	limit             int
+	schedule          chan struct{}
+	pollingInterval   time.Duration
+	tieringInterval   time.Duration
+	lastTiering       time.Time
+	reEncryptInterval time.Duration
+	lastReEncrypt     time.Time
+	subjectInterval   time.Duration
+	lastSubject       time.Time
+	rescanInterval    time.Duration
+	lastRescan        time.Time
+	ttsCacheInterval  time.Duration
+	lastTtsCache      time.Time
+	subjectRequests   chan struct{}
+	stopSignal        chan struct{}
+	pool              interfaces.PoolInterface
+	mx                sync.RWMutex
+	stopped           bool
 }
 
 func init() {
 	app.RegisterSynchronizer(func(a *app.App) interfaces.SynchronizerFilesInterface {
 		wlog.Debug("Initialize synchronizer")
 		return &synchronizer{
-			App:             a,
-			limit:           100,
-			schedule:        make(chan struct{}, 1),
-			stopSignal:      make(chan struct{}),
-			pollingInterval: time.Second * 1,
-			pool:            pool.NewPool(5, 10), //FIXME added config
+			App:               a,
+			limit:             100,
+			schedule:          make(chan struct{}, 1),
+			stopSignal:        make(chan struct{}),
+			pollingInterval:   time.Second * 1,
+			tieringInterval:   time.Minute * 1,
+			reEncryptInterval: time.Minute * 10,
+			subjectInterval:   time.Second * 10,
+			rescanInterval:    time.Minute * 10,
+			ttsCacheInterval:  time.Minute * 1,
+			subjectRequests:   make(chan struct{}, 1),
+			pool:              pool.NewPool(5, 10), //FIXME added config
 		}
 	})
 }
@@ -60,6 +77,39 @@ func (s *synchronizer) run() {
 				wlog.Error(err.Error())
 			}
 
+			if time.Since(s.lastTiering) >= s.tieringInterval {
+				s.lastTiering = time.Now()
+				if err = s.App.SetMigrateFileJobs(); err != nil {
+					wlog.Error(err.Error())
+				}
+			}
+
+			if time.Since(s.lastReEncrypt) >= s.reEncryptInterval {
+				s.lastReEncrypt = time.Now()
+				if err = s.App.SetReEncryptFileJobs(); err != nil {
+					wlog.Error(err.Error())
+				}
+			}
+
+			if time.Since(s.lastRescan) >= s.rescanInterval {
+				s.lastRescan = time.Now()
+				if err = s.App.SetRescanFileJobs(); err != nil {
+					wlog.Error(err.Error())
+				}
+			}
+
+			if time.Since(s.lastTtsCache) >= s.ttsCacheInterval {
+				s.lastTtsCache = time.Now()
+				if err = s.App.EvictTtsCache(); err != nil {
+					wlog.Error(err.Error())
+				}
+			}
+
+			if time.Since(s.lastSubject) >= s.subjectInterval {
+				s.lastSubject = time.Now()
+				s.execSubjectRequest()
+			}
+
 			jobs, err = s.App.FetchFileJobs(s.limit)
 			if err != nil {
 				wlog.Error(err.Error())
@@ -90,6 +140,30 @@ func (s *synchronizer) run() {
 	}
 }
 
+// execSubjectRequest runs the next subject request in the pool, the requests are processed one at a time
+func (s *synchronizer) execSubjectRequest() {
+	select {
+	case s.subjectRequests <- struct{}{}:
+	default:
+		return
+	}
+
+	req, err := s.App.FetchSubjectRequest()
+	if err != nil || req == nil {
+		<-s.subjectRequests
+		if err != nil {
+			wlog.Error(err.Error())
+		}
+		return
+	}
+
+	s.pool.Exec(&subjectRequestJob{
+		app:  s.App,
+		req:  req,
+		done: s.subjectRequests,
+	})
+}
+
 func (s *synchronizer) isStopped() bool {
 	s.mx.RLock()
 	defer s.mx.RUnlock()
@@ -133,6 +207,30 @@ func (s *synchronizer) getTask(src *model.SyncJob) interfaces.TaskInterface {
 			file: *src,
 		}
 
+	case model.Replicate:
+		return &replicateFileJob{fileJob{
+			app:  s.App,
+			file: *src,
+		}}
+
+	case model.Migrate:
+		return &migrateFileJob{fileJob{
+			app:  s.App,
+			file: *src,
+		}}
+
+	case model.ReEncrypt, model.Encrypt, model.Decrypt:
+		return &rewriteFileJob{fileJob{
+			app:  s.App,
+			file: *src,
+		}}
+
+	case model.Rescan:
+		return &rescanFileJob{fileJob{
+			app:  s.App,
+			file: *src,
+		}}
+
 	default:
 		return nil
 	}
@@ -0,0 +1,50 @@
+package tts
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// cacheVoiceSettings are the parameters of the voice that change the synthesized speech,
+// the other parameters of the request (id, token, ...) are not a part of the cache key
+var cacheVoiceSettings = []string{"similarity_boost", "stability", "style", "use_speaker_boost", "model", "apply_text_normalization"}
+
+// CacheKey returns the hash of the normalized request, the requests that synthesize the same speech have the same key.
+// The syncTag of the profile changes the key when the profile is updated
+func (p TTSParams) CacheKey(provider string, profileId int, syncTag int64) string {
+	var b strings.Builder
+	field := func(name, value string) {
+		fmt.Fprintf(&b, "%s=%d:%s\n", name, len(value), value)
+	}
+
+	field("provider", strings.ToLower(provider))
+	field("profile", strconv.Itoa(profileId))
+	field("sync_tag", strconv.FormatInt(syncTag, 10))
+	field("voice", strings.ToLower(p.Voice))
+	field("locale", strings.ToLower(p.Locale))
+	field("language", strings.ToLower(p.Language))
+	field("format", strings.ToLower(p.Format))
+	field("rate", strconv.Itoa(p.Rate))
+	field("speaking_rate", strconv.FormatFloat(p.SpeakingRate, 'g', -1, 64))
+	field("pitch", strconv.FormatFloat(p.Pitch, 'g', -1, 64))
+	field("volume_gain_db", strconv.FormatFloat(p.VolumeGainDb, 'g', -1, 64))
+	field("effects", strings.Join(p.EffectsProfileId, ","))
+	field("background", p.BackgroundNode())
+	field("text_type", strings.ToLower(p.TextType))
+	field("text", strings.Join(strings.Fields(p.Text), " "))
+
+	if p.VoiceSettings != nil {
+		for _, name := range cacheVoiceSettings {
+			if p.VoiceSettings.Has(name) {
+				field(name, p.VoiceSettings.Get(name))
+			}
+		}
+	}
+
+	sum := sha256.Sum256([]byte(b.String()))
+
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,60 @@
+package tts
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestCacheKey(t *testing.T) {
+	base := TTSParams{
+		Id:       "call-1",
+		Token:    "secret",
+		Voice:    "en-US-JennyNeural",
+		Language: "en-US",
+		Format:   "mp3",
+		Text:     "Hello,  thank you for calling.",
+		VoiceSettings: url.Values{
+			"id":        {"call-1"},
+			"stability": {"0.5"},
+		},
+	}
+	key := base.CacheKey("Microsoft", 1, 100)
+
+	same := base
+	same.Id = "call-2"
+	same.Token = "other"
+	same.Voice = "en-us-jennyneural"
+	same.Text = " Hello, thank you\tfor calling. "
+	same.VoiceSettings = url.Values{
+		"id":        {"call-2"},
+		"stability": {"0.5"},
+	}
+	if v := same.CacheKey("microsoft", 1, 100); v != key {
+		t.Errorf("normalized request has other key %s, expected %s", v, key)
+	}
+
+	other := func(name string, p TTSParams, provider string, profileId int, syncTag int64) {
+		if p.CacheKey(provider, profileId, syncTag) == key {
+			t.Errorf("%s: request has the same key", name)
+		}
+	}
+
+	p := base
+	p.Text = "Hello, thank you for calling!"
+	other("text", p, "Microsoft", 1, 100)
+	other("provider", base, "Google", 1, 100)
+	other("profile", base, "Microsoft", 2, 100)
+	other("sync tag", base, "Microsoft", 1, 101)
+
+	p = base
+	p.Format = "wav"
+	other("format", p, "Microsoft", 1, 100)
+
+	p = base
+	p.Rate = 8000
+	other("rate", p, "Microsoft", 1, 100)
+
+	p = base
+	p.VoiceSettings = url.Values{"stability": {"0.7"}}
+	other("voice settings", p, "Microsoft", 1, 100)
+}
@@ -0,0 +1,188 @@
+package tts
+
+import (
+	"strings"
+	"unicode"
+)
+
+// SplitParams splits the long text of the request at the sentence or SSML boundaries, the first chunk is the first sentence
+// to start the playback early, the next chunks are the sentences up to maxChunk characters. The text must be in the dialect of the provider
+func SplitParams(params TTSParams, provider string, maxChunk int) []TTSParams {
+	if maxChunk <= 0 || len(params.Text) <= maxChunk || params.Background != nil {
+		return []TTSParams{params}
+	}
+
+	var texts []string
+	if strings.EqualFold(params.TextType, TextTypeSSML) {
+		doc, err := ParseSSML(params.Text)
+		if err != nil {
+			return []TTSParams{params}
+		}
+		for _, group := range groupUnits(doc.units(), maxChunk) {
+			chunk := &SSMLNode{Name: doc.Name, Attrs: doc.Attrs, Children: group}
+			text, _ := chunk.Render(provider)
+			texts = append(texts, text)
+		}
+	} else {
+		var units [][]*SSMLNode
+		for _, s := range splitSentences(params.Text) {
+			if strings.TrimSpace(s) != "" {
+				units = append(units, []*SSMLNode{{Text: s}})
+			}
+		}
+		for _, group := range groupUnits(units, maxChunk) {
+			var b strings.Builder
+			for _, n := range group {
+				b.WriteString(n.Text)
+			}
+			texts = append(texts, strings.TrimSpace(b.String()))
+		}
+	}
+
+	if len(texts) < 2 {
+		return []TTSParams{params}
+	}
+
+	chunks := make([]TTSParams, 0, len(texts))
+	for _, text := range texts {
+		p := params
+		p.Text = text
+		chunks = append(chunks, p)
+	}
+
+	return chunks
+}
+
+// units returns the sentences of the document, p, s and break end the sentence
+func (n *SSMLNode) units() [][]*SSMLNode {
+	var units [][]*SSMLNode
+	var cur []*SSMLNode
+	flush := func() {
+		for _, c := range cur {
+			if c.Name != "" || strings.TrimSpace(c.Text) != "" {
+				units = append(units, cur)
+				break
+			}
+		}
+		cur = nil
+	}
+
+	for _, c := range n.Children {
+		switch {
+		case c.Name == "":
+			sentences := splitSentences(c.Text)
+			for i, s := range sentences {
+				cur = append(cur, &SSMLNode{Text: s})
+				if i < len(sentences)-1 || endsSentence(s) {
+					flush()
+				}
+			}
+		case c.Space == "" && (c.Name == "p" || c.Name == "s"):
+			flush()
+			cur = append(cur, c)
+			flush()
+		case c.Space == "" && c.Name == "break":
+			cur = append(cur, c)
+			flush()
+		default:
+			cur = append(cur, c)
+		}
+	}
+	flush()
+
+	return units
+}
+
+func groupUnits(units [][]*SSMLNode, maxChunk int) [][]*SSMLNode {
+	var groups [][]*SSMLNode
+	var cur []*SSMLNode
+	var curLen int
+
+	for i, u := range units {
+		l := ssmlUnitLen(u)
+		if len(cur) != 0 && curLen+l > maxChunk {
+			groups = append(groups, cur)
+			cur, curLen = nil, 0
+		}
+		cur = append(cur, u...)
+		curLen += l
+		if i == 0 {
+			// the first sentence is synthesized alone
+			groups = append(groups, cur)
+			cur, curLen = nil, 0
+		}
+	}
+	if len(cur) != 0 {
+		groups = append(groups, cur)
+	}
+
+	return groups
+}
+
+func ssmlUnitLen(nodes []*SSMLNode) int {
+	var l int
+	for _, n := range nodes {
+		l += len(n.Text)
+		for _, a := range n.Attrs {
+			l += len(a.Value)
+		}
+		l += ssmlUnitLen(n.Children)
+	}
+
+	return l
+}
+
+// splitSentences splits the text after the end of the sentence or the line, the parts keep the separators
+func splitSentences(text string) []string {
+	var parts []string
+	var start int
+	runes := []rune(text)
+	pos := 0
+
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		size := len(string(r))
+		if r == '\n' || (isTerminator(r) && (i+1 == len(runes) || unicode.IsSpace(runes[i+1]))) {
+			// the separator is kept with the sentence
+			end := pos + size
+			for i+1 < len(runes) && (isTerminator(runes[i+1]) || unicode.IsSpace(runes[i+1])) {
+				i++
+				end += len(string(runes[i]))
+			}
+			parts = append(parts, text[start:end])
+			start = end
+			pos = end
+			continue
+		}
+		pos += size
+	}
+
+	if start < len(text) {
+		parts = append(parts, text[start:])
+	}
+
+	return parts
+}
+
+func endsSentence(s string) bool {
+	trimmed := strings.TrimRightFunc(s, unicode.IsSpace)
+	if trimmed == "" {
+		return false
+	}
+	if strings.ContainsRune(s[len(trimmed):], '\n') {
+		return true
+	}
+	s = trimmed
+	r := []rune(s)
+
+	return isTerminator(r[len(r)-1])
+}
+
+func isTerminator(r rune) bool {
+	switch r {
+	case '.', '!', '?', ';', '…', '。', '！', '？':
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,149 @@
+package tts
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/webitel/wlog"
+)
+
+var ErrTimeout = errors.New("tts timeout")
+
+type Synthesizer func(TTSParams) (io.ReadCloser, *string, *int, error)
+
+// Candidate is the provider of the failover chain with the parameters of its profile
+type Candidate struct {
+	Provider  string
+	ProfileId int
+	Params    TTSParams
+	Synth     Synthesizer
+}
+
+// CandidateFunc resolves the candidate when the previous candidates failed, so the profiles of the fallback
+// are not loaded while the primary provider answers. The nil candidate is skipped
+type CandidateFunc func() *Candidate
+
+// Resolved returns the candidate that doesn't need to be resolved
+func Resolved(c Candidate) CandidateFunc {
+	return func() *Candidate {
+		return &c
+	}
+}
+
+// Speech is the synthesized speech and the provider that answered
+type Speech struct {
+	Body      io.ReadCloser
+	MimeType  *string
+	Size      *int
+	Provider  string
+	ProfileId int
+}
+
+type synthResult struct {
+	body io.ReadCloser
+	mime *string
+	size *int
+	err  error
+}
+
+// candidateQueue resolves the candidates one by one, the nil candidates are skipped
+type candidateQueue []CandidateFunc
+
+func (q *candidateQueue) next() *Candidate {
+	for len(*q) != 0 {
+		resolve := (*q)[0]
+		*q = (*q)[1:]
+		if c := resolve(); c != nil {
+			return c
+		}
+	}
+
+	return nil
+}
+
+// Failover synthesizes the speech by the first candidate that answers, the next candidate is used on error
+// or when the candidate doesn't answer in the timeout. The next candidate is resolved when the timeout expires,
+// the candidate is waited without the timeout when there is no next one
+func Failover(candidates []CandidateFunc, timeout time.Duration) (*Speech, error) {
+	var err error
+	queue := candidateQueue(candidates)
+	failed := false
+
+	for c := queue.next(); c != nil; failed = true {
+		res, next := synthesize(*c, timeout, queue.next)
+		if res.err == nil {
+			if failed {
+				wlog.Info(fmt.Sprintf("[tts] failover to provider %s (profile %d)", c.Provider, c.ProfileId))
+			}
+			return &Speech{
+				Body:      res.body,
+				MimeType:  res.mime,
+				Size:      res.size,
+				Provider:  c.Provider,
+				ProfileId: c.ProfileId,
+			}, nil
+		}
+
+		err = res.err
+		if next == nil {
+			next = queue.next()
+		}
+		if next != nil {
+			wlog.Warn(fmt.Sprintf("[tts] provider %s (profile %d) error: %s, try next", c.Provider, c.ProfileId, err.Error()))
+		}
+		c = next
+	}
+
+	if err == nil {
+		err = errors.New("no tts provider")
+	}
+
+	return nil, err
+}
+
+// synthesize waits the speech of the candidate, when the timeout expires the next candidate is resolved by next
+// and returned with ErrTimeout. Without the next candidate the speech is waited further
+func synthesize(c Candidate, timeout time.Duration, next func() *Candidate) (synthResult, *Candidate) {
+	ch := make(chan synthResult, 1)
+	go func() {
+		var res synthResult
+		res.body, res.mime, res.size, res.err = c.Synth(c.Params)
+		ch <- res
+	}()
+
+	if timeout <= 0 {
+		return <-ch, nil
+	}
+
+	select {
+	case res := <-ch:
+		return res, nil
+	case <-time.After(timeout):
+	}
+
+	n := next()
+	if n == nil {
+		return <-ch, nil
+	}
+
+	select {
+	case res := <-ch:
+		// the speech is received while the next candidate was resolved
+		if res.err == nil {
+			return res, nil
+		}
+		return res, n
+	default:
+	}
+
+	// the late speech is dropped
+	go func() {
+		if res := <-ch; res.body != nil {
+			res.body.Close()
+		}
+	}()
+
+	return synthResult{err: ErrTimeout}, n
+}
@@ -0,0 +1,131 @@
+package tts
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// fakeProvider synthesizes the speech by the local http server of the provider
+func fakeProvider(t *testing.T, handler http.HandlerFunc) Synthesizer {
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	return func(params TTSParams) (io.ReadCloser, *string, *int, error) {
+		res, err := http.Get(srv.URL + "/?voice=" + params.Voice)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if res.StatusCode != http.StatusOK {
+			res.Body.Close()
+			return nil, nil, nil, fmt.Errorf("status %d", res.StatusCode)
+		}
+		ct := res.Header.Get("Content-Type")
+
+		return res.Body, &ct, nil, nil
+	}
+}
+
+func TestFailover(t *testing.T) {
+	down := fakeProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	slow := fakeProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(time.Millisecond * 300)
+		w.Write([]byte("slow"))
+	})
+	ok := fakeProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "audio/wav")
+		w.Write([]byte("voice " + r.URL.Query().Get("voice")))
+	})
+
+	tests := []struct {
+		name       string
+		candidates []CandidateFunc
+		provider   string
+		body       string
+		err        bool
+	}{
+		{
+			name:       "primary",
+			candidates: []CandidateFunc{Resolved(Candidate{Provider: "microsoft", Synth: ok, Params: TTSParams{Voice: "jenny"}}), Resolved(Candidate{Provider: "google", Synth: down})},
+			provider:   "microsoft",
+			body:       "voice jenny",
+		},
+		{
+			name:       "error",
+			candidates: []CandidateFunc{Resolved(Candidate{Provider: "microsoft", Synth: down}), Resolved(Candidate{Provider: "google", Synth: ok, Params: TTSParams{Voice: "FEMALE"}})},
+			provider:   "google",
+			body:       "voice FEMALE",
+		},
+		{
+			name:       "timeout",
+			candidates: []CandidateFunc{Resolved(Candidate{Provider: "microsoft", Synth: slow}), Resolved(Candidate{Provider: "google", Synth: down}), Resolved(Candidate{Provider: "polly", Synth: ok})},
+			provider:   "polly",
+			body:       "voice ",
+		},
+		{
+			name:       "last without timeout",
+			candidates: []CandidateFunc{Resolved(Candidate{Provider: "microsoft", Synth: down}), Resolved(Candidate{Provider: "google", Synth: slow})},
+			provider:   "google",
+			body:       "slow",
+		},
+		{
+			name:       "no timeout before the skipped fallback",
+			candidates: []CandidateFunc{Resolved(Candidate{Provider: "microsoft", Synth: slow}), func() *Candidate { return nil }},
+			provider:   "microsoft",
+			body:       "slow",
+		},
+		{
+			name:       "skip fallback",
+			candidates: []CandidateFunc{Resolved(Candidate{Provider: "microsoft", Synth: down}), func() *Candidate { return nil }, Resolved(Candidate{Provider: "polly", Synth: ok})},
+			provider:   "polly",
+			body:       "voice ",
+		},
+		{
+			name:       "all down",
+			candidates: []CandidateFunc{Resolved(Candidate{Provider: "microsoft", Synth: down}), Resolved(Candidate{Provider: "google", Synth: down})},
+			err:        true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			speech, err := Failover(tt.candidates, time.Millisecond*100)
+			if tt.err {
+				if err == nil {
+					speech.Body.Close()
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer speech.Body.Close()
+
+			body, _ := io.ReadAll(speech.Body)
+			if speech.Provider != tt.provider || string(body) != tt.body {
+				t.Errorf("provider %s %q, expected %s %q", speech.Provider, body, tt.provider, tt.body)
+			}
+		})
+	}
+}
+
+func TestFailoverLazy(t *testing.T) {
+	ok := fakeProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("voice"))
+	})
+
+	speech, err := Failover([]CandidateFunc{Resolved(Candidate{Provider: "microsoft", Synth: ok}), func() *Candidate {
+		t.Error("fallback is resolved while the primary provider answers")
+		return nil
+	}}, time.Millisecond*100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	speech.Body.Close()
+}
@@ -0,0 +1,486 @@
+package tts
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/webitel/storage/model"
+	"github.com/webitel/storage/utils"
+)
+
+const (
+	LocalApiPiper = "piper"
+	LocalApiCoqui = "coqui"
+
+	localModelExt       = ".onnx"
+	localWavRate        = 8000
+	localUlawRate       = 8000
+	localPiperRate      = 22050
+	localMaxErrorBody   = 1024
+	localDefaultTimeout = time.Minute
+)
+
+var localEngine = model.TtsLocalSettings{
+	Api:     LocalApiPiper,
+	Timeout: localDefaultTimeout,
+}
+
+// SetLocalEngine sets the offline engine of the Local provider
+func SetLocalEngine(settings model.TtsLocalSettings) {
+	if settings.Api == "" {
+		settings.Api = LocalApiPiper
+	}
+	if settings.Timeout <= 0 {
+		settings.Timeout = localDefaultTimeout
+	}
+	localEngine = settings
+}
+
+// localVoiceConfig is the config of the piper voice model <voice>.onnx.json, the piper server lists the voices with it
+type localVoiceConfig struct {
+	Audio struct {
+		SampleRate int `json:"sample_rate"`
+	} `json:"audio"`
+	Language struct {
+		Code        string `json:"code"`
+		NameEnglish string `json:"name_english"`
+	} `json:"language"`
+}
+
+// localRequest is the request of the piper server, speaker is the name or the id of the speaker of the multi speaker model
+type localRequest struct {
+	Text        string   `json:"text"`
+	Voice       string   `json:"voice,omitempty"`
+	Speaker     string   `json:"speaker,omitempty"`
+	SpeakerId   *int     `json:"speaker_id,omitempty"`
+	LengthScale *float64 `json:"length_scale,omitempty"`
+
+	speaker  string
+	language string
+}
+
+type localSpeech struct {
+	io.Reader
+	close func() error
+}
+
+func (s *localSpeech) Close() error {
+	return s.close()
+}
+
+// Local synthesizes the speech by the offline engine of the service (config tts_local_address). The voice is the model of piper
+// or the speaker of coqui, the speaker of the multi speaker piper model is set by the voice setting speaker. The speaking rate
+// is the length scale of piper. The speech is converted to the format and the sample rate of the request
+func Local(params TTSParams) (io.ReadCloser, *string, *int, error) {
+	engine := localEngine
+	if engine.Address == "" {
+		return nil, nil, nil, errors.New("local tts engine is not configured")
+	}
+
+	req, err := newLocalRequest(params, engine)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), engine.Timeout)
+	var src io.ReadCloser
+	var f pcmFormat
+	if isLocalHttp(engine.Address) {
+		src, f, err = localHttp(ctx, engine, req)
+	} else {
+		src, f, err = localProcess(ctx, engine, req)
+	}
+	if err != nil {
+		cancel()
+		return nil, nil, nil, err
+	}
+
+	body, mime, err := convertSpeech(src, f, params.Format, params.Rate)
+	if err != nil {
+		cancel()
+		src.Close()
+		return nil, nil, nil, err
+	}
+
+	return &localSpeech{
+		Reader: body,
+		close: func() error {
+			// the engine is stopped when the speech is not read to the end
+			defer cancel()
+			body.Close()
+			return src.Close()
+		},
+	}, &mime, nil, nil
+}
+
+func newLocalRequest(params TTSParams, engine model.TtsLocalSettings) (localRequest, error) {
+	req := localRequest{
+		Text:     strings.TrimSpace(params.Text),
+		Voice:    params.Voice,
+		language: params.Language,
+	}
+	if req.Text == "" {
+		return req, errors.New("empty text")
+	}
+	if req.Voice == "" {
+		req.Voice = engine.Voice
+	}
+
+	if speaker := params.VoiceSettings.Get("speaker"); speaker != "" {
+		req.speaker = speaker
+		if id, err := strconv.Atoi(speaker); err == nil {
+			req.SpeakerId = &id
+		} else {
+			req.Speaker = speaker
+		}
+	}
+
+	var scale float64
+	if params.SpeakingRate > 0 {
+		scale = 1 / params.SpeakingRate
+	}
+	if v := params.VoiceSettings.Get("length_scale"); v != "" {
+		scale, _ = strconv.ParseFloat(v, 64)
+	}
+	if scale > 0 {
+		req.LengthScale = &scale
+	}
+
+	return req, nil
+}
+
+func isLocalHttp(address string) bool {
+	address = strings.ToLower(address)
+	return strings.HasPrefix(address, "http://") || strings.HasPrefix(address, "https://")
+}
+
+func localUrl(address, path string) (string, error) {
+	u, err := url.ParseRequestURI(address)
+	if err != nil {
+		return "", err
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/") + path
+
+	return u.String(), nil
+}
+
+// localHttp requests the wav of the Piper or Coqui HTTP server
+func localHttp(ctx context.Context, engine model.TtsLocalSettings, req localRequest) (io.ReadCloser, pcmFormat, error) {
+	var r *http.Request
+	var f pcmFormat
+
+	switch strings.ToLower(engine.Api) {
+	case LocalApiPiper:
+		u, err := localUrl(engine.Address, "/")
+		if err != nil {
+			return nil, f, err
+		}
+		data, err := json.Marshal(&req)
+		if err != nil {
+			return nil, f, err
+		}
+		if r, err = http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(data)); err != nil {
+			return nil, f, err
+		}
+		r.Header.Set("Content-Type", "application/json")
+	case LocalApiCoqui:
+		u, err := localUrl(engine.Address, "/api/tts")
+		if err != nil {
+			return nil, f, err
+		}
+		speaker := req.speaker
+		if speaker == "" {
+			speaker = req.Voice
+		}
+		query := url.Values{}
+		query.Set("text", req.Text)
+		query.Set("speaker_id", speaker)
+		query.Set("language_id", req.language)
+		query.Set("style_wav", "")
+		if r, err = http.NewRequestWithContext(ctx, http.MethodGet, u+"?"+query.Encode(), nil); err != nil {
+			return nil, f, err
+		}
+	default:
+		return nil, f, fmt.Errorf("unsupported local tts api \"%s\"", engine.Api)
+	}
+
+	res, err := http.DefaultClient.Do(r)
+	if err != nil {
+		return nil, f, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		defer res.Body.Close()
+		body, _ := io.ReadAll(io.LimitReader(res.Body, localMaxErrorBody))
+		return nil, f, fmt.Errorf("local tts engine status %d: %s", res.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	br := bufio.NewReader(res.Body)
+	if f, err = readWavHeader(br); err != nil {
+		res.Body.Close()
+		return nil, f, err
+	}
+
+	return &localSpeech{Reader: br, close: res.Body.Close}, f, nil
+}
+
+// localProcess runs the piper binary with the model of the voice, the raw samples of the model rate are read from stdout
+func localProcess(ctx context.Context, engine model.TtsLocalSettings, req localRequest) (io.ReadCloser, pcmFormat, error) {
+	f := pcmFormat{rate: localPiperRate, channels: 1, size: -1}
+
+	// the voice is the name of the model in the directory
+	if req.Voice == "" || req.Voice != filepath.Base(req.Voice) || strings.HasPrefix(req.Voice, ".") {
+		return nil, f, fmt.Errorf("bad local tts voice \"%s\"", req.Voice)
+	}
+
+	modelFile := filepath.Join(engine.Models, req.Voice+localModelExt)
+	cfg, err := readLocalVoiceConfig(modelFile + ".json")
+	if err != nil {
+		return nil, f, fmt.Errorf("local tts voice %s: %w", req.Voice, err)
+	}
+	if cfg.Audio.SampleRate > 0 {
+		f.rate = cfg.Audio.SampleRate
+	}
+
+	args := []string{"--model", modelFile, "--output_raw"}
+	if req.SpeakerId != nil {
+		args = append(args, "--speaker", strconv.Itoa(*req.SpeakerId))
+	}
+	if req.LengthScale != nil {
+		args = append(args, "--length_scale", strconv.FormatFloat(*req.LengthScale, 'f', -1, 64))
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, engine.Address, args...)
+	cmd.Stdin = strings.NewReader(req.Text + "\n")
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, f, err
+	}
+	if err = cmd.Start(); err != nil {
+		return nil, f, err
+	}
+
+	br := bufio.NewReader(stdout)
+	if _, err = br.Peek(1); err != nil {
+		// the process ended without the speech
+		if wErr := cmd.Wait(); wErr != nil {
+			err = wErr
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, f, fmt.Errorf("piper: %s: %s", err.Error(), msg)
+		}
+		return nil, f, fmt.Errorf("piper: %w", err)
+	}
+
+	body := &eofReader{r: br}
+	return &localSpeech{
+		Reader: body,
+		close: func() error {
+			if !body.eof.Load() {
+				// the speech is not read to the end, the result of the process is not needed
+				cmd.Process.Kill()
+				cmd.Wait()
+				return nil
+			}
+			// the process that failed after the output is closed may have written the part of the speech
+			if err := cmd.Wait(); err != nil {
+				if msg := strings.TrimSpace(stderr.String()); msg != "" {
+					return fmt.Errorf("piper: %s: %s", err.Error(), msg)
+				}
+				return fmt.Errorf("piper: %w", err)
+			}
+			return nil
+		},
+	}, f, nil
+}
+
+// eofReader reports whether the reader is read to the end, the speech may be read by the encoder in another goroutine
+type eofReader struct {
+	r   io.Reader
+	eof atomic.Bool
+}
+
+func (r *eofReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if err == io.EOF {
+		r.eof.Store(true)
+	}
+
+	return n, err
+}
+
+func readLocalVoiceConfig(name string) (localVoiceConfig, error) {
+	var cfg localVoiceConfig
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return cfg, err
+	}
+	err = json.Unmarshal(data, &cfg)
+
+	return cfg, err
+}
+
+// convertSpeech converts the 16 bit PCM of the engine to the format of the request: wav and ulaw are resampled to the rate
+// of the request (8000 by default), mp3 and ogg are encoded by ffmpeg
+func convertSpeech(src io.Reader, f pcmFormat, format string, rate int) (io.ReadCloser, string, error) {
+	if f.size >= 0 {
+		// the chunks after the samples are skipped
+		src = io.LimitReader(src, f.size)
+	}
+
+	switch strings.ToLower(format) {
+	case "ulaw":
+		return io.NopCloser(newResampler(src, f, localUlawRate, encodeUlaw)), "audio/ulaw", nil
+	case model.AudioFormatMp3, model.AudioFormatOgg:
+		if rate <= 0 {
+			rate = f.rate
+		}
+		audio := &model.AudioFormat{
+			Codec:    strings.ToLower(format),
+			Channels: model.AudioChannelsMono,
+		}
+		mime := "audio/mpeg"
+		if audio.Codec == model.AudioFormatOgg {
+			mime = "audio/ogg"
+		}
+
+		pr, pw := io.Pipe()
+		t, err := utils.NewAudioTranscoding(pcmToWav(src, f, rate), pw, audio)
+		if err != nil {
+			return nil, "", err
+		}
+		if err = t.Start(); err != nil {
+			return nil, "", err
+		}
+		go func() {
+			pw.CloseWithError(t.Wait())
+		}()
+
+		return pr, mime, nil
+	default:
+		if rate <= 0 {
+			rate = localWavRate
+		}
+		return io.NopCloser(pcmToWav(src, f, rate)), "audio/wav", nil
+	}
+}
+
+// pcmToWav returns the mono wav of the rate, the size of the header is unknown when the engine streams the samples
+func pcmToWav(src io.Reader, f pcmFormat, rate int) io.Reader {
+	size := int64(-1)
+	if f.size >= 0 {
+		size = resampledSamples(f.size/int64(2*f.channels), f.rate, rate) * 2
+	}
+
+	if f.channels != 1 || f.rate != rate {
+		src = newResampler(src, f, rate, encodePCM16)
+	}
+
+	return io.MultiReader(bytes.NewReader(wavHeader(rate, size)), src)
+}
+
+// LocalVoices returns the voices of the offline engine that contain q: the voices of the piper server
+// or the models of the directory of the piper binary
+func LocalVoices(q string) ([]*model.CognitiveProfileVoice, error) {
+	engine := localEngine
+	var configs map[string]localVoiceConfig
+	var err error
+
+	switch {
+	case engine.Address == "":
+		return nil, errors.New("local tts engine is not configured")
+	case isLocalHttp(engine.Address):
+		if !strings.EqualFold(engine.Api, LocalApiPiper) {
+			return nil, fmt.Errorf("local tts api \"%s\" doesn't list the voices", engine.Api)
+		}
+		configs, err = piperVoices(engine)
+	default:
+		configs, err = modelVoices(engine.Models)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	q = strings.ToLower(q)
+	voices := make([]*model.CognitiveProfileVoice, 0, len(configs))
+	for id, cfg := range configs {
+		name := id
+		if cfg.Language.NameEnglish != "" {
+			name = fmt.Sprintf("%s (%s)", id, cfg.Language.NameEnglish)
+		}
+		if q != "" && !strings.Contains(strings.ToLower(name), q) {
+			continue
+		}
+		voices = append(voices, &model.CognitiveProfileVoice{
+			Id:   id,
+			Name: name,
+		})
+	}
+
+	sort.Slice(voices, func(i, j int) bool {
+		return voices[i].Id < voices[j].Id
+	})
+
+	return voices, nil
+}
+
+func piperVoices(engine model.TtsLocalSettings) (map[string]localVoiceConfig, error) {
+	u, err := localUrl(engine.Address, "/voices")
+	if err != nil {
+		return nil, err
+	}
+
+	client := &http.Client{Timeout: engine.Timeout}
+	res, err := client.Get(u)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("local tts engine status %d", res.StatusCode)
+	}
+
+	var configs map[string]localVoiceConfig
+	if err = json.NewDecoder(res.Body).Decode(&configs); err != nil {
+		return nil, err
+	}
+
+	return configs, nil
+}
+
+func modelVoices(dir string) (map[string]localVoiceConfig, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	configs := make(map[string]localVoiceConfig)
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), localModelExt) {
+			continue
+		}
+		// the model without the config is listed without the language
+		cfg, _ := readLocalVoiceConfig(filepath.Join(dir, e.Name()+".json"))
+		configs[strings.TrimSuffix(e.Name(), localModelExt)] = cfg
+	}
+
+	return configs, nil
+}
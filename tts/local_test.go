@@ -0,0 +1,378 @@
+package tts
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/webitel/storage/model"
+)
+
+func pcm16(samples ...int16) []byte {
+	var b []byte
+	return encodePCM16(b, samples)
+}
+
+func readPcm16(t *testing.T, data []byte) []int16 {
+	t.Helper()
+	if len(data)%2 != 0 {
+		t.Fatalf("odd pcm length %d", len(data))
+	}
+	samples := make([]int16, len(data)/2)
+	for i := range samples {
+		samples[i] = int16(binary.LittleEndian.Uint16(data[i*2:]))
+	}
+
+	return samples
+}
+
+func TestResampler(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   pcmFormat
+		rate     int
+		src      []byte
+		expected []int16
+	}{
+		{
+			name:     "downsample",
+			format:   pcmFormat{rate: 16000, channels: 1},
+			rate:     8000,
+			src:      pcm16(300, 300, 300, 300, 300, 300, 300),
+			expected: []int16{300, 300, 300, 300},
+		},
+		{
+			name:     "upsample",
+			format:   pcmFormat{rate: 8000, channels: 1},
+			rate:     16000,
+			src:      pcm16(0, 100, -100),
+			expected: []int16{0, 50, 100, 0, -100, -100},
+		},
+		{
+			name:     "stereo",
+			format:   pcmFormat{rate: 8000, channels: 2},
+			rate:     8000,
+			src:      append(pcm16(100, 300, -100, -300), 1),
+			expected: []int16{200, -200},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// the source is read by one byte to check the partial frames
+			out, err := io.ReadAll(newResampler(&oneByteReader{r: bytes.NewReader(tt.src)}, tt.format, tt.rate, encodePCM16))
+			if err != nil {
+				t.Fatal(err)
+			}
+			samples := readPcm16(t, out)
+			if len(samples) != len(tt.expected) {
+				t.Fatalf("samples %v, expected %v", samples, tt.expected)
+			}
+			for i := range samples {
+				if samples[i] != tt.expected[i] {
+					t.Fatalf("samples %v, expected %v", samples, tt.expected)
+				}
+			}
+			frames := int64(len(tt.src) / (2 * tt.format.channels))
+			if n := resampledSamples(frames, tt.format.rate, tt.rate); n != int64(len(samples)) {
+				t.Errorf("resampled size %d, samples %d", n, len(samples))
+			}
+		})
+	}
+}
+
+// TestResamplerLowpass checks the spectrum of the downsampled tones: the tone above the half of the target rate
+// is filtered, the tone of the speech band passes
+func TestResamplerLowpass(t *testing.T) {
+	const from, to = 22050, 8000
+
+	tests := []struct {
+		freq float64
+		pass bool
+	}{
+		{freq: 1000, pass: true},
+		{freq: 3000, pass: true},
+		{freq: 5000},
+		{freq: 7000},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%.0fHz", tt.freq), func(t *testing.T) {
+			const amplitude = 10000
+			samples := make([]int16, from)
+			for i := range samples {
+				samples[i] = int16(amplitude * math.Sin(2*math.Pi*tt.freq*float64(i)/from))
+			}
+
+			out, err := io.ReadAll(newResampler(bytes.NewReader(pcm16(samples...)), pcmFormat{rate: from, channels: 1}, to, encodePCM16))
+			if err != nil {
+				t.Fatal(err)
+			}
+			res := readPcm16(t, out)
+			if n := resampledSamples(int64(len(samples)), from, to); n != int64(len(res)) {
+				t.Fatalf("resampled size %d, samples %d", n, len(res))
+			}
+
+			// the level of the whole spectrum of the output without the edges, the folded tone is counted as well
+			var sum float64
+			body := res[to/10 : len(res)-to/10]
+			for _, v := range body {
+				sum += float64(v) * float64(v)
+			}
+			gain := math.Sqrt(sum/float64(len(body))) / (amplitude / math.Sqrt2)
+
+			if tt.pass && (gain < 0.9 || gain > 1.1) {
+				t.Errorf("gain %.3f of the tone in the band", gain)
+			}
+			if !tt.pass && gain > 0.01 {
+				t.Errorf("gain %.3f of the tone above %d Hz", gain, to/2)
+			}
+		})
+	}
+}
+
+type oneByteReader struct {
+	r io.Reader
+}
+
+func (o *oneByteReader) Read(p []byte) (int, error) {
+	if len(p) > 1 {
+		p = p[:1]
+	}
+	return o.r.Read(p)
+}
+
+func TestLinearToUlaw(t *testing.T) {
+	tests := map[int16]byte{
+		0:      0xFF,
+		-1:     0x7F,
+		32767:  0x80,
+		-32768: 0x00,
+		1000:   0xCE,
+	}
+	for s, expected := range tests {
+		if v := linearToUlaw(s); v != expected {
+			t.Errorf("ulaw of %d is 0x%02X, expected 0x%02X", s, v, expected)
+		}
+	}
+}
+
+func setTestLocalEngine(t *testing.T, settings model.TtsLocalSettings) {
+	t.Helper()
+	prev := localEngine
+	SetLocalEngine(settings)
+	t.Cleanup(func() {
+		localEngine = prev
+	})
+}
+
+func readTestWav(t *testing.T, r io.Reader) (pcmFormat, []int16) {
+	t.Helper()
+	br := bufio.NewReader(r)
+	f, err := readWavHeader(br)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(br)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.size >= 0 && f.size != int64(len(data)) {
+		t.Errorf("wav data size %d, read %d", f.size, len(data))
+	}
+
+	return f, readPcm16(t, data)
+}
+
+func TestLocalPiperHttp(t *testing.T) {
+	samples := pcm16(300, 300, 300, 300, 300, 300)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/voices":
+			w.Write([]byte(`{"en_US-lessac-medium": {"language": {"code": "en_US", "name_english": "English"}}, "uk_UA-ukrainian_tts-medium": {}}`))
+			return
+		case "/":
+		default:
+			http.NotFound(w, r)
+			return
+		}
+
+		var req map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+		}
+		if req["text"] != "Hello" || req["voice"] != "en_US-lessac-medium" || req["length_scale"] != 0.5 || req["speaker_id"] != 3.0 {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(req)
+			return
+		}
+		w.Header().Set("Content-Type", "audio/wav")
+		w.Write(wavHeader(16000, int64(len(samples))))
+		w.Write(samples)
+		// the chunk after the samples is not audio
+		w.Write([]byte("LIST\x04\x00\x00\x00INFO"))
+	}))
+	defer srv.Close()
+
+	setTestLocalEngine(t, model.TtsLocalSettings{Address: srv.URL, Voice: "en_US-lessac-medium"})
+
+	params := TTSParams{
+		Text:          " Hello ",
+		SpeakingRate:  2,
+		VoiceSettings: map[string][]string{"speaker": {"3"}},
+	}
+	body, mime, _, err := Local(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer body.Close()
+
+	if *mime != "audio/wav" {
+		t.Errorf("mime %s", *mime)
+	}
+	f, out := readTestWav(t, body)
+	if f.rate != localWavRate || f.channels != 1 {
+		t.Errorf("format %+v", f)
+	}
+	if len(out) != 3 || out[0] != 300 || out[2] != 300 {
+		t.Errorf("samples %v", out)
+	}
+
+	params.Format = "ulaw"
+	body, mime, _, err = Local(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(body)
+	body.Close()
+	if *mime != "audio/ulaw" || !bytes.Equal(data, bytes.Repeat([]byte{linearToUlaw(300)}, 3)) {
+		t.Errorf("ulaw %s %x", *mime, data)
+	}
+
+	params.VoiceSettings = nil
+	if _, _, _, err = Local(params); err == nil || !strings.Contains(err.Error(), "status 400") {
+		t.Errorf("expected engine error, got %v", err)
+	}
+
+	voices, err := LocalVoices("english")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(voices) != 1 || voices[0].Id != "en_US-lessac-medium" || voices[0].Name != "en_US-lessac-medium (English)" {
+		t.Errorf("voices %+v", voices)
+	}
+}
+
+func TestLocalCoquiHttp(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if r.URL.Path != "/api/tts" || q.Get("text") != "Hello" || q.Get("speaker_id") != "p225" || q.Get("language_id") != "en" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(wavHeader(8000, 4))
+		w.Write(pcm16(10, 20))
+	}))
+	defer srv.Close()
+
+	setTestLocalEngine(t, model.TtsLocalSettings{Address: srv.URL + "/", Api: LocalApiCoqui})
+
+	body, _, _, err := Local(TTSParams{Text: "Hello", Voice: "p225", Language: "en", Rate: 8000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer body.Close()
+
+	if _, out := readTestWav(t, body); len(out) != 2 || out[0] != 10 || out[1] != 20 {
+		t.Errorf("samples %v", out)
+	}
+
+	if _, err = LocalVoices(""); err == nil {
+		t.Error("coqui voices must not be listed")
+	}
+}
+
+func TestLocalProcess(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell script")
+	}
+
+	dir := t.TempDir()
+	models := filepath.Join(dir, "models")
+	os.Mkdir(models, 0o755)
+	os.WriteFile(filepath.Join(models, "uk_UA-test-medium.onnx"), nil, 0o644)
+	os.WriteFile(filepath.Join(models, "uk_UA-test-medium.onnx.json"), []byte(`{"audio": {"sample_rate": 16000}, "language": {"name_english": "Ukrainian"}}`), 0o644)
+	os.WriteFile(filepath.Join(models, "en_US-test-low.onnx"), nil, 0o644)
+
+	// the fake piper writes its arguments and the text, the samples 256, 256, 256, 256 are the raw output
+	args := filepath.Join(dir, "args")
+	piper := filepath.Join(dir, "piper")
+	script := "#!/bin/sh\necho \"$@\" > " + args + "\ncat >> " + args + "\nif [ -n \"$FAIL\" ]; then echo bad model >&2; exit 1; fi\nprintf '\\000\\001\\000\\001\\000\\001\\000\\001'\nif [ -n \"$FAIL_LATE\" ]; then echo model crashed >&2; exit 1; fi\n"
+	if err := os.WriteFile(piper, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	setTestLocalEngine(t, model.TtsLocalSettings{Address: piper, Models: models})
+
+	body, _, _, err := Local(TTSParams{Text: "Привіт", Voice: "uk_UA-test-medium", VoiceSettings: map[string][]string{"length_scale": {"1.2"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, out := readTestWav(t, body)
+	if err = body.Close(); err != nil {
+		t.Errorf("close error %v", err)
+	}
+
+	if f.rate != localWavRate || f.size != -1 {
+		t.Errorf("format %+v", f)
+	}
+	if len(out) != 2 || out[0] != 256 || out[1] != 256 {
+		t.Errorf("samples %v", out)
+	}
+
+	data, _ := os.ReadFile(args)
+	expected := "--model " + filepath.Join(models, "uk_UA-test-medium.onnx") + " --output_raw --length_scale 1.2\nПривіт\n"
+	if string(data) != expected {
+		t.Errorf("piper args %q, expected %q", data, expected)
+	}
+
+	if _, _, _, err = Local(TTSParams{Text: "Hello", Voice: "../models/uk_UA-test-medium"}); err == nil {
+		t.Error("voice out of the models directory")
+	}
+
+	// the speech of the process that failed after the output may be truncated, the error is returned on close
+	t.Setenv("FAIL_LATE", "1")
+	body, _, _, err = Local(TTSParams{Text: "Hello", Voice: "uk_UA-test-medium"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	io.ReadAll(body)
+	if err = body.Close(); err == nil || !strings.Contains(err.Error(), "model crashed") {
+		t.Errorf("expected piper error on close, got %v", err)
+	}
+	t.Setenv("FAIL_LATE", "")
+
+	t.Setenv("FAIL", "1")
+	if _, _, _, err = Local(TTSParams{Text: "Hello", Voice: "uk_UA-test-medium"}); err == nil || !strings.Contains(err.Error(), "bad model") {
+		t.Errorf("expected piper error, got %v", err)
+	}
+
+	voices, err := LocalVoices("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(voices) != 2 || voices[0].Id != "en_US-test-low" || voices[1].Name != "uk_UA-test-medium (Ukrainian)" {
+		t.Errorf("voices %+v", voices)
+	}
+}
@@ -0,0 +1,371 @@
+package tts
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+const (
+	wavHeaderSize = 44
+	pcmFormatTag  = 1
+
+	// lowpassTaps is the length of the anti-aliasing filter, the transition band is about 5.5/lowpassTaps of the source rate
+	lowpassTaps = 101
+)
+
+// pcmFormat is the 16 bit PCM of the speech, size is the length of the samples in bytes (-1 - unknown)
+type pcmFormat struct {
+	rate     int
+	channels int
+	size     int64
+}
+
+// readWavHeader reads the header of the 16 bit PCM wav, the reader is left at the start of the samples
+func readWavHeader(r *bufio.Reader) (pcmFormat, error) {
+	f := pcmFormat{size: -1}
+	riff := make([]byte, 12)
+	if _, err := io.ReadFull(r, riff); err != nil {
+		return f, fmt.Errorf("wav header: %w", err)
+	}
+	if !bytes.Equal(riff[:4], []byte("RIFF")) || !bytes.Equal(riff[8:], []byte("WAVE")) {
+		return f, errors.New("bad wav header")
+	}
+
+	chunk := make([]byte, 8)
+	for {
+		if _, err := io.ReadFull(r, chunk); err != nil {
+			return f, fmt.Errorf("wav data chunk: %w", err)
+		}
+		size := binary.LittleEndian.Uint32(chunk[4:])
+
+		switch string(chunk[:4]) {
+		case "fmt ":
+			if size < 16 {
+				return f, errors.New("bad wav fmt chunk")
+			}
+			fmtChunk := make([]byte, size+size%2)
+			if _, err := io.ReadFull(r, fmtChunk); err != nil {
+				return f, err
+			}
+			tag := binary.LittleEndian.Uint16(fmtChunk[0:])
+			bits := binary.LittleEndian.Uint16(fmtChunk[14:])
+			if tag != pcmFormatTag || bits != 16 {
+				return f, fmt.Errorf("unsupported wav format %d, %d bits", tag, bits)
+			}
+			f.channels = int(binary.LittleEndian.Uint16(fmtChunk[2:]))
+			f.rate = int(binary.LittleEndian.Uint32(fmtChunk[4:]))
+		case "data":
+			if f.rate == 0 || f.channels == 0 {
+				return f, errors.New("wav data before fmt chunk")
+			}
+			if size != 0 && size != streamSize {
+				f.size = int64(size)
+			}
+			return f, nil
+		default:
+			if _, err := io.CopyN(io.Discard, r, int64(size+size%2)); err != nil {
+				return f, err
+			}
+		}
+	}
+}
+
+// wavHeader returns the header of the mono 16 bit PCM wav, the unknown size (-1) is written as the streamed wav
+func wavHeader(rate int, size int64) []byte {
+	riffSize, dataSize := uint32(streamSize), uint32(streamSize)
+	if size >= 0 {
+		dataSize = uint32(size)
+		riffSize = dataSize + wavHeaderSize - 8
+	}
+
+	h := make([]byte, wavHeaderSize)
+	copy(h[0:], "RIFF")
+	binary.LittleEndian.PutUint32(h[4:], riffSize)
+	copy(h[8:], "WAVEfmt ")
+	binary.LittleEndian.PutUint32(h[16:], 16)
+	binary.LittleEndian.PutUint16(h[20:], pcmFormatTag)
+	binary.LittleEndian.PutUint16(h[22:], 1)
+	binary.LittleEndian.PutUint32(h[24:], uint32(rate))
+	binary.LittleEndian.PutUint32(h[28:], uint32(rate*2))
+	binary.LittleEndian.PutUint16(h[32:], 2)
+	binary.LittleEndian.PutUint16(h[34:], 16)
+	copy(h[36:], "data")
+	binary.LittleEndian.PutUint32(h[40:], dataSize)
+
+	return h
+}
+
+// resampledSamples is the number of the samples of the resampled speech
+func resampledSamples(samples int64, from, to int) int64 {
+	return (samples*int64(to) + int64(from) - 1) / int64(from)
+}
+
+type pcmEncoder func(dst []byte, samples []int16) []byte
+
+func encodePCM16(dst []byte, samples []int16) []byte {
+	for _, s := range samples {
+		dst = binary.LittleEndian.AppendUint16(dst, uint16(s))
+	}
+
+	return dst
+}
+
+func encodeUlaw(dst []byte, samples []int16) []byte {
+	for _, s := range samples {
+		dst = append(dst, linearToUlaw(s))
+	}
+
+	return dst
+}
+
+// linearToUlaw is the G.711 mu-law of the sample
+func linearToUlaw(s int16) byte {
+	const (
+		bias = 0x84
+		clip = 32635
+	)
+
+	var sign int
+	v := int(s)
+	if v < 0 {
+		v = -v
+		sign = 0x80
+	}
+	if v > clip {
+		v = clip
+	}
+	v += bias
+
+	exp := 7
+	for mask := 0x4000; v&mask == 0 && exp > 0; mask >>= 1 {
+		exp--
+	}
+	mantissa := (v >> (exp + 3)) & 0x0F
+
+	return ^byte(sign | exp<<4 | mantissa)
+}
+
+// lowpass is the windowed-sinc FIR filter (Blackman window), the delay of the filter is compensated,
+// so the filtered speech has the same samples count. The edges of the speech are extended by the first and the last samples
+type lowpass struct {
+	taps    []float64
+	history []float64
+	// skip is the count of the delayed output samples that are dropped
+	skip int
+}
+
+// newLowpass returns the filter of the cutoff frequency, cutoff is the fraction of the sample rate (0 - 0.5)
+func newLowpass(cutoff float64) *lowpass {
+	taps := make([]float64, lowpassTaps)
+	m := float64(lowpassTaps-1) / 2
+	var sum float64
+	for i := range taps {
+		x := float64(i) - m
+		v := 2 * cutoff
+		if x != 0 {
+			v = math.Sin(2*math.Pi*cutoff*x) / (math.Pi * x)
+		}
+		w := 0.42 - 0.5*math.Cos(2*math.Pi*float64(i)/float64(lowpassTaps-1)) + 0.08*math.Cos(4*math.Pi*float64(i)/float64(lowpassTaps-1))
+		taps[i] = v * w
+		sum += taps[i]
+	}
+	// the unity gain of the constant signal
+	for i := range taps {
+		taps[i] /= sum
+	}
+
+	return &lowpass{
+		taps: taps,
+		skip: lowpassTaps / 2,
+	}
+}
+
+func (f *lowpass) process(dst, src []int16) []int16 {
+	if len(src) == 0 {
+		return dst
+	}
+	if f.history == nil {
+		f.history = make([]float64, len(f.taps)-1)
+		for i := range f.history {
+			f.history[i] = float64(src[0])
+		}
+	}
+
+	data := f.history
+	for _, v := range src {
+		data = append(data, float64(v))
+	}
+
+	n := len(f.taps)
+	for i := n - 1; i < len(data); i++ {
+		if f.skip > 0 {
+			f.skip--
+			continue
+		}
+		var y float64
+		for k, t := range f.taps {
+			y += t * data[i-k]
+		}
+		dst = append(dst, clamp16(y))
+	}
+	f.history = append(f.history[:0], data[len(data)-(n-1):]...)
+
+	return dst
+}
+
+// flush returns the delayed samples of the end of the speech
+func (f *lowpass) flush(dst []int16) []int16 {
+	if f.history == nil {
+		return dst
+	}
+	tail := make([]int16, len(f.taps)/2)
+	for i := range tail {
+		tail[i] = int16(f.history[len(f.history)-1])
+	}
+
+	return f.process(dst, tail)
+}
+
+func clamp16(v float64) int16 {
+	v = math.Round(v)
+	switch {
+	case v > math.MaxInt16:
+		return math.MaxInt16
+	case v < math.MinInt16:
+		return math.MinInt16
+	default:
+		return int16(v)
+	}
+}
+
+// resampler converts the 16 bit PCM to mono of the rate by the linear interpolation and encodes the samples,
+// on downsampling the source is filtered by the low-pass at the half of the target rate, so the higher frequencies
+// are not folded to the band of the speech
+type resampler struct {
+	r        io.Reader
+	channels int
+	from, to int64
+	encode   pcmEncoder
+	filter   *lowpass
+
+	in      []byte
+	rest    []byte
+	mono    []int16
+	samples []int16
+	// base is the index of samples[0] in the source, next is the index of the next output sample
+	base, next int64
+	out        []byte
+	eof        bool
+}
+
+func newResampler(r io.Reader, f pcmFormat, rate int, encode pcmEncoder) *resampler {
+	s := &resampler{
+		r:        r,
+		channels: f.channels,
+		from:     int64(f.rate),
+		to:       int64(rate),
+		encode:   encode,
+		in:       make([]byte, 8192),
+	}
+	if rate < f.rate {
+		s.filter = newLowpass(float64(rate) / float64(2*f.rate))
+	}
+
+	return s
+}
+
+func (s *resampler) Read(p []byte) (int, error) {
+	for len(s.out) == 0 {
+		if s.eof {
+			return 0, io.EOF
+		}
+		if err := s.fill(); err != nil {
+			return 0, err
+		}
+		s.generate()
+	}
+
+	n := copy(p, s.out)
+	s.out = s.out[n:]
+
+	return n, nil
+}
+
+// fill reads the next frames of the source, the channels are mixed to mono
+func (s *resampler) fill() error {
+	n, err := s.r.Read(s.in)
+	if err == io.EOF {
+		s.eof = true
+	} else if err != nil {
+		return err
+	}
+
+	data := append(s.rest, s.in[:n]...)
+	frame := 2 * s.channels
+	frames := len(data) / frame
+	mono := s.samples
+	if s.filter != nil {
+		mono = s.mono[:0]
+	}
+	for i := 0; i < frames; i++ {
+		var sum int
+		for c := 0; c < s.channels; c++ {
+			sum += int(int16(binary.LittleEndian.Uint16(data[i*frame+c*2:])))
+		}
+		mono = append(mono, int16(sum/s.channels))
+	}
+	s.rest = append(s.rest[:0], data[frames*frame:]...)
+
+	if s.filter == nil {
+		s.samples = mono
+		return nil
+	}
+
+	s.mono = mono
+	s.samples = s.filter.process(s.samples, mono)
+	if s.eof {
+		s.samples = s.filter.flush(s.samples)
+	}
+
+	return nil
+}
+
+func (s *resampler) generate() {
+	var out []int16
+	for {
+		pos := s.next * s.from
+		idx := pos/s.to - s.base
+		frac := pos % s.to
+
+		var v int16
+		switch {
+		case idx+1 < int64(len(s.samples)):
+			a, b := int64(s.samples[idx]), int64(s.samples[idx+1])
+			v = int16(a + (b-a)*frac/s.to)
+		case s.eof && idx < int64(len(s.samples)):
+			v = s.samples[idx]
+		default:
+			// the next sample is required
+			s.drop(idx)
+			s.out = s.encode(s.out, out)
+			return
+		}
+		out = append(out, v)
+		s.next++
+	}
+}
+
+func (s *resampler) drop(idx int64) {
+	if idx > int64(len(s.samples)) {
+		idx = int64(len(s.samples))
+	}
+	if idx > 0 {
+		s.samples = append(s.samples[:0], s.samples[idx:]...)
+		s.base += idx
+	}
+}
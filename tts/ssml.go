@@ -0,0 +1,393 @@
+package tts
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+const (
+	TextTypeSSML = "ssml"
+	TextTypeText = "text"
+
+	ssmlNamespace = "http://www.w3.org/2001/10/synthesis"
+	xmlNamespace  = "http://www.w3.org/XML/1998/namespace"
+	msttsPrefix   = "mstts"
+	amazonPrefix  = "amazon"
+)
+
+var (
+	ssmlTime       = regexp.MustCompile(`^\d+(\.\d+)?(ms|s)$`)
+	ssmlPercent    = regexp.MustCompile(`^[+-]?\d+(\.\d+)?%$`)
+	ssmlPitch      = regexp.MustCompile(`^[+-]?\d+(\.\d+)?(%|Hz|st)$`)
+	ssmlVolume     = regexp.MustCompile(`^[+-]?\d+(\.\d+)?dB$`)
+	ssmlStrength   = []string{"none", "x-weak", "weak", "medium", "strong", "x-strong"}
+	ssmlRate       = []string{"x-slow", "slow", "medium", "fast", "x-fast", "default"}
+	ssmlPitchLevel = []string{"x-low", "low", "medium", "high", "x-high", "default"}
+	ssmlVolumeName = []string{"silent", "x-soft", "soft", "medium", "loud", "x-loud", "default"}
+	ssmlEmphasis   = []string{"strong", "moderate", "reduced", "none"}
+	ssmlAlphabet   = []string{"ipa", "x-sampa"}
+
+	ssmlEscape = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", "\"", "&quot;")
+)
+
+// SSMLError is the error of the canonical SSML document of the request
+type SSMLError struct {
+	msg string
+}
+
+func (e *SSMLError) Error() string {
+	return "ssml: " + e.msg
+}
+
+func ssmlErrorf(format string, a ...any) error {
+	return &SSMLError{msg: fmt.Sprintf(format, a...)}
+}
+
+// SSMLNode is the element or the text (Name is empty) of the SSML document
+type SSMLNode struct {
+	Name     string
+	Space    string
+	Attrs    []xml.Attr
+	Text     string
+	Children []*SSMLNode
+}
+
+// ssmlDialect is the subset of SSML that the provider accepts, the other elements are downgraded:
+// the element is replaced with its content, sub with the alias
+type ssmlDialect struct {
+	elements map[string]bool
+	// vendor is the namespace prefix of the provider extensions (amazon:effect, mstts:express-as)
+	vendor string
+	// fragment is the content of speak, the provider wraps it to its own document
+	fragment bool
+	// text is the plain text, only break is kept in the text when it's in elements
+	text bool
+}
+
+func newDialect(elements ...string) map[string]bool {
+	m := make(map[string]bool, len(elements))
+	for _, v := range elements {
+		m[v] = true
+	}
+
+	return m
+}
+
+var ssmlDialects = map[string]ssmlDialect{
+	"google": {
+		elements: newDialect("p", "s", "break", "prosody", "emphasis", "say-as", "sub", "phoneme", "audio", "mark", "lang", "voice"),
+	},
+	"polly": {
+		elements: newDialect("p", "s", "break", "prosody", "emphasis", "say-as", "sub", "phoneme", "mark", "lang", "w"),
+		vendor:   amazonPrefix,
+	},
+	"microsoft": {
+		elements: newDialect("p", "s", "break", "prosody", "emphasis", "say-as", "sub", "phoneme", "audio", "mark", "lang"),
+		vendor:   msttsPrefix,
+		fragment: true,
+	},
+	"yandex": {
+		elements: newDialect("p", "s", "break", "phoneme", "sub"),
+	},
+	"elevenlabs": {
+		elements: newDialect("break"),
+		text:     true,
+	},
+	"webitel": {
+		text: true,
+	},
+	"local": {
+		text: true,
+	},
+}
+
+// ParseSSML parses and validates the canonical SSML document, the document without the root speak element is wrapped to speak
+func ParseSSML(src string) (*SSMLNode, error) {
+	src = strings.TrimSpace(src)
+	if !strings.HasPrefix(src, "<speak") && !strings.HasPrefix(src, "<?xml") {
+		src = "<speak>" + src + "</speak>"
+	}
+
+	dec := xml.NewDecoder(strings.NewReader(src))
+	root := &SSMLNode{}
+	stack := []*SSMLNode{root}
+
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var syntax *xml.SyntaxError
+			if errors.As(err, &syntax) {
+				return nil, ssmlErrorf("line %d: %s", syntax.Line, syntax.Msg)
+			}
+			return nil, &SSMLError{msg: err.Error()}
+		}
+
+		parent := stack[len(stack)-1]
+		switch t := tok.(type) {
+		case xml.StartElement:
+			node := &SSMLNode{Name: t.Name.Local, Space: t.Name.Space}
+			if node.Space == ssmlNamespace {
+				node.Space = ""
+			}
+			for _, a := range t.Attr {
+				if a.Name.Space == "xmlns" || a.Name.Local == "xmlns" {
+					continue
+				}
+				node.Attrs = append(node.Attrs, a)
+			}
+			if err = node.valid(parent == root); err != nil {
+				return nil, err
+			}
+			parent.Children = append(parent.Children, node)
+			stack = append(stack, node)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			if len(stack) == 1 {
+				if strings.TrimSpace(string(t)) != "" {
+					return nil, ssmlErrorf("text outside of speak")
+				}
+				continue
+			}
+			parent.Children = append(parent.Children, &SSMLNode{Text: string(t)})
+		}
+	}
+
+	if len(root.Children) != 1 {
+		return nil, ssmlErrorf("document must have one speak element")
+	}
+
+	return root.Children[0], nil
+}
+
+func (n *SSMLNode) attr(name string) (string, bool) {
+	for _, a := range n.Attrs {
+		if a.Name.Local == name {
+			return a.Value, true
+		}
+	}
+
+	return "", false
+}
+
+func (n *SSMLNode) vendor() string {
+	if strings.HasSuffix(n.Space, "/mstts") {
+		return msttsPrefix
+	}
+
+	return n.Space
+}
+
+func (n *SSMLNode) valid(root bool) error {
+	if n.Space != "" {
+		// the extensions of the providers are kept only for their provider
+		return nil
+	}
+
+	if root != (n.Name == "speak") {
+		if root {
+			return ssmlErrorf("root element must be speak, not %s", n.Name)
+		}
+		return ssmlErrorf("speak must be the root element")
+	}
+
+	enum := func(name string, values []string, pattern *regexp.Regexp) error {
+		v, ok := n.attr(name)
+		if !ok {
+			return nil
+		}
+		for _, e := range values {
+			if v == e {
+				return nil
+			}
+		}
+		if pattern != nil && pattern.MatchString(v) {
+			return nil
+		}
+
+		return ssmlErrorf("bad %s \"%s\" of %s", name, v, n.Name)
+	}
+	required := func(names ...string) error {
+		for _, name := range names {
+			if v, ok := n.attr(name); !ok || v == "" {
+				return ssmlErrorf("%s requires %s", n.Name, name)
+			}
+		}
+		return nil
+	}
+
+	switch n.Name {
+	case "speak", "p", "s", "w":
+		return nil
+	case "break":
+		if err := enum("time", nil, ssmlTime); err != nil {
+			return err
+		}
+		return enum("strength", ssmlStrength, nil)
+	case "prosody":
+		if err := enum("rate", ssmlRate, ssmlPercent); err != nil {
+			return err
+		}
+		if err := enum("pitch", ssmlPitchLevel, ssmlPitch); err != nil {
+			return err
+		}
+		return enum("volume", ssmlVolumeName, ssmlVolume)
+	case "emphasis":
+		return enum("level", ssmlEmphasis, nil)
+	case "say-as":
+		return required("interpret-as")
+	case "sub":
+		return required("alias")
+	case "phoneme":
+		if err := required("ph"); err != nil {
+			return err
+		}
+		return enum("alphabet", ssmlAlphabet, nil)
+	case "audio":
+		return required("src")
+	case "lang":
+		return required("lang")
+	case "mark", "voice":
+		return required("name")
+	default:
+		return ssmlErrorf("unsupported element %s", n.Name)
+	}
+}
+
+// Render returns the document in the dialect of the provider and true when the result is SSML
+func (n *SSMLNode) Render(provider string) (string, bool) {
+	d, ok := ssmlDialects[strings.ToLower(provider)]
+	if !ok {
+		// the unknown provider gets the canonical document
+		d = ssmlDialect{elements: ssmlDialects["google"].elements}
+	}
+
+	var b strings.Builder
+	if d.text {
+		n.renderText(&b, d)
+		return strings.Join(strings.Fields(b.String()), " "), false
+	}
+
+	if d.fragment {
+		for _, c := range n.Children {
+			c.render(&b, d)
+		}
+	} else {
+		n.render(&b, d)
+	}
+
+	return b.String(), true
+}
+
+func (n *SSMLNode) render(b *strings.Builder, d ssmlDialect) {
+	if n.Name == "" {
+		ssmlEscape.WriteString(b, n.Text)
+		return
+	}
+
+	name := n.Name
+	switch {
+	case n.Space != "":
+		if n.vendor() != d.vendor {
+			n.renderChildren(b, d)
+			return
+		}
+		name = d.vendor + ":" + n.Name
+	case n.Name == "speak":
+	case !d.elements[n.Name]:
+		if n.Name == "sub" {
+			v, _ := n.attr("alias")
+			ssmlEscape.WriteString(b, v)
+		} else {
+			n.renderChildren(b, d)
+		}
+		return
+	}
+
+	b.WriteString("<" + name)
+	for _, a := range n.Attrs {
+		b.WriteString(" ")
+		switch a.Name.Space {
+		case "":
+		case xmlNamespace:
+			b.WriteString("xml:")
+		default:
+			if n.Space != "" {
+				b.WriteString(d.vendor + ":")
+			}
+		}
+		b.WriteString(a.Name.Local + "=\"")
+		ssmlEscape.WriteString(b, a.Value)
+		b.WriteString("\"")
+	}
+
+	if len(n.Children) == 0 {
+		b.WriteString("/>")
+		return
+	}
+
+	b.WriteString(">")
+	n.renderChildren(b, d)
+	b.WriteString("</" + name + ">")
+}
+
+func (n *SSMLNode) renderChildren(b *strings.Builder, d ssmlDialect) {
+	for _, c := range n.Children {
+		c.render(b, d)
+	}
+}
+
+func (n *SSMLNode) renderText(b *strings.Builder, d ssmlDialect) {
+	switch n.Name {
+	case "":
+		b.WriteString(n.Text)
+		return
+	case "sub":
+		v, _ := n.attr("alias")
+		b.WriteString(v)
+		return
+	case "break":
+		if v, ok := n.attr("time"); ok && d.elements["break"] {
+			b.WriteString(" <break time=\"" + v + "\" /> ")
+		} else {
+			b.WriteString(" ")
+		}
+		return
+	}
+
+	for _, c := range n.Children {
+		c.renderText(b, d)
+	}
+	if n.Name == "p" || n.Name == "s" {
+		b.WriteString(" ")
+	}
+}
+
+// PrepareSSML converts the canonical SSML of the request (text_type=ssml) to the dialect of the provider,
+// the providers without SSML get the plain text
+func (p *TTSParams) PrepareSSML(provider string) error {
+	if !strings.EqualFold(p.TextType, TextTypeSSML) {
+		return nil
+	}
+
+	doc, err := ParseSSML(p.Text)
+	if err != nil {
+		return err
+	}
+
+	var ssml bool
+	if p.Text, ssml = doc.Render(provider); ssml {
+		p.TextType = TextTypeSSML
+	} else {
+		p.TextType = TextTypeText
+	}
+
+	return nil
+}
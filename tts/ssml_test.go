@@ -0,0 +1,133 @@
+package tts
+
+import (
+	"errors"
+	"testing"
+)
+
+const testSSML = `<speak xmlns="http://www.w3.org/2001/10/synthesis" xmlns:amazon="http://www.amazon.com/ssml">
+<p>Your balance is <say-as interpret-as="currency">$42.10</say-as>.</p>
+<break time="500ms"/>
+<prosody rate="slow">Press <emphasis level="strong">one</emphasis></prosody> for <sub alias="World Wide Web Consortium">W3C</sub>.
+<audio src="https://example.com/beep.wav">beep</audio>
+</speak>`
+
+func TestParseSSML(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		err  bool
+	}{
+		{name: "document", src: testSSML},
+		{name: "fragment", src: `Hello <break time="1s"/> world`},
+		{name: "vendor", src: `<speak><amazon:effect name="whispered">secret</amazon:effect></speak>`},
+		{name: "malformed", src: `<speak>Hello <break time="1s"></speak>`, err: true},
+		{name: "unknown element", src: `<speak><music>la</music></speak>`, err: true},
+		{name: "break time", src: `<speak><break time="soon"/></speak>`, err: true},
+		{name: "prosody rate", src: `<speak><prosody rate="very fast">hi</prosody></speak>`, err: true},
+		{name: "sub alias", src: `<speak><sub>W3C</sub></speak>`, err: true},
+		{name: "nested speak", src: `<speak><speak>hi</speak></speak>`, err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseSSML(tt.src)
+			if tt.err {
+				var ssmlErr *SSMLError
+				if !errors.As(err, &ssmlErr) {
+					t.Errorf("expected ssml error, got %v", err)
+				}
+			} else if err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestRenderSSML(t *testing.T) {
+	doc, err := ParseSSML(testSSML)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		provider string
+		out      string
+		ssml     bool
+	}{
+		{
+			provider: "google",
+			ssml:     true,
+			out: `<speak>
+<p>Your balance is <say-as interpret-as="currency">$42.10</say-as>.</p>
+<break time="500ms"/>
+<prosody rate="slow">Press <emphasis level="strong">one</emphasis></prosody> for <sub alias="World Wide Web Consortium">W3C</sub>.
+<audio src="https://example.com/beep.wav">beep</audio>
+</speak>`,
+		},
+		{
+			provider: "Polly",
+			ssml:     true,
+			out: `<speak>
+<p>Your balance is <say-as interpret-as="currency">$42.10</say-as>.</p>
+<break time="500ms"/>
+<prosody rate="slow">Press <emphasis level="strong">one</emphasis></prosody> for <sub alias="World Wide Web Consortium">W3C</sub>.
+beep
+</speak>`,
+		},
+		{
+			provider: "microsoft",
+			ssml:     true,
+			out: `
+<p>Your balance is <say-as interpret-as="currency">$42.10</say-as>.</p>
+<break time="500ms"/>
+<prosody rate="slow">Press <emphasis level="strong">one</emphasis></prosody> for <sub alias="World Wide Web Consortium">W3C</sub>.
+<audio src="https://example.com/beep.wav">beep</audio>
+`,
+		},
+		{
+			provider: "yandex",
+			ssml:     true,
+			out: `<speak>
+<p>Your balance is $42.10.</p>
+<break time="500ms"/>
+Press one for <sub alias="World Wide Web Consortium">W3C</sub>.
+beep
+</speak>`,
+		},
+		{
+			provider: "elevenlabs",
+			out:      `Your balance is $42.10. <break time="500ms" /> Press one for World Wide Web Consortium. beep`,
+		},
+		{
+			provider: "webitel",
+			out:      `Your balance is $42.10. Press one for World Wide Web Consortium. beep`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.provider, func(t *testing.T) {
+			out, ssml := doc.Render(tt.provider)
+			if ssml != tt.ssml {
+				t.Errorf("ssml %v, expected %v", ssml, tt.ssml)
+			}
+			if out != tt.out {
+				t.Errorf("render:\n%s\nexpected:\n%s", out, tt.out)
+			}
+		})
+	}
+}
+
+func TestRenderVendorSSML(t *testing.T) {
+	doc, err := ParseSSML(`<speak xmlns:mstts="https://www.w3.org/2001/mstts"><mstts:express-as style="cheerful">Hi &amp; bye</mstts:express-as></speak>`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if out, _ := doc.Render("microsoft"); out != `<mstts:express-as style="cheerful">Hi &amp; bye</mstts:express-as>` {
+		t.Errorf("microsoft %s", out)
+	}
+	if out, _ := doc.Render("google"); out != `<speak>Hi &amp; bye</speak>` {
+		t.Errorf("google %s", out)
+	}
+}
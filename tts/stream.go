@@ -0,0 +1,222 @@
+package tts
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	formatRaw = iota
+	formatWav
+	formatMp3
+)
+
+// streamSize is the size of the RIFF and data chunks of the streamed wav, the length of the speech is unknown
+const streamSize = 0xFFFFFFFF
+
+var errChunkCanceled = errors.New("tts chunk canceled")
+
+type chunkResult struct {
+	body     io.ReadCloser
+	mime     *string
+	err      error
+	acquired bool
+}
+
+// Chunked synthesizes the long text by the chunks of SplitParams, the chunks are synthesized concurrently by the workers
+// and the audio is stitched in order into one stream. The speech is returned when the first chunk is synthesized,
+// the error of the next chunks breaks the stream
+func Chunked(fn Synthesizer, provider string, maxChunk, workers int) Synthesizer {
+	if workers < 1 {
+		workers = 1
+	}
+
+	return func(params TTSParams) (io.ReadCloser, *string, *int, error) {
+		chunks := SplitParams(params, provider, maxChunk)
+		if len(chunks) < 2 {
+			return fn(params)
+		}
+
+		sem := make(chan struct{}, workers)
+		done := make(chan struct{})
+		results := make([]chan chunkResult, len(chunks))
+		for i := range chunks {
+			results[i] = make(chan chunkResult, 1)
+		}
+
+		// the slots are taken in the order of the chunks, the worker holds the slot until its audio is written to the stream,
+		// so at most workers responses of the providers are open
+		go func() {
+			for i, chunk := range chunks {
+				select {
+				case sem <- struct{}{}:
+				case <-done:
+					for j := i; j < len(chunks); j++ {
+						results[j] <- chunkResult{err: errChunkCanceled}
+					}
+					return
+				}
+				go func(i int, chunk TTSParams) {
+					r := chunkResult{acquired: true}
+					r.body, r.mime, _, r.err = fn(chunk)
+					results[i] <- r
+				}(i, chunk)
+			}
+		}()
+
+		first := <-results[0]
+		if first.err != nil {
+			close(done)
+			go releaseChunks(results[1:], sem)
+			return nil, nil, nil, first.err
+		}
+
+		pr, pw := io.Pipe()
+		go func() {
+			var err error
+			s := &stitcher{w: pw}
+			next := len(chunks)
+
+			for i := range chunks {
+				r := first
+				if i > 0 {
+					r = <-results[i]
+				}
+				if r.err != nil {
+					err = fmt.Errorf("chunk %d: %w", i, r.err)
+				} else {
+					err = s.write(r.body)
+					r.body.Close()
+				}
+				<-sem
+				if err != nil {
+					next = i + 1
+					break
+				}
+			}
+
+			close(done)
+			releaseChunks(results[next:], sem)
+			pw.CloseWithError(err)
+		}()
+
+		return pr, first.mime, nil, nil
+	}
+}
+
+// releaseChunks closes the speech of the chunks that are not written to the stream
+func releaseChunks(results []chan chunkResult, sem chan struct{}) {
+	for _, ch := range results {
+		r := <-ch
+		if r.body != nil {
+			r.body.Close()
+		}
+		if r.acquired {
+			<-sem
+		}
+	}
+}
+
+// stitcher writes the audio of the chunks as one stream: the header of the wav is written once
+// with the unknown size, the ID3 tags of the next mp3 chunks are skipped, the other formats are concatenated
+type stitcher struct {
+	w      io.Writer
+	format int
+	count  int
+}
+
+func (s *stitcher) write(src io.Reader) error {
+	r := bufio.NewReader(src)
+	if s.count == 0 {
+		head, _ := r.Peek(4)
+		switch {
+		case bytes.Equal(head, []byte("RIFF")):
+			s.format = formatWav
+		case bytes.HasPrefix(head, []byte("ID3")) || (len(head) > 1 && head[0] == 0xFF && head[1]&0xE0 == 0xE0):
+			s.format = formatMp3
+		default:
+			s.format = formatRaw
+		}
+	}
+	s.count++
+
+	switch s.format {
+	case formatWav:
+		return s.writeWav(r, s.count == 1)
+	case formatMp3:
+		if s.count > 1 {
+			if err := skipID3(r); err != nil {
+				return err
+			}
+		}
+	}
+
+	_, err := io.Copy(s.w, r)
+	return err
+}
+
+// writeWav writes the samples of the data chunk, the first chunk writes the header with the unknown size
+func (s *stitcher) writeWav(r *bufio.Reader, header bool) error {
+	var hdr bytes.Buffer
+	riff := make([]byte, 12)
+	if _, err := io.ReadFull(r, riff); err != nil {
+		return err
+	}
+	if !bytes.Equal(riff[:4], []byte("RIFF")) || !bytes.Equal(riff[8:], []byte("WAVE")) {
+		return errors.New("bad wav header")
+	}
+	binary.LittleEndian.PutUint32(riff[4:], streamSize)
+	hdr.Write(riff)
+
+	chunk := make([]byte, 8)
+	for {
+		if _, err := io.ReadFull(r, chunk); err != nil {
+			return fmt.Errorf("wav data chunk: %w", err)
+		}
+		size := binary.LittleEndian.Uint32(chunk[4:])
+		if bytes.Equal(chunk[:4], []byte("data")) {
+			binary.LittleEndian.PutUint32(chunk[4:], streamSize)
+			hdr.Write(chunk)
+			if header {
+				if _, err := s.w.Write(hdr.Bytes()); err != nil {
+					return err
+				}
+			}
+
+			var err error
+			if size == 0 || size == streamSize {
+				_, err = io.Copy(s.w, r)
+			} else if _, err = io.CopyN(s.w, r, int64(size)); err == io.EOF {
+				// the provider ended the stream before the declared size
+				err = nil
+			}
+			return err
+		}
+
+		// fmt and the other chunks before the samples, the chunks are word aligned
+		hdr.Write(chunk)
+		if _, err := io.CopyN(&hdr, r, int64(size+size%2)); err != nil {
+			return err
+		}
+	}
+}
+
+// skipID3 skips the ID3v2 tag at the start of the mp3
+func skipID3(r *bufio.Reader) error {
+	head, _ := r.Peek(10)
+	if len(head) < 10 || !bytes.HasPrefix(head, []byte("ID3")) {
+		return nil
+	}
+
+	size := int64(head[6]&0x7F)<<21 | int64(head[7]&0x7F)<<14 | int64(head[8]&0x7F)<<7 | int64(head[9]&0x7F) + 10
+	if head[5]&0x10 != 0 {
+		size += 10
+	}
+	_, err := io.CopyN(io.Discard, r, size)
+
+	return err
+}
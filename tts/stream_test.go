@@ -0,0 +1,201 @@
+package tts
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSplitParams(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider string
+		params   TTSParams
+		chunks   []string
+	}{
+		{
+			name:   "short",
+			params: TTSParams{Text: "Hello. Thank you for calling."},
+			chunks: []string{"Hello. Thank you for calling."},
+		},
+		{
+			name:   "text",
+			params: TTSParams{Text: "Hello! Your call is important to us. Please stay on the line.\nThe next operator will answer you shortly. Version 2.5 is out."},
+			chunks: []string{"Hello!", "Your call is important to us.", "Please stay on the line.", "The next operator will answer you shortly.", "Version 2.5 is out."},
+		},
+		{
+			name:     "ssml",
+			provider: "google",
+			params: TTSParams{TextType: TextTypeSSML, Text: `<speak>Hello, <emphasis>dear</emphasis> customer. <p>Your balance is <say-as interpret-as="currency">$42</say-as>.</p>` +
+				`<break time="1s"/>Press one to pay. Press two to repeat.</speak>`},
+			chunks: []string{
+				`<speak>Hello, <emphasis>dear</emphasis> customer. </speak>`,
+				`<speak><p>Your balance is <say-as interpret-as="currency">$42</say-as>.</p><break time="1s"/></speak>`,
+				`<speak>Press one to pay. Press two to repeat.</speak>`,
+			},
+		},
+		{
+			name:     "microsoft fragment",
+			provider: "microsoft",
+			params:   TTSParams{TextType: TextTypeSSML, Text: `Hello, dear customer. <p>Your balance is forty two dollars, thank you.</p>`},
+			chunks:   []string{`Hello, dear customer. `, `<p>Your balance is forty two dollars, thank you.</p>`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chunks := SplitParams(tt.params, tt.provider, 45)
+			var texts []string
+			for _, c := range chunks {
+				texts = append(texts, c.Text)
+			}
+			if strings.Join(texts, "|") != strings.Join(tt.chunks, "|") {
+				t.Errorf("chunks:\n%q\nexpected:\n%q", texts, tt.chunks)
+			}
+		})
+	}
+}
+
+func testWav(samples string) []byte {
+	var b bytes.Buffer
+	b.WriteString("RIFF")
+	binary.Write(&b, binary.LittleEndian, uint32(36+len(samples)))
+	b.WriteString("WAVEfmt ")
+	binary.Write(&b, binary.LittleEndian, uint32(16))
+	b.Write(make([]byte, 16))
+	b.WriteString("data")
+	binary.Write(&b, binary.LittleEndian, uint32(len(samples)))
+	b.WriteString(samples)
+	// the chunk after the samples is not audio
+	b.WriteString("LIST")
+	binary.Write(&b, binary.LittleEndian, uint32(4))
+	b.WriteString("INFO")
+
+	return b.Bytes()
+}
+
+func testMp3(frames string) []byte {
+	return append([]byte{'I', 'D', '3', 4, 0, 0, 0, 0, 0, 3, 'x', 'y', 'z'}, frames...)
+}
+
+func TestChunked(t *testing.T) {
+	text := "First sentence. Second sentence. Third sentence. Fourth sentence. Fifth sentence."
+
+	t.Run("wav", func(t *testing.T) {
+		var active, peak int32
+		fn := func(p TTSParams) (io.ReadCloser, *string, *int, error) {
+			n := atomic.AddInt32(&active, 1)
+			for {
+				m := atomic.LoadInt32(&peak)
+				if n <= m || atomic.CompareAndSwapInt32(&peak, m, n) {
+					break
+				}
+			}
+			// the later chunks are synthesized faster, the order of the stream must be kept
+			time.Sleep(time.Millisecond * time.Duration(30-len(p.Text)%20))
+			mime := "audio/wav"
+			return &closeCounter{Reader: bytes.NewReader(testWav("[" + p.Text + "]")), closed: func() { atomic.AddInt32(&active, -1) }}, &mime, nil, nil
+		}
+
+		out, mime, _, err := Chunked(fn, "microsoft", 20, 2)(TTSParams{Text: text})
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(out)
+		out.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if *mime != "audio/wav" {
+			t.Errorf("mime %s", *mime)
+		}
+		if binary.LittleEndian.Uint32(data[4:]) != streamSize || string(data[36:40]) != "data" || binary.LittleEndian.Uint32(data[40:]) != streamSize {
+			t.Errorf("bad stream header %q", data[:44])
+		}
+		samples := string(data[44:])
+		expected := "[First sentence.][Second sentence.][Third sentence.][Fourth sentence.][Fifth sentence.]"
+		if samples != expected {
+			t.Errorf("samples %q, expected %q", samples, expected)
+		}
+		if peak > 2 {
+			t.Errorf("%d chunks synthesized at once, expected 2", peak)
+		}
+	})
+
+	t.Run("mp3", func(t *testing.T) {
+		fn := func(p TTSParams) (io.ReadCloser, *string, *int, error) {
+			return io.NopCloser(bytes.NewReader(testMp3("<" + p.Text + ">"))), nil, nil, nil
+		}
+
+		out, _, _, err := Chunked(fn, "google", 35, 3)(TTSParams{Text: text})
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(out)
+		expected := string(testMp3("<First sentence.>")) + "<Second sentence. Third sentence.><Fourth sentence. Fifth sentence.>"
+		if string(data) != expected {
+			t.Errorf("stream %q, expected %q", data, expected)
+		}
+	})
+
+	t.Run("error", func(t *testing.T) {
+		var mx sync.Mutex
+		var opened, closed int
+		fn := func(p TTSParams) (io.ReadCloser, *string, *int, error) {
+			if strings.HasPrefix(p.Text, "Third") {
+				return nil, nil, nil, errors.New("provider error")
+			}
+			mx.Lock()
+			opened++
+			mx.Unlock()
+			return &closeCounter{Reader: strings.NewReader(p.Text), closed: func() {
+				mx.Lock()
+				closed++
+				mx.Unlock()
+			}}, nil, nil, nil
+		}
+
+		out, _, _, err := Chunked(fn, "webitel", 20, 2)(TTSParams{Text: text})
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(out)
+		if err == nil || string(data) != "First sentence.Second sentence." {
+			t.Errorf("stream %q, error %v", data, err)
+		}
+
+		time.Sleep(time.Millisecond * 50)
+		mx.Lock()
+		defer mx.Unlock()
+		// the speech of the chunks after the error is closed or not synthesized
+		if closed != opened || closed < 2 {
+			t.Errorf("closed %d of %d chunks", closed, opened)
+		}
+	})
+
+	t.Run("first error", func(t *testing.T) {
+		fn := func(p TTSParams) (io.ReadCloser, *string, *int, error) {
+			return nil, nil, nil, errors.New("provider error")
+		}
+		if _, _, _, err := Chunked(fn, "webitel", 20, 2)(TTSParams{Text: text}); err == nil {
+			t.Error("expected error of the first chunk")
+		}
+	})
+}
+
+type closeCounter struct {
+	io.Reader
+	closed func()
+}
+
+func (c *closeCounter) Close() error {
+	c.closed()
+	return nil
+}
@@ -1,6 +1,7 @@
 package tts
 
 import (
+	"errors"
 	"io"
 	"net/http"
 	"net/url"
@@ -12,7 +13,7 @@ const (
 )
 
 var (
-	wbtTTSEndpoint = "https://f500-176-39-35-14.ngrok-free.app"
+	wbtTTSEndpoint string
 )
 
 func SetWbtTTSEndpoint(endpoint string) {
@@ -20,8 +21,15 @@ func SetWbtTTSEndpoint(endpoint string) {
 }
 
 func Webitel(req TTSParams) (io.ReadCloser, *string, *int, error) {
+	if wbtTTSEndpoint == "" {
+		return nil, nil, nil, errors.New("webitel tts endpoint is not configured")
+	}
+
 	req.Text = strings.TrimSpace(req.Text)
 	l := len(req.Text)
+	if l == 0 {
+		return nil, nil, nil, errors.New("empty text")
+	}
 
 	if req.Text[l-1:l] != "." {
 		req.Text = req.Text + "."
@@ -1,6 +1,7 @@
 package uploader
 
 import (
+	"crypto/sha256"
 	"fmt"
 	"io"
 
@@ -41,6 +42,7 @@ func (u *UploadTask) Execute() {
 	defer r.Close()
 
 	f := &model.File{
+		Id:        u.job.Id, // the job is moved to files with the same id
 		DomainId:  u.job.DomainId,
 		Uuid:      u.job.Uuid,
 		ProfileId: u.job.ProfileId,
@@ -62,7 +64,8 @@ func (u *UploadTask) Execute() {
 	}
 	defer reader.Close()
 
-	if _, err = store.Write(reader, f); err != nil && err.GetId() != utils.ErrFileWriteExistsId {
+	h := sha256.New()
+	if _, err = store.Write(io.TeeReader(reader, h), f); err != nil && err.GetId() != utils.ErrFileWriteExistsId {
 		if model.IsFilePolicyError(err) {
 			u.cancelUpload(err)
 		} else {
@@ -73,13 +76,31 @@ func (u *UploadTask) Execute() {
 
 	u.log.Debug(fmt.Sprintf("store %s to %s %d bytes [encrypted=%v]", u.job.GetStoreName(), store.Name(), u.job.Size, f.IsEncrypted()))
 
-	result := <-u.app.Store.File().MoveFromJob(u.job.Id, u.job.ProfileId, f.Properties, f.RetentionUntil)
+	var own *model.File
+	if err == nil {
+		// hash is known only when the object was written by this task
+		sha := fmt.Sprintf("%x", h.Sum(nil))
+		f.SHA256Sum = &sha
+		own = u.app.DeduplicateFile(f)
+	}
+
+	result := <-u.app.Store.File().MoveFromJob(u.job.Id, f)
 	if result.Err != nil {
-		store.Remove(f)
+		if own != nil {
+			store.Remove(own)
+		} else {
+			store.Remove(f)
+		}
 		u.storeError(result.Err)
 		return
 	}
 
+	// the file is stored with the id of the job
+	f.Id = u.job.Id
+	u.app.ConfirmDeduplicate(store, f, own)
+
+	u.app.ReplicateFile(store, f)
+
 	u.removeCacheFile()
 	u.log.Debug(fmt.Sprintf("finish upload task %d [%s]", u.job.Id, u.Name()))
 }
@@ -19,6 +19,8 @@ type UploaderInterfaceImpl struct {
 	limit             int
 	schedule          chan struct{}
 	pollingInterval   time.Duration
+	cleanInterval     time.Duration
+	lastClean         time.Time
 	stopSignal        chan struct{}
 	pool              interfaces.PoolInterface
 	mx                sync.RWMutex
@@ -36,6 +38,7 @@ func init() {
 			schedule:          make(chan struct{}, 1),
 			stopSignal:        make(chan struct{}),
 			pollingInterval:   time.Second * 2,
+			cleanInterval:     time.Minute * 5,
 			pool:              pool.NewPool(100, 10), //FIXME added config
 			log: a.Log.With(
 				wlog.Namespace("context"),
@@ -60,6 +63,16 @@ func (u *UploaderInterfaceImpl) run() {
 		case <-u.schedule:
 		case <-time.After(u.pollingInterval):
 		start:
+			if time.Since(u.lastClean) >= u.cleanInterval {
+				u.lastClean = time.Now()
+				if err := u.App.RemoveExpiredResumableUploads(u.App.Config().ResumableUploadExpire); err != nil {
+					u.log.Error(err.Error(), wlog.Err(err))
+				}
+				if err := u.App.RemoveExpiredDirectUploads(); err != nil {
+					u.log.Error(err.Error(), wlog.Err(err))
+				}
+			}
+
 			if result = <-u.App.Store.UploadJob().UpdateWithProfile(u.limit, u.App.GetInstanceId(), u.betweenAttemptSec, u.App.UseDefaultStore()); result.Err != nil {
 				u.log.Critical(result.Err.Error(),
 					wlog.Err(result.Err),
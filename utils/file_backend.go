@@ -5,6 +5,7 @@ import (
 	"github.com/webitel/storage/model"
 	"io"
 	"regexp"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -22,8 +23,9 @@ type BaseFileBackend struct {
 	writeSize   float64
 	expireDay   int
 	maxFileSize float64
-	chipher     Chipher
+	keyring     *Keyring
 	id          int
+	replicas    []int
 }
 
 func (b *BaseFileBackend) GetSyncTime() int64 {
@@ -49,6 +51,70 @@ func (b *BaseFileBackend) Id() *int {
 	return nil
 }
 
+// Replicas returns ids of the profiles that keep copies of the files
+func (b *BaseFileBackend) Replicas() []int {
+	return b.replicas
+}
+
+// encrypt returns the reader that encrypts src with the data key of the domain or the current key of the keyring,
+// the key is recorded in the file
+func (b *BaseFileBackend) encrypt(src io.Reader, file File) (io.Reader, model.AppError) {
+	if b.keyring == nil {
+		return nil, model.NewInternalError("utils.file.encrypt.app_error", "keyring is not configured")
+	}
+
+	if b.keyring.domains != nil {
+		id, c, err := b.keyring.domains.ActiveKey(file.Domain())
+		if err != nil {
+			return nil, model.NewInternalError("utils.file.encrypt.domain_key", err.Error())
+		}
+		file.SetPropertyString(DomainKeyProperty, strconv.FormatInt(id, 10))
+		if file.GetPropertyString(KeyVersionProperty) != "" {
+			file.SetPropertyString(KeyVersionProperty, "")
+		}
+
+		return NewEncryptingReader(src, c), nil
+	}
+
+	if file.GetPropertyString(DomainKeyProperty) != "" {
+		file.SetPropertyString(DomainKeyProperty, "")
+	}
+	version := b.keyring.Current()
+	c, err := b.keyring.Chipher(version)
+	if err != nil {
+		return nil, model.NewInternalError("utils.file.encrypt.app_error", err.Error())
+	}
+	file.SetPropertyString(KeyVersionProperty, strconv.Itoa(version))
+
+	return NewEncryptingReader(src, c), nil
+}
+
+// decrypt returns the reader that decrypts src with the key the file is encrypted with, the domain key or the version of the keyring
+func (b *BaseFileBackend) decrypt(src io.ReadCloser, file File, offset int64) (io.ReadCloser, model.AppError) {
+	if b.keyring == nil {
+		src.Close()
+		return nil, model.NewInternalError("utils.file.decrypt.app_error", "keyring is not configured")
+	}
+
+	var c Chipher
+	var err error
+	if id, ok := FileDomainKey(file); ok {
+		if b.keyring.domains == nil {
+			src.Close()
+			return nil, model.NewInternalError("utils.file.decrypt.domain_key", "domain keys are not configured")
+		}
+		c, err = b.keyring.domains.Key(file.Domain(), id)
+	} else {
+		c, err = b.keyring.Chipher(FileKeyVersion(file))
+	}
+	if err != nil {
+		src.Close()
+		return nil, model.NewInternalError("utils.file.decrypt.app_error", err.Error())
+	}
+
+	return NewDecryptingReader(src, c, offset), nil
+}
+
 // save to megabytes
 func (b *BaseFileBackend) setWriteSize(writtenBytes int64) {
 	b.Lock()
@@ -82,9 +148,21 @@ type FileBackend interface {
 	ExpireDay() int
 	Name() string
 	Id() *int
+	Replicas() []int
 }
 
-func NewBackendStore(profile *model.FileBackendProfile, chipher Chipher) (FileBackend, model.AppError) {
+// DirectUploadBackend issues presigned urls to write objects to the backend bypassing the service
+type DirectUploadBackend interface {
+	FileBackend
+	PresignPut(file File, size int64, expire time.Duration) (string, map[string]string, model.AppError)
+	CreateMultipartUpload(file File) (string, model.AppError)
+	PresignPart(file File, uploadId string, number int, size int64, expire time.Duration) (string, model.AppError)
+	CompleteMultipartUpload(file File, uploadId string, parts []model.DirectUploadPart) model.AppError
+	AbortMultipartUpload(file File, uploadId string) model.AppError
+	Stat(file File) (int64, string, model.AppError)
+}
+
+func NewBackendStore(profile *model.FileBackendProfile, keyring *Keyring) (FileBackend, model.AppError) {
 	switch profile.Type {
 	case model.FileDriverLocal:
 		return &LocalFileBackend{
@@ -93,7 +171,8 @@ func NewBackendStore(profile *model.FileBackendProfile, chipher Chipher) (FileBa
 				syncTime:  profile.UpdatedAt,
 				writeSize: 0,
 				expireDay: profile.ExpireDay,
-				chipher:   chipher,
+				keyring:   keyring,
+				replicas:  profile.ReplicaIds(),
 			},
 			name:        profile.Name,
 			directory:   profile.Properties.GetString("directory"),
@@ -106,7 +185,8 @@ func NewBackendStore(profile *model.FileBackendProfile, chipher Chipher) (FileBa
 				syncTime:  profile.UpdatedAt,
 				writeSize: 0,
 				expireDay: profile.ExpireDay,
-				chipher:   chipher,
+				keyring:   keyring,
+				replicas:  profile.ReplicaIds(),
 			},
 			name:           profile.Name,
 			pathPattern:    profile.Properties.GetString("path_pattern"),
@@ -121,6 +201,90 @@ func NewBackendStore(profile *model.FileBackendProfile, chipher Chipher) (FileBa
 			return d, err
 		}
 		return d, nil
+	case model.FileDriverGDrive:
+		d := &GDriveFileBackend{
+			BaseFileBackend: BaseFileBackend{
+				id:        int(profile.Id),
+				syncTime:  profile.UpdatedAt,
+				writeSize: 0,
+				expireDay: profile.ExpireDay,
+				keyring:   keyring,
+				replicas:  profile.ReplicaIds(),
+			},
+			name:        profile.Name,
+			pathPattern: profile.Properties.GetString("path_pattern"),
+			email:       profile.Properties.GetString("email"),
+			privateKey:  profile.Properties.GetString("private_key"),
+			rootFolder:  profile.Properties.GetString("directory"),
+			endpoint:    profile.Properties.GetString("endpoint"),
+		}
+		if d.rootFolder == "" {
+			d.rootFolder = gDriveRootFolder
+		}
+		if err := d.TestConnection(); err != nil {
+			return d, err
+		}
+		return d, nil
+	case model.FileDriverDropBox:
+		d := &DropBoxFileBackend{
+			BaseFileBackend: BaseFileBackend{
+				id:        int(profile.Id),
+				syncTime:  profile.UpdatedAt,
+				writeSize: 0,
+				expireDay: profile.ExpireDay,
+				keyring:   keyring,
+				replicas:  profile.ReplicaIds(),
+			},
+			name:        profile.Name,
+			pathPattern: profile.Properties.GetString("path_pattern"),
+			token:       profile.Properties.GetString("token"),
+		}
+		if err := d.TestConnection(); err != nil {
+			return d, err
+		}
+		return d, nil
+	case model.FileDriverAzure:
+		d := &AzureBlobFileBackend{
+			BaseFileBackend: BaseFileBackend{
+				id:        int(profile.Id),
+				syncTime:  profile.UpdatedAt,
+				writeSize: 0,
+				expireDay: profile.ExpireDay,
+				keyring:   keyring,
+				replicas:  profile.ReplicaIds(),
+			},
+			name:        profile.Name,
+			pathPattern: profile.Properties.GetString("path_pattern"),
+			accountName: profile.Properties.GetString("account_name"),
+			accountKey:  profile.Properties.GetString("account_key"),
+			sasToken:    profile.Properties.GetString("sas_token"),
+			container:   profile.Properties.GetString("container"),
+			endpoint:    profile.Properties.GetString("endpoint"),
+		}
+		if err := d.TestConnection(); err != nil {
+			return d, err
+		}
+		return d, nil
+	case model.FileDriverGCS:
+		d := &GCSFileBackend{
+			BaseFileBackend: BaseFileBackend{
+				id:        int(profile.Id),
+				syncTime:  profile.UpdatedAt,
+				writeSize: 0,
+				expireDay: profile.ExpireDay,
+				keyring:   keyring,
+				replicas:  profile.ReplicaIds(),
+			},
+			name:        profile.Name,
+			pathPattern: profile.Properties.GetString("path_pattern"),
+			bucket:      profile.Properties.GetString("bucket_name"),
+			credentials: profile.Properties.GetString("credentials"),
+			endpoint:    profile.Properties.GetString("endpoint"),
+		}
+		if err := d.TestConnection(); err != nil {
+			return d, err
+		}
+		return d, nil
 	}
 
 	return nil, model.NewInternalError("api.file.no_driver.app_error", "")
@@ -0,0 +1,209 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/bloberror"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blockblob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/container"
+	"github.com/webitel/storage/model"
+	"github.com/webitel/wlog"
+)
+
+const (
+	azureBlobEndpoint = "https://%s.blob.core.windows.net"
+	// probe object of TestConnection, checks create and delete permissions
+	azureBlobProbe = ".webitel_probe"
+	// StartCopyFromURL is asynchronous, wait for the copy to complete
+	azureBlobCopyTimeout = 5 * time.Minute
+)
+
+// AzureBlobFileBackend stores files in a container of Azure Blob Storage, "location" keeps the blob name.
+// Endpoint may point to Azurite, e.g. http://127.0.0.1:10000/devstoreaccount1
+type AzureBlobFileBackend struct {
+	BaseFileBackend
+	name        string
+	accountName string
+	accountKey  string
+	sasToken    string
+	container   string
+	endpoint    string
+	pathPattern string
+	client      *container.Client
+}
+
+func (self *AzureBlobFileBackend) Name() string {
+	return self.name
+}
+
+func (self *AzureBlobFileBackend) GetStoreDirectory(f File) string {
+	return path.Join(parseStorePattern(self.pathPattern, f))
+}
+
+func (self *AzureBlobFileBackend) containerURL() string {
+	endpoint := self.endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf(azureBlobEndpoint, self.accountName)
+	}
+
+	u := strings.TrimRight(endpoint, "/") + "/" + url.PathEscape(self.container)
+	if self.sasToken != "" {
+		u += "?" + strings.TrimPrefix(self.sasToken, "?")
+	}
+
+	return u
+}
+
+func (self *AzureBlobFileBackend) TestConnection() model.AppError {
+	var client *container.Client
+	var err error
+
+	if self.accountKey != "" {
+		var cred *container.SharedKeyCredential
+		if cred, err = container.NewSharedKeyCredential(self.accountName, self.accountKey); err != nil {
+			return model.NewInternalError("utils.file.azure_blob.test_connection.app_error", err.Error())
+		}
+		client, err = container.NewClientWithSharedKeyCredential(self.containerURL(), cred, nil)
+	} else {
+		client, err = container.NewClientWithNoCredential(self.containerURL(), nil)
+	}
+
+	if err != nil {
+		return model.NewInternalError("utils.file.azure_blob.test_connection.app_error", err.Error())
+	}
+
+	ctx := context.Background()
+	probe := client.NewBlockBlobClient(azureBlobProbe)
+
+	if _, err = probe.UploadBuffer(ctx, []byte{}, nil); err != nil {
+		return model.NewInternalError("utils.file.azure_blob.test_connection.app_error", err.Error())
+	}
+	if _, err = probe.Delete(ctx, nil); err != nil {
+		return model.NewInternalError("utils.file.azure_blob.test_connection.app_error", err.Error())
+	}
+
+	self.client = client
+
+	return nil
+}
+
+func (self *AzureBlobFileBackend) Write(src io.Reader, file File) (int64, model.AppError) {
+	directory := self.GetStoreDirectory(file)
+	location := path.Join(directory, file.GetStoreName())
+	isEncrypted := file.IsEncrypted()
+
+	if isEncrypted {
+		var err model.AppError
+		if src, err = self.encrypt(src, file); err != nil {
+			return 0, err
+		}
+	}
+
+	body := NewCountingReader(src)
+	mimeType := file.GetMimeType()
+	_, err := self.client.NewBlockBlobClient(location).UploadStream(context.Background(), body, &blockblob.UploadStreamOptions{
+		HTTPHeaders: &blob.HTTPHeaders{
+			BlobContentType: &mimeType,
+		},
+	})
+
+	if err != nil {
+		var apperr model.AppError
+		if errors.As(err, &apperr) {
+			return 0, apperr
+		}
+
+		return 0, model.NewInternalError("utils.file.azure_blob.writing.app_error", err.Error())
+	}
+
+	self.setWriteSize(body.Count())
+
+	written := body.Count()
+	if isEncrypted {
+		written, _ = EstimateOriginalSize(written)
+	}
+
+	file.SetPropertyString("location", location)
+	wlog.Debug(fmt.Sprintf("[%s] create new file %s", self.name, location))
+
+	return written, nil
+}
+
+func (self *AzureBlobFileBackend) Remove(file File) model.AppError {
+	_, err := self.client.NewBlobClient(file.GetPropertyString("location")).Delete(context.Background(), nil)
+	if err != nil {
+		if bloberror.HasCode(err, bloberror.BlobNotFound) {
+			return model.NewNotFoundError("utils.file.azure_blob.removing.not_found", err.Error())
+		}
+		return model.NewInternalError("utils.file.azure_blob.removing.app_error", err.Error())
+	}
+
+	return nil
+}
+
+func (self *AzureBlobFileBackend) CopyTo(file File, to func(string) string) model.AppError {
+	oldPath := file.GetPropertyString("location")
+	newPath := to(oldPath)
+
+	file.SetPropertyString("old_path", oldPath)
+
+	ctx, cancel := context.WithTimeout(context.Background(), azureBlobCopyTimeout)
+	defer cancel()
+
+	dst := self.client.NewBlobClient(newPath)
+	res, err := dst.StartCopyFromURL(ctx, self.client.NewBlobClient(oldPath).URL(), nil)
+	if err != nil {
+		return model.NewInternalError("utils.file.azure_blob.copy", err.Error())
+	}
+
+	status := res.CopyStatus
+	for status != nil && *status == blob.CopyStatusTypePending {
+		select {
+		case <-ctx.Done():
+			return model.NewInternalError("utils.file.azure_blob.copy", ctx.Err().Error())
+		case <-time.After(time.Second):
+		}
+
+		props, err := dst.GetProperties(ctx, nil)
+		if err != nil {
+			return model.NewInternalError("utils.file.azure_blob.copy", err.Error())
+		}
+		status = props.CopyStatus
+	}
+
+	if status != nil && *status != blob.CopyStatusTypeSuccess {
+		return model.NewInternalError("utils.file.azure_blob.copy", fmt.Sprintf("copy status %s", *status))
+	}
+
+	file.SetPropertyString("location", newPath)
+
+	return nil
+}
+
+func (self *AzureBlobFileBackend) Reader(file File, offset int64) (io.ReadCloser, model.AppError) {
+	var opts *blob.DownloadStreamOptions
+	if offset > 0 {
+		opts = &blob.DownloadStreamOptions{
+			Range: blob.HTTPRange{Offset: EstimateFirstBlockOffset(file, offset)},
+		}
+	}
+
+	res, err := self.client.NewBlobClient(file.GetPropertyString("location")).DownloadStream(context.Background(), opts)
+	if err != nil {
+		return nil, model.NewInternalError("utils.file.azure_blob.reader.app_error", err.Error())
+	}
+
+	if file.IsEncrypted() {
+		return self.decrypt(res.Body, file, offset)
+	}
+
+	return res.Body, nil
+}
@@ -0,0 +1,193 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// azureBlobStandIn keeps blobs in memory and serves the subset of Blob REST API used by the backend, like Azurite
+type azureBlobStandIn struct {
+	sync.Mutex
+	account   string
+	container string
+	blobs     map[string][]byte
+	blocks    map[string][]byte
+}
+
+func newAzureBlobStandIn(account, container string) (*azureBlobStandIn, *httptest.Server) {
+	s := &azureBlobStandIn{
+		account:   account,
+		container: container,
+		blobs:     make(map[string][]byte),
+		blocks:    make(map[string][]byte),
+	}
+	return s, httptest.NewServer(s)
+}
+
+func (s *azureBlobStandIn) error(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("x-ms-error-code", code)
+	w.WriteHeader(status)
+	fmt.Fprintf(w, `<?xml version="1.0" encoding="utf-8"?><Error><Code>%s</Code><Message>%s</Message></Error>`, code, code)
+}
+
+func (s *azureBlobStandIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.Lock()
+	defer s.Unlock()
+
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "SharedKey "+s.account+":") {
+		s.error(w, http.StatusForbidden, "AuthenticationFailed")
+		return
+	}
+
+	parts := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/"+s.account+"/"), "/", 2)
+	if len(parts) != 2 || parts[0] != s.container {
+		s.error(w, http.StatusNotFound, "ContainerNotFound")
+		return
+	}
+	name, _ := url.PathUnescape(parts[1])
+	q := r.URL.Query()
+
+	w.Header().Set("x-ms-request-id", "test")
+	w.Header().Set("ETag", `"0x1"`)
+	w.Header().Set("Last-Modified", time.Now().UTC().Format(http.TimeFormat))
+
+	switch {
+	case r.Method == http.MethodPut && q.Get("comp") == "block":
+		data, _ := io.ReadAll(r.Body)
+		s.blocks[name+"/"+q.Get("blockid")] = data
+		w.WriteHeader(http.StatusCreated)
+	case r.Method == http.MethodPut && q.Get("comp") == "blocklist":
+		var list struct {
+			Latest []string `xml:"Latest"`
+		}
+		xml.NewDecoder(r.Body).Decode(&list)
+		var data []byte
+		for _, id := range list.Latest {
+			data = append(data, s.blocks[name+"/"+id]...)
+		}
+		s.blobs[name] = data
+		w.WriteHeader(http.StatusCreated)
+	case r.Method == http.MethodPut && r.Header.Get("x-ms-copy-source") != "":
+		src, _ := url.Parse(r.Header.Get("x-ms-copy-source"))
+		data, ok := s.blobs[strings.TrimPrefix(src.Path, "/"+s.account+"/"+s.container+"/")]
+		if !ok {
+			s.error(w, http.StatusNotFound, "CannotVerifyCopySource")
+			return
+		}
+		s.blobs[name] = append([]byte(nil), data...)
+		w.Header().Set("x-ms-copy-id", "copy")
+		w.Header().Set("x-ms-copy-status", "success")
+		w.WriteHeader(http.StatusAccepted)
+	case r.Method == http.MethodPut:
+		data, _ := io.ReadAll(r.Body)
+		s.blobs[name] = data
+		w.WriteHeader(http.StatusCreated)
+	case r.Method == http.MethodDelete:
+		if _, ok := s.blobs[name]; !ok {
+			s.error(w, http.StatusNotFound, "BlobNotFound")
+			return
+		}
+		delete(s.blobs, name)
+		w.WriteHeader(http.StatusAccepted)
+	case r.Method == http.MethodGet:
+		data, ok := s.blobs[name]
+		if !ok {
+			s.error(w, http.StatusNotFound, "BlobNotFound")
+			return
+		}
+		if rng := r.Header.Get("x-ms-range"); rng != "" {
+			r.Header.Set("Range", rng)
+		}
+		w.Header().Set("x-ms-blob-type", "BlockBlob")
+		http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(data))
+	default:
+		s.error(w, http.StatusBadRequest, "UnsupportedHttpVerb")
+	}
+}
+
+func newTestAzureBlobBackend(t *testing.T, account string) (*AzureBlobFileBackend, *azureBlobStandIn) {
+	s, srv := newAzureBlobStandIn("devstoreaccount1", "records")
+	t.Cleanup(srv.Close)
+
+	return &AzureBlobFileBackend{
+		BaseFileBackend: BaseFileBackend{
+			keyring: testBackendKeyring(t),
+		},
+		name:        "azure",
+		accountName: account,
+		accountKey:  base64.StdEncoding.EncodeToString([]byte("key")),
+		container:   "records",
+		endpoint:    srv.URL + "/devstoreaccount1",
+		pathPattern: "$DOMAIN/$CHANNEL",
+	}, s
+}
+
+func TestAzureBlobTestConnection(t *testing.T) {
+	b, _ := newTestAzureBlobBackend(t, "other")
+	if err := b.TestConnection(); err == nil {
+		t.Fatal("expected error for invalid account")
+	}
+
+	b, s := newTestAzureBlobBackend(t, "devstoreaccount1")
+	if err := b.TestConnection(); err != nil {
+		t.Fatal(err)
+	}
+	if len(s.blobs) != 0 {
+		t.Fatalf("probe blob was not removed: %v", s.blobs)
+	}
+}
+
+func TestAzureBlobFileBackend(t *testing.T) {
+	for _, encrypted := range []bool{false, true} {
+		t.Run(fmt.Sprintf("encrypted=%v", encrypted), func(t *testing.T) {
+			b, _ := newTestAzureBlobBackend(t, "devstoreaccount1")
+			if err := b.TestConnection(); err != nil {
+				t.Fatal(err)
+			}
+
+			data := bytes.Repeat([]byte("0123456789"), 300000)
+			f := testBackendFile(encrypted)
+
+			n, err := b.Write(bytes.NewReader(data), f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n != int64(len(data)) {
+				t.Fatalf("written %d, expected %d", n, len(data))
+			}
+			if loc := f.GetPropertyString("location"); loc != "1/call/record.wav" {
+				t.Fatalf("unexpected location %s", loc)
+			}
+
+			checkBackendRead(t, b, f, data, 0)
+			checkBackendRead(t, b, f, data, 1234567)
+
+			if err = b.CopyTo(f, func(s string) string {
+				return strings.Replace(s, "/call/", "/chat/", 1)
+			}); err != nil {
+				t.Fatal(err)
+			}
+			if loc := f.GetPropertyString("location"); loc != "1/chat/record.wav" {
+				t.Fatalf("unexpected location %s", loc)
+			}
+			checkBackendRead(t, b, f, data, 0)
+
+			if err = b.Remove(f); err != nil {
+				t.Fatal(err)
+			}
+			if err = b.Remove(f); err == nil || err.GetStatusCode() != http.StatusNotFound {
+				t.Fatalf("expected not found, got %v", err)
+			}
+		})
+	}
+}
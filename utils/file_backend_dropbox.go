@@ -0,0 +1,299 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/webitel/storage/model"
+	"github.com/webitel/wlog"
+)
+
+const (
+	dropBoxApiEndpoint     = "https://api.dropboxapi.com"
+	dropBoxContentEndpoint = "https://content.dropboxapi.com"
+	// upload_session accepts up to 150 MB per request
+	dropBoxChunkSize = 8 * 1024 * 1024
+)
+
+// DropBoxFileBackend stores files in Dropbox over HTTP API v2, "location" keeps the full object path
+type DropBoxFileBackend struct {
+	BaseFileBackend
+	name            string
+	token           string
+	pathPattern     string
+	apiEndpoint     string
+	contentEndpoint string
+	client          *http.Client
+}
+
+type dropBoxCursor struct {
+	SessionId string `json:"session_id"`
+	Offset    int64  `json:"offset"`
+}
+
+type dropBoxCommit struct {
+	Path string `json:"path"`
+	Mode string `json:"mode"`
+	Mute bool   `json:"mute"`
+}
+
+type dropBoxError struct {
+	Summary string `json:"error_summary"`
+	path    string
+	status  int
+}
+
+func (e *dropBoxError) Error() string {
+	return fmt.Sprintf("dropbox %s: status %d, %s", e.path, e.status, e.Summary)
+}
+
+// isDropBoxNotFound reports whether the path of the request doesn't exist: path/not_found or path_lookup/not_found
+func isDropBoxNotFound(err error) bool {
+	var e *dropBoxError
+	return errors.As(err, &e) && e.status == http.StatusConflict && strings.Contains(e.Summary, "/not_found")
+}
+
+func (self *DropBoxFileBackend) Name() string {
+	return self.name
+}
+
+func (self *DropBoxFileBackend) GetStoreDirectory(f File) string {
+	return path.Join(parseStorePattern(self.pathPattern, f))
+}
+
+func (self *DropBoxFileBackend) TestConnection() model.AppError {
+	if self.client == nil {
+		self.client = http.DefaultClient
+	}
+	if self.apiEndpoint == "" {
+		self.apiEndpoint = dropBoxApiEndpoint
+	}
+	if self.contentEndpoint == "" {
+		self.contentEndpoint = dropBoxContentEndpoint
+	}
+
+	res, err := self.rpc(self.apiEndpoint, "/2/users/get_current_account", nil, nil, nil)
+	if err != nil {
+		return model.NewInternalError("utils.file.dropbox.test_connection.app_error", err.Error())
+	}
+	res.Body.Close()
+
+	return nil
+}
+
+func (self *DropBoxFileBackend) Write(src io.Reader, file File) (int64, model.AppError) {
+	directory := self.GetStoreDirectory(file)
+	location := "/" + path.Join(directory, file.GetStoreName())
+	isEncrypted := file.IsEncrypted()
+
+	if isEncrypted {
+		var err model.AppError
+		if src, err = self.encrypt(src, file); err != nil {
+			return 0, err
+		}
+	}
+
+	written, err := self.upload(src, location)
+	if err != nil {
+		switch err.(type) {
+		case model.AppError:
+			return 0, err.(model.AppError)
+		default:
+			return 0, model.NewInternalError("utils.file.dropbox.writing.app_error", err.Error())
+		}
+	}
+
+	self.setWriteSize(written)
+
+	if isEncrypted {
+		written, _ = EstimateOriginalSize(written)
+	}
+
+	file.SetPropertyString("location", location)
+	wlog.Debug(fmt.Sprintf("[%s] create new file %s", self.name, location))
+
+	return written, nil
+}
+
+// upload streams src with an upload session, so the size is not required in advance
+func (self *DropBoxFileBackend) upload(src io.Reader, location string) (int64, error) {
+	buf := make([]byte, dropBoxChunkSize)
+	var cursor dropBoxCursor
+
+	res, err := self.rpc(self.contentEndpoint, "/2/files/upload_session/start", map[string]any{"close": false}, bytes.NewReader(nil), &cursor)
+	if err != nil {
+		return 0, err
+	}
+	res.Body.Close()
+
+	for {
+		n, rErr := io.ReadFull(src, buf)
+		if n > 0 {
+			res, err = self.rpc(self.contentEndpoint, "/2/files/upload_session/append_v2", map[string]any{
+				"cursor": cursor,
+				"close":  false,
+			}, bytes.NewReader(buf[:n]), nil)
+			if err != nil {
+				return 0, err
+			}
+			res.Body.Close()
+			cursor.Offset += int64(n)
+		}
+
+		if rErr == io.EOF || rErr == io.ErrUnexpectedEOF {
+			break
+		} else if rErr != nil {
+			return 0, rErr
+		}
+	}
+
+	res, err = self.rpc(self.contentEndpoint, "/2/files/upload_session/finish", map[string]any{
+		"cursor": cursor,
+		"commit": dropBoxCommit{
+			Path: location,
+			Mode: "overwrite",
+			Mute: true,
+		},
+	}, bytes.NewReader(nil), nil)
+	if err != nil {
+		return 0, err
+	}
+	res.Body.Close()
+
+	return cursor.Offset, nil
+}
+
+func (self *DropBoxFileBackend) Remove(file File) model.AppError {
+	res, err := self.rpc(self.apiEndpoint, "/2/files/delete_v2", nil, jsonBody(map[string]string{
+		"path": file.GetPropertyString("location"),
+	}), nil)
+	if err != nil {
+		if isDropBoxNotFound(err) {
+			return model.NewNotFoundError("utils.file.dropbox.removing.not_found", err.Error())
+		}
+		return model.NewInternalError("utils.file.dropbox.removing.app_error", err.Error())
+	}
+	res.Body.Close()
+
+	return nil
+}
+
+func (self *DropBoxFileBackend) CopyTo(file File, to func(string) string) model.AppError {
+	oldPath := file.GetPropertyString("location")
+	newPath := to(oldPath)
+
+	file.SetPropertyString("old_path", oldPath)
+
+	res, err := self.rpc(self.apiEndpoint, "/2/files/copy_v2", nil, jsonBody(map[string]any{
+		"from_path":  oldPath,
+		"to_path":    newPath,
+		"autorename": false,
+	}), nil)
+	if err != nil {
+		return model.NewInternalError("utils.file.dropbox.copy", err.Error())
+	}
+	res.Body.Close()
+
+	file.SetPropertyString("location", newPath)
+
+	return nil
+}
+
+func (self *DropBoxFileBackend) Reader(file File, offset int64) (io.ReadCloser, model.AppError) {
+	req, err := self.request(self.contentEndpoint, "/2/files/download", map[string]string{
+		"path": file.GetPropertyString("location"),
+	}, nil)
+	if err != nil {
+		return nil, model.NewInternalError("utils.file.dropbox.reader.app_error", err.Error())
+	}
+
+	if offset > 0 {
+		req.Header.Set("Range", "bytes="+strconv.FormatInt(EstimateFirstBlockOffset(file, offset), 10)+"-")
+	}
+
+	res, err := self.do(req, nil)
+	if err != nil {
+		return nil, model.NewInternalError("utils.file.dropbox.reader.app_error", err.Error())
+	}
+
+	if file.IsEncrypted() {
+		return self.decrypt(res.Body, file, offset)
+	}
+
+	return res.Body, nil
+}
+
+// rpc sends the request, arg goes to the Dropbox-API-Arg header of content endpoints and
+// body is the raw content for them, otherwise body is the JSON argument
+func (self *DropBoxFileBackend) rpc(endpoint, method string, arg any, body io.Reader, out any) (*http.Response, error) {
+	req, err := self.request(endpoint, method, arg, body)
+	if err != nil {
+		return nil, err
+	}
+
+	return self.do(req, out)
+}
+
+func (self *DropBoxFileBackend) request(endpoint, method string, arg any, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequest(http.MethodPost, endpoint+method, body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+self.token)
+	if arg != nil {
+		a, err := json.Marshal(arg)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Dropbox-API-Arg", string(a))
+	}
+
+	if body != nil {
+		if arg != nil {
+			req.Header.Set("Content-Type", "application/octet-stream")
+		} else {
+			req.Header.Set("Content-Type", "application/json")
+		}
+	}
+
+	return req, nil
+}
+
+func (self *DropBoxFileBackend) do(req *http.Request, out any) (*http.Response, error) {
+	res, err := self.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		defer res.Body.Close()
+		e := &dropBoxError{path: req.URL.Path, status: res.StatusCode}
+		data, _ := io.ReadAll(res.Body)
+		if json.Unmarshal(data, e) != nil || e.Summary == "" {
+			e.Summary = string(data)
+		}
+		return nil, e
+	}
+
+	if out != nil {
+		defer res.Body.Close()
+		if err = json.NewDecoder(res.Body).Decode(out); err != nil {
+			return nil, err
+		}
+	}
+
+	return res, nil
+}
+
+func jsonBody(v any) io.Reader {
+	data, _ := json.Marshal(v)
+	return bytes.NewReader(data)
+}
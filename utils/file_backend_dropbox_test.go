@@ -0,0 +1,225 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/webitel/storage/model"
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// dropBoxStandIn keeps uploaded objects in memory and serves the subset of Dropbox API v2 used by the backend
+type dropBoxStandIn struct {
+	sync.Mutex
+	token    string
+	objects  map[string][]byte
+	sessions map[string][]byte
+}
+
+func newDropBoxStandIn(token string) *httptest.Server {
+	s := &dropBoxStandIn{
+		token:    token,
+		objects:  make(map[string][]byte),
+		sessions: make(map[string][]byte),
+	}
+	return httptest.NewServer(s)
+}
+
+func (s *dropBoxStandIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Bearer "+s.token {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]string{"error_summary": "invalid_access_token/"})
+		return
+	}
+
+	var arg struct {
+		Path     string        `json:"path"`
+		FromPath string        `json:"from_path"`
+		ToPath   string        `json:"to_path"`
+		Cursor   dropBoxCursor `json:"cursor"`
+		Commit   dropBoxCommit `json:"commit"`
+	}
+	if h := r.Header.Get("Dropbox-API-Arg"); h != "" {
+		json.Unmarshal([]byte(h), &arg)
+	} else if r.Header.Get("Content-Type") == "application/json" {
+		json.NewDecoder(r.Body).Decode(&arg)
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	switch r.URL.Path {
+	case "/2/users/get_current_account":
+		json.NewEncoder(w).Encode(map[string]string{"account_id": "test"})
+	case "/2/files/upload_session/start":
+		id := fmt.Sprintf("session-%d", len(s.sessions)+1)
+		data, _ := io.ReadAll(r.Body)
+		s.sessions[id] = data
+		json.NewEncoder(w).Encode(map[string]string{"session_id": id})
+	case "/2/files/upload_session/append_v2":
+		data, _ := io.ReadAll(r.Body)
+		if int64(len(s.sessions[arg.Cursor.SessionId])) != arg.Cursor.Offset {
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(map[string]string{"error_summary": "incorrect_offset/"})
+			return
+		}
+		s.sessions[arg.Cursor.SessionId] = append(s.sessions[arg.Cursor.SessionId], data...)
+		w.Write([]byte("null"))
+	case "/2/files/upload_session/finish":
+		s.objects[arg.Commit.Path] = s.sessions[arg.Cursor.SessionId]
+		delete(s.sessions, arg.Cursor.SessionId)
+		json.NewEncoder(w).Encode(map[string]string{"path_display": arg.Commit.Path})
+	case "/2/files/download":
+		data, ok := s.objects[arg.Path]
+		if !ok {
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(map[string]string{"error_summary": "path/not_found/"})
+			return
+		}
+		http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(data))
+	case "/2/files/delete_v2":
+		if _, ok := s.objects[arg.Path]; !ok {
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(map[string]string{"error_summary": "path_lookup/not_found/"})
+			return
+		}
+		delete(s.objects, arg.Path)
+		w.Write([]byte("{}"))
+	case "/2/files/copy_v2":
+		s.objects[arg.ToPath] = append([]byte(nil), s.objects[arg.FromPath]...)
+		w.Write([]byte("{}"))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func testBackendFile(encrypted bool) *model.File {
+	ch := "call"
+	f := &model.File{
+		DomainId: 1,
+		Uuid:     "uuid",
+		BaseFile: model.BaseFile{
+			Name:       "record.wav",
+			MimeType:   "audio/wav",
+			Channel:    &ch,
+			Properties: model.StringInterface{},
+		},
+	}
+	f.SetEncrypted(encrypted)
+	return f
+}
+
+func testBackendKeyring(t *testing.T) *Keyring {
+	c, err := chacha20poly1305.New(bytes.Repeat([]byte{7}, chacha20poly1305.KeySize))
+	if err != nil {
+		t.Fatal(err)
+	}
+	k, err := NewKeyring(map[int]Chipher{0: c})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return k
+}
+
+func newTestDropBoxBackend(t *testing.T, token string) (*DropBoxFileBackend, *httptest.Server) {
+	srv := newDropBoxStandIn("token")
+	t.Cleanup(srv.Close)
+
+	return &DropBoxFileBackend{
+		BaseFileBackend: BaseFileBackend{
+			keyring: testBackendKeyring(t),
+		},
+		name:            "dropbox",
+		token:           token,
+		pathPattern:     "$DOMAIN/$CHANNEL",
+		apiEndpoint:     srv.URL,
+		contentEndpoint: srv.URL,
+		client:          srv.Client(),
+	}, srv
+}
+
+func TestDropBoxTestConnection(t *testing.T) {
+	b, _ := newTestDropBoxBackend(t, "bad")
+	if err := b.TestConnection(); err == nil {
+		t.Fatal("expected error for invalid token")
+	}
+
+	b, _ = newTestDropBoxBackend(t, "token")
+	if err := b.TestConnection(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDropBoxFileBackend(t *testing.T) {
+	for _, encrypted := range []bool{false, true} {
+		t.Run(fmt.Sprintf("encrypted=%v", encrypted), func(t *testing.T) {
+			b, _ := newTestDropBoxBackend(t, "token")
+			if err := b.TestConnection(); err != nil {
+				t.Fatal(err)
+			}
+
+			data := bytes.Repeat([]byte("0123456789"), dropBoxChunkSize/5+3)
+			f := testBackendFile(encrypted)
+
+			n, err := b.Write(bytes.NewReader(data), f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n != int64(len(data)) {
+				t.Fatalf("written %d, expected %d", n, len(data))
+			}
+			if loc := f.GetPropertyString("location"); loc != "/1/call/record.wav" {
+				t.Fatalf("unexpected location %s", loc)
+			}
+
+			checkBackendRead(t, b, f, data, 0)
+			checkBackendRead(t, b, f, data, 100000)
+
+			if err = b.CopyTo(f, func(s string) string {
+				return strings.Replace(s, "/call/", "/chat/", 1)
+			}); err != nil {
+				t.Fatal(err)
+			}
+			if loc := f.GetPropertyString("location"); loc != "/1/chat/record.wav" {
+				t.Fatalf("unexpected location %s", loc)
+			}
+			checkBackendRead(t, b, f, data, 0)
+
+			if err = b.Remove(f); err != nil {
+				t.Fatal(err)
+			}
+			if _, err = b.Reader(f, 0); err == nil {
+				t.Fatal("expected error for removed file")
+			}
+			if err = b.Remove(f); err == nil || err.GetStatusCode() != http.StatusNotFound {
+				t.Fatalf("expected not found on the second remove, got %v", err)
+			}
+		})
+	}
+}
+
+func checkBackendRead(t *testing.T, b FileBackend, f File, data []byte, offset int64) {
+	t.Helper()
+
+	r, err := b.Reader(f, offset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	got, e := io.ReadAll(r)
+	if e != nil {
+		t.Fatal(e)
+	}
+	if !bytes.Equal(got, data[offset:]) {
+		t.Fatalf("read from %d: got %d bytes, expected %d", offset, len(got), len(data)-int(offset))
+	}
+}
@@ -0,0 +1,182 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"slices"
+	"strings"
+
+	"cloud.google.com/go/storage"
+	"github.com/webitel/storage/model"
+	"github.com/webitel/wlog"
+	"google.golang.org/api/option"
+)
+
+// gcsPermissions are required by the backend, checked by TestConnection
+var gcsPermissions = []string{
+	"storage.objects.create",
+	"storage.objects.get",
+	"storage.objects.delete",
+}
+
+// GCSFileBackend stores files in a Google Cloud Storage bucket, "location" keeps the object name.
+// Endpoint may point to fake-gcs-server, e.g. http://127.0.0.1:4443/storage/v1/
+type GCSFileBackend struct {
+	BaseFileBackend
+	name        string
+	bucket      string
+	credentials string
+	endpoint    string
+	pathPattern string
+	client      *storage.Client
+}
+
+func (self *GCSFileBackend) Name() string {
+	return self.name
+}
+
+func (self *GCSFileBackend) GetStoreDirectory(f File) string {
+	return path.Join(parseStorePattern(self.pathPattern, f))
+}
+
+func (self *GCSFileBackend) TestConnection() model.AppError {
+	ctx := context.Background()
+	opts := []option.ClientOption{}
+
+	if self.credentials != "" {
+		opts = append(opts, option.WithCredentialsJSON([]byte(self.credentials)))
+	} else if self.endpoint != "" {
+		opts = append(opts, option.WithoutAuthentication())
+	}
+
+	if self.endpoint != "" {
+		opts = append(opts, option.WithEndpoint(self.endpoint))
+	}
+
+	client, err := storage.NewClient(ctx, opts...)
+	if err != nil {
+		return model.NewInternalError("utils.file.gcs.test_connection.app_error", err.Error())
+	}
+
+	granted, err := client.Bucket(self.bucket).IAM().TestPermissions(ctx, gcsPermissions)
+	if err != nil {
+		client.Close()
+		return model.NewInternalError("utils.file.gcs.test_connection.app_error", err.Error())
+	}
+
+	if missing := missingPermissions(gcsPermissions, granted); len(missing) > 0 {
+		client.Close()
+		return model.NewForbiddenError("utils.file.gcs.test_connection.permissions", "missing permissions: "+strings.Join(missing, ", "))
+	}
+
+	self.client = client
+
+	return nil
+}
+
+func (self *GCSFileBackend) Write(src io.Reader, file File) (int64, model.AppError) {
+	directory := self.GetStoreDirectory(file)
+	location := path.Join(directory, file.GetStoreName())
+	isEncrypted := file.IsEncrypted()
+
+	if isEncrypted {
+		var err model.AppError
+		if src, err = self.encrypt(src, file); err != nil {
+			return 0, err
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	w := self.client.Bucket(self.bucket).Object(location).NewWriter(ctx)
+	w.ContentType = file.GetMimeType()
+
+	if _, err := io.Copy(w, src); err != nil {
+		// cancel aborts the upload, the object is not created
+		cancel()
+		w.Close()
+
+		var apperr model.AppError
+		if errors.As(err, &apperr) {
+			return 0, apperr
+		}
+		return 0, model.NewInternalError("utils.file.gcs.writing.app_error", err.Error())
+	}
+
+	if err := w.Close(); err != nil {
+		return 0, model.NewInternalError("utils.file.gcs.writing.app_error", err.Error())
+	}
+
+	written := w.Attrs().Size
+	self.setWriteSize(written)
+
+	if isEncrypted {
+		written, _ = EstimateOriginalSize(written)
+	}
+
+	file.SetPropertyString("location", location)
+	wlog.Debug(fmt.Sprintf("[%s] create new file %s", self.name, location))
+
+	return written, nil
+}
+
+func (self *GCSFileBackend) Remove(file File) model.AppError {
+	err := self.client.Bucket(self.bucket).Object(file.GetPropertyString("location")).Delete(context.Background())
+	if err != nil {
+		if errors.Is(err, storage.ErrObjectNotExist) {
+			return model.NewNotFoundError("utils.file.gcs.removing.not_found", err.Error())
+		}
+		return model.NewInternalError("utils.file.gcs.removing.app_error", err.Error())
+	}
+
+	return nil
+}
+
+func (self *GCSFileBackend) CopyTo(file File, to func(string) string) model.AppError {
+	oldPath := file.GetPropertyString("location")
+	newPath := to(oldPath)
+
+	file.SetPropertyString("old_path", oldPath)
+
+	bucket := self.client.Bucket(self.bucket)
+	if _, err := bucket.Object(newPath).CopierFrom(bucket.Object(oldPath)).Run(context.Background()); err != nil {
+		return model.NewInternalError("utils.file.gcs.copy", err.Error())
+	}
+
+	file.SetPropertyString("location", newPath)
+
+	return nil
+}
+
+func (self *GCSFileBackend) Reader(file File, offset int64) (io.ReadCloser, model.AppError) {
+	var start int64
+	if offset > 0 {
+		start = EstimateFirstBlockOffset(file, offset)
+	}
+
+	r, err := self.client.Bucket(self.bucket).Object(file.GetPropertyString("location")).NewRangeReader(context.Background(), start, -1)
+	if err != nil {
+		return nil, model.NewInternalError("utils.file.gcs.reader.app_error", err.Error())
+	}
+
+	if file.IsEncrypted() {
+		return self.decrypt(r, file, offset)
+	}
+
+	return r, nil
+}
+
+func missingPermissions(required, granted []string) []string {
+	var missing []string
+	for _, p := range required {
+		if !slices.Contains(granted, p) {
+			missing = append(missing, p)
+		}
+	}
+
+	return missing
+}
@@ -0,0 +1,191 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// gcsStandIn keeps objects in memory and serves the subset of GCS JSON and XML API used by the backend, like fake-gcs-server
+type gcsStandIn struct {
+	sync.Mutex
+	bucket      string
+	permissions []string
+	objects     map[string][]byte
+}
+
+func newGCSStandIn(bucket string, permissions []string) (*gcsStandIn, *httptest.Server) {
+	s := &gcsStandIn{
+		bucket:      bucket,
+		permissions: permissions,
+		objects:     make(map[string][]byte),
+	}
+	return s, httptest.NewServer(s)
+}
+
+func (s *gcsStandIn) meta(w http.ResponseWriter, name string) {
+	json.NewEncoder(w).Encode(map[string]string{
+		"kind":   "storage#object",
+		"bucket": s.bucket,
+		"name":   name,
+		"size":   strconv.Itoa(len(s.objects[name])),
+	})
+}
+
+func (s *gcsStandIn) notFound(w http.ResponseWriter) {
+	w.WriteHeader(http.StatusNotFound)
+	json.NewEncoder(w).Encode(map[string]any{"error": map[string]any{"code": 404, "message": "No such object"}})
+}
+
+func (s *gcsStandIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.Lock()
+	defer s.Unlock()
+
+	p := r.URL.EscapedPath()
+	bucketPath := "/storage/v1/b/" + s.bucket
+
+	switch {
+	case r.Method == http.MethodGet && p == bucketPath+"/iam/testPermissions":
+		var granted []string
+		for _, perm := range r.URL.Query()["permissions"] {
+			for _, g := range s.permissions {
+				if g == perm {
+					granted = append(granted, perm)
+				}
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]any{"permissions": granted})
+	case r.Method == http.MethodPost && p == "/upload"+bucketPath+"/o":
+		_, params, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		mr := multipart.NewReader(r.Body, params["boundary"])
+
+		var o struct {
+			Name string `json:"name"`
+		}
+		part, _ := mr.NextPart()
+		json.NewDecoder(part).Decode(&o)
+		part, _ = mr.NextPart()
+		s.objects[o.Name], _ = io.ReadAll(part)
+		s.meta(w, o.Name)
+	case r.Method == http.MethodPost && strings.Contains(p, "/rewriteTo/"):
+		names := strings.SplitN(strings.TrimPrefix(p, bucketPath+"/o/"), "/rewriteTo/b/"+s.bucket+"/o/", 2)
+		src, _ := url.PathUnescape(names[0])
+		dst, _ := url.PathUnescape(names[1])
+		data, ok := s.objects[src]
+		if !ok {
+			s.notFound(w)
+			return
+		}
+		s.objects[dst] = append([]byte(nil), data...)
+		json.NewEncoder(w).Encode(map[string]any{
+			"kind":                "storage#rewriteResponse",
+			"done":                true,
+			"totalBytesRewritten": strconv.Itoa(len(data)),
+			"objectSize":          strconv.Itoa(len(data)),
+			"resource":            map[string]string{"bucket": s.bucket, "name": dst},
+		})
+	case r.Method == http.MethodDelete && strings.HasPrefix(p, bucketPath+"/o/"):
+		name, _ := url.PathUnescape(strings.TrimPrefix(p, bucketPath+"/o/"))
+		if _, ok := s.objects[name]; !ok {
+			s.notFound(w)
+			return
+		}
+		delete(s.objects, name)
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodGet && strings.HasPrefix(p, "/"+s.bucket+"/"):
+		name, _ := url.PathUnescape(strings.TrimPrefix(p, "/"+s.bucket+"/"))
+		data, ok := s.objects[name]
+		if !ok {
+			s.notFound(w)
+			return
+		}
+		http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(data))
+	default:
+		w.WriteHeader(http.StatusNotImplemented)
+		fmt.Fprintf(w, "unexpected request %s %s", r.Method, r.URL)
+	}
+}
+
+func newTestGCSBackend(t *testing.T, permissions []string) (*GCSFileBackend, *gcsStandIn) {
+	s, srv := newGCSStandIn("records", permissions)
+	t.Cleanup(srv.Close)
+
+	return &GCSFileBackend{
+		BaseFileBackend: BaseFileBackend{
+			keyring: testBackendKeyring(t),
+		},
+		name:        "gcs",
+		bucket:      "records",
+		endpoint:    srv.URL + "/storage/v1/",
+		pathPattern: "$DOMAIN/$CHANNEL",
+	}, s
+}
+
+func TestGCSTestConnection(t *testing.T) {
+	b, _ := newTestGCSBackend(t, gcsPermissions[:1])
+	err := b.TestConnection()
+	if err == nil || err.GetStatusCode() != http.StatusForbidden {
+		t.Fatalf("expected forbidden, got %v", err)
+	}
+
+	b, _ = newTestGCSBackend(t, gcsPermissions)
+	if err = b.TestConnection(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGCSFileBackend(t *testing.T) {
+	for _, encrypted := range []bool{false, true} {
+		t.Run(fmt.Sprintf("encrypted=%v", encrypted), func(t *testing.T) {
+			b, _ := newTestGCSBackend(t, gcsPermissions)
+			if err := b.TestConnection(); err != nil {
+				t.Fatal(err)
+			}
+
+			data := bytes.Repeat([]byte("0123456789"), 300000)
+			f := testBackendFile(encrypted)
+
+			n, err := b.Write(bytes.NewReader(data), f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n != int64(len(data)) {
+				t.Fatalf("written %d, expected %d", n, len(data))
+			}
+			if loc := f.GetPropertyString("location"); loc != "1/call/record.wav" {
+				t.Fatalf("unexpected location %s", loc)
+			}
+
+			checkBackendRead(t, b, f, data, 0)
+			checkBackendRead(t, b, f, data, 1234567)
+
+			if err = b.CopyTo(f, func(s string) string {
+				return strings.Replace(s, "/call/", "/chat/", 1)
+			}); err != nil {
+				t.Fatal(err)
+			}
+			if loc := f.GetPropertyString("location"); loc != "1/chat/record.wav" {
+				t.Fatalf("unexpected location %s", loc)
+			}
+			checkBackendRead(t, b, f, data, 0)
+
+			if err = b.Remove(f); err != nil {
+				t.Fatal(err)
+			}
+			if err = b.Remove(f); err == nil || err.GetStatusCode() != http.StatusNotFound {
+				t.Fatalf("expected not found, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,245 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/webitel/storage/model"
+	"github.com/webitel/wlog"
+	"golang.org/x/oauth2/google"
+	"golang.org/x/oauth2/jwt"
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
+)
+
+const (
+	gDriveFolderMimeType = "application/vnd.google-apps.folder"
+	gDriveRootFolder     = "root"
+)
+
+// GDriveFileBackend stores files in Google Drive on behalf of a service account, under the folder
+// with id from the "directory" profile property.
+// Drive addresses objects by id, so "location" keeps the file id and "directory" the folder path
+type GDriveFileBackend struct {
+	BaseFileBackend
+	name        string
+	email       string
+	privateKey  string
+	rootFolder  string
+	endpoint    string
+	pathPattern string
+	client      *http.Client
+	svc         *drive.Service
+
+	foldersMu sync.Mutex
+	folders   map[string]string
+}
+
+func (self *GDriveFileBackend) Name() string {
+	return self.name
+}
+
+func (self *GDriveFileBackend) GetStoreDirectory(f File) string {
+	return path.Join(parseStorePattern(self.pathPattern, f))
+}
+
+func (self *GDriveFileBackend) TestConnection() model.AppError {
+	ctx := context.Background()
+	opts := []option.ClientOption{}
+
+	if self.client != nil {
+		opts = append(opts, option.WithHTTPClient(self.client))
+	} else {
+		conf := &jwt.Config{
+			Email:      self.email,
+			PrivateKey: []byte(strings.ReplaceAll(self.privateKey, `\n`, "\n")),
+			Scopes:     []string{drive.DriveScope},
+			TokenURL:   google.JWTTokenURL,
+		}
+		opts = append(opts, option.WithHTTPClient(conf.Client(ctx)))
+	}
+
+	if self.endpoint != "" {
+		opts = append(opts, option.WithEndpoint(self.endpoint))
+	}
+
+	svc, err := drive.NewService(ctx, opts...)
+	if err != nil {
+		return model.NewInternalError("utils.file.gdrive.test_connection.app_error", err.Error())
+	}
+
+	if _, err = svc.Files.Get(self.rootFolder).Fields("id").Do(); err != nil {
+		return model.NewInternalError("utils.file.gdrive.test_connection.app_error", err.Error())
+	}
+
+	self.svc = svc
+	self.folders = make(map[string]string)
+
+	return nil
+}
+
+func (self *GDriveFileBackend) Write(src io.Reader, file File) (int64, model.AppError) {
+	return self.write(src, file, self.GetStoreDirectory(file), file.IsEncrypted())
+}
+
+func (self *GDriveFileBackend) write(src io.Reader, file File, directory string, encrypt bool) (int64, model.AppError) {
+	parent, err := self.folder(directory)
+	if err != nil {
+		return 0, err
+	}
+
+	if encrypt {
+		var err model.AppError
+		if src, err = self.encrypt(src, file); err != nil {
+			return 0, err
+		}
+	}
+
+	res, e := self.svc.Files.Create(&drive.File{
+		Name:     file.GetStoreName(),
+		Parents:  []string{parent},
+		MimeType: file.GetMimeType(),
+	}).Media(src).Fields("id", "size").Do()
+
+	if e != nil {
+		switch e.(type) {
+		case model.AppError:
+			return 0, e.(model.AppError)
+		default:
+			return 0, model.NewInternalError("utils.file.gdrive.writing.app_error", e.Error())
+		}
+	}
+
+	self.setWriteSize(res.Size)
+
+	written := res.Size
+	if encrypt {
+		written, _ = EstimateOriginalSize(written)
+	}
+
+	file.SetPropertyString("directory", directory)
+	file.SetPropertyString("location", res.Id)
+	wlog.Debug(fmt.Sprintf("[%s] create new file %s/%s", self.name, directory, file.GetStoreName()))
+
+	return written, nil
+}
+
+func (self *GDriveFileBackend) Remove(file File) model.AppError {
+	err := self.svc.Files.Delete(file.GetPropertyString("location")).Do()
+	if err != nil {
+		if isGDriveNotFound(err) {
+			return model.NewNotFoundError("utils.file.gdrive.removing.not_found", err.Error())
+		}
+		return model.NewInternalError("utils.file.gdrive.removing.app_error", err.Error())
+	}
+
+	return nil
+}
+
+func (self *GDriveFileBackend) CopyTo(file File, to func(string) string) model.AppError {
+	oldPath := file.GetPropertyString("directory")
+	newPath := to(oldPath)
+
+	parent, err := self.folder(newPath)
+	if err != nil {
+		return err
+	}
+
+	file.SetPropertyString("old_path", oldPath)
+
+	res, e := self.svc.Files.Copy(file.GetPropertyString("location"), &drive.File{
+		Name:    file.GetStoreName(),
+		Parents: []string{parent},
+	}).Fields("id").Do()
+	if e != nil {
+		return model.NewInternalError("utils.file.gdrive.copy", e.Error())
+	}
+
+	file.SetPropertyString("directory", newPath)
+	file.SetPropertyString("location", res.Id)
+
+	return nil
+}
+
+func (self *GDriveFileBackend) Reader(file File, offset int64) (io.ReadCloser, model.AppError) {
+	call := self.svc.Files.Get(file.GetPropertyString("location"))
+	if offset > 0 {
+		call.Header().Set("Range", "bytes="+strconv.FormatInt(EstimateFirstBlockOffset(file, offset), 10)+"-")
+	}
+
+	res, err := call.Download()
+	if err != nil {
+		return nil, model.NewInternalError("utils.file.gdrive.reader.app_error", err.Error())
+	}
+
+	if file.IsEncrypted() {
+		return self.decrypt(res.Body, file, offset)
+	}
+
+	return res.Body, nil
+}
+
+// folder returns id of the directory, missing folders are created
+func (self *GDriveFileBackend) folder(directory string) (string, model.AppError) {
+	self.foldersMu.Lock()
+	defer self.foldersMu.Unlock()
+
+	parent := self.rootFolder
+	current := ""
+
+	for _, name := range strings.Split(directory, "/") {
+		if name == "" || name == "." {
+			continue
+		}
+		current = path.Join(current, name)
+
+		if id, ok := self.folders[current]; ok {
+			parent = id
+			continue
+		}
+
+		list, err := self.svc.Files.List().
+			Q(fmt.Sprintf("name = '%s' and '%s' in parents and mimeType = '%s' and trashed = false",
+				escapeGDriveQuery(name), parent, gDriveFolderMimeType)).
+			Fields("files(id)").
+			PageSize(1).
+			Do()
+		if err != nil {
+			return "", model.NewInternalError("utils.file.gdrive.folder.app_error", err.Error())
+		}
+
+		if len(list.Files) > 0 {
+			parent = list.Files[0].Id
+		} else {
+			f, err := self.svc.Files.Create(&drive.File{
+				Name:     name,
+				Parents:  []string{parent},
+				MimeType: gDriveFolderMimeType,
+			}).Fields("id").Do()
+			if err != nil {
+				return "", model.NewInternalError("utils.file.gdrive.folder.app_error", err.Error())
+			}
+			parent = f.Id
+		}
+
+		self.folders[current] = parent
+	}
+
+	return parent, nil
+}
+
+func escapeGDriveQuery(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s)
+}
+
+func isGDriveNotFound(err error) bool {
+	e, ok := err.(*googleapi.Error)
+	return ok && e.Code == http.StatusNotFound
+}
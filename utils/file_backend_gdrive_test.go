@@ -0,0 +1,219 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type gDriveObject struct {
+	name     string
+	parent   string
+	mimeType string
+	data     []byte
+}
+
+// gDriveStandIn keeps objects in memory and serves the subset of Drive API v3 used by the backend
+type gDriveStandIn struct {
+	sync.Mutex
+	seq     int
+	objects map[string]*gDriveObject
+}
+
+var gDriveFolderQuery = regexp.MustCompile(`^name = '(.*)' and '(.*)' in parents`)
+
+func newGDriveStandIn() (*gDriveStandIn, *httptest.Server) {
+	s := &gDriveStandIn{
+		objects: map[string]*gDriveObject{
+			gDriveRootFolder: {name: gDriveRootFolder, mimeType: gDriveFolderMimeType},
+		},
+	}
+	return s, httptest.NewServer(s)
+}
+
+func (s *gDriveStandIn) add(o *gDriveObject) string {
+	s.seq++
+	id := fmt.Sprintf("id%d", s.seq)
+	s.objects[id] = o
+	return id
+}
+
+func (s *gDriveStandIn) meta(w http.ResponseWriter, id string) {
+	o := s.objects[id]
+	json.NewEncoder(w).Encode(map[string]string{
+		"id":   id,
+		"name": o.name,
+		"size": strconv.Itoa(len(o.data)),
+	})
+}
+
+func (s *gDriveStandIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.Lock()
+	defer s.Unlock()
+
+	p := strings.TrimPrefix(r.URL.Path, "/drive/v3")
+
+	switch {
+	case r.Method == http.MethodPost && p == "/upload/drive/v3/files":
+		_, params, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		mr := multipart.NewReader(r.Body, params["boundary"])
+
+		var f struct {
+			Name     string   `json:"name"`
+			Parents  []string `json:"parents"`
+			MimeType string   `json:"mimeType"`
+		}
+		part, _ := mr.NextPart()
+		json.NewDecoder(part).Decode(&f)
+		part, _ = mr.NextPart()
+		data, _ := io.ReadAll(part)
+
+		s.meta(w, s.add(&gDriveObject{name: f.Name, parent: f.Parents[0], mimeType: f.MimeType, data: data}))
+	case r.Method == http.MethodPost && p == "/files":
+		var f struct {
+			Name     string   `json:"name"`
+			Parents  []string `json:"parents"`
+			MimeType string   `json:"mimeType"`
+		}
+		json.NewDecoder(r.Body).Decode(&f)
+		s.meta(w, s.add(&gDriveObject{name: f.Name, parent: f.Parents[0], mimeType: f.MimeType}))
+	case r.Method == http.MethodGet && p == "/files":
+		m := gDriveFolderQuery.FindStringSubmatch(r.URL.Query().Get("q"))
+		files := []map[string]string{}
+		for id, o := range s.objects {
+			if m != nil && o.name == m[1] && o.parent == m[2] && o.mimeType == gDriveFolderMimeType {
+				files = append(files, map[string]string{"id": id})
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]any{"files": files})
+	case strings.HasPrefix(p, "/files/"):
+		id := strings.TrimPrefix(p, "/files/")
+		copyId, isCopy := strings.CutSuffix(id, "/copy")
+		if isCopy {
+			id = copyId
+		}
+
+		o, ok := s.objects[id]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]any{"error": map[string]any{"code": 404, "message": "File not found"}})
+			return
+		}
+
+		switch {
+		case isCopy:
+			var f struct {
+				Name    string   `json:"name"`
+				Parents []string `json:"parents"`
+			}
+			json.NewDecoder(r.Body).Decode(&f)
+			s.meta(w, s.add(&gDriveObject{name: f.Name, parent: f.Parents[0], mimeType: o.mimeType, data: o.data}))
+		case r.Method == http.MethodDelete:
+			delete(s.objects, id)
+			w.WriteHeader(http.StatusNoContent)
+		case r.URL.Query().Get("alt") == "media":
+			http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(o.data))
+		default:
+			s.meta(w, id)
+		}
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func newTestGDriveBackend(t *testing.T) (*GDriveFileBackend, *gDriveStandIn) {
+	s, srv := newGDriveStandIn()
+	t.Cleanup(srv.Close)
+
+	b := &GDriveFileBackend{
+		BaseFileBackend: BaseFileBackend{
+			keyring: testBackendKeyring(t),
+		},
+		name:        "gdrive",
+		pathPattern: "$DOMAIN/$CHANNEL",
+		rootFolder:  gDriveRootFolder,
+		endpoint:    srv.URL + "/drive/v3/",
+		client:      srv.Client(),
+	}
+	if err := b.TestConnection(); err != nil {
+		t.Fatal(err)
+	}
+
+	return b, s
+}
+
+func TestGDriveTestConnection(t *testing.T) {
+	b, _ := newTestGDriveBackend(t)
+	b.rootFolder = "unknown"
+	if err := b.TestConnection(); err == nil {
+		t.Fatal("expected error for unknown root folder")
+	}
+}
+
+func TestGDriveFileBackend(t *testing.T) {
+	for _, encrypted := range []bool{false, true} {
+		t.Run(fmt.Sprintf("encrypted=%v", encrypted), func(t *testing.T) {
+			b, s := newTestGDriveBackend(t)
+
+			data := bytes.Repeat([]byte("0123456789"), 50000)
+			f := testBackendFile(encrypted)
+
+			n, err := b.Write(bytes.NewReader(data), f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n != int64(len(data)) {
+				t.Fatalf("written %d, expected %d", n, len(data))
+			}
+			if dir := f.GetPropertyString("directory"); dir != "1/call" {
+				t.Fatalf("unexpected directory %s", dir)
+			}
+
+			// the second file reuses the folders
+			if _, err = b.Write(bytes.NewReader(data), testBackendFile(encrypted)); err != nil {
+				t.Fatal(err)
+			}
+			folders := 0
+			for _, o := range s.objects {
+				if o.mimeType == gDriveFolderMimeType {
+					folders++
+				}
+			}
+			if folders != 3 {
+				t.Fatalf("expected 3 folders, got %d", folders)
+			}
+
+			checkBackendRead(t, b, f, data, 0)
+			checkBackendRead(t, b, f, data, 123456)
+
+			oldId := f.GetPropertyString("location")
+			if err = b.CopyTo(f, func(s string) string {
+				return strings.Replace(s, "/call", "/chat", 1)
+			}); err != nil {
+				t.Fatal(err)
+			}
+			if f.GetPropertyString("directory") != "1/chat" || f.GetPropertyString("location") == oldId {
+				t.Fatalf("unexpected properties %v", f.Properties)
+			}
+			checkBackendRead(t, b, f, data, 0)
+
+			if err = b.Remove(f); err != nil {
+				t.Fatal(err)
+			}
+			if err = b.Remove(f); err == nil || err.GetStatusCode() != http.StatusNotFound {
+				t.Fatalf("expected not found, got %v", err)
+			}
+		})
+	}
+}
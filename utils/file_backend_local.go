@@ -55,6 +55,13 @@ func (self *LocalFileBackend) write(src io.Reader, file File, directory string,
 		return 0, model.NewInternalError("utils.file.locally.create_dir.app_error", err.Error())
 	}
 
+	if encrypt {
+		var appErr model.AppError
+		if src, appErr = self.encrypt(src, file); appErr != nil {
+			return 0, appErr
+		}
+	}
+
 	fw, err := os.OpenFile(allPath, os.O_WRONLY|os.O_CREATE, 0644)
 	if err != nil {
 		return 0, model.NewInternalError("utils.file.locally.writing.app_error", err.Error())
@@ -62,13 +69,7 @@ func (self *LocalFileBackend) write(src io.Reader, file File, directory string,
 
 	defer fw.Close()
 
-	var written int64
-
-	if encrypt {
-		written, err = io.Copy(fw, NewEncryptingReader(src, self.chipher))
-	} else {
-		written, err = io.Copy(fw, src)
-	}
+	written, err := io.Copy(fw, src)
 
 	if err != nil {
 		os.Remove(allPath)
@@ -141,7 +142,7 @@ func (self *LocalFileBackend) Reader(file File, offset int64) (io.ReadCloser, mo
 		}
 
 		if file.IsEncrypted() {
-			return NewDecryptingReader(f, self.chipher, offset), nil
+			return self.decrypt(f, file, offset)
 		}
 		return f, nil
 	}
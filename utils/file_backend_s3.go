@@ -4,9 +4,11 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net/http"
 	"path"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws/awserr"
 
@@ -123,7 +125,11 @@ func (self *S3FileBackend) write(src io.Reader, file File) (int64, model.AppErro
 	}
 
 	if isEncrypted {
-		params.Body = NewEncryptingReader(src, self.chipher)
+		body, err := self.encrypt(src, file)
+		if err != nil {
+			return 0, err
+		}
+		params.Body = body
 	} else {
 		params.Body = src
 	}
@@ -223,8 +229,124 @@ func (self *S3FileBackend) Reader(file File, offset int64) (io.ReadCloser, model
 	}
 
 	if file.IsEncrypted() {
-		return NewDecryptingReader(out.Body, self.chipher, offset), nil
+		return self.decrypt(out.Body, file, offset)
 	}
 
 	return out.Body, nil
 }
+
+// objectLocation returns the key of the file, the new key is computed by the path pattern
+func (self *S3FileBackend) objectLocation(file File) string {
+	location := file.GetPropertyString("location")
+	if location == "" {
+		location = path.Join(self.GetStoreDirectory(file), file.GetStoreName())
+		file.SetPropertyString("location", location)
+	}
+
+	return location
+}
+
+func (self *S3FileBackend) PresignPut(file File, size int64, expire time.Duration) (string, map[string]string, model.AppError) {
+	req, _ := self.svc.PutObjectRequest(&s3.PutObjectInput{
+		Bucket:        &self.bucket,
+		Key:           aws.String(self.objectLocation(file)),
+		ContentType:   aws.String(file.GetMimeType()),
+		ContentLength: aws.Int64(size),
+	})
+
+	url, signed, err := req.PresignRequest(expire)
+	if err != nil {
+		return "", nil, model.NewInternalError("utils.file.s3.presign_put", err.Error())
+	}
+
+	headers := make(map[string]string)
+	for k, v := range signed {
+		if k = http.CanonicalHeaderKey(k); k != "Host" && len(v) != 0 {
+			headers[k] = v[0]
+		}
+	}
+
+	return url, headers, nil
+}
+
+func (self *S3FileBackend) CreateMultipartUpload(file File) (string, model.AppError) {
+	out, err := self.svc.CreateMultipartUpload(&s3.CreateMultipartUploadInput{
+		Bucket:      &self.bucket,
+		Key:         aws.String(self.objectLocation(file)),
+		ContentType: aws.String(file.GetMimeType()),
+	})
+	if err != nil {
+		return "", model.NewInternalError("utils.file.s3.create_multipart", err.Error())
+	}
+
+	return *out.UploadId, nil
+}
+
+func (self *S3FileBackend) PresignPart(file File, uploadId string, number int, size int64, expire time.Duration) (string, model.AppError) {
+	req, _ := self.svc.UploadPartRequest(&s3.UploadPartInput{
+		Bucket:        &self.bucket,
+		Key:           aws.String(self.objectLocation(file)),
+		UploadId:      aws.String(uploadId),
+		PartNumber:    aws.Int64(int64(number)),
+		ContentLength: aws.Int64(size),
+	})
+
+	url, _, err := req.PresignRequest(expire)
+	if err != nil {
+		return "", model.NewInternalError("utils.file.s3.presign_part", err.Error())
+	}
+
+	return url, nil
+}
+
+func (self *S3FileBackend) CompleteMultipartUpload(file File, uploadId string, parts []model.DirectUploadPart) model.AppError {
+	completed := make([]*s3.CompletedPart, 0, len(parts))
+	for _, p := range parts {
+		completed = append(completed, &s3.CompletedPart{
+			ETag:       aws.String(p.ETag),
+			PartNumber: aws.Int64(int64(p.Number)),
+		})
+	}
+
+	_, err := self.svc.CompleteMultipartUpload(&s3.CompleteMultipartUploadInput{
+		Bucket:          &self.bucket,
+		Key:             aws.String(self.objectLocation(file)),
+		UploadId:        aws.String(uploadId),
+		MultipartUpload: &s3.CompletedMultipartUpload{Parts: completed},
+	})
+	if err != nil {
+		return model.NewBadRequestError("utils.file.s3.complete_multipart", err.Error())
+	}
+
+	return nil
+}
+
+func (self *S3FileBackend) AbortMultipartUpload(file File, uploadId string) model.AppError {
+	_, err := self.svc.AbortMultipartUpload(&s3.AbortMultipartUploadInput{
+		Bucket:   &self.bucket,
+		Key:      aws.String(self.objectLocation(file)),
+		UploadId: aws.String(uploadId),
+	})
+	if err != nil {
+		return model.NewInternalError("utils.file.s3.abort_multipart", err.Error())
+	}
+
+	return nil
+}
+
+// Stat returns size and content type of the stored object
+func (self *S3FileBackend) Stat(file File) (int64, string, model.AppError) {
+	h, err := self.svc.HeadObject(&s3.HeadObjectInput{
+		Bucket: &self.bucket,
+		Key:    aws.String(self.objectLocation(file)),
+	})
+	if err != nil {
+		var aerr awserr.RequestFailure
+		if errors.As(err, &aerr) && aerr.StatusCode() == http.StatusNotFound {
+			return 0, "", model.NewNotFoundError("utils.file.s3.stat", err.Error())
+		}
+		return 0, "", model.NewInternalError("utils.file.s3.stat", err.Error())
+	}
+
+	return aws.Int64Value(h.ContentLength), aws.StringValue(h.ContentType), nil
+}
@@ -0,0 +1,223 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/webitel/storage/model"
+)
+
+// s3StandIn keeps objects in memory and serves the subset of S3 API used by direct uploads, like MinIO
+type s3StandIn struct {
+	sync.Mutex
+	bucket  string
+	objects map[string][]byte
+	types   map[string]string
+	parts   map[string]map[int][]byte
+}
+
+func newS3StandIn(bucket string) (*s3StandIn, *httptest.Server) {
+	s := &s3StandIn{
+		bucket:  bucket,
+		objects: make(map[string][]byte),
+		types:   make(map[string]string),
+		parts:   make(map[string]map[int][]byte),
+	}
+	return s, httptest.NewServer(s)
+}
+
+func (s *s3StandIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.Lock()
+	defer s.Unlock()
+
+	key := strings.TrimPrefix(r.URL.Path, "/"+s.bucket+"/")
+	q := r.URL.Query()
+
+	switch {
+	case r.Method == http.MethodPost && q.Has("uploads"):
+		id := fmt.Sprintf("upload-%d", len(s.parts)+1)
+		s.parts[id] = make(map[int][]byte)
+		s.types[key] = r.Header.Get("Content-Type")
+		fmt.Fprintf(w, `<InitiateMultipartUploadResult><Bucket>%s</Bucket><Key>%s</Key><UploadId>%s</UploadId></InitiateMultipartUploadResult>`, s.bucket, key, id)
+	case r.Method == http.MethodPut && q.Get("uploadId") != "":
+		n, _ := strconv.Atoi(q.Get("partNumber"))
+		s.parts[q.Get("uploadId")][n], _ = io.ReadAll(r.Body)
+		w.Header().Set("ETag", fmt.Sprintf(`"etag-%d"`, n))
+	case r.Method == http.MethodPost && q.Get("uploadId") != "":
+		var req struct {
+			Parts []struct {
+				PartNumber int `xml:"PartNumber"`
+			} `xml:"Part"`
+		}
+		xml.NewDecoder(r.Body).Decode(&req)
+		parts := s.parts[q.Get("uploadId")]
+		var data []byte
+		for _, p := range req.Parts {
+			data = append(data, parts[p.PartNumber]...)
+		}
+		s.objects[key] = data
+		delete(s.parts, q.Get("uploadId"))
+		fmt.Fprintf(w, `<CompleteMultipartUploadResult><Bucket>%s</Bucket><Key>%s</Key></CompleteMultipartUploadResult>`, s.bucket, key)
+	case r.Method == http.MethodDelete && q.Get("uploadId") != "":
+		delete(s.parts, q.Get("uploadId"))
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodPut:
+		s.objects[key], _ = io.ReadAll(r.Body)
+		s.types[key] = r.Header.Get("Content-Type")
+	case r.Method == http.MethodHead:
+		data, ok := s.objects[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", s.types[key])
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	case r.Method == http.MethodDelete:
+		delete(s.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusNotImplemented)
+	}
+}
+
+func newTestS3Backend(t *testing.T) (*S3FileBackend, *s3StandIn) {
+	s, srv := newS3StandIn("records")
+	t.Cleanup(srv.Close)
+
+	b := &S3FileBackend{
+		name:           "s3",
+		region:         "us-east-1",
+		accessKey:      "key",
+		accessToken:    "secret",
+		bucket:         "records",
+		endpoint:       srv.URL,
+		pathPattern:    "$DOMAIN/$CHANNEL",
+		forcePathStyle: true,
+	}
+	if err := b.TestConnection(); err != nil {
+		t.Fatal(err)
+	}
+
+	return b, s
+}
+
+func putPresigned(t *testing.T, rawUrl string, headers map[string]string, data []byte) string {
+	req, _ := http.NewRequest(http.MethodPut, rawUrl, bytes.NewReader(data))
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status %d", res.StatusCode)
+	}
+	return res.Header.Get("ETag")
+}
+
+func signedHeaders(t *testing.T, rawUrl string) []string {
+	u, err := url.Parse(rawUrl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := strings.Split(u.Query().Get("X-Amz-SignedHeaders"), ";")
+	sort.Strings(h)
+	return h
+}
+
+func TestS3PresignPut(t *testing.T) {
+	b, s := newTestS3Backend(t)
+	f := testBackendFile(false)
+	data := []byte("RIFF....WAVE")
+
+	rawUrl, headers, err := b.PresignPut(f, int64(len(data)), time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loc := f.GetPropertyString("location"); loc != "1/call/record.wav" {
+		t.Fatalf("unexpected location %s", loc)
+	}
+	if h := signedHeaders(t, rawUrl); strings.Join(h, ";") != "content-length;content-type;host" {
+		t.Fatalf("size and mime type must be signed, got %v", h)
+	}
+	if headers["Content-Type"] != "audio/wav" || headers["Content-Length"] != strconv.Itoa(len(data)) {
+		t.Fatalf("unexpected headers %v", headers)
+	}
+
+	putPresigned(t, rawUrl, headers, data)
+
+	size, mimeType, err := b.Stat(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size != int64(len(data)) || mimeType != "audio/wav" {
+		t.Fatalf("unexpected object %d %s", size, mimeType)
+	}
+
+	if err = b.Remove(f); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err = b.Stat(f); err == nil || err.GetStatusCode() != http.StatusNotFound {
+		t.Fatalf("expected not found, got %v", err)
+	}
+	if len(s.objects) != 0 {
+		t.Fatalf("object was not removed")
+	}
+}
+
+func TestS3PresignMultipart(t *testing.T) {
+	b, s := newTestS3Backend(t)
+	f := testBackendFile(false)
+	data := bytes.Repeat([]byte("0123456789"), 25)
+	partSize := int64(100)
+
+	uploadId, err := b.CreateMultipartUpload(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var parts []model.DirectUploadPart
+	for offset, n := int64(0), 1; offset < int64(len(data)); offset, n = offset+partSize, n+1 {
+		chunk := data[offset:min(offset+partSize, int64(len(data)))]
+		rawUrl, err := b.PresignPart(f, uploadId, n, int64(len(chunk)), time.Minute)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if h := signedHeaders(t, rawUrl); strings.Join(h, ";") != "content-length;host" {
+			t.Fatalf("part size must be signed, got %v", h)
+		}
+		etag := putPresigned(t, rawUrl, map[string]string{"Content-Length": strconv.Itoa(len(chunk))}, chunk)
+		parts = append(parts, model.DirectUploadPart{Number: n, ETag: etag})
+	}
+
+	if err = b.CompleteMultipartUpload(f, uploadId, parts); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(s.objects["1/call/record.wav"], data) {
+		t.Fatalf("unexpected object %q", s.objects["1/call/record.wav"])
+	}
+
+	uploadId, err = b.CreateMultipartUpload(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = b.AbortMultipartUpload(f, uploadId); err != nil {
+		t.Fatal(err)
+	}
+	if len(s.parts) != 0 {
+		t.Fatalf("multipart upload was not aborted")
+	}
+}
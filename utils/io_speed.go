@@ -7,6 +7,27 @@ import (
 	"time"
 )
 
+// CountingReader counts bytes that are read from the source
+type CountingReader struct {
+	r io.Reader
+	n int64
+}
+
+func NewCountingReader(r io.Reader) *CountingReader {
+	return &CountingReader{r: r}
+}
+
+func (c *CountingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// Count is the number of bytes read
+func (c *CountingReader) Count() int64 {
+	return c.n
+}
+
 type speedCalc struct {
 	count      int64 // may have large (2GB+) files - so don't use int
 	start, end time.Time
@@ -0,0 +1,116 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// KeyVersionProperty is the property of the encrypted file with the version of the key it's encrypted with,
+// the files encrypted before the keyring don't have the property and are decrypted with the version 0
+const KeyVersionProperty = "key_version"
+
+// DomainKeyProperty is the property of the file encrypted with the data key of the domain (envelope encryption),
+// the value is the id of the domain key
+const DomainKeyProperty = "domain_key"
+
+const keyFileExt = ".key"
+
+// Keyring keeps the versions of the at-rest encryption key, the files are encrypted with the newest version.
+// With the domain keys the files are encrypted with the data key of the domain instead
+type Keyring struct {
+	keys    map[int]Chipher
+	current int
+	domains DomainKeys
+}
+
+func NewKeyring(keys map[int]Chipher) (*Keyring, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("keyring is empty")
+	}
+
+	k := &Keyring{
+		keys:    keys,
+		current: -1,
+	}
+	for version := range keys {
+		if version < 0 {
+			return nil, fmt.Errorf("bad key version %d", version)
+		}
+		k.current = max(k.current, version)
+	}
+
+	return k, nil
+}
+
+// LoadKeyring reads the key file as the version 0 and the "<version>.key" files of the directory as the next versions,
+// a new version is added to the directory to rotate the key
+func LoadKeyring(keyFile string, dir string) (*Keyring, error) {
+	keys := make(map[int]Chipher)
+
+	c, err := NewChipher(keyFile)
+	if err != nil {
+		return nil, err
+	}
+	keys[0] = c
+
+	if dir == "" {
+		return NewKeyring(keys)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), keyFileExt) {
+			continue
+		}
+
+		version, err := strconv.Atoi(strings.TrimSuffix(e.Name(), keyFileExt))
+		if err != nil || version < 1 {
+			return nil, fmt.Errorf("bad key file name %s, expected <version>%s", e.Name(), keyFileExt)
+		}
+
+		if keys[version], err = NewChipher(filepath.Join(dir, e.Name())); err != nil {
+			return nil, err
+		}
+	}
+
+	return NewKeyring(keys)
+}
+
+// Current returns the version of the key the new files are encrypted with
+func (k *Keyring) Current() int {
+	return k.current
+}
+
+func (k *Keyring) Chipher(version int) (Chipher, error) {
+	c, ok := k.keys[version]
+	if !ok {
+		return nil, fmt.Errorf("key version %d not found", version)
+	}
+
+	return c, nil
+}
+
+// SetDomainKeys enables the envelope encryption with the data keys of the domains
+func (k *Keyring) SetDomainKeys(domains DomainKeys) {
+	k.domains = domains
+}
+
+// FileKeyVersion returns the version of the key the file is encrypted with
+func FileKeyVersion(file File) int {
+	version, _ := strconv.Atoi(file.GetPropertyString(KeyVersionProperty))
+	return version
+}
+
+// FileDomainKey returns the id of the domain key the file is encrypted with
+func FileDomainKey(file File) (int64, bool) {
+	id, err := strconv.ParseInt(file.GetPropertyString(DomainKeyProperty), 10, 64)
+	return id, err == nil && id > 0
+}
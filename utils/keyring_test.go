@@ -0,0 +1,115 @@
+package utils
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeTestKey(t *testing.T, name string, secret string) string {
+	t.Helper()
+
+	if err := os.WriteFile(name, []byte(secret), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return name
+}
+
+func newTestLocalBackend(dir string, keyring *Keyring) *LocalFileBackend {
+	return &LocalFileBackend{
+		BaseFileBackend: BaseFileBackend{
+			keyring: keyring,
+		},
+		name:        "local",
+		directory:   dir,
+		pathPattern: "$DOMAIN/$CHANNEL",
+	}
+}
+
+func TestKeyringRotation(t *testing.T) {
+	keyFile := writeTestKey(t, filepath.Join(t.TempDir(), "crypto.key"), "legacy secret")
+	keyDir := t.TempDir()
+	storeDir := t.TempDir()
+	data := bytes.Repeat([]byte("0123456789"), BlockSize/5)
+
+	k0, err := LoadKeyring(keyFile, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if k0.Current() != 0 {
+		t.Fatalf("unexpected current version %d", k0.Current())
+	}
+
+	b := newTestLocalBackend(storeDir, k0)
+
+	before := testBackendFile(true)
+	before.Name = "before.wav"
+	if _, err := b.Write(bytes.NewReader(data), before); err != nil {
+		t.Fatal(err)
+	}
+	if v := before.GetPropertyString(KeyVersionProperty); v != "0" {
+		t.Fatalf("unexpected key version %q", v)
+	}
+
+	// the file encrypted before the keyring doesn't have the version
+	legacy := testBackendFile(true)
+	legacy.Name = "legacy.wav"
+	if _, err := b.Write(bytes.NewReader(data), legacy); err != nil {
+		t.Fatal(err)
+	}
+	legacy.Properties.Remove(KeyVersionProperty)
+
+	writeTestKey(t, filepath.Join(keyDir, "1.key"), "rotated secret")
+	writeTestKey(t, filepath.Join(keyDir, "readme.txt"), "ignored")
+
+	k1, err := LoadKeyring(keyFile, keyDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if k1.Current() != 1 {
+		t.Fatalf("unexpected current version %d", k1.Current())
+	}
+
+	b = newTestLocalBackend(storeDir, k1)
+
+	after := testBackendFile(true)
+	after.Name = "after.wav"
+	if _, err := b.Write(bytes.NewReader(data), after); err != nil {
+		t.Fatal(err)
+	}
+	if v := after.GetPropertyString(KeyVersionProperty); v != "1" {
+		t.Fatalf("unexpected key version %q", v)
+	}
+
+	for _, f := range []struct {
+		name string
+		file File
+	}{{"before", before}, {"legacy", legacy}, {"after", after}} {
+		t.Run(f.name, func(t *testing.T) {
+			checkBackendRead(t, b, f.file, data, 0)
+			checkBackendRead(t, b, f.file, data, BlockSize+7)
+		})
+	}
+
+	// the retired key is removed from the keyring
+	c1, _ := k1.Chipher(1)
+	k, err := NewKeyring(map[int]Chipher{1: c1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := newTestLocalBackend(storeDir, k).Reader(before, 0); err == nil {
+		t.Fatal("expected error of the missing key version")
+	}
+	checkBackendRead(t, newTestLocalBackend(storeDir, k), after, data, 0)
+}
+
+func TestKeyringBadFile(t *testing.T) {
+	keyFile := writeTestKey(t, filepath.Join(t.TempDir(), "crypto.key"), "legacy secret")
+	keyDir := t.TempDir()
+	writeTestKey(t, filepath.Join(keyDir, "new.key"), "secret")
+
+	if _, err := LoadKeyring(keyFile, keyDir); err == nil {
+		t.Fatal("expected error of the key file name")
+	}
+}
@@ -0,0 +1,218 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+const (
+	KMSLocal = "local"
+	KMSVault = "vault"
+
+	localKMSPrefix = "local:v"
+)
+
+// KMS wraps the data keys of the domains with the master key, only the wrapped keys are stored in the database
+type KMS interface {
+	Name() string
+	WrapKey(key []byte) (string, error)
+	UnwrapKey(wrapped string) ([]byte, error)
+}
+
+// DomainKeys resolves the data keys of the domains for the envelope encryption
+type DomainKeys interface {
+	// ActiveKey returns the id and the data key the new files of the domain are encrypted with
+	ActiveKey(domainId int64) (int64, Chipher, error)
+	// Key returns the data key by id, the destroyed key returns an error
+	Key(domainId int64, id int64) (Chipher, error)
+}
+
+// NewDataKey generates the random data key of the domain
+func NewDataKey() ([]byte, error) {
+	key := make([]byte, chacha20poly1305.KeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+func NewDataKeyChipher(key []byte) (Chipher, error) {
+	return chacha20poly1305.New(key)
+}
+
+// NewKMS returns the KMS by the config: "local" wraps the keys with the keyring,
+// the url "http://vault:8200/transit/storage" uses the key "storage" of the Vault transit engine mounted at "transit"
+func NewKMS(uri string, token string, keyring *Keyring) (KMS, error) {
+	if uri == KMSLocal {
+		return NewLocalKMS(keyring), nil
+	}
+
+	u, err := url.Parse(uri)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, fmt.Errorf("bad kms %s, expected %s or the url of the Vault transit key", uri, KMSLocal)
+	}
+
+	i := strings.LastIndex(u.Path, "/")
+	if i <= 0 || i == len(u.Path)-1 {
+		return nil, fmt.Errorf("bad kms %s, expected the url http://vault:8200/<mount>/<key>", uri)
+	}
+
+	return NewVaultKMS(u.Scheme+"://"+u.Host, token, strings.Trim(u.Path[:i], "/"), u.Path[i+1:]), nil
+}
+
+// LocalKMS wraps the data keys with the current key of the keyring, the wrapped key is "local:v<version>:<base64>"
+type LocalKMS struct {
+	keyring *Keyring
+}
+
+func NewLocalKMS(keyring *Keyring) *LocalKMS {
+	return &LocalKMS{
+		keyring: keyring,
+	}
+}
+
+func (k *LocalKMS) Name() string {
+	return KMSLocal
+}
+
+func (k *LocalKMS) WrapKey(key []byte) (string, error) {
+	version := k.keyring.Current()
+	c, err := k.keyring.Chipher(version)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, c.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	return localKMSPrefix + strconv.Itoa(version) + ":" + base64.StdEncoding.EncodeToString(c.Seal(nonce, nonce, key, nil)), nil
+}
+
+// CurrentPrefix is the prefix of the keys wrapped with the current key of the keyring, the keys without it are wrapped again after the rotation
+func (k *LocalKMS) CurrentPrefix() string {
+	return localKMSPrefix + strconv.Itoa(k.keyring.Current()) + ":"
+}
+
+func (k *LocalKMS) UnwrapKey(wrapped string) ([]byte, error) {
+	v, data, ok := strings.Cut(strings.TrimPrefix(wrapped, localKMSPrefix), ":")
+	if !ok || !strings.HasPrefix(wrapped, localKMSPrefix) {
+		return nil, errors.New("bad wrapped key")
+	}
+
+	version, err := strconv.Atoi(v)
+	if err != nil {
+		return nil, errors.New("bad wrapped key version")
+	}
+
+	c, err := k.keyring.Chipher(version)
+	if err != nil {
+		return nil, err
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(data)
+	if err != nil || len(sealed) < c.NonceSize() {
+		return nil, errors.New("bad wrapped key")
+	}
+
+	return c.Open(nil, sealed[:c.NonceSize()], sealed[c.NonceSize():], nil)
+}
+
+// VaultKMS wraps the data keys with the HashiCorp Vault transit engine (encrypt and decrypt endpoints)
+type VaultKMS struct {
+	address string
+	token   string
+	mount   string
+	key     string
+	client  *http.Client
+}
+
+func NewVaultKMS(address, token, mount, key string) *VaultKMS {
+	return &VaultKMS{
+		address: strings.TrimRight(address, "/"),
+		token:   token,
+		mount:   mount,
+		key:     key,
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+func (k *VaultKMS) Name() string {
+	return KMSVault
+}
+
+func (k *VaultKMS) WrapKey(key []byte) (string, error) {
+	var res struct {
+		Ciphertext string `json:"ciphertext"`
+	}
+	err := k.call("encrypt", map[string]string{
+		"plaintext": base64.StdEncoding.EncodeToString(key),
+	}, &res)
+	if err != nil {
+		return "", err
+	}
+	if res.Ciphertext == "" {
+		return "", errors.New("vault: empty ciphertext")
+	}
+
+	return res.Ciphertext, nil
+}
+
+func (k *VaultKMS) UnwrapKey(wrapped string) ([]byte, error) {
+	var res struct {
+		Plaintext string `json:"plaintext"`
+	}
+	err := k.call("decrypt", map[string]string{
+		"ciphertext": wrapped,
+	}, &res)
+	if err != nil {
+		return nil, err
+	}
+
+	return base64.StdEncoding.DecodeString(res.Plaintext)
+}
+
+func (k *VaultKMS) call(operation string, body map[string]string, data any) error {
+	b, _ := json.Marshal(body)
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("%s/v1/%s/%s/%s", k.address, k.mount, operation, k.key), bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-Vault-Token", k.token)
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := k.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	var out struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []string        `json:"errors"`
+	}
+	if err = json.NewDecoder(res.Body).Decode(&out); err != nil && res.StatusCode == http.StatusOK {
+		return fmt.Errorf("vault %s: %w", operation, err)
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("vault %s: status %d %s", operation, res.StatusCode, strings.Join(out.Errors, "; "))
+	}
+
+	return json.Unmarshal(out.Data, data)
+}
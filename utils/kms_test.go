@@ -0,0 +1,270 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// vaultTransitStandIn serves the encrypt and decrypt endpoints of the Vault transit engine
+type vaultTransitStandIn struct {
+	sync.Mutex
+	token   string
+	mount   string
+	key     string
+	secrets map[string]string
+}
+
+func (s *vaultTransitStandIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.Lock()
+	defer s.Unlock()
+
+	if r.Header.Get("X-Vault-Token") != s.token {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"errors":["permission denied"]}`))
+		return
+	}
+
+	var req map[string]string
+	json.NewDecoder(r.Body).Decode(&req)
+
+	switch r.URL.Path {
+	case "/v1/" + s.mount + "/encrypt/" + s.key:
+		ciphertext := "vault:v1:" + base64.StdEncoding.EncodeToString([]byte(strings.Repeat("x", len(s.secrets)+1)))
+		s.secrets[ciphertext] = req["plaintext"]
+		json.NewEncoder(w).Encode(map[string]any{"data": map[string]string{"ciphertext": ciphertext}})
+	case "/v1/" + s.mount + "/decrypt/" + s.key:
+		plaintext, ok := s.secrets[req["ciphertext"]]
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"errors":["invalid ciphertext"]}`))
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"data": map[string]string{"plaintext": plaintext}})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"errors":[]}`))
+	}
+}
+
+// testDomainKeys keeps the data keys of the domains wrapped by the kms in memory
+type testDomainKeys struct {
+	kms       KMS
+	wrapped   map[int64]string
+	active    map[int64]int64
+	destroyed map[int64]bool
+}
+
+func newTestDomainKeys(kms KMS) *testDomainKeys {
+	return &testDomainKeys{
+		kms:       kms,
+		wrapped:   make(map[int64]string),
+		active:    make(map[int64]int64),
+		destroyed: make(map[int64]bool),
+	}
+}
+
+func (d *testDomainKeys) ActiveKey(domainId int64) (int64, Chipher, error) {
+	id, ok := d.active[domainId]
+	if !ok {
+		key, err := NewDataKey()
+		if err != nil {
+			return 0, nil, err
+		}
+		id = int64(len(d.wrapped) + 1)
+		if d.wrapped[id], err = d.kms.WrapKey(key); err != nil {
+			return 0, nil, err
+		}
+		d.active[domainId] = id
+	}
+
+	c, err := d.Key(domainId, id)
+	return id, c, err
+}
+
+func (d *testDomainKeys) Key(domainId int64, id int64) (Chipher, error) {
+	if d.destroyed[id] {
+		return nil, errors.New("key is destroyed")
+	}
+
+	key, err := d.kms.UnwrapKey(d.wrapped[id])
+	if err != nil {
+		return nil, err
+	}
+	return NewDataKeyChipher(key)
+}
+
+func (d *testDomainKeys) destroy(domainId int64) {
+	d.destroyed[d.active[domainId]] = true
+	delete(d.active, domainId)
+}
+
+func TestLocalKMS(t *testing.T) {
+	keyFile := writeTestKey(t, filepath.Join(t.TempDir(), "crypto.key"), "legacy secret")
+	keyDir := t.TempDir()
+
+	k0, err := LoadKeyring(keyFile, keyDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	kms, err := NewKMS(KMSLocal, "", k0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, _ := NewDataKey()
+	wrapped, err := kms.WrapKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(wrapped, "local:v0:") {
+		t.Fatalf("unexpected wrapped key %s", wrapped)
+	}
+
+	// the keys wrapped before the rotation are unwrapped with the old version
+	writeTestKey(t, filepath.Join(keyDir, "1.key"), "rotated secret")
+	k1, err := LoadKeyring(keyFile, keyDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	local := NewLocalKMS(k1)
+	kms = local
+
+	unwrapped, err := kms.UnwrapKey(wrapped)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(unwrapped, key) {
+		t.Fatal("unexpected unwrapped key")
+	}
+
+	if strings.HasPrefix(wrapped, local.CurrentPrefix()) {
+		t.Fatalf("key %s wrapped before the rotation has the current prefix %s", wrapped, local.CurrentPrefix())
+	}
+
+	if wrapped, err = kms.WrapKey(key); err != nil || !strings.HasPrefix(wrapped, local.CurrentPrefix()) || !strings.HasPrefix(wrapped, "local:v1:") {
+		t.Fatalf("unexpected wrapped key %s, %v", wrapped, err)
+	}
+
+	for _, bad := range []string{"", "vault:v1:abc", "local:vx:abc", "local:v1:" + base64.StdEncoding.EncodeToString(make([]byte, 64))} {
+		if _, err = kms.UnwrapKey(bad); err == nil {
+			t.Fatalf("expected error of the wrapped key %q", bad)
+		}
+	}
+}
+
+func TestVaultKMS(t *testing.T) {
+	s := &vaultTransitStandIn{
+		token:   "s.token",
+		mount:   "secrets/transit",
+		key:     "storage",
+		secrets: make(map[string]string),
+	}
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	if _, err := NewKMS("vault", "", nil); err == nil {
+		t.Fatal("expected error of the kms url")
+	}
+	if _, err := NewKMS(srv.URL+"/transit", "", nil); err == nil {
+		t.Fatal("expected error of the kms url without the key")
+	}
+
+	kms, err := NewKMS(srv.URL+"/secrets/transit/storage", s.token, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kms.Name() != KMSVault {
+		t.Fatalf("unexpected kms %s", kms.Name())
+	}
+
+	key, _ := NewDataKey()
+	wrapped, err := kms.WrapKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(wrapped, "vault:v1:") {
+		t.Fatalf("unexpected wrapped key %s", wrapped)
+	}
+
+	unwrapped, err := kms.UnwrapKey(wrapped)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(unwrapped, key) {
+		t.Fatal("unexpected unwrapped key")
+	}
+
+	if _, err = kms.UnwrapKey("vault:v1:unknown"); err == nil || !strings.Contains(err.Error(), "invalid ciphertext") {
+		t.Fatalf("expected error of the ciphertext, got %v", err)
+	}
+
+	if _, err = NewVaultKMS(srv.URL, "bad", s.mount, s.key).WrapKey(key); err == nil || !strings.Contains(err.Error(), "permission denied") {
+		t.Fatalf("expected error of the token, got %v", err)
+	}
+}
+
+func TestDomainKeyEncryption(t *testing.T) {
+	keyFile := writeTestKey(t, filepath.Join(t.TempDir(), "crypto.key"), "legacy secret")
+	storeDir := t.TempDir()
+	data := bytes.Repeat([]byte("0123456789"), BlockSize/5)
+
+	keyring, err := LoadKeyring(keyFile, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := newTestLocalBackend(storeDir, keyring)
+
+	// the file encrypted before the domain keys
+	legacy := testBackendFile(true)
+	legacy.Name = "legacy.wav"
+	if _, err := b.Write(bytes.NewReader(data), legacy); err != nil {
+		t.Fatal(err)
+	}
+
+	domains := newTestDomainKeys(NewLocalKMS(keyring))
+	keyring.SetDomainKeys(domains)
+
+	f := testBackendFile(true)
+	f.Name = "domain.wav"
+	if _, err := b.Write(bytes.NewReader(data), f); err != nil {
+		t.Fatal(err)
+	}
+	if id, ok := FileDomainKey(f); !ok || id != domains.active[f.Domain()] {
+		t.Fatalf("unexpected domain key %q", f.GetPropertyString(DomainKeyProperty))
+	}
+
+	checkBackendRead(t, b, f, data, BlockSize+7)
+	checkBackendRead(t, b, legacy, data, 0)
+
+	// the file is readable with the key of the domain only
+	other := newTestLocalBackend(storeDir, keyring)
+	if _, err := newTestLocalBackend(storeDir, &Keyring{keys: keyring.keys}).Reader(f, 0); err == nil {
+		t.Fatal("expected error of the disabled domain keys")
+	}
+
+	domains.destroy(f.Domain())
+	if _, err := other.Reader(f, 0); err == nil {
+		t.Fatal("expected error of the destroyed key")
+	}
+	checkBackendRead(t, other, legacy, data, 0)
+
+	// the next file creates the new key
+	next := testBackendFile(true)
+	next.Name = "next.wav"
+	if _, err := b.Write(bytes.NewReader(data), next); err != nil {
+		t.Fatal(err)
+	}
+	if id, _ := FileDomainKey(next); id == 1 {
+		t.Fatal("expected the new key")
+	}
+	checkBackendRead(t, b, next, data, 0)
+}
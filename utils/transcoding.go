@@ -1,9 +1,13 @@
 package utils
 
 import (
+	"encoding/binary"
+	"errors"
 	"io"
 	"os"
 	"os/exec"
+
+	"github.com/webitel/storage/model"
 )
 
 type Transcoding struct {
@@ -51,6 +55,50 @@ func NewTranscoding(src io.ReadCloser, writer io.Writer) (*Transcoding, error) {
 	}, nil
 }
 
+// NewAudioTranscoding converts the audio of src to the format, it's used to convert the files on download
+func NewAudioTranscoding(src io.Reader, writer io.Writer, format *model.AudioFormat) (*Transcoding, error) {
+	cmd := exec.Command("ffmpeg", audioCmdArgs(format)...)
+	cmd.Stderr = os.Stderr
+
+	cmd.Stdin = src
+	cmd.Stdout = writer
+
+	return &Transcoding{
+		cmd: cmd,
+	}, nil
+}
+
+func audioCmdArgs(format *model.AudioFormat) []string {
+	args := []string{
+		"-nostdin",
+		"-loglevel", "error",
+		"-i", "pipe:0",
+		"-vn",
+	}
+
+	switch format.Channels {
+	case model.AudioChannelsMono:
+		args = append(args, "-ac", "1")
+	case model.AudioChannelsStereo:
+		args = append(args, "-ac", "2")
+	case model.AudioChannelsLeft:
+		args = append(args, "-af", "pan=mono|c0=c0")
+	case model.AudioChannelsRight:
+		args = append(args, "-af", "pan=mono|c0=c1")
+	}
+
+	switch format.Codec {
+	case model.AudioFormatMp3:
+		args = append(args, "-c:a", "libmp3lame", "-q:a", "4", "-f", "mp3")
+	case model.AudioFormatOgg:
+		args = append(args, "-c:a", "libopus", "-b:a", "32k", "-f", "ogg")
+	default:
+		args = append(args, "-c:a", "pcm_s16le", "-f", "wav")
+	}
+
+	return append(args, "pipe:1")
+}
+
 func (t *Transcoding) Start() error {
 	return t.cmd.Start()
 }
@@ -74,3 +122,45 @@ func (t *Transcoding) Close() (err error) {
 
 	return nil
 }
+
+// PatchWavSizes sets the sizes of the RIFF and "data" chunks of the WAV file of size bytes. ffmpeg can't seek back
+// in the pipe, so the WAV written to stdout has the unknown sizes in the header
+func PatchWavSizes(f interface {
+	io.ReaderAt
+	io.WriterAt
+}, size int64) error {
+	var header [12]byte
+	if _, err := f.ReadAt(header[:], 0); err != nil {
+		return err
+	}
+	if string(header[0:4]) != "RIFF" || string(header[8:12]) != "WAVE" {
+		return errors.New("not a wav file")
+	}
+	if size > 0xFFFFFFFF {
+		return errors.New("wav file is too large")
+	}
+
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], uint32(size-8))
+	if _, err := f.WriteAt(b[:], 4); err != nil {
+		return err
+	}
+
+	var chunk [8]byte
+	for pos := int64(12); pos+8 <= size; {
+		if _, err := f.ReadAt(chunk[:], pos); err != nil {
+			return err
+		}
+
+		if string(chunk[0:4]) == "data" {
+			binary.LittleEndian.PutUint32(b[:], uint32(size-pos-8))
+			_, err := f.WriteAt(b[:], pos+4)
+			return err
+		}
+
+		n := int64(binary.LittleEndian.Uint32(chunk[4:8]))
+		pos += 8 + n + n%2
+	}
+
+	return errors.New("wav data chunk not found")
+}
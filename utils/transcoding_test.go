@@ -1,8 +1,12 @@
 package utils
 
 import (
+	"encoding/binary"
 	"os"
+	"strings"
 	"testing"
+
+	"github.com/webitel/storage/model"
 )
 
 func TestTranscoding(t *testing.T) {
@@ -34,3 +38,67 @@ func testVP9(t *testing.T) {
 		panic(err)
 	}
 }
+
+func TestAudioCmdArgs(t *testing.T) {
+	for _, c := range []struct {
+		format string
+		args   string
+	}{
+		{"mp3", "-nostdin -loglevel error -i pipe:0 -vn -c:a libmp3lame -q:a 4 -f mp3 pipe:1"},
+		{"opus:mono", "-nostdin -loglevel error -i pipe:0 -vn -ac 1 -c:a libopus -b:a 32k -f ogg pipe:1"},
+		{"WAV:right", "-nostdin -loglevel error -i pipe:0 -vn -af pan=mono|c0=c1 -c:a pcm_s16le -f wav pipe:1"},
+	} {
+		format, err := model.ParseAudioFormat(c.format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if args := strings.Join(audioCmdArgs(format), " "); args != c.args {
+			t.Fatalf("%s: unexpected args %s", c.format, args)
+		}
+	}
+
+	if _, err := model.ParseAudioFormat("flac"); err == nil {
+		t.Fatal("expected unsupported format")
+	}
+	if _, err := model.ParseAudioFormat("mp3:center"); err == nil {
+		t.Fatal("expected unsupported channels")
+	}
+}
+
+func TestPatchWavSizes(t *testing.T) {
+	f, err := os.CreateTemp(t.TempDir(), "wav_")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	// the header of ffmpeg writing to the pipe: unknown sizes, the LIST chunk before the data
+	wav := []byte("RIFF\xff\xff\xff\xffWAVE")
+	wav = append(wav, "fmt \x10\x00\x00\x00"...)
+	wav = append(wav, make([]byte, 16)...)
+	wav = append(wav, "LIST\x03\x00\x00\x00abc\x00"...)
+	wav = append(wav, "data\xff\xff\xff\xff"...)
+	wav = append(wav, make([]byte, 100)...)
+	if _, err = f.Write(wav); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = PatchWavSizes(f, int64(len(wav))); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := os.ReadFile(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := binary.LittleEndian.Uint32(b[4:8]); n != uint32(len(wav)-8) {
+		t.Fatalf("unexpected RIFF size %d", n)
+	}
+	if n := binary.LittleEndian.Uint32(b[len(b)-104 : len(b)-100]); n != 100 {
+		t.Fatalf("unexpected data size %d", n)
+	}
+
+	if err = PatchWavSizes(f, 10); err == nil {
+		t.Fatal("expected data chunk not found")
+	}
+}